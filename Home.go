@@ -3,22 +3,43 @@ package controllers
 import (
 	"AutoM/config"
 	"AutoM/models"
+	"database/sql"
+	"errors"
 	"html/template"
 	"log"
 	"net/http"
+	"net/url"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+
+	"github.com/gorilla/mux"
 )
 
 // HomePageData – структура для передачи данных в шаблон главной страницы.
 type HomePageData struct {
-	Username         string
-	IsAdmin          bool
-	Parts            []models.Part
-	Categories       []models.Category
-	SelectedCategory string
+	Username     string
+	IsAdmin      bool
+	Parts        []models.PartListItem
+	Categories   []models.Category
+	Location     *models.CatalogLocation // раздел каталога, если страница открыта по адресу /catalog/...
+	CatalogPath  string                  // адрес текущего раздела для форм фильтров
+	Makes        []models.VehicleMake    // марки для выбора автомобиля
+	Vehicle      models.VehicleSelection // выбранный автомобиль
+	Brands       []models.Brand          // бренды для фильтра
+	BrandID      int                     // выбранный бренд
+	OriginalOnly bool                    // показывать только оригинальные запчасти
+	VIN          string                  // VIN, введённый покупателем
+	VINInfo      *models.VINInfo         // расшифровка VIN, если он корректен
+	VINError     string                  // причина, по которой VIN не удалось расшифровать
+	Currency     string                  // валюта цен на витрине (GET-параметр currency)
+	Currencies   []string                // валюты с заданным курсом для переключателя
+}
+
+// CurrencySign возвращает символ валюты цен на витрине.
+func (d HomePageData) CurrencySign() string {
+	return currencySign(d.Currency)
 }
 
 var (
@@ -36,39 +57,176 @@ func loadHomeTemplate() {
 	}
 }
 
-// HomeHandler обрабатывает запрос на главную страницу и осуществляет выборку товаров.
-// Если передан GET-параметр "category", происходит выборка товаров по данной категории;
-// если параметр не указан – загружаются все товары.
-func HomeHandler(w http.ResponseWriter, r *http.Request) {
-	// Загружаем шаблон один раз.
-	tplOnce.Do(loadHomeTemplate)
+// loadStorefrontParts возвращает все запчасти, подходящие под фильтр, упорядоченные по названию.
+// Выборка идёт страницами по models.MaxPartsLimit.
+func loadStorefrontParts(filter models.PartFilter) ([]models.PartListItem, error) {
+	filter.Sort, filter.Limit, filter.Offset = "name", models.MaxPartsLimit, 0
+	var parts []models.PartListItem
+	for {
+		page, err := models.ListParts(config.DB, filter)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, page.Items...)
+		filter.Offset += len(page.Items)
+		if len(page.Items) == 0 || filter.Offset >= page.Total {
+			return parts, nil
+		}
+	}
+}
 
-	// Получаем выбранную категорию из параметра запроса.
-	selectedCategory := strings.TrimSpace(r.URL.Query().Get("category"))
-	var parts []models.Part
-	var err error
+// redirectPermanent отправляет 301 на новый адрес, сохраняя параметры запроса.
+func redirectPermanent(w http.ResponseWriter, r *http.Request, path string, query url.Values) {
+	if encoded := query.Encode(); encoded != "" {
+		path += "?" + encoded
+	}
+	log.Printf("[INFO] Перенаправление %s → %s", r.URL.RequestURI(), path)
+	http.Redirect(w, r, path, http.StatusMovedPermanently)
+}
 
-	// Если категория выбрана, пробуем преобразовать её к числовому ID.
-	if selectedCategory != "" {
-		if subcategoryID, convErr := strconv.Atoi(selectedCategory); convErr == nil {
-			parts, err = models.GetPartsBySubcategory(config.DB, subcategoryID)
+// redirectOrNotFound ищет перенаправление для устаревшего адреса и отправляет 301,
+// а если перенаправления нет – 404.
+func redirectOrNotFound(w http.ResponseWriter, r *http.Request) {
+	target, ok, err := models.LookupRedirect(config.DB, r.URL.Path)
+	if err != nil {
+		log.Printf("[ERROR] Ошибка поиска перенаправления для %s: %v", r.URL.Path, err)
+		http.Error(w, "Ошибка получения страницы", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	redirectPermanent(w, r, target, r.URL.Query())
+}
+
+// HomeHandler обрабатывает запрос на главную страницу и выводит все товары.
+// Устаревшие адреса с GET-параметром "category" (ID подкатегории или название категории)
+// перенаправляются на адреса разделов вида /catalog/{group}/{category}/{subcategory}.
+func HomeHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if category := strings.TrimSpace(query.Get("category")); category != "" {
+		var location models.CatalogLocation
+		var err error
+		if subcategoryID, convErr := strconv.Atoi(category); convErr == nil {
+			location, err = models.GetSubcategoryLocation(config.DB, subcategoryID)
 		} else {
-			// Если не число - выбираем товары по имени категории.
-			parts, err = models.GetPartsByCategoryName(config.DB, selectedCategory)
+			location, err = models.GetCategoryLocationByName(config.DB, category)
 		}
-		if err != nil {
-			log.Printf("[ERROR] Ошибка получения товаров по категории (%s): %v", selectedCategory, err)
-			http.Error(w, "Ошибка получения товаров", http.StatusInternalServerError)
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Printf("[WARN] Категория %s из устаревшего адреса не найдена", category)
+			http.NotFound(w, r)
 			return
 		}
-	} else {
-		// Если категория не выбрана, загружаем все товары.
-		parts, err = models.GetAllParts(config.DB)
 		if err != nil {
-			log.Printf("[ERROR] Ошибка получения товаров: %v", err)
+			log.Printf("[ERROR] Ошибка поиска категории %s: %v", category, err)
 			http.Error(w, "Ошибка получения товаров", http.StatusInternalServerError)
 			return
 		}
+		query.Del("category")
+		redirectPermanent(w, r, location.Path(), query)
+		return
+	}
+	renderStorefront(w, r, nil)
+}
+
+// CatalogPageHandler выводит товары раздела каталога по адресу
+// /catalog/{group}, /catalog/{group}/{category} или /catalog/{group}/{category}/{subcategory}.
+// Для адресов, изменившихся после переименования, отправляется 301 на новый адрес.
+func CatalogPageHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	location, err := models.ResolveCatalogPath(config.DB, vars["group"], vars["category"], vars["subcategory"])
+	if errors.Is(err, sql.ErrNoRows) {
+		redirectOrNotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Ошибка поиска раздела каталога %s: %v", r.URL.Path, err)
+		http.Error(w, "Ошибка получения товаров", http.StatusInternalServerError)
+		return
+	}
+	renderStorefront(w, r, &location)
+}
+
+// renderStorefront выводит витрину: товары раздела каталога (или все товары, если location = nil).
+// Параметры make_id, model_id, generation_id и engine_id оставляют только запчасти,
+// подходящие к выбранному автомобилю. Параметр vin, если автомобиль не выбран явно,
+// расшифровывается и сужает выборку по марке и модельному году.
+// Параметры brand_id и original оставляют запчасти выбранного бренда или только оригинальные.
+func renderStorefront(w http.ResponseWriter, r *http.Request, location *models.CatalogLocation) {
+	// Загружаем шаблон один раз.
+	tplOnce.Do(loadHomeTemplate)
+
+	vehicle, err := parseVehicleSelection(r)
+	if err != nil {
+		log.Printf("[ERROR] Неверные параметры автомобиля: %v", err)
+		http.Error(w, "Неверные параметры автомобиля", http.StatusBadRequest)
+		return
+	}
+
+	brandID, err := queryInt(r, "brand_id")
+	if err != nil {
+		log.Printf("[ERROR] Неверный параметр brand_id: %v", err)
+		http.Error(w, "Неверные параметры бренда", http.StatusBadRequest)
+		return
+	}
+	// Параметр original разбирается так же, как в API (parsePartFilter).
+	var originalOnly bool
+	if val := r.URL.Query().Get("original"); val != "" {
+		if originalOnly, err = strconv.ParseBool(val); err != nil {
+			log.Printf("[ERROR] Неверный параметр original: %v", err)
+			http.Error(w, "Неверное значение параметра original", http.StatusBadRequest)
+			return
+		}
+	}
+
+	vin := strings.TrimSpace(r.URL.Query().Get("vin"))
+	var vinInfo *models.VINInfo
+	var vinError string
+	if vin != "" && vehicle.IsEmpty() {
+		info, selection, vinErr := decodeVINForCatalog(vin)
+		switch {
+		case vinErr == nil:
+			vinInfo, vehicle = &info, selection
+			if vehicle.IsEmpty() {
+				vinError = "Марка " + info.Make + " пока отсутствует в нашем каталоге"
+				if info.Make == "" {
+					vinError = "Не удалось определить марку по VIN"
+				}
+			}
+		case isVINValidationError(vinErr):
+			vinError = vinErr.Error()
+		default:
+			log.Printf("[ERROR] Ошибка расшифровки VIN %s: %v", vin, vinErr)
+			vinError = "Ошибка расшифровки VIN"
+		}
+	}
+
+	filter := models.PartFilter{Vehicle: vehicle, BrandID: brandID, OriginalOnly: originalOnly}
+	catalogPath := "/"
+	if location != nil {
+		filter.GroupID, filter.CategoryID, filter.SubcategoryID = location.GroupID, location.CategoryID, location.SubcategoryID
+		catalogPath = location.Path()
+	}
+	parts, err := loadStorefrontParts(filter)
+	if err != nil {
+		log.Printf("[ERROR] Ошибка получения товаров по фильтру %+v: %v", filter, err)
+		http.Error(w, "Ошибка получения товаров", http.StatusInternalServerError)
+		return
+	}
+	sessionPricing(r).ApplyTo(parts)
+	currency, converter := storefrontCurrency(r)
+	convertPartItems(converter, currency, parts)
+
+	// Валюты для переключателя цен.
+	currencies := []string{models.BaseCurrency}
+	if rates, err := models.GetCurrencyRates(config.DB); err != nil {
+		log.Printf("[ERROR] Ошибка получения курсов валют: %v", err)
+	} else {
+		currencies = currencies[:0]
+		for _, rate := range rates {
+			currencies = append(currencies, rate.Code)
+		}
 	}
 
 	// Получаем все категории для формирования меню фильтрации.
@@ -79,6 +237,19 @@ func HomeHandler(w http.ResponseWriter, r *http.Request) {
 		categories = []models.Category{}
 	}
 
+	// Марки для блока "Подобрать запчасти для моей машины".
+	makes, err := models.GetAllVehicleMakes(config.DB)
+	if err != nil {
+		log.Printf("[ERROR] Ошибка получения марок автомобилей: %v", err)
+		makes = []models.VehicleMake{}
+	}
+
+	brands, err := models.GetAllBrands(config.DB)
+	if err != nil {
+		log.Printf("[ERROR] Ошибка получения брендов: %v", err)
+		brands = []models.Brand{}
+	}
+
 	// Извлекаем из сессии данные пользователя.
 	session, _ := config.Store.Get(r, "session")
 	var username string
@@ -94,11 +265,22 @@ func HomeHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Формируем данные для шаблона.
 	data := HomePageData{
-		Username:         username,
-		IsAdmin:          isAdmin,
-		Parts:            parts,
-		Categories:       categories,
-		SelectedCategory: selectedCategory,
+		Username:     username,
+		IsAdmin:      isAdmin,
+		Parts:        parts,
+		Categories:   categories,
+		Location:     location,
+		CatalogPath:  catalogPath,
+		Makes:        makes,
+		Vehicle:      vehicle,
+		Brands:       brands,
+		BrandID:      brandID,
+		OriginalOnly: originalOnly,
+		VIN:          vin,
+		VINInfo:      vinInfo,
+		VINError:     vinError,
+		Currency:     currency,
+		Currencies:   currencies,
 	}
 
 	// Рендерим шаблон и передаём данные.
@@ -29,8 +29,8 @@ func AdminPageHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Доступ запрещён", http.StatusForbidden)
 		return
 	}
-	// Получаем данные запчастей из базы.
-	parts, err := models.GetAllParts(config.DB)
+	// Получаем данные запчастей из базы (без запчастей в корзине).
+	parts, err := loadStorefrontParts(models.PartFilter{})
 	if err != nil {
 		log.Printf("[ERROR] Ошибка получения данных для админ панели: %v", err)
 		http.Error(w, "Ошибка получения данных", http.StatusInternalServerError)
@@ -50,6 +50,10 @@ func AdminPageHandler(w http.ResponseWriter, r *http.Request) {
 // AdminImportPartsHandler – обработчик импорта запчастей из Excel.
 // Осуществляет чтение файла, парсит строки (пропуская заголовок),
 // создаёт объекты Part и вставляет их в базу.
+// Бренд берётся из 7-го столбца, а если он пуст – из поля формы "brand";
+// отсутствующие бренды создаются автоматически.
+// Остаток приходуется в журнал движений на склад из 8-го столбца, а если он пуст –
+// на склад из поля формы "warehouse" или склад по умолчанию.
 func AdminImportPartsHandler(w http.ResponseWriter, r *http.Request) {
 	// Ограничиваем размер файла 10 МБ.
 	if err := r.ParseMultipartForm(10 << 20); err != nil {
@@ -92,6 +96,31 @@ func AdminImportPartsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	defaultBrand := strings.TrimSpace(r.FormValue("brand"))
+	// Цены всех товаров файла попадают в историю под общим идентификатором загрузки.
+	priceSource := models.PriceSource{
+		Source:   models.PriceSourceImport,
+		Batch:    models.NewPriceBatch(models.PriceSourceImport),
+		Username: sessionUsername(r),
+	}
+	brandIDs := make(map[string]int) // кэш ID брендов по названию в нижнем регистре
+
+	// Склад для строк без столбца склада; если не указан – склад по умолчанию.
+	defaultWarehouse := 0
+	if name := strings.TrimSpace(r.FormValue("warehouse")); name != "" {
+		if defaultWarehouse, err = models.GetWarehouseByName(config.DB, name); err != nil {
+			log.Printf("[WARN] Склад '%s' для импорта не найден: %v", name, err)
+			http.Error(w, "Склад не найден: "+name, http.StatusBadRequest)
+			return
+		}
+	}
+	warehouseIDs := make(map[string]int) // кэш ID складов по названию в нижнем регистре
+	// Поступления файла объединяются в журнале движений документом с именем файла.
+	stockDocument := header.Filename
+	if len([]rune(stockDocument)) > 100 {
+		stockDocument = string([]rune(stockDocument)[:100])
+	}
+
 	// Предполагается, что первая строка – заголовки.
 	importedCount := 0
 	for i, row := range rows {
@@ -127,21 +156,68 @@ func AdminImportPartsHandler(w http.ResponseWriter, r *http.Request) {
 			url := strings.TrimSpace(row[5])
 			imageURL = &url
 		}
+		// Количество приходуется на склад движением по журналу после добавления запчасти.
 		part := models.Part{
 			Name:          name,
 			Description:   description,
 			Price:         price,
 			SubcategoryID: subcategoryID,
-			Quantity:      quantity,
 			ImageURL:      imageURL,
 		}
-		if err := models.InsertPart(config.DB, &part); err != nil {
+		brandName := defaultBrand
+		if len(row) > 6 && strings.TrimSpace(row[6]) != "" {
+			brandName = strings.TrimSpace(row[6])
+		}
+		brandID := 0
+		if brandName != "" {
+			key := strings.ToLower(brandName)
+			if id, ok := brandIDs[key]; ok {
+				brandID = id
+			} else if id, err := models.EnsureBrand(config.DB, brandName); err != nil {
+				log.Printf("[WARN] Строка %d: не удалось определить бренд %s: %v", i+1, brandName, err)
+			} else {
+				brandIDs[key], brandID = id, id
+			}
+		}
+		warehouseID := defaultWarehouse
+		if len(row) > 7 && strings.TrimSpace(row[7]) != "" {
+			warehouseName := strings.TrimSpace(row[7])
+			key := strings.ToLower(warehouseName)
+			if id, ok := warehouseIDs[key]; ok {
+				warehouseID = id
+			} else if id, err := models.GetWarehouseByName(config.DB, warehouseName); err != nil {
+				log.Printf("[WARN] Строка %d: склад %s не найден, остаток записан на склад импорта: %v", i+1, warehouseName, err)
+			} else {
+				warehouseIDs[key], warehouseID = id, id
+			}
+		}
+		id, err := models.InsertBrandedPart(config.DB, part, brandID)
+		if id > 0 {
+			if err := models.RecordPriceChange(config.DB, id, nil, part.Price, priceSource); err != nil {
+				log.Printf("[ERROR] Строка %d: не удалось записать историю цены: %v", i+1, err)
+			}
+			if quantity > 0 {
+				receipt := models.StockMovement{
+					PartID: id, WarehouseID: warehouseID, Kind: models.MovementReceipt, Quantity: quantity,
+					Reason: "Импорт из Excel", Username: priceSource.Username, Document: stockDocument,
+				}
+				if _, err := models.AddStockMovement(config.DB, receipt); err != nil {
+					log.Printf("[ERROR] Строка %d: не удалось оприходовать остаток на склад: %v", i+1, err)
+				}
+			}
+		}
+		if err != nil {
 			log.Printf("[ERROR] Строка %d: не удалось вставить товар: %v", i+1, err)
 			continue
 		}
 		importedCount++
 	}
 
+	// Новые товары попадают в поисковый индекс после импорта всего файла.
+	if err := models.SyncPartsSearchIndex(config.DB); err != nil {
+		log.Printf("[ERROR] Ошибка синхронизации поискового индекса после импорта: %v", err)
+	}
+
 	msg := fmt.Sprintf("Импортировано товаров: %d", importedCount)
 	log.Printf("[INFO] %s", msg)
 	http.Redirect(w, r, "/admin?msg="+msg, http.StatusSeeOther)
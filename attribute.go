@@ -0,0 +1,458 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Типы характеристик запчастей.
+const (
+	AttributeNumber = "number" // число с единицей измерения (диаметр, ёмкость)
+	AttributeEnum   = "enum"   // значение из фиксированного списка (полярность)
+	AttributeString = "string" // произвольная строка (размер резьбы)
+)
+
+// Ошибки характеристик и фильтров по ним.
+var (
+	ErrAttributeType       = errors.New("тип характеристики должен быть number, enum или string")
+	ErrAttributeCode       = errors.New("код характеристики может содержать только латинские буквы, цифры и _")
+	ErrAttributeOptions    = errors.New("для характеристики типа enum необходимо указать варианты значений")
+	ErrAttributeValue      = errors.New("недопустимое значение характеристики")
+	ErrUnknownAttribute    = errors.New("неизвестная характеристика")
+	ErrAttributeFilterNeed = errors.New("фильтр по характеристикам требует subcategory_id")
+)
+
+// attributeCodePattern – допустимый код характеристики (используется в параметрах запроса attr.<code>).
+var attributeCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// Attribute описывает характеристику, заданную для подкатегории (subcategory_attributes).
+type Attribute struct {
+	ID            int       `json:"id"`             // attribute_id – первичный ключ
+	SubcategoryID int       `json:"subcategory_id"` // подкатегория, к которой относится характеристика
+	Code          string    `json:"code"`           // код для фильтров, например diameter
+	Name          string    `json:"name"`           // название для покупателя, например "Диаметр"
+	Type          string    `json:"type"`           // number, enum или string
+	Unit          string    `json:"unit"`           // единица измерения, например "мм"
+	Options       []string  `json:"options"`        // варианты значений для enum
+	SortOrder     int       `json:"sort_order"`     // порядок вывода в фильтрах
+	CreateAt      time.Time `json:"create_at"`      // время создания
+}
+
+// PartAttributeValue – значение характеристики у запчасти.
+type PartAttributeValue struct {
+	AttributeID int    `json:"attribute_id"`
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Unit        string `json:"unit"`
+	Value       string `json:"value"` // значение в текстовом виде; для number – число без лишних нулей
+}
+
+// AttributeFilter – условие по характеристике в списке запчастей.
+// Для number используются Min и Max, для enum и string – список Values.
+type AttributeFilter struct {
+	Code   string
+	Values []string
+	Min    *float64
+	Max    *float64
+
+	attr Attribute // характеристика, найденная по коду в resolveAttributes
+}
+
+// FacetValue – значение характеристики и количество запчастей с ним.
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// AttributeFacet – сводка по характеристике для текущей выборки запчастей.
+// Счётчики учитывают все фильтры, кроме фильтра по самой характеристике,
+// чтобы покупатель видел, сколько запчастей даст выбор другого значения.
+type AttributeFacet struct {
+	AttributeID int          `json:"attribute_id"`
+	Code        string       `json:"code"`
+	Name        string       `json:"name"`
+	Type        string       `json:"type"`
+	Unit        string       `json:"unit"`
+	Values      []FacetValue `json:"values"`
+	Min         *float64     `json:"min,omitempty"` // для number – минимальное значение
+	Max         *float64     `json:"max,omitempty"` // для number – максимальное значение
+}
+
+// migrateAttributes создаёт таблицы характеристик подкатегорий и значений характеристик запчастей.
+func migrateAttributes(db *sql.DB) error {
+	return execAll(db,
+		"CREATE TABLE IF NOT EXISTS subcategory_attributes ("+
+			"attribute_id INT AUTO_INCREMENT PRIMARY KEY, "+
+			"subcategory_id INT NOT NULL, "+
+			"code VARCHAR(50) NOT NULL, "+
+			"name VARCHAR(100) NOT NULL, "+
+			"type VARCHAR(10) NOT NULL, "+
+			"unit VARCHAR(20) NOT NULL DEFAULT '', "+
+			"options TEXT NULL, "+
+			"sort_order INT NOT NULL DEFAULT 0, "+
+			"create_at DATETIME NOT NULL, "+
+			"UNIQUE KEY uq_attribute_code (subcategory_id, code)"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"CREATE TABLE IF NOT EXISTS part_attribute_values ("+
+			"part_id INT NOT NULL, "+
+			"attribute_id INT NOT NULL, "+
+			"value_text VARCHAR(255) NOT NULL, "+
+			"value_number DOUBLE NULL, "+
+			"PRIMARY KEY (part_id, attribute_id), "+
+			"KEY idx_value_text (attribute_id, value_text), "+
+			"KEY idx_value_number (attribute_id, value_number), "+
+			"FOREIGN KEY (attribute_id) REFERENCES subcategory_attributes (attribute_id) ON DELETE CASCADE"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+	)
+}
+
+// Validate проверяет описание характеристики перед сохранением.
+func (a Attribute) Validate() error {
+	if !attributeCodePattern.MatchString(a.Code) {
+		return ErrAttributeCode
+	}
+	switch a.Type {
+	case AttributeNumber, AttributeString:
+	case AttributeEnum:
+		if len(a.Options) == 0 {
+			return ErrAttributeOptions
+		}
+	default:
+		return ErrAttributeType
+	}
+	if strings.TrimSpace(a.Name) == "" {
+		return errors.New("не указано название характеристики")
+	}
+	return nil
+}
+
+// normalizeValue проверяет значение характеристики и приводит его к виду для хранения.
+// Для number возвращается и числовое значение.
+func (a Attribute) normalizeValue(raw string) (string, *float64, error) {
+	value := strings.TrimSpace(raw)
+	switch a.Type {
+	case AttributeNumber:
+		n, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %s ожидает число", ErrAttributeValue, a.Code)
+		}
+		return formatNumber(n), &n, nil
+	case AttributeEnum:
+		for _, option := range a.Options {
+			if strings.EqualFold(option, value) {
+				return option, nil, nil
+			}
+		}
+		return "", nil, fmt.Errorf("%w: %s допускает значения %s", ErrAttributeValue, a.Code, strings.Join(a.Options, ", "))
+	default:
+		if len([]rune(value)) > 255 {
+			return "", nil, fmt.Errorf("%w: %s длиннее 255 символов", ErrAttributeValue, a.Code)
+		}
+		return value, nil, nil
+	}
+}
+
+// formatNumber форматирует число без лишних нулей: 280, 22.5.
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// scanAttribute считывает строку таблицы subcategory_attributes.
+func scanAttribute(row interface{ Scan(...interface{}) error }) (Attribute, error) {
+	var a Attribute
+	var options sql.NullString
+	if err := row.Scan(&a.ID, &a.SubcategoryID, &a.Code, &a.Name, &a.Type, &a.Unit, &options, &a.SortOrder, &a.CreateAt); err != nil {
+		return Attribute{}, err
+	}
+	a.Options = []string{}
+	if options.Valid && options.String != "" {
+		if err := json.Unmarshal([]byte(options.String), &a.Options); err != nil {
+			return Attribute{}, err
+		}
+	}
+	return a, nil
+}
+
+// attributeColumns – столбцы subcategory_attributes в порядке scanAttribute.
+const attributeColumns = "attribute_id, subcategory_id, code, name, type, unit, options, sort_order, create_at"
+
+// GetSubcategoryAttributes возвращает характеристики подкатегории в порядке вывода.
+func GetSubcategoryAttributes(db *sql.DB, subcategoryID int) ([]Attribute, error) {
+	query := "SELECT " + attributeColumns + " FROM subcategory_attributes WHERE subcategory_id = ? ORDER BY sort_order, attribute_id"
+	rows, err := db.Query(query, subcategoryID)
+	if err != nil {
+		log.Printf("[ERROR] GetSubcategoryAttributes: ошибка выполнения запроса: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []Attribute{}
+	for rows.Next() {
+		a, err := scanAttribute(rows)
+		if err != nil {
+			log.Printf("[ERROR] GetSubcategoryAttributes: ошибка сканирования строки: %v", err)
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+// GetAttributeByID возвращает характеристику по её ID.
+func GetAttributeByID(db *sql.DB, id int) (Attribute, error) {
+	return scanAttribute(db.QueryRow("SELECT "+attributeColumns+" FROM subcategory_attributes WHERE attribute_id = ?", id))
+}
+
+// encodeOptions сериализует варианты значений enum для хранения.
+func encodeOptions(a Attribute) (interface{}, error) {
+	if a.Type != AttributeEnum {
+		return nil, nil
+	}
+	data, err := json.Marshal(a.Options)
+	return string(data), err
+}
+
+// AddAttribute добавляет характеристику подкатегории.
+func AddAttribute(db *sql.DB, a Attribute) error {
+	log.Printf("[INFO] AddAttribute: добавление характеристики %s для подкатегории %d", a.Code, a.SubcategoryID)
+	options, err := encodeOptions(a)
+	if err != nil {
+		return err
+	}
+	query := "INSERT INTO subcategory_attributes (subcategory_id, code, name, type, unit, options, sort_order, create_at) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?, NOW())"
+	_, err = db.Exec(query, a.SubcategoryID, a.Code, strings.TrimSpace(a.Name), a.Type, strings.TrimSpace(a.Unit), options, a.SortOrder)
+	if err != nil {
+		log.Printf("[ERROR] AddAttribute: ошибка выполнения запроса: %v", err)
+	}
+	return err
+}
+
+// UpdateAttribute обновляет название, единицу, варианты и порядок вывода характеристики.
+// Код и тип не меняются: от них зависят сохранённые значения и ссылки на фильтры.
+func UpdateAttribute(db *sql.DB, id int, a Attribute) (int64, error) {
+	log.Printf("[INFO] UpdateAttribute: обновление характеристики с ID %d", id)
+	options, err := encodeOptions(a)
+	if err != nil {
+		return 0, err
+	}
+	query := "UPDATE subcategory_attributes SET name = ?, unit = ?, options = ?, sort_order = ? WHERE attribute_id = ?"
+	n, err := rowsAffected(db.Exec(query, strings.TrimSpace(a.Name), strings.TrimSpace(a.Unit), options, a.SortOrder, id))
+	if err == nil && n == 0 {
+		// MySQL не считает строку изменённой, если значения совпали.
+		var exists int
+		err = db.QueryRow("SELECT COUNT(*) FROM subcategory_attributes WHERE attribute_id = ?", id).Scan(&exists)
+		n = int64(exists)
+	}
+	return n, err
+}
+
+// DeleteAttribute удаляет характеристику вместе со значениями у запчастей.
+func DeleteAttribute(db *sql.DB, id int) (int64, error) {
+	log.Printf("[INFO] DeleteAttribute: удаление характеристики с ID %d", id)
+	return rowsAffected(db.Exec("DELETE FROM subcategory_attributes WHERE attribute_id = ?", id))
+}
+
+// GetPartAttributes возвращает значения характеристик запчасти в порядке вывода.
+func GetPartAttributes(db *sql.DB, partID int) ([]PartAttributeValue, error) {
+	query := "SELECT a.attribute_id, a.code, a.name, a.type, a.unit, v.value_text FROM part_attribute_values v " +
+		"JOIN subcategory_attributes a ON a.attribute_id = v.attribute_id WHERE v.part_id = ? ORDER BY a.sort_order, a.attribute_id"
+	rows, err := db.Query(query, partID)
+	if err != nil {
+		log.Printf("[ERROR] GetPartAttributes: ошибка выполнения запроса: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []PartAttributeValue{}
+	for rows.Next() {
+		var v PartAttributeValue
+		if err := rows.Scan(&v.AttributeID, &v.Code, &v.Name, &v.Type, &v.Unit, &v.Value); err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, rows.Err()
+}
+
+// SetPartAttributes сохраняет значения характеристик запчасти (ключ – код характеристики).
+// Допускаются только характеристики подкатегории запчасти; пустое значение удаляет характеристику.
+// Все значения сохраняются в одной транзакции: при ошибке ничего не меняется.
+func SetPartAttributes(db *sql.DB, partID int, values map[string]string) error {
+	var subcategoryID int
+	if err := db.QueryRow("SELECT subcategory_id FROM parts WHERE part_id = ? AND deleted_at IS NULL", partID).Scan(&subcategoryID); err != nil {
+		return err
+	}
+	attrs, err := GetSubcategoryAttributes(db, subcategoryID)
+	if err != nil {
+		return err
+	}
+	byCode := make(map[string]Attribute, len(attrs))
+	for _, a := range attrs {
+		byCode[a.Code] = a
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for code, raw := range values {
+		a, ok := byCode[code]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownAttribute, code)
+		}
+		if strings.TrimSpace(raw) == "" {
+			if _, err := tx.Exec("DELETE FROM part_attribute_values WHERE part_id = ? AND attribute_id = ?", partID, a.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		text, number, err := a.normalizeValue(raw)
+		if err != nil {
+			return err
+		}
+		query := "INSERT INTO part_attribute_values (part_id, attribute_id, value_text, value_number) VALUES (?, ?, ?, ?) " +
+			"ON DUPLICATE KEY UPDATE value_text = VALUES(value_text), value_number = VALUES(value_number)"
+		if _, err := tx.Exec(query, partID, a.ID, text, number); err != nil {
+			log.Printf("[ERROR] SetPartAttributes: ошибка сохранения %s для запчасти %d: %v", code, partID, err)
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	log.Printf("[INFO] SetPartAttributes: сохранено характеристик запчасти %d: %d", partID, len(values))
+	return nil
+}
+
+// resolveAttributes находит характеристики фильтра по кодам в подкатегории фильтра
+// и возвращает характеристики подкатегории (для фасетов).
+func (f *PartFilter) resolveAttributes(db *sql.DB) ([]Attribute, error) {
+	if f.SubcategoryID <= 0 {
+		if len(f.Attributes) > 0 {
+			return nil, ErrAttributeFilterNeed
+		}
+		return nil, nil
+	}
+	attrs, err := GetSubcategoryAttributes(db, f.SubcategoryID)
+	if err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]Attribute, len(attrs))
+	for _, a := range attrs {
+		byCode[a.Code] = a
+	}
+	for i, af := range f.Attributes {
+		a, ok := byCode[af.Code]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownAttribute, af.Code)
+		}
+		f.Attributes[i].attr = a
+		for j, raw := range af.Values {
+			if f.Attributes[i].Values[j], _, err = a.normalizeValue(raw); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return attrs, nil
+}
+
+// condition формирует условие по характеристике для списка запчастей.
+func (af AttributeFilter) condition() (string, []interface{}) {
+	cond := "p.part_id IN (SELECT v.part_id FROM part_attribute_values v WHERE v.attribute_id = ?"
+	args := []interface{}{af.attr.ID}
+	if af.attr.Type == AttributeNumber {
+		if af.Min != nil {
+			cond += " AND v.value_number >= ?"
+			args = append(args, *af.Min)
+		}
+		if af.Max != nil {
+			cond += " AND v.value_number <= ?"
+			args = append(args, *af.Max)
+		}
+	}
+	if len(af.Values) > 0 {
+		cond += " AND v.value_text IN (" + placeholders(len(af.Values)) + ")"
+		args = append(args, stringArgs(af.Values)...)
+	}
+	return cond + ")", args
+}
+
+// attributeFacets считает фасеты по характеристикам подкатегории для фильтра f.
+func attributeFacets(db *sql.DB, f PartFilter, attrs []Attribute) ([]AttributeFacet, error) {
+	facets := make([]AttributeFacet, 0, len(attrs))
+	for _, a := range attrs {
+		// Фильтр по самой характеристике не учитывается, иначе в фасете
+		// остались бы только уже выбранные значения.
+		others := f
+		others.Attributes = nil
+		for _, af := range f.Attributes {
+			if af.attr.ID != a.ID {
+				others.Attributes = append(others.Attributes, af)
+			}
+		}
+		where, args := others.where()
+		query := "SELECT v.value_text, MIN(v.value_number), COUNT(*) FROM part_attribute_values v " +
+			"JOIN parts p ON p.part_id = v.part_id" + where + " AND v.attribute_id = ? GROUP BY v.value_text"
+		rows, err := db.Query(query, append(args, a.ID)...)
+		if err != nil {
+			log.Printf("[ERROR] attributeFacets: ошибка подсчёта значений %s: %v", a.Code, err)
+			return nil, err
+		}
+
+		facet := AttributeFacet{AttributeID: a.ID, Code: a.Code, Name: a.Name, Type: a.Type, Unit: a.Unit, Values: []FacetValue{}}
+		numbers := map[string]float64{}
+		for rows.Next() {
+			var fv FacetValue
+			var number sql.NullFloat64
+			if err := rows.Scan(&fv.Value, &number, &fv.Count); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if number.Valid {
+				n := number.Float64
+				numbers[fv.Value] = n
+				if facet.Min == nil || n < *facet.Min {
+					facet.Min = &n
+				}
+				if facet.Max == nil || n > *facet.Max {
+					facet.Max = &n
+				}
+			}
+			facet.Values = append(facet.Values, fv)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		// Числа упорядочиваются по значению, варианты enum – как в описании характеристики.
+		order := make(map[string]int, len(a.Options))
+		for i, option := range a.Options {
+			order[option] = i
+		}
+		sort.SliceStable(facet.Values, func(i, j int) bool {
+			vi, vj := facet.Values[i].Value, facet.Values[j].Value
+			switch a.Type {
+			case AttributeNumber:
+				return numbers[vi] < numbers[vj]
+			case AttributeEnum:
+				return order[vi] < order[vj]
+			}
+			return vi < vj
+		})
+		facets = append(facets, facet)
+	}
+	return facets, nil
+}
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"AutoM/config"
+	"AutoM/models"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// GetSubcategoryAttributes – список характеристик подкатегории (API).
+func GetSubcategoryAttributes(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "GetSubcategoryAttributes")
+	if !ok {
+		return
+	}
+	list, err := models.GetSubcategoryAttributes(config.DB, id)
+	if err != nil {
+		http.Error(w, "Ошибка загрузки характеристик", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetSubcategoryAttributes", list)
+}
+
+// AddSubcategoryAttribute – добавление характеристики подкатегории.
+// Ожидается JSON с полями code, name, type (number, enum, string), unit,
+// options (варианты для enum) и sort_order.
+func AddSubcategoryAttribute(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "AddSubcategoryAttribute")
+	if !ok {
+		return
+	}
+	var a models.Attribute
+	if !decodeJSON(w, r, "AddSubcategoryAttribute", &a) {
+		return
+	}
+	a.SubcategoryID = id
+	if err := a.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := models.AddAttribute(config.DB, a); err != nil {
+		http.Error(w, "Ошибка добавления характеристики", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// UpdateAttribute – изменение названия, единицы, вариантов и порядка вывода характеристики.
+// Код и тип характеристики после создания не меняются.
+func UpdateAttribute(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "UpdateAttribute")
+	if !ok {
+		return
+	}
+	current, err := models.GetAttributeByID(config.DB, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Характеристика не найдена", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] UpdateAttribute: %v", err)
+		http.Error(w, "Ошибка загрузки характеристики", http.StatusInternalServerError)
+		return
+	}
+	var a models.Attribute
+	if !decodeJSON(w, r, "UpdateAttribute", &a) {
+		return
+	}
+	a.Code, a.Type = current.Code, current.Type
+	if err := a.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rows, err := models.UpdateAttribute(config.DB, id, a)
+	respondAffected(w, "UpdateAttribute", "Характеристика не найдена", rows, err)
+}
+
+// DeleteAttribute – удаление характеристики вместе со значениями у запчастей.
+func DeleteAttribute(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "DeleteAttribute")
+	if !ok {
+		return
+	}
+	rows, err := models.DeleteAttribute(config.DB, id)
+	respondAffected(w, "DeleteAttribute", "Характеристика не найдена", rows, err)
+}
+
+// GetPartAttributes – значения характеристик запчасти (API).
+func GetPartAttributes(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "GetPartAttributes")
+	if !ok {
+		return
+	}
+	list, err := models.GetPartAttributes(config.DB, id)
+	if err != nil {
+		http.Error(w, "Ошибка загрузки характеристик запчасти", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetPartAttributes", list)
+}
+
+// SetPartAttributes – сохранение значений характеристик запчасти.
+// Ожидается JSON-объект {"код": "значение"}; пустое значение удаляет характеристику.
+func SetPartAttributes(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "SetPartAttributes")
+	if !ok {
+		return
+	}
+	var values map[string]string
+	if !decodeJSON(w, r, "SetPartAttributes", &values) {
+		return
+	}
+	err := models.SetPartAttributes(config.DB, id, values)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusOK)
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "Запчасть не найдена", http.StatusNotFound)
+	case errors.Is(err, models.ErrUnknownAttribute), errors.Is(err, models.ErrAttributeValue):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("[ERROR] SetPartAttributes: %v", err)
+		http.Error(w, "Ошибка сохранения характеристик", http.StatusInternalServerError)
+	}
+}
@@ -278,7 +278,7 @@ func GetUserByUsername(db *sql.DB, username string) (models.User, error) {
 // GetAllUsers – возвращает список всех пользователей в формате JSON.
 func GetAllUsers(w http.ResponseWriter, r *http.Request) {
 	log.Println("[INFO] GetAllUsers: Начало запроса пользователей")
-	query := "SELECT id, username, email, is_admin FROM users"
+	query := "SELECT id, username, email, is_admin, price_group_id FROM users"
 	log.Printf("[INFO] GetAllUsers: Выполняется запрос: %s", query)
 	rows, err := config.DB.Query(query)
 	if err != nil {
@@ -299,7 +299,7 @@ func GetAllUsers(w http.ResponseWriter, r *http.Request) {
 	for rows.Next() {
 		rowNum++
 		var user models.User
-		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.IsAdmin); err != nil {
+		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.IsAdmin, &user.PriceGroupID); err != nil {
 			log.Printf("[ERROR] GetAllUsers: Ошибка сканирования строки %d: %v", rowNum, err)
 			http.Error(w, "Ошибка обработки данных", http.StatusInternalServerError)
 			return
@@ -330,11 +330,11 @@ func GetUserByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	log.Printf("[INFO] GetUserByID: Получен ID: %d", id)
-	query := "SELECT id, username, email, is_admin FROM users WHERE id = ?"
+	query := "SELECT id, username, email, is_admin, price_group_id FROM users WHERE id = ?"
 	log.Printf("[INFO] GetUserByID: Выполняется запрос: %s", query)
 	row := config.DB.QueryRow(query, id)
 	var user models.User
-	if err := row.Scan(&user.ID, &user.Username, &user.Email, &user.IsAdmin); err != nil {
+	if err := row.Scan(&user.ID, &user.Username, &user.Email, &user.IsAdmin, &user.PriceGroupID); err != nil {
 		log.Printf("[ERROR] GetUserByID: Пользователь с ID %d не найден: %v", id, err)
 		http.Error(w, "Пользователь не найден", http.StatusNotFound)
 		return
@@ -0,0 +1,305 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Виды штрихкодов.
+const (
+	BarcodeEAN13 = "ean13"
+	BarcodeEAN8  = "ean8"
+	BarcodeUPCA  = "upca"
+)
+
+// Ошибки штрихкодов.
+var (
+	ErrBarcodeFormat   = errors.New("штрихкод должен состоять из 8 (EAN-8), 12 (UPC-A) или 13 (EAN-13) цифр")
+	ErrBarcodeChecksum = errors.New("неверная контрольная цифра штрихкода")
+	ErrBarcodeExists   = errors.New("штрихкод уже назначен другой запчасти")
+)
+
+// PartBarcode – штрихкод запчасти. У запчасти может быть несколько штрихкодов
+// (разные упаковки, коды разных поставщиков), а штрихкод принадлежит одной запчасти.
+type PartBarcode struct {
+	ID       int       `json:"id"`
+	PartID   int       `json:"part_id"`
+	Code     string    `json:"code"` // цифры штрихкода в исходной длине
+	Kind     string    `json:"kind"` // ean13, ean8 или upca
+	CreateAt time.Time `json:"create_at"`
+}
+
+// migrateBarcodes создаёт таблицу штрихкодов запчастей. Уникальность проверяется по коду,
+// дополненному нулями слева до 13 цифр: UPC-A 036000291452 и EAN-13 0036000291452 – один товар.
+func migrateBarcodes(db *sql.DB) error {
+	return execAll(db,
+		"CREATE TABLE IF NOT EXISTS part_barcodes ("+
+			"barcode_id INT AUTO_INCREMENT PRIMARY KEY, "+
+			"part_id INT NOT NULL, "+
+			"code VARCHAR(13) NOT NULL, "+
+			"kind VARCHAR(8) NOT NULL, "+
+			"gtin CHAR(13) NOT NULL, "+
+			"create_at DATETIME NOT NULL, "+
+			"UNIQUE KEY uq_part_barcode_gtin (gtin), "+
+			"KEY idx_part_barcodes_part (part_id)"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+	)
+}
+
+// barcodeCheckDigit вычисляет контрольную цифру GTIN для цифр без неё:
+// справа налево веса 3 и 1, дополнение суммы до кратного 10.
+func barcodeCheckDigit(digits string) byte {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if (len(digits)-1-i)%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// ParseBarcode убирает из штрихкода пробелы и дефисы, определяет его вид по длине
+// и проверяет контрольную цифру. Возвращает цифры и вид штрихкода.
+func ParseBarcode(code string) (string, string, error) {
+	code = strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(code))
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return "", "", ErrBarcodeFormat
+		}
+	}
+	var kind string
+	switch len(code) {
+	case 8:
+		kind = BarcodeEAN8
+	case 12:
+		kind = BarcodeUPCA
+	case 13:
+		kind = BarcodeEAN13
+	default:
+		return "", "", ErrBarcodeFormat
+	}
+	if barcodeCheckDigit(code[:len(code)-1]) != code[len(code)-1] {
+		return "", "", ErrBarcodeChecksum
+	}
+	return code, kind, nil
+}
+
+// barcodeGTIN дополняет проверенный штрихкод нулями слева до 13 цифр.
+func barcodeGTIN(code string) string {
+	return strings.Repeat("0", 13-len(code)) + code
+}
+
+// GetPartBarcodes возвращает штрихкоды запчасти в порядке добавления; первый печатается на этикетке.
+func GetPartBarcodes(db *sql.DB, partID int) ([]PartBarcode, error) {
+	rows, err := db.Query("SELECT barcode_id, part_id, code, kind, create_at FROM part_barcodes WHERE part_id = ? ORDER BY barcode_id", partID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	barcodes := []PartBarcode{}
+	for rows.Next() {
+		var b PartBarcode
+		if err := rows.Scan(&b.ID, &b.PartID, &b.Code, &b.Kind, &b.CreateAt); err != nil {
+			return nil, err
+		}
+		barcodes = append(barcodes, b)
+	}
+	return barcodes, rows.Err()
+}
+
+// AddPartBarcode назначает запчасти штрихкод и возвращает его ID. Повторное назначение
+// того же штрихкода той же запчасти ничего не меняет. Возвращает sql.ErrNoRows, если
+// запчасти нет, и ErrBarcodeExists, если штрихкод принадлежит другой запчасти.
+func AddPartBarcode(db *sql.DB, partID int, code string) (int64, error) {
+	code, kind, err := ParseBarcode(code)
+	if err != nil {
+		return 0, err
+	}
+	var live bool
+	if err := db.QueryRow("SELECT deleted_at IS NULL FROM parts WHERE part_id = ?", partID).Scan(&live); err != nil {
+		return 0, err
+	}
+	if !live {
+		return 0, sql.ErrNoRows
+	}
+	// Штрихкод запчасти, удалённой до очистки корзины без него, свободен.
+	orphan := "DELETE b FROM part_barcodes b LEFT JOIN parts p ON p.part_id = b.part_id WHERE b.gtin = ? AND p.part_id IS NULL"
+	if _, err := db.Exec(orphan, barcodeGTIN(code)); err != nil {
+		return 0, err
+	}
+	var id int64
+	var owner int
+	query := "SELECT b.barcode_id, b.part_id FROM part_barcodes b JOIN parts p ON p.part_id = b.part_id WHERE b.gtin = ?"
+	err = db.QueryRow(query, barcodeGTIN(code)).Scan(&id, &owner)
+	switch {
+	case err == nil && owner == partID:
+		return id, nil
+	case err == nil:
+		return 0, fmt.Errorf("%w (запчасть %d)", ErrBarcodeExists, owner)
+	case err != sql.ErrNoRows:
+		return 0, err
+	}
+	res, err := db.Exec("INSERT INTO part_barcodes (part_id, code, kind, gtin, create_at) VALUES (?, ?, ?, ?, NOW())",
+		partID, code, kind, barcodeGTIN(code))
+	if isDuplicateKey(err) {
+		return 0, ErrBarcodeExists
+	}
+	if err != nil {
+		return 0, err
+	}
+	log.Printf("[INFO] AddPartBarcode: запчасти %d назначен штрихкод %s (%s)", partID, code, kind)
+	return res.LastInsertId()
+}
+
+// DeletePartBarcode удаляет штрихкод запчасти.
+func DeletePartBarcode(db *sql.DB, partID, barcodeID int) (int64, error) {
+	return rowsAffected(db.Exec("DELETE FROM part_barcodes WHERE barcode_id = ? AND part_id = ?", barcodeID, partID))
+}
+
+// FindPartByBarcode возвращает ID запчасти вне корзины со штрихкодом code. UPC-A находится
+// и по коду EAN-13 с ведущим нулём, который выдают многие сканеры, и наоборот.
+// Возвращает sql.ErrNoRows, если запчасти нет.
+func FindPartByBarcode(db *sql.DB, code string) (int, error) {
+	code, _, err := ParseBarcode(code)
+	if err != nil {
+		return 0, err
+	}
+	var partID int
+	query := "SELECT b.part_id FROM part_barcodes b JOIN parts p ON p.part_id = b.part_id WHERE b.gtin = ? AND p.deleted_at IS NULL"
+	err = db.QueryRow(query, barcodeGTIN(code)).Scan(&partID)
+	return partID, err
+}
+
+// Шаблоны цифр EAN: набор L (нечётный), для набора G – зеркальный R, R – инверсия L.
+var eanL = [10]string{"0001101", "0011001", "0010011", "0111101", "0100011", "0110001", "0101111", "0111011", "0110111", "0001011"}
+
+// eanParity – наборы (L или G) цифр левой половины EAN-13 в зависимости от первой цифры.
+var eanParity = [10]string{"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG", "LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL"}
+
+// eanDigit возвращает модули цифры в наборе L, G или R.
+func eanDigit(d byte, set byte) string {
+	l := eanL[d-'0']
+	var b strings.Builder
+	for i := range l {
+		switch set {
+		case 'L':
+			b.WriteByte(l[i])
+		case 'R':
+			b.WriteByte('0' + '1' - l[i])
+		case 'G':
+			b.WriteByte('0' + '1' - l[len(l)-1-i])
+		}
+	}
+	return b.String()
+}
+
+// BarcodeModules кодирует штрихкод в последовательность модулей (true – штрих) без свободных
+// зон: 95 модулей для EAN-13 и UPC-A, 67 – для EAN-8.
+func BarcodeModules(code string) ([]bool, error) {
+	code, kind, err := ParseBarcode(code)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	b.WriteString("101")
+	switch kind {
+	case BarcodeEAN8:
+		for i := 0; i < 4; i++ {
+			b.WriteString(eanDigit(code[i], 'L'))
+		}
+		b.WriteString("01010")
+		for i := 4; i < 8; i++ {
+			b.WriteString(eanDigit(code[i], 'R'))
+		}
+	default:
+		// UPC-A кодируется как EAN-13 с первой цифрой 0.
+		code = barcodeGTIN(code)
+		parity := eanParity[code[0]-'0']
+		for i := 1; i < 7; i++ {
+			b.WriteString(eanDigit(code[i], parity[i-1]))
+		}
+		b.WriteString("01010")
+		for i := 7; i < 13; i++ {
+			b.WriteString(eanDigit(code[i], 'R'))
+		}
+	}
+	b.WriteString("101")
+	modules := make([]bool, 0, b.Len())
+	for _, c := range b.String() {
+		modules = append(modules, c == '1')
+	}
+	return modules, nil
+}
+
+// LabelText приводит текст к знакам, которые есть в шрифтах этикеток: латиница в верхнем регистре,
+// цифры и " -./:#"; кириллические буквы-двойники заменяются латинскими, остальное – знаком "?".
+func LabelText(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		if latin, ok := cyrillicLookalikes[r]; ok {
+			r = latin
+		}
+		if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune(" -./:#", r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('?')
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// BarcodeLabel – данные этикетки: запчасть, её первый штрихкод и цена в BaseCurrency.
+type BarcodeLabel struct {
+	PartID  int
+	Name    string
+	Article string
+	Code    string
+	Price   float64
+}
+
+// LabelRequest – запчасть и число её этикеток.
+type LabelRequest struct {
+	PartID int
+	Copies int
+}
+
+// ErrLabelNoBarcode – у запчасти нет штрихкода для этикетки.
+var ErrLabelNoBarcode = errors.New("у запчастей нет штрихкода")
+
+// GetBarcodeLabels возвращает этикетки для запчастей в порядке запроса, каждую – Copies раз.
+// Возвращает sql.ErrNoRows, если запчасти нет, и ErrLabelNoBarcode со списком запчастей без штрихкода.
+func GetBarcodeLabels(db *sql.DB, requests []LabelRequest) ([]BarcodeLabel, error) {
+	var labels []BarcodeLabel
+	var missing []string
+	for _, req := range requests {
+		var label BarcodeLabel
+		var code sql.NullString
+		query := "SELECT p.part_id, p.name, p.price, " +
+			"COALESCE((SELECT n.number FROM part_numbers n WHERE n.part_id = p.part_id AND n.kind = ? ORDER BY n.number_id LIMIT 1), ''), " +
+			"(SELECT b.code FROM part_barcodes b WHERE b.part_id = p.part_id ORDER BY b.barcode_id LIMIT 1) " +
+			"FROM parts p WHERE p.part_id = ? AND p.deleted_at IS NULL"
+		err := db.QueryRow(query, NumberKindArticle, req.PartID).Scan(&label.PartID, &label.Name, &label.Price, &label.Article, &code)
+		if err != nil {
+			return nil, fmt.Errorf("запчасть %d: %w", req.PartID, err)
+		}
+		if !code.Valid {
+			missing = append(missing, fmt.Sprint(req.PartID))
+			continue
+		}
+		label.Code = code.String
+		for i := 0; i < req.Copies; i++ {
+			labels = append(labels, label)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrLabelNoBarcode, strings.Join(missing, ", "))
+	}
+	return labels, nil
+}
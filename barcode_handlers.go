@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"AutoM/config"
+	"AutoM/models"
+
+	"github.com/gorilla/mux"
+)
+
+// respondBarcodeError отвечает статусом ошибки штрихкода; notFound – сообщение для sql.ErrNoRows.
+func respondBarcodeError(w http.ResponseWriter, handler, notFound string, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, notFound, http.StatusNotFound)
+	case errors.Is(err, models.ErrBarcodeFormat), errors.Is(err, models.ErrBarcodeChecksum), errors.Is(err, models.ErrLabelNoBarcode):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, models.ErrBarcodeExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("[ERROR] %s: %v", handler, err)
+		http.Error(w, "Ошибка работы со штрихкодами", http.StatusInternalServerError)
+	}
+}
+
+// GetPartBarcodes – штрихкоды запчасти (API).
+func GetPartBarcodes(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "GetPartBarcodes")
+	if !ok {
+		return
+	}
+	barcodes, err := models.GetPartBarcodes(config.DB, id)
+	if err != nil {
+		respondBarcodeError(w, "GetPartBarcodes", "Запчасть не найдена", err)
+		return
+	}
+	writeJSON(w, "GetPartBarcodes", barcodes)
+}
+
+// AddPartBarcode – назначение штрихкода запчасти (API). Ожидается JSON {"code": "4006633101004"};
+// принимаются EAN-13, EAN-8 и UPC-A с верной контрольной цифрой.
+func AddPartBarcode(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "AddPartBarcode")
+	if !ok {
+		return
+	}
+	var req struct {
+		Code string `json:"code"`
+	}
+	if !decodeJSON(w, r, "AddPartBarcode", &req) {
+		return
+	}
+	barcodeID, err := models.AddPartBarcode(config.DB, id, req.Code)
+	if err != nil {
+		respondBarcodeError(w, "AddPartBarcode", "Запчасть не найдена", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, "AddPartBarcode", map[string]int64{"id": barcodeID})
+}
+
+// DeletePartBarcode – удаление штрихкода запчасти (API).
+func DeletePartBarcode(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "DeletePartBarcode")
+	if !ok {
+		return
+	}
+	barcodeID, ok := pathInt(w, r, "barcodeId", "DeletePartBarcode")
+	if !ok {
+		return
+	}
+	n, err := models.DeletePartBarcode(config.DB, id, barcodeID)
+	if err == nil && n == 0 {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		respondBarcodeError(w, "DeletePartBarcode", "Штрихкод не найден", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetPartByBarcode – запчасть по отсканированному штрихкоду (API) в том же виде,
+// что и GetPartByID: с брендом, ценой группы покупателя и остатками по складам.
+func GetPartByBarcode(w http.ResponseWriter, r *http.Request) {
+	code := mux.Vars(r)["code"]
+	id, err := models.FindPartByBarcode(config.DB, code)
+	if err != nil {
+		respondBarcodeError(w, "GetPartByBarcode", "Запчасть со штрихкодом "+code+" не найдена", err)
+		return
+	}
+	currency, converter, ok := apiCurrency(w, r, "GetPartByBarcode")
+	if !ok {
+		return
+	}
+	part, err := models.GetPartListItem(config.DB, id)
+	if err != nil {
+		respondBarcodeError(w, "GetPartByBarcode", "Запчасть со штрихкодом "+code+" не найдена", err)
+		return
+	}
+	sessionPricing(r).ApplyToItem(&part)
+	convertPartItem(converter, currency, &part)
+	if part.Stock, err = models.GetPartStock(config.DB, id); err != nil {
+		log.Printf("[ERROR] GetPartByBarcode: ошибка получения остатков запчасти %d по складам: %v", id, err)
+	}
+	log.Printf("[INFO] GetPartByBarcode: штрихкод %s – запчасть %d", code, id)
+	writeJSON(w, "GetPartByBarcode", part)
+}
@@ -0,0 +1,418 @@
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"AutoM/config"
+	"AutoM/models"
+)
+
+// labelLayout – размер этикетки и её раскладка на листе; размеры в миллиметрах.
+// Для термопринтера лист равен этикетке.
+type labelLayout struct {
+	Title            string
+	PageW, PageH     float64
+	LabelW, LabelH   float64
+	Cols, Rows       int
+	MarginX, MarginY float64 // от края листа до первой этикетки
+	GapX, GapY       float64 // между этикетками
+}
+
+// labelLayouts – распространённые форматы этикеток: рулоны термопринтеров и листы A4.
+var labelLayouts = map[string]labelLayout{
+	"58x40": {Title: "Термоэтикетка 58×40 мм", PageW: 58, PageH: 40, LabelW: 58, LabelH: 40, Cols: 1, Rows: 1},
+	"58x30": {Title: "Термоэтикетка 58×30 мм", PageW: 58, PageH: 30, LabelW: 58, LabelH: 30, Cols: 1, Rows: 1},
+	"43x25": {Title: "Термоэтикетка 43×25 мм", PageW: 43, PageH: 25, LabelW: 43, LabelH: 25, Cols: 1, Rows: 1},
+	"a4-24": {Title: "A4, 24 этикетки 70×37 мм", PageW: 210, PageH: 297, LabelW: 70, LabelH: 37, Cols: 3, Rows: 8, MarginY: 0.5},
+	"a4-40": {Title: "A4, 40 этикеток 48,5×25,4 мм", PageW: 210, PageH: 297, LabelW: 48.5, LabelH: 25.4, Cols: 4, Rows: 10, MarginX: 8, MarginY: 21.5},
+	"a4-65": {Title: "A4, 65 этикеток 38,1×21,2 мм", PageW: 210, PageH: 297, LabelW: 38.1, LabelH: 21.2, Cols: 5, Rows: 13,
+		MarginX: 4.75, MarginY: 10.7, GapX: 2.5},
+}
+
+// defaultLabelLayout – формат этикеток, если параметр size не задан.
+const defaultLabelLayout = "58x40"
+
+// labelPNGDPI – разрешение PNG с этикетками (типичное для термопринтеров 300 dpi).
+const labelPNGDPI = 300
+
+// maxLabels ограничивает число этикеток в одном файле.
+const maxLabels = 5000
+
+// labelCanvas – поверхность, на которой рисуется этикетка. Координаты в миллиметрах
+// от левого верхнего угла листа; y текста – базовая линия, текст центрируется по x.
+type labelCanvas interface {
+	rect(x, y, w, h float64)
+	text(x, y, capHeight float64, s string)
+	// module возвращает ширину модуля штрихкода, которую поверхность может нарисовать точно.
+	module(w float64) float64
+}
+
+// drawLabel рисует этикетку с левым верхним углом (x, y): артикул (или ID запчасти),
+// штрихкод с цифрами и цену. Название не печатается: шрифты этикетки – только латиница.
+func drawLabel(c labelCanvas, x, y float64, l labelLayout, label models.BarcodeLabel) error {
+	modules, err := models.BarcodeModules(label.Code)
+	if err != nil {
+		return err
+	}
+	pad := math.Min(2, l.LabelH*0.06)
+	capHeight := math.Max(1.6, math.Min(3, l.LabelH*0.09))
+	lineGap := capHeight * 0.5
+	centerX := x + l.LabelW/2
+
+	title := models.LabelText(label.Article)
+	if title == "" {
+		title = fmt.Sprintf("ID %d", label.PartID)
+	}
+	topBaseline := y + pad + capHeight
+	c.text(centerX, topBaseline, capHeight, title)
+	priceBaseline := y + l.LabelH - pad
+	c.text(centerX, priceBaseline, capHeight, models.LabelText(fmt.Sprintf("%.2f %s", label.Price, models.BaseCurrency)))
+	digitsBaseline := priceBaseline - capHeight - lineGap
+	c.text(centerX, digitsBaseline, capHeight, label.Code)
+
+	// Свободные зоны: 11 и 7 модулей у EAN-13 и UPC-A, по 7 у EAN-8.
+	quietLeft, quietRight := 11, 7
+	if len(modules) == 67 {
+		quietLeft = 7
+	}
+	total := len(modules) + quietLeft + quietRight
+	mw := c.module((l.LabelW - 2*pad) / float64(total))
+	barsX := centerX - mw*float64(total)/2 + mw*float64(quietLeft)
+	barsY := topBaseline + lineGap
+	barsH := digitsBaseline - capHeight - lineGap - barsY
+	if barsH <= 0 || mw <= 0 {
+		return fmt.Errorf("этикетка %.1f×%.1f мм слишком мала для штрихкода", l.LabelW, l.LabelH)
+	}
+	for i := 0; i < len(modules); {
+		if !modules[i] {
+			i++
+			continue
+		}
+		start := i
+		for i < len(modules) && modules[i] {
+			i++
+		}
+		c.rect(barsX+mw*float64(start), barsY, mw*float64(i-start), barsH)
+	}
+	return nil
+}
+
+// labelPages раскладывает этикетки по листам: для каждого листа – вызов draw с координатами этикеток.
+func labelPages(l labelLayout, labels []models.BarcodeLabel, page func(n int, draw func(c labelCanvas) error) error) error {
+	perPage := l.Cols * l.Rows
+	for start, n := 0, 0; start < len(labels); start, n = start+perPage, n+1 {
+		end := start + perPage
+		if end > len(labels) {
+			end = len(labels)
+		}
+		batch := labels[start:end]
+		err := page(n, func(c labelCanvas) error {
+			for i, label := range batch {
+				x := l.MarginX + float64(i%l.Cols)*(l.LabelW+l.GapX)
+				y := l.MarginY + float64(i/l.Cols)*(l.LabelH+l.GapY)
+				if err := drawLabel(c, x, y, l, label); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mmToPt переводит миллиметры в пункты PDF.
+const mmToPt = 72 / 25.4
+
+// pdfCanvas рисует этикетки в поток содержимого страницы PDF шрифтом Helvetica.
+type pdfCanvas struct {
+	buf   bytes.Buffer
+	pageH float64 // высота страницы в мм: у PDF ось y направлена вверх
+}
+
+func (c *pdfCanvas) rect(x, y, w, h float64) {
+	fmt.Fprintf(&c.buf, "%.3f %.3f %.3f %.3f re f\n", x*mmToPt, (c.pageH-y-h)*mmToPt, w*mmToPt, h*mmToPt)
+}
+
+func (c *pdfCanvas) text(x, y, capHeight float64, s string) {
+	// Высота прописных Helvetica – 0,718 кегля, средняя ширина знака – около 0,6 кегля (цифры – 0,556).
+	size := capHeight / 0.718 * mmToPt
+	width := 0.6 * size * float64(len(s))
+	s = strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s)
+	fmt.Fprintf(&c.buf, "BT /F1 %.2f Tf %.3f %.3f Td (%s) Tj ET\n", size, x*mmToPt-width/2, (c.pageH-y)*mmToPt, s)
+}
+
+func (c *pdfCanvas) module(w float64) float64 { return w }
+
+// writeLabelsPDF записывает этикетки в PDF: по странице на лист раскладки.
+func writeLabelsPDF(w io.Writer, l labelLayout, labels []models.BarcodeLabel) error {
+	var pages [][]byte
+	err := labelPages(l, labels, func(n int, draw func(c labelCanvas) error) error {
+		c := &pdfCanvas{pageH: l.PageH}
+		if err := draw(c); err != nil {
+			return err
+		}
+		pages = append(pages, c.buf.Bytes())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Объекты: 1 – каталог, 2 – дерево страниц, 3 – шрифт, далее страница и её содержимое.
+	out := bufio.NewWriter(w)
+	var offsets []int
+	written := 0
+	write := func(format string, args ...interface{}) {
+		n, _ := fmt.Fprintf(out, format, args...)
+		written += n
+	}
+	object := func(body string, args ...interface{}) {
+		offsets = append(offsets, written)
+		write("%d 0 obj\n"+body+"\nendobj\n", append([]interface{}{len(offsets)}, args...)...)
+	}
+	write("%%PDF-1.4\n")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	for i, content := range pages {
+		object("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.3f %.3f] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			l.PageW*mmToPt, l.PageH*mmToPt, 5+2*i)
+		object("<< /Length %d >>\nstream\n%sendstream", len(content), content)
+	}
+	xref := written
+	write("xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		write("%010d 00000 n \n", off)
+	}
+	write("trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Flush()
+}
+
+// pngCanvas рисует этикетки в растровое изображение листа.
+type pngCanvas struct {
+	img   *image.Gray
+	scale float64 // пикселей на миллиметр
+}
+
+func (c *pngCanvas) fill(x0, y0, x1, y1 int) {
+	b := c.img.Bounds()
+	for py := max(y0, b.Min.Y); py < min(y1, b.Max.Y); py++ {
+		for px := max(x0, b.Min.X); px < min(x1, b.Max.X); px++ {
+			c.img.SetGray(px, py, color.Gray{})
+		}
+	}
+}
+
+func (c *pngCanvas) rect(x, y, w, h float64) {
+	c.fill(int(math.Round(x*c.scale)), int(math.Round(y*c.scale)), int(math.Round((x+w)*c.scale)), int(math.Round((y+h)*c.scale)))
+}
+
+func (c *pngCanvas) text(x, y, capHeight float64, s string) {
+	dot := int(math.Max(1, math.Round(capHeight*c.scale/7)))
+	width := (len(s)*6 - 1) * dot
+	left := int(math.Round(x*c.scale)) - width/2
+	top := int(math.Round(y*c.scale)) - 7*dot
+	for i, r := range s {
+		glyph, ok := labelFont[r]
+		if !ok {
+			glyph = labelFont['?']
+		}
+		for row, bits := range glyph {
+			for col := 0; col < 5; col++ {
+				if bits&(0x10>>col) != 0 {
+					px, py := left+(i*6+col)*dot, top+row*dot
+					c.fill(px, py, px+dot, py+dot)
+				}
+			}
+		}
+	}
+}
+
+// module округляет ширину модуля до целого числа пикселей (не меньше одного), чтобы штрихи были одинаковыми.
+func (c *pngCanvas) module(w float64) float64 {
+	return math.Max(1, math.Floor(w*c.scale)) / c.scale
+}
+
+// writeLabelsPNG записывает в PNG лист номер page (с нуля) и возвращает число листов.
+func writeLabelsPNG(w io.Writer, l labelLayout, labels []models.BarcodeLabel, page int) (int, error) {
+	scale := labelPNGDPI / 25.4
+	pages := (len(labels) + l.Cols*l.Rows - 1) / (l.Cols * l.Rows)
+	if page < 0 || page >= pages {
+		return pages, fmt.Errorf("нет листа %d; всего листов: %d", page+1, pages)
+	}
+	perPage := l.Cols * l.Rows
+	img := image.NewGray(image.Rect(0, 0, int(math.Round(l.PageW*scale)), int(math.Round(l.PageH*scale))))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+	end := min((page+1)*perPage, len(labels))
+	err := labelPages(l, labels[page*perPage:end], func(n int, draw func(c labelCanvas) error) error {
+		return draw(&pngCanvas{img: img, scale: scale})
+	})
+	if err != nil {
+		return pages, err
+	}
+	return pages, png.Encode(w, img)
+}
+
+// labelFont – растровый шрифт 5×7 для PNG: строки сверху вниз, старший из пяти битов – левый столбец.
+var labelFont = map[rune][7]byte{
+	'0': {0x0E, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0E}, '1': {0x04, 0x0C, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'2': {0x0E, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1F}, '3': {0x1F, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0E},
+	'4': {0x02, 0x06, 0x0A, 0x12, 0x1F, 0x02, 0x02}, '5': {0x1F, 0x10, 0x1E, 0x01, 0x01, 0x11, 0x0E},
+	'6': {0x06, 0x08, 0x10, 0x1E, 0x11, 0x11, 0x0E}, '7': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
+	'8': {0x0E, 0x11, 0x11, 0x0E, 0x11, 0x11, 0x0E}, '9': {0x0E, 0x11, 0x11, 0x0F, 0x01, 0x02, 0x0C},
+	'A': {0x0E, 0x11, 0x11, 0x11, 0x1F, 0x11, 0x11}, 'B': {0x1E, 0x11, 0x11, 0x1E, 0x11, 0x11, 0x1E},
+	'C': {0x0E, 0x11, 0x10, 0x10, 0x10, 0x11, 0x0E}, 'D': {0x1C, 0x12, 0x11, 0x11, 0x11, 0x12, 0x1C},
+	'E': {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x1F}, 'F': {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x10},
+	'G': {0x0E, 0x11, 0x10, 0x17, 0x11, 0x11, 0x0F}, 'H': {0x11, 0x11, 0x11, 0x1F, 0x11, 0x11, 0x11},
+	'I': {0x0E, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0E}, 'J': {0x07, 0x02, 0x02, 0x02, 0x02, 0x12, 0x0C},
+	'K': {0x11, 0x12, 0x14, 0x18, 0x14, 0x12, 0x11}, 'L': {0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x1F},
+	'M': {0x11, 0x1B, 0x15, 0x15, 0x11, 0x11, 0x11}, 'N': {0x11, 0x11, 0x19, 0x15, 0x13, 0x11, 0x11},
+	'O': {0x0E, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E}, 'P': {0x1E, 0x11, 0x11, 0x1E, 0x10, 0x10, 0x10},
+	'Q': {0x0E, 0x11, 0x11, 0x11, 0x15, 0x12, 0x0D}, 'R': {0x1E, 0x11, 0x11, 0x1E, 0x14, 0x12, 0x11},
+	'S': {0x0F, 0x10, 0x10, 0x0E, 0x01, 0x01, 0x1E}, 'T': {0x1F, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04},
+	'U': {0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E}, 'V': {0x11, 0x11, 0x11, 0x11, 0x11, 0x0A, 0x04},
+	'W': {0x11, 0x11, 0x11, 0x15, 0x15, 0x15, 0x0A}, 'X': {0x11, 0x11, 0x0A, 0x04, 0x0A, 0x11, 0x11},
+	'Y': {0x11, 0x11, 0x11, 0x0A, 0x04, 0x04, 0x04}, 'Z': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x10, 0x1F},
+	' ': {}, '-': {0, 0, 0, 0x1F, 0, 0, 0}, '.': {0, 0, 0, 0, 0, 0x0C, 0x0C},
+	'/': {0, 0x01, 0x02, 0x04, 0x08, 0x10, 0}, ':': {0, 0x0C, 0x0C, 0, 0x0C, 0x0C, 0},
+	'#': {0x0A, 0x0A, 0x1F, 0x0A, 0x1F, 0x0A, 0x0A}, '?': {0x0E, 0x11, 0x01, 0x02, 0x04, 0, 0x04},
+}
+
+// parseLabelRequests разбирает список "ID[:копий]" через запятую, например "5:3, 8".
+func parseLabelRequests(s string) ([]models.LabelRequest, error) {
+	var requests []models.LabelRequest
+	total := 0
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		idText, copiesText, hasCopies := strings.Cut(item, ":")
+		id, err := strconv.Atoi(strings.TrimSpace(idText))
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("неверный ID запчасти %q", idText)
+		}
+		copies := 1
+		if hasCopies {
+			if copies, err = strconv.Atoi(strings.TrimSpace(copiesText)); err != nil || copies <= 0 {
+				return nil, fmt.Errorf("неверное число этикеток %q", copiesText)
+			}
+		}
+		if total += copies; total > maxLabels {
+			return nil, fmt.Errorf("не больше %d этикеток за раз", maxLabels)
+		}
+		requests = append(requests, models.LabelRequest{PartID: id, Copies: copies})
+	}
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("не указаны запчасти")
+	}
+	return requests, nil
+}
+
+// loadLabels читает параметры parts и size запроса и собирает этикетки.
+// При ошибке отвечает клиенту и возвращает false.
+func loadLabels(w http.ResponseWriter, r *http.Request, handler string) (labelLayout, []models.BarcodeLabel, bool) {
+	size := r.URL.Query().Get("size")
+	if size == "" {
+		size = defaultLabelLayout
+	}
+	layout, ok := labelLayouts[size]
+	if !ok {
+		names := make([]string, 0, len(labelLayouts))
+		for name := range labelLayouts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		http.Error(w, "Неизвестный формат этикеток; доступны: "+strings.Join(names, ", "), http.StatusBadRequest)
+		return layout, nil, false
+	}
+	requests, err := parseLabelRequests(r.URL.Query().Get("parts"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return layout, nil, false
+	}
+	labels, err := models.GetBarcodeLabels(config.DB, requests)
+	if err != nil {
+		respondBarcodeError(w, handler, "Запчасть не найдена", err)
+		return layout, nil, false
+	}
+	return layout, labels, true
+}
+
+// BarcodeLabelsPDFHandler – PDF с этикетками штрихкодов (только для администратора).
+// Параметры: parts – "ID[:копий]" через запятую, size – формат из labelLayouts (по умолчанию 58x40).
+func BarcodeLabelsPDFHandler(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	layout, labels, ok := loadLabels(w, r, "BarcodeLabelsPDFHandler")
+	if !ok {
+		return
+	}
+	var buf bytes.Buffer
+	if err := writeLabelsPDF(&buf, layout, labels); err != nil {
+		log.Printf("[ERROR] BarcodeLabelsPDFHandler: ошибка формирования PDF: %v", err)
+		http.Error(w, "Ошибка формирования этикеток: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Printf("[INFO] BarcodeLabelsPDFHandler: сформировано этикеток: %d (%s)", len(labels), layout.Title)
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `inline; filename="labels.pdf"`)
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Printf("[ERROR] BarcodeLabelsPDFHandler: ошибка отправки PDF: %v", err)
+	}
+}
+
+// BarcodeLabelsPNGHandler – лист этикеток штрихкодов в PNG 300 dpi (только для администратора).
+// Параметры те же, что у BarcodeLabelsPDFHandler, и page – номер листа с единицы;
+// число листов возвращается в заголовке X-Label-Pages.
+func BarcodeLabelsPNGHandler(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	layout, labels, ok := loadLabels(w, r, "BarcodeLabelsPNGHandler")
+	if !ok {
+		return
+	}
+	page, err := queryInt(r, "page")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if page == 0 {
+		page = 1
+	}
+	var buf bytes.Buffer
+	pages, err := writeLabelsPNG(&buf, layout, labels, page-1)
+	if err != nil {
+		log.Printf("[ERROR] BarcodeLabelsPNGHandler: ошибка формирования PNG: %v", err)
+		http.Error(w, "Ошибка формирования этикеток: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("X-Label-Pages", strconv.Itoa(pages))
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Printf("[ERROR] BarcodeLabelsPNGHandler: ошибка отправки PNG: %v", err)
+	}
+}
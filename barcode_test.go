@@ -0,0 +1,65 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseBarcode(t *testing.T) {
+	tests := []struct {
+		in, code, kind string
+		err            error
+	}{
+		{"4006381333931", "4006381333931", BarcodeEAN13, nil},
+		{"400-638-133393-1", "4006381333931", BarcodeEAN13, nil},
+		{"5901234123457", "5901234123457", BarcodeEAN13, nil},
+		{"036000291452", "036000291452", BarcodeUPCA, nil},
+		{"96385074", "96385074", BarcodeEAN8, nil},
+		{"4006381333932", "", "", ErrBarcodeChecksum},
+		{"036000291453", "", "", ErrBarcodeChecksum},
+		{"12345", "", "", ErrBarcodeFormat},
+		{"40063813339AB", "", "", ErrBarcodeFormat},
+	}
+	for _, tt := range tests {
+		code, kind, err := ParseBarcode(tt.in)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ParseBarcode(%q): ошибка %v, ожидалась %v", tt.in, err, tt.err)
+			continue
+		}
+		if code != tt.code || kind != tt.kind {
+			t.Errorf("ParseBarcode(%q) = %q, %q, ожидалось %q, %q", tt.in, code, kind, tt.code, tt.kind)
+		}
+	}
+}
+
+func TestBarcodeModules(t *testing.T) {
+	modules := func(code string) string {
+		m, err := BarcodeModules(code)
+		if err != nil {
+			t.Fatalf("BarcodeModules(%q): %v", code, err)
+		}
+		s := make([]byte, len(m))
+		for i, bar := range m {
+			s[i] = '0'
+			if bar {
+				s[i] = '1'
+			}
+		}
+		return string(s)
+	}
+	// Эталонное кодирование EAN-8 96385074: L-набор слева, R-набор справа.
+	want := "101" + "0001011" + "0101111" + "0111101" + "0110111" + "01010" + "1001110" + "1110010" + "1000100" + "1011100" + "101"
+	if got := modules("96385074"); got != want {
+		t.Errorf("BarcodeModules(96385074) = %s, ожидалось %s", got, want)
+	}
+	// UPC-A кодируется так же, как EAN-13 с ведущим нулём.
+	upc, ean := modules("036000291452"), modules("0036000291452")
+	if len(upc) != 95 || upc != ean {
+		t.Errorf("BarcodeModules: UPC-A %s не совпадает с EAN-13 %s", upc, ean)
+	}
+	// У EAN-13 с первой цифрой 4 наборы левой половины – LGLLGG.
+	got := modules("4006381333931")
+	if len(got) != 95 || got[3:10] != "0001101" || got[10:17] != "0100111" {
+		t.Errorf("BarcodeModules(4006381333931) = %s: неверные наборы левой половины", got)
+	}
+}
@@ -0,0 +1,210 @@
+package models
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+)
+
+// Brand описывает запись из таблицы брендов (производителей запчастей).
+// Таблица имеет следующие столбцы:
+// brand_id (PK, auto_increment), name, country, is_original, create_at, update_at.
+type Brand struct {
+	ID         int       `json:"id"`          // brand_id – первичный ключ
+	Name       string    `json:"name"`        // название бренда
+	Country    string    `json:"country"`     // страна бренда
+	IsOriginal bool      `json:"is_original"` // бренд выпускает оригинальные запчасти (поставщик конвейера)
+	CreateAt   time.Time `json:"create_at"`   // время создания
+	UpdateAt   time.Time `json:"update_at"`   // время последнего обновления
+}
+
+// migrateBrands создаёт таблицу брендов и добавляет ссылку на бренд в таблицу запчастей.
+func migrateBrands(db *sql.DB) error {
+	err := execAll(db,
+		"CREATE TABLE IF NOT EXISTS brands ("+
+			"brand_id INT AUTO_INCREMENT PRIMARY KEY, "+
+			"name VARCHAR(100) NOT NULL, "+
+			"country VARCHAR(100) NOT NULL DEFAULT '', "+
+			"is_original TINYINT(1) NOT NULL DEFAULT 0, "+
+			"create_at DATETIME NOT NULL, "+
+			"update_at DATETIME NOT NULL, "+
+			"UNIQUE KEY uq_brand_name (name)"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+	)
+	if err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "parts", "brand_id", "INT NULL"); err != nil {
+		return err
+	}
+	return ensureIndex(db, "parts", "idx_parts_brand", "brand_id")
+}
+
+// GetAllBrands возвращает список всех брендов, упорядоченный по названию.
+func GetAllBrands(db *sql.DB) ([]Brand, error) {
+	log.Println("[INFO] GetAllBrands: начало запроса")
+	query := "SELECT brand_id AS id, name, country, is_original, create_at, update_at FROM brands ORDER BY name"
+	log.Printf("[INFO] GetAllBrands: выполняется запрос: %s", query)
+
+	rows, err := db.Query(query)
+	if err != nil {
+		log.Printf("[ERROR] GetAllBrands: ошибка выполнения запроса: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var brands []Brand
+	for rows.Next() {
+		var b Brand
+		if err := rows.Scan(&b.ID, &b.Name, &b.Country, &b.IsOriginal, &b.CreateAt, &b.UpdateAt); err != nil {
+			log.Printf("[ERROR] GetAllBrands: ошибка сканирования строки: %v", err)
+			return nil, err
+		}
+		brands = append(brands, b)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("[ERROR] GetAllBrands: ошибка итерации по строкам: %v", err)
+		return nil, err
+	}
+	log.Printf("[INFO] GetAllBrands: успешно получено %d брендов", len(brands))
+	return brands, nil
+}
+
+// GetBrandByID возвращает бренд по его ID.
+func GetBrandByID(db *sql.DB, id int) (Brand, error) {
+	log.Printf("[INFO] GetBrandByID: запрос бренда с ID %d", id)
+	var b Brand
+	query := "SELECT brand_id AS id, name, country, is_original, create_at, update_at FROM brands WHERE brand_id = ?"
+	err := db.QueryRow(query, id).Scan(&b.ID, &b.Name, &b.Country, &b.IsOriginal, &b.CreateAt, &b.UpdateAt)
+	if err != nil {
+		log.Printf("[ERROR] GetBrandByID: ошибка получения бренда с ID %d: %v", id, err)
+		return Brand{}, err
+	}
+	return b, nil
+}
+
+// GetBrandByName возвращает бренд по названию без учёта регистра.
+func GetBrandByName(db *sql.DB, name string) (Brand, error) {
+	var b Brand
+	query := "SELECT brand_id AS id, name, country, is_original, create_at, update_at FROM brands WHERE LOWER(name) = LOWER(?)"
+	err := db.QueryRow(query, strings.TrimSpace(name)).Scan(&b.ID, &b.Name, &b.Country, &b.IsOriginal, &b.CreateAt, &b.UpdateAt)
+	return b, err
+}
+
+// AddBrand добавляет новый бренд в базу данных.
+func AddBrand(db *sql.DB, b Brand) error {
+	log.Printf("[INFO] AddBrand: добавление нового бренда: '%s'", b.Name)
+	query := "INSERT INTO brands (name, country, is_original, create_at, update_at) VALUES (?, ?, ?, NOW(), NOW())"
+	if _, err := db.Exec(query, strings.TrimSpace(b.Name), b.Country, b.IsOriginal); err != nil {
+		log.Printf("[ERROR] AddBrand: ошибка выполнения запроса: %v", err)
+		return err
+	}
+	log.Printf("[INFO] AddBrand: бренд '%s' успешно добавлен", b.Name)
+	return nil
+}
+
+// EnsureBrand возвращает ID бренда с указанным названием, создавая бренд при его отсутствии.
+// Используется при импорте, где бренд задан только названием.
+func EnsureBrand(db *sql.DB, name string) (int, error) {
+	name = strings.TrimSpace(name)
+	b, err := GetBrandByName(db, name)
+	if err == nil {
+		return b.ID, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, err
+	}
+	log.Printf("[INFO] EnsureBrand: создание бренда '%s'", name)
+	res, err := db.Exec("INSERT INTO brands (name, create_at, update_at) VALUES (?, NOW(), NOW())", name)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+// UpdateBrand обновляет данные бренда по его ID.
+func UpdateBrand(db *sql.DB, id int, b Brand) (int64, error) {
+	log.Printf("[INFO] UpdateBrand: обновление бренда с ID %d", id)
+	query := "UPDATE brands SET name = ?, country = ?, is_original = ?, update_at = NOW() WHERE brand_id = ?"
+	return rowsAffected(db.Exec(query, strings.TrimSpace(b.Name), b.Country, b.IsOriginal, id))
+}
+
+// DeleteBrand удаляет бренд по его ID. Запчасти бренда остаются без бренда.
+func DeleteBrand(db *sql.DB, id int) (int64, error) {
+	log.Printf("[INFO] DeleteBrand: удаление бренда с ID %d", id)
+	if _, err := db.Exec("UPDATE parts SET brand_id = NULL WHERE brand_id = ?", id); err != nil {
+		log.Printf("[ERROR] DeleteBrand: ошибка отвязки запчастей от бренда %d: %v", id, err)
+		return 0, err
+	}
+	return rowsAffected(db.Exec("DELETE FROM brands WHERE brand_id = ?", id))
+}
+
+// SetPartBrand устанавливает бренд запчасти; brandID = 0 снимает привязку к бренду.
+func SetPartBrand(db *sql.DB, partID, brandID int) error {
+	var value interface{}
+	if brandID > 0 {
+		value = brandID
+	}
+	if _, err := db.Exec("UPDATE parts SET brand_id = ? WHERE part_id = ?", value, partID); err != nil {
+		log.Printf("[ERROR] SetPartBrand: ошибка установки бренда %d для запчасти %d: %v", brandID, partID, err)
+		return err
+	}
+	return nil
+}
+
+// InsertBrandedPart добавляет запчасть вместе с брендом (brandID = 0 – без бренда)
+// и возвращает её ID. Остаток part.Quantity заводится на склад по умолчанию движением по журналу.
+func InsertBrandedPart(db *sql.DB, part Part, brandID int) (int, error) {
+	defer InvalidateCatalogTree()
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	id, err := insertBrandedPart(tx, part, brandID, MovementSource{Reason: "Начальный остаток"})
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	partSlugs.update(db, id, "")
+	if part.ImageURL != nil {
+		err = EnsureExternalPartImage(db, id, strings.TrimSpace(*part.ImageURL))
+	}
+	return id, err
+}
+
+// insertBrandedPart – InsertBrandedPart внутри транзакции, без слага и изображения.
+// Запчасть создаётся с нулевым остатком; part.Quantity записывается в журнал движений с основанием src.
+func insertBrandedPart(db dbExecutor, part Part, brandID int, src MovementSource) (int, error) {
+	if part.Quantity < 0 {
+		return 0, ErrStockQuantity
+	}
+	var brand interface{}
+	if brandID > 0 {
+		brand = brandID
+	}
+	query := "INSERT INTO parts (name, description, price, image_url, subcategory_id, quantity, brand_id, create_at, update_at) " +
+		"VALUES (?, ?, ?, ?, ?, 0, ?, NOW(), NOW())"
+	res, err := db.Exec(query, part.Name, part.Description, part.Price, part.ImageURL, part.SubcategoryID, brand)
+	if err != nil {
+		log.Printf("[ERROR] InsertBrandedPart: ошибка добавления запчасти '%s': %v", part.Name, err)
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if part.Quantity > 0 {
+		// Остаток записывается в журнал; пересчёт общего остатка сверяет его с минимальным.
+		return int(id), setPartStock(db, int(id), 0, part.Quantity, src)
+	}
+	// Запчасть без остатка сразу сверяется с минимальным остатком подкатегории.
+	if err := checkStockAlerts(db, "p.part_id = ?", id); err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
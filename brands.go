@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"AutoM/config"
+	"AutoM/models"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// GetAllBrands – обработчик для получения списка всех брендов в формате JSON.
+func GetAllBrands(w http.ResponseWriter, r *http.Request) {
+	log.Println("[INFO] GetAllBrands: начало запроса")
+
+	brands, err := models.GetAllBrands(config.DB)
+	if err != nil {
+		log.Printf("[ERROR] GetAllBrands: ошибка загрузки брендов: %v", err)
+		http.Error(w, "Ошибка загрузки брендов", http.StatusInternalServerError)
+		return
+	}
+	if brands == nil {
+		brands = []models.Brand{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(brands); err != nil {
+		log.Printf("[ERROR] GetAllBrands: ошибка кодирования JSON: %v", err)
+	} else {
+		log.Println("[INFO] GetAllBrands: данные успешно отправлены")
+	}
+}
+
+// GetBrandByID – обработчик для получения бренда по ID.
+func GetBrandByID(w http.ResponseWriter, r *http.Request) {
+	log.Println("[INFO] GetBrandByID: начало запроса")
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Printf("[ERROR] GetBrandByID: неверный ID, ошибка преобразования: %v", err)
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	brand, err := models.GetBrandByID(config.DB, id)
+	if err != nil {
+		log.Printf("[ERROR] GetBrandByID: ошибка получения бренда: %v", err)
+		http.Error(w, "Бренд не найден", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(brand); err != nil {
+		log.Printf("[ERROR] GetBrandByID: ошибка кодирования JSON: %v", err)
+	} else {
+		log.Printf("[INFO] GetBrandByID: бренд с ID %d успешно отправлен", id)
+	}
+}
+
+// AddBrand – обработчик для добавления нового бренда.
+func AddBrand(w http.ResponseWriter, r *http.Request) {
+	log.Println("[INFO] AddBrand: начало запроса")
+
+	var brand models.Brand
+	if err := json.NewDecoder(r.Body).Decode(&brand); err != nil {
+		log.Printf("[ERROR] AddBrand: ошибка декодирования JSON: %v", err)
+		http.Error(w, "Неверный формат данных", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(brand.Name) == "" {
+		http.Error(w, "Не указано название бренда", http.StatusBadRequest)
+		return
+	}
+
+	if err := models.AddBrand(config.DB, brand); err != nil {
+		log.Printf("[ERROR] AddBrand: ошибка добавления бренда: %v", err)
+		http.Error(w, "Ошибка добавления бренда", http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("[INFO] AddBrand: бренд успешно добавлен")
+	w.WriteHeader(http.StatusCreated)
+}
+
+// UpdateBrand – обработчик для обновления бренда.
+func UpdateBrand(w http.ResponseWriter, r *http.Request) {
+	log.Println("[INFO] UpdateBrand: начало запроса")
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Printf("[ERROR] UpdateBrand: неверный ID, ошибка преобразования: %v", err)
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	var brand models.Brand
+	if err := json.NewDecoder(r.Body).Decode(&brand); err != nil {
+		log.Printf("[ERROR] UpdateBrand: ошибка декодирования JSON: %v", err)
+		http.Error(w, "Неверный формат данных", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(brand.Name) == "" {
+		http.Error(w, "Не указано название бренда", http.StatusBadRequest)
+		return
+	}
+
+	rowsAffected, err := models.UpdateBrand(config.DB, id, brand)
+	if err != nil {
+		log.Printf("[ERROR] UpdateBrand: ошибка обновления бренда: %v", err)
+		http.Error(w, "Ошибка обновления бренда", http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected == 0 {
+		log.Printf("[ERROR] UpdateBrand: бренд с ID %d не найден", id)
+		http.Error(w, "Бренд не найден", http.StatusNotFound)
+		return
+	}
+
+	log.Printf("[INFO] UpdateBrand: бренд с ID %d успешно обновлён", id)
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeleteBrand – обработчик для удаления бренда.
+func DeleteBrand(w http.ResponseWriter, r *http.Request) {
+	log.Println("[INFO] DeleteBrand: начало запроса")
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Printf("[ERROR] DeleteBrand: неверный ID, ошибка преобразования: %v", err)
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	rowsAffected, err := models.DeleteBrand(config.DB, id)
+	if err != nil {
+		log.Printf("[ERROR] DeleteBrand: ошибка удаления бренда: %v", err)
+		http.Error(w, "Ошибка удаления бренда", http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected == 0 {
+		log.Printf("[ERROR] DeleteBrand: бренд с ID %d не найден", id)
+		http.Error(w, "Бренд не найден", http.StatusNotFound)
+		return
+	}
+
+	log.Printf("[INFO] DeleteBrand: бренд с ID %d успешно удалён", id)
+	w.WriteHeader(http.StatusOK)
+}
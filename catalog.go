@@ -0,0 +1,20 @@
+package controllers
+
+import (
+	"AutoM/config"
+	"AutoM/models"
+	"log"
+	"net/http"
+)
+
+// GetCatalogTree – дерево каталога группа → категория → подкатегория
+// с количеством запчастей и запчастей в наличии в каждом узле (API).
+func GetCatalogTree(w http.ResponseWriter, r *http.Request) {
+	tree, err := models.GetCatalogTree(config.DB)
+	if err != nil {
+		log.Printf("[ERROR] GetCatalogTree: ошибка построения дерева каталога: %v", err)
+		http.Error(w, "Ошибка загрузки каталога", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetCatalogTree", tree)
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"AutoM/config"
+	"AutoM/models"
+)
+
+// parseDeleteOptions читает параметры удаления mode и target из строки запроса.
+func parseDeleteOptions(r *http.Request) (models.DeleteOptions, error) {
+	opts := models.DeleteOptions{Mode: r.URL.Query().Get("mode")}
+	if v := r.URL.Query().Get("target"); v != "" {
+		target, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, models.ErrDeleteTarget
+		}
+		opts.Target = target
+	}
+	return opts, opts.Validate()
+}
+
+// catalogDeleteStatus сопоставляет ошибку удаления элемента каталога HTTP-статусу.
+func catalogDeleteStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrDeleteMode), errors.Is(err, models.ErrDeleteTarget):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrDeleteNotEmpty), errors.Is(err, models.ErrPartDocuments):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// deleteCatalogItem перемещает группу, категорию или подкатегорию в корзину с параметрами
+// из запроса и отвечает итогом удаления.
+func deleteCatalogItem(w http.ResponseWriter, r *http.Request, handler, entity string,
+	remove func(db *sql.DB, id int, opts models.DeleteOptions) (models.DeleteResult, error)) {
+	id, ok := pathID(w, r, handler)
+	if !ok {
+		return
+	}
+	opts, err := parseDeleteOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	result, err := remove(config.DB, id, opts)
+	if err != nil {
+		status := catalogDeleteStatus(err)
+		switch status {
+		case http.StatusNotFound:
+			http.Error(w, entity+" не найдена", status)
+		case http.StatusInternalServerError:
+			log.Printf("[ERROR] %s: ошибка удаления: %v", handler, err)
+			http.Error(w, "Ошибка удаления: "+entity, status)
+		default:
+			log.Printf("[WARN] %s: удаление %d отклонено: %v", handler, id, err)
+			http.Error(w, err.Error(), status)
+		}
+		return
+	}
+	log.Printf("[INFO] %s: запись с ID %d удалена (%s)", handler, id, result.Mode)
+	writeJSON(w, handler, result)
+}
+
+// previewCatalogDelete отвечает количеством записей, которые затронет удаление элемента каталога.
+func previewCatalogDelete(w http.ResponseWriter, r *http.Request, handler, entity string,
+	impact func(db *sql.DB, id int) (models.DeleteImpact, error)) {
+	id, ok := pathID(w, r, handler)
+	if !ok {
+		return
+	}
+	result, err := impact(config.DB, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, entity+" не найдена", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] %s: ошибка подсчёта: %v", handler, err)
+		http.Error(w, "Ошибка получения данных", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, handler, result)
+}
+
+// GroupDeletePreview – сколько категорий, подкатегорий и запчастей затронет удаление группы.
+func GroupDeletePreview(w http.ResponseWriter, r *http.Request) {
+	previewCatalogDelete(w, r, "GroupDeletePreview", "Группа", models.GetGroupDeleteImpact)
+}
+
+// CategoryDeletePreview – сколько подкатегорий и запчастей затронет удаление категории.
+func CategoryDeletePreview(w http.ResponseWriter, r *http.Request) {
+	previewCatalogDelete(w, r, "CategoryDeletePreview", "Категория", models.GetCategoryDeleteImpact)
+}
+
+// SubcategoryDeletePreview – сколько запчастей затронет удаление подкатегории.
+func SubcategoryDeletePreview(w http.ResponseWriter, r *http.Request) {
+	previewCatalogDelete(w, r, "SubcategoryDeletePreview", "Подкатегория", models.GetSubcategoryDeleteImpact)
+}
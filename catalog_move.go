@@ -0,0 +1,287 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+)
+
+// Операции реорганизации каталога, записываемые в журнал.
+const (
+	AuditMergeSubcategories = "merge_subcategories" // слияние подкатегории с другой
+	AuditMoveCategory       = "move_category"       // перенос категории в другую группу
+	AuditMoveParts          = "move_parts"          // перенос запчастей в другую подкатегорию
+)
+
+// Ошибки реорганизации каталога.
+var (
+	ErrMoveTarget = errors.New("укажите существующий элемент назначения, отличный от исходного")
+	ErrMoveParts  = errors.New("не указаны запчасти для переноса")
+)
+
+// MoveSummary – итог слияния или переноса элементов каталога.
+type MoveSummary struct {
+	Action            string `json:"action"`
+	SourceID          int    `json:"source_id,omitempty"` // исходный элемент; для переноса запчастей не задаётся
+	TargetID          int    `json:"target_id"`
+	Subcategories     int    `json:"subcategories,omitempty"` // подкатегорий перенесено вместе с категорией
+	Parts             int    `json:"parts"`                   // запчастей перенесено
+	AttributesKept    int    `json:"attributes_kept"`         // значений характеристик сопоставлено по коду
+	AttributesDropped int    `json:"attributes_dropped"`      // значений без пары в подкатегории назначения
+	AuditID           int64  `json:"audit_id,omitempty"`
+}
+
+// CatalogAuditEntry – запись журнала реорганизации каталога.
+type CatalogAuditEntry struct {
+	ID       int             `json:"id"`
+	Action   string          `json:"action"`
+	Username string          `json:"username"`
+	Summary  json.RawMessage `json:"summary"` // MoveSummary на момент операции
+	CreateAt time.Time       `json:"create_at"`
+}
+
+// migrateCatalogAudit создаёт журнал операций реорганизации каталога.
+func migrateCatalogAudit(db *sql.DB) error {
+	return execAll(db,
+		"CREATE TABLE IF NOT EXISTS catalog_audit ("+
+			"audit_id INT AUTO_INCREMENT PRIMARY KEY, "+
+			"action VARCHAR(32) NOT NULL, "+
+			"username VARCHAR(100) NOT NULL DEFAULT '', "+
+			"summary TEXT NOT NULL, "+
+			"create_at DATETIME NOT NULL, "+
+			"KEY idx_catalog_audit_created (create_at)"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+	)
+}
+
+// recordCatalogAudit записывает операцию в журнал в той же транзакции, что и сама операция.
+func recordCatalogAudit(tx dbExecutor, username string, summary *MoveSummary) error {
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	query := "INSERT INTO catalog_audit (action, username, summary, create_at) VALUES (?, ?, ?, NOW())"
+	result, err := tx.Exec(query, summary.Action, username, string(data))
+	if err != nil {
+		return err
+	}
+	summary.AuditID, err = result.LastInsertId()
+	return err
+}
+
+// GetCatalogAudit возвращает последние записи журнала реорганизации каталога, новые первыми.
+func GetCatalogAudit(db *sql.DB, limit int) ([]CatalogAuditEntry, error) {
+	query := "SELECT audit_id, action, username, summary, create_at FROM catalog_audit ORDER BY audit_id DESC LIMIT ?"
+	rows, err := db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []CatalogAuditEntry{}
+	for rows.Next() {
+		var e CatalogAuditEntry
+		var summary string
+		if err := rows.Scan(&e.ID, &e.Action, &e.Username, &summary, &e.CreateAt); err != nil {
+			return nil, err
+		}
+		e.Summary = json.RawMessage(summary)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// liveExists сообщает, есть ли запись уровня каталога с указанным ID вне корзины.
+func (l catalogLevel) liveExists(db dbExecutor, id int) (bool, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM " + l.table + " WHERE " + l.idColumn + " = ? AND deleted_at IS NULL"
+	err := db.QueryRow(query, id).Scan(&count)
+	return count > 0, err
+}
+
+// remapPartAttributes переносит значения характеристик запчастей в подкатегорию target.
+// Характеристики сопоставляются по коду и типу; значения без пары удаляются,
+// так как характеристики у каждой подкатегории свои. partIDs – запрос ID запчастей с аргументами args.
+// Вызывается до изменения subcategory_id запчастей.
+func remapPartAttributes(tx dbExecutor, target int, partIDs string, args ...interface{}) (kept, dropped int, err error) {
+	query := "UPDATE part_attribute_values v " +
+		"JOIN subcategory_attributes a ON a.attribute_id = v.attribute_id " +
+		"JOIN subcategory_attributes b ON b.subcategory_id = ? AND b.code = a.code AND b.type = a.type " +
+		"SET v.attribute_id = b.attribute_id WHERE a.subcategory_id <> ? AND v.part_id IN (" + partIDs + ")"
+	n, err := rowsAffected(tx.Exec(query, append([]interface{}{target, target}, args...)...))
+	if err != nil {
+		return 0, 0, err
+	}
+	query = "DELETE v FROM part_attribute_values v " +
+		"JOIN subcategory_attributes a ON a.attribute_id = v.attribute_id " +
+		"WHERE a.subcategory_id <> ? AND v.part_id IN (" + partIDs + ")"
+	d, err := rowsAffected(tx.Exec(query, append([]interface{}{target}, args...)...))
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(n), int(d), nil
+}
+
+// MergeSubcategories переносит все запчасти подкатегории sourceID в targetID и перемещает
+// sourceID в корзину. Запчасти, уже находящиеся в корзине, тоже переносятся, чтобы их
+// можно было восстановить. Старый адрес подкатегории перенаправляется на адрес targetID.
+// Возвращает sql.ErrNoRows, если исходной подкатегории нет.
+func MergeSubcategories(db *sql.DB, sourceID, targetID int, username string) (MoveSummary, error) {
+	log.Printf("[INFO] MergeSubcategories: слияние подкатегории %d с %d", sourceID, targetID)
+	summary := MoveSummary{Action: AuditMergeSubcategories, SourceID: sourceID, TargetID: targetID}
+	oldPath := subcategorySlugs.path(db, sourceID)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return MoveSummary{}, err
+	}
+	defer tx.Rollback()
+
+	if ok, err := subcategoryLevel.liveExists(tx, sourceID); err != nil || !ok {
+		if err == nil {
+			err = sql.ErrNoRows
+		}
+		return MoveSummary{}, err
+	}
+	if ok, err := subcategoryLevel.liveExists(tx, targetID); err != nil || !ok || targetID == sourceID {
+		if err == nil {
+			err = ErrMoveTarget
+		}
+		return MoveSummary{}, err
+	}
+
+	partIDs := "SELECT part_id FROM parts WHERE subcategory_id = ?"
+	if summary.AttributesKept, summary.AttributesDropped, err = remapPartAttributes(tx, targetID, partIDs, sourceID); err != nil {
+		return MoveSummary{}, err
+	}
+	if err := tx.QueryRow("SELECT COUNT(*) FROM parts WHERE subcategory_id = ? AND deleted_at IS NULL", sourceID).Scan(&summary.Parts); err != nil {
+		return MoveSummary{}, err
+	}
+	if _, err := tx.Exec("UPDATE parts SET subcategory_id = ?, update_at = NOW() WHERE subcategory_id = ?", targetID, sourceID); err != nil {
+		return MoveSummary{}, err
+	}
+	if err := checkStockAlerts(tx, "p.subcategory_id = ?", targetID); err != nil {
+		return MoveSummary{}, err
+	}
+	if _, err := tx.Exec("UPDATE subcategories SET deleted_at = NOW() WHERE subcategory_id = ?", sourceID); err != nil {
+		return MoveSummary{}, err
+	}
+	if err := recordCatalogAudit(tx, username, &summary); err != nil {
+		return MoveSummary{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return MoveSummary{}, err
+	}
+	InvalidateCatalogTree()
+
+	if newPath := subcategorySlugs.path(db, targetID); oldPath != "" && newPath != "" {
+		if err := recordRedirect(db, oldPath, newPath); err != nil {
+			log.Printf("[ERROR] MergeSubcategories: ошибка записи перенаправления %s → %s: %v", oldPath, newPath, err)
+		}
+	}
+	log.Printf("[INFO] MergeSubcategories: итог %+v", summary)
+	return summary, nil
+}
+
+// MoveCategory переносит категорию вместе с подкатегориями и запчастями в группу groupID.
+// Название и описание категории сохраняются; слаг назначается заново в пределах новой группы,
+// старые адреса категории и её подкатегорий перенаправляются на новые.
+// Возвращает sql.ErrNoRows, если категории нет.
+func MoveCategory(db *sql.DB, categoryID, groupID int, username string) (MoveSummary, error) {
+	log.Printf("[INFO] MoveCategory: перенос категории %d в группу %d", categoryID, groupID)
+	summary := MoveSummary{Action: AuditMoveCategory, SourceID: categoryID, TargetID: groupID}
+	oldPath := categorySlugs.path(db, categoryID)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return MoveSummary{}, err
+	}
+	defer tx.Rollback()
+
+	var cat Category
+	query := "SELECT group_id, name, description FROM `categories` WHERE category_id = ? AND deleted_at IS NULL FOR UPDATE"
+	if err := tx.QueryRow(query, categoryID).Scan(&cat.GroupID, &cat.Name, &cat.Description); err != nil {
+		return MoveSummary{}, err
+	}
+	if ok, err := groupLevel.liveExists(tx, groupID); err != nil || !ok || cat.GroupID == groupID {
+		if err == nil {
+			err = ErrMoveTarget
+		}
+		return MoveSummary{}, err
+	}
+	impact, err := categoryLevel.impact(tx, categoryID)
+	if err != nil {
+		return MoveSummary{}, err
+	}
+	summary.Subcategories, summary.Parts = impact.Subcategories, impact.Parts
+
+	if _, err := updateCategoryRow(tx, categoryID, groupID, cat.Name, cat.Description); err != nil {
+		return MoveSummary{}, err
+	}
+	if err := recordCatalogAudit(tx, username, &summary); err != nil {
+		return MoveSummary{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return MoveSummary{}, err
+	}
+	InvalidateCatalogTree()
+	// Адреса подкатегорий начинаются с адреса категории: LookupRedirect найдёт их по префиксу.
+	categorySlugs.update(db, categoryID, oldPath)
+	log.Printf("[INFO] MoveCategory: итог %+v", summary)
+	return summary, nil
+}
+
+// MoveParts переносит выбранные запчасти в подкатегорию subcategoryID.
+// Запчасти, которых нет или которые уже в корзине, пропускаются; Parts в итоге –
+// количество действительно перенесённых запчастей.
+func MoveParts(db *sql.DB, partIDs []int, subcategoryID int, username string) (MoveSummary, error) {
+	if len(partIDs) == 0 {
+		return MoveSummary{}, ErrMoveParts
+	}
+	log.Printf("[INFO] MoveParts: перенос %d запчастей в подкатегорию %d", len(partIDs), subcategoryID)
+	summary := MoveSummary{Action: AuditMoveParts, TargetID: subcategoryID}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return MoveSummary{}, err
+	}
+	defer tx.Rollback()
+
+	if ok, err := subcategoryLevel.liveExists(tx, subcategoryID); err != nil || !ok {
+		if err == nil {
+			err = ErrMoveTarget
+		}
+		return MoveSummary{}, err
+	}
+
+	in := placeholders(len(partIDs))
+	args := make([]interface{}, len(partIDs))
+	for i, id := range partIDs {
+		args[i] = id
+	}
+	selected := "SELECT part_id FROM parts WHERE part_id IN (" + in + ") AND deleted_at IS NULL AND subcategory_id <> ?"
+	if summary.AttributesKept, summary.AttributesDropped, err = remapPartAttributes(tx, subcategoryID, selected, append(args, subcategoryID)...); err != nil {
+		return MoveSummary{}, err
+	}
+	query := "UPDATE parts SET subcategory_id = ?, update_at = NOW() WHERE part_id IN (" + in + ") AND deleted_at IS NULL AND subcategory_id <> ?"
+	moved, err := rowsAffected(tx.Exec(query, append(append([]interface{}{subcategoryID}, args...), subcategoryID)...))
+	if err != nil {
+		return MoveSummary{}, err
+	}
+	summary.Parts = int(moved)
+	// Перенесённые запчасти сверяются с минимальным остатком новой подкатегории.
+	if err := checkStockAlerts(tx, "p.part_id IN ("+in+")", args...); err != nil {
+		return MoveSummary{}, err
+	}
+	if err := recordCatalogAudit(tx, username, &summary); err != nil {
+		return MoveSummary{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return MoveSummary{}, err
+	}
+	InvalidateCatalogTree()
+	log.Printf("[INFO] MoveParts: итог %+v", summary)
+	return summary, nil
+}
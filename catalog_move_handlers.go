@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"AutoM/config"
+	"AutoM/models"
+)
+
+// catalogAuditDefaultLimit – сколько записей журнала возвращается без параметра limit.
+const catalogAuditDefaultLimit = 100
+
+// sessionUsername возвращает имя пользователя из сессии (пусто для анонимного запроса).
+func sessionUsername(r *http.Request) string {
+	session, _ := config.Store.Get(r, "session")
+	if val, ok := session.Values["username"].(string); ok {
+		return strings.TrimSpace(val)
+	}
+	return ""
+}
+
+// respondMove отвечает итогом слияния или переноса либо статусом ошибки.
+func respondMove(w http.ResponseWriter, handler, entity string, summary models.MoveSummary, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, entity+" не найдена", http.StatusNotFound)
+	case errors.Is(err, models.ErrMoveTarget), errors.Is(err, models.ErrMoveParts):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err != nil:
+		log.Printf("[ERROR] %s: ошибка выполнения операции: %v", handler, err)
+		http.Error(w, "Ошибка изменения каталога", http.StatusInternalServerError)
+	default:
+		writeJSON(w, handler, summary)
+	}
+}
+
+// MergeSubcategory – слияние подкатегории {id} с подкатегорией target_id:
+// запчасти переносятся, исходная подкатегория перемещается в корзину.
+// Ожидается JSON {"target_id": 12}.
+func MergeSubcategory(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "MergeSubcategory")
+	if !ok {
+		return
+	}
+	var req struct {
+		TargetID int `json:"target_id"`
+	}
+	if !decodeJSON(w, r, "MergeSubcategory", &req) {
+		return
+	}
+	summary, err := models.MergeSubcategories(config.DB, id, req.TargetID, sessionUsername(r))
+	respondMove(w, "MergeSubcategory", "Подкатегория", summary, err)
+}
+
+// MoveCategory – перенос категории {id} со всем содержимым в другую группу.
+// Ожидается JSON {"group_id": 3}.
+func MoveCategory(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "MoveCategory")
+	if !ok {
+		return
+	}
+	var req struct {
+		GroupID int `json:"group_id"`
+	}
+	if !decodeJSON(w, r, "MoveCategory", &req) {
+		return
+	}
+	summary, err := models.MoveCategory(config.DB, id, req.GroupID, sessionUsername(r))
+	respondMove(w, "MoveCategory", "Категория", summary, err)
+}
+
+// MoveParts – перенос выбранных запчастей в другую подкатегорию.
+// Ожидается JSON {"part_ids": [1, 2, 3], "subcategory_id": 7}.
+func MoveParts(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PartIDs       []int `json:"part_ids"`
+		SubcategoryID int   `json:"subcategory_id"`
+	}
+	if !decodeJSON(w, r, "MoveParts", &req) {
+		return
+	}
+	summary, err := models.MoveParts(config.DB, req.PartIDs, req.SubcategoryID, sessionUsername(r))
+	respondMove(w, "MoveParts", "Подкатегория", summary, err)
+}
+
+// GetCatalogAudit – журнал слияний и переносов в каталоге, новые записи первыми.
+// Параметр limit ограничивает количество записей (по умолчанию 100).
+func GetCatalogAudit(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryInt(r, "limit")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if limit == 0 {
+		limit = catalogAuditDefaultLimit
+	}
+	entries, err := models.GetCatalogAudit(config.DB, limit)
+	if err != nil {
+		log.Printf("[ERROR] GetCatalogAudit: ошибка получения журнала: %v", err)
+		http.Error(w, "Ошибка получения журнала", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetCatalogAudit", entries)
+}
@@ -0,0 +1,180 @@
+package models
+
+import (
+	"database/sql"
+	"log"
+	"sync"
+)
+
+// CatalogSubcategory – подкатегория в дереве каталога.
+type CatalogSubcategory struct {
+	ID           int    `json:"id"`
+	CategoryID   int    `json:"category_id"`
+	Name         string `json:"name"`
+	Path         string `json:"path"`           // адрес раздела /catalog/...; пусто, если слаг не назначен
+	PartCount    int    `json:"part_count"`     // всего запчастей
+	InStockCount int    `json:"in_stock_count"` // запчастей в наличии (quantity > 0)
+}
+
+// CatalogCategory – категория в дереве каталога; счётчики суммируются по подкатегориям.
+type CatalogCategory struct {
+	ID            int                  `json:"id"`
+	GroupID       int                  `json:"group_id"`
+	Name          string               `json:"name"`
+	Description   string               `json:"description"`
+	Path          string               `json:"path"`
+	PartCount     int                  `json:"part_count"`
+	InStockCount  int                  `json:"in_stock_count"`
+	Subcategories []CatalogSubcategory `json:"subcategories"`
+}
+
+// CatalogGroup – группа в дереве каталога; счётчики суммируются по категориям.
+type CatalogGroup struct {
+	ID           int               `json:"id"`
+	Name         string            `json:"name"`
+	Path         string            `json:"path"`
+	PartCount    int               `json:"part_count"`
+	InStockCount int               `json:"in_stock_count"`
+	Categories   []CatalogCategory `json:"categories"`
+}
+
+// catalogTreeCache хранит построенное дерево каталога до первого изменения
+// групп, категорий, подкатегорий или запчастей.
+var catalogTreeCache struct {
+	sync.RWMutex
+	tree    []CatalogGroup
+	valid   bool
+	version uint64 // увеличивается при каждой инвалидации
+}
+
+// InvalidateCatalogTree сбрасывает кэш дерева каталога.
+// Вызывается функциями, изменяющими группы, категории, подкатегории и запчасти.
+func InvalidateCatalogTree() {
+	catalogTreeCache.Lock()
+	catalogTreeCache.tree, catalogTreeCache.valid = nil, false
+	catalogTreeCache.version++
+	catalogTreeCache.Unlock()
+}
+
+// GetCatalogTree возвращает иерархию группа → категория → подкатегория с количеством
+// запчастей в каждом узле. Результат кэшируется до следующей инвалидации.
+// Возвращаемое дерево общее для всех вызывающих и не должно изменяться.
+func GetCatalogTree(db *sql.DB) ([]CatalogGroup, error) {
+	catalogTreeCache.RLock()
+	tree, valid, version := catalogTreeCache.tree, catalogTreeCache.valid, catalogTreeCache.version
+	catalogTreeCache.RUnlock()
+	if valid {
+		return tree, nil
+	}
+
+	tree, err := buildCatalogTree(db)
+	if err != nil {
+		return nil, err
+	}
+	// Если во время построения каталог изменился, дерево могло устареть – не кэшируем его.
+	catalogTreeCache.Lock()
+	if catalogTreeCache.version == version {
+		catalogTreeCache.tree, catalogTreeCache.valid = tree, true
+	}
+	catalogTreeCache.Unlock()
+	return tree, nil
+}
+
+// partCounts – количество запчастей подкатегории.
+type partCounts struct {
+	total, inStock int
+}
+
+// buildCatalogTree строит дерево каталога из базы данных.
+func buildCatalogTree(db *sql.DB) ([]CatalogGroup, error) {
+	log.Println("[INFO] buildCatalogTree: построение дерева каталога")
+	groups, err := GetAllGroups(db)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := GetAllCategories(db)
+	if err != nil {
+		return nil, err
+	}
+	subcategories, err := GetAllSubcategories(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var slugs [3]map[int]string
+	for i, e := range []slugEntity{groupSlugs, categorySlugs, subcategorySlugs} {
+		if slugs[i], err = e.slugsByID(db); err != nil {
+			return nil, err
+		}
+	}
+	groupPaths := make(map[int]string)
+	for _, g := range groups {
+		if slug := slugs[0][g.ID]; slug != "" {
+			groupPaths[g.ID] = "/catalog/" + slug
+		}
+	}
+	categoryPaths := make(map[int]string)
+	for _, c := range categories {
+		if parent, slug := groupPaths[c.GroupID], slugs[1][c.ID]; parent != "" && slug != "" {
+			categoryPaths[c.ID] = parent + "/" + slug
+		}
+	}
+
+	counts := make(map[int]partCounts)
+	rows, err := db.Query("SELECT subcategory_id, COUNT(*), COALESCE(SUM(quantity > 0), 0) FROM parts WHERE deleted_at IS NULL GROUP BY subcategory_id")
+	if err != nil {
+		log.Printf("[ERROR] buildCatalogTree: ошибка подсчёта запчастей: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var c partCounts
+		if err := rows.Scan(&id, &c.total, &c.inStock); err != nil {
+			return nil, err
+		}
+		counts[id] = c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	subsByCategory := make(map[int][]CatalogSubcategory)
+	for _, s := range subcategories {
+		c := counts[s.SubcategoryID]
+		node := CatalogSubcategory{ID: s.SubcategoryID, CategoryID: s.CategoryID, Name: s.Name, PartCount: c.total, InStockCount: c.inStock}
+		if parent, slug := categoryPaths[s.CategoryID], slugs[2][s.SubcategoryID]; parent != "" && slug != "" {
+			node.Path = parent + "/" + slug
+		}
+		subsByCategory[s.CategoryID] = append(subsByCategory[s.CategoryID], node)
+	}
+
+	catsByGroup := make(map[int][]CatalogCategory)
+	for _, c := range categories {
+		node := CatalogCategory{ID: c.ID, GroupID: c.GroupID, Name: c.Name, Description: c.Description,
+			Path: categoryPaths[c.ID], Subcategories: subsByCategory[c.ID]}
+		if node.Subcategories == nil {
+			node.Subcategories = []CatalogSubcategory{}
+		}
+		for _, s := range node.Subcategories {
+			node.PartCount += s.PartCount
+			node.InStockCount += s.InStockCount
+		}
+		catsByGroup[c.GroupID] = append(catsByGroup[c.GroupID], node)
+	}
+
+	tree := make([]CatalogGroup, 0, len(groups))
+	for _, g := range groups {
+		node := CatalogGroup{ID: g.ID, Name: g.Name, Path: groupPaths[g.ID], Categories: catsByGroup[g.ID]}
+		if node.Categories == nil {
+			node.Categories = []CatalogCategory{}
+		}
+		for _, c := range node.Categories {
+			node.PartCount += c.PartCount
+			node.InStockCount += c.InStockCount
+		}
+		tree = append(tree, node)
+	}
+	log.Printf("[INFO] buildCatalogTree: дерево построено, групп: %d", len(tree))
+	return tree, nil
+}
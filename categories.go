@@ -108,26 +108,8 @@ func UpdateCategory(w http.ResponseWriter, r *http.Request) {
 }
 
 // DeleteCategory – обработчик для удаления категории по её ID.
+// Параметры mode и target – как у DeleteGroup; при reassign подкатегории переносятся в категорию target.
 func DeleteCategory(w http.ResponseWriter, r *http.Request) {
 	log.Println("[INFO] DeleteCategory: начало запроса")
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
-	if err != nil {
-		log.Printf("[ERROR] DeleteCategory: неверный формат ID: %v", err)
-		http.Error(w, "Неверный ID", http.StatusBadRequest)
-		return
-	}
-	rowsAffected, err := models.DeleteCategory(config.DB, id)
-	if err != nil {
-		log.Printf("[ERROR] DeleteCategory: ошибка удаления категории: %v", err)
-		http.Error(w, "Ошибка удаления категории", http.StatusInternalServerError)
-		return
-	}
-	if rowsAffected == 0 {
-		log.Printf("[WARN] DeleteCategory: категория с ID %d не найдена", id)
-		http.Error(w, "Категория не найдена", http.StatusNotFound)
-		return
-	}
-	log.Printf("[INFO] DeleteCategory: категория с ID %d успешно удалена", id)
-	w.WriteHeader(http.StatusOK)
+	deleteCatalogItem(w, r, "DeleteCategory", "Категория", models.DeleteCategory)
 }
@@ -23,7 +23,7 @@ type Category struct {
 func GetAllCategories(db *sql.DB) ([]Category, error) {
 	log.Printf("[INFO] GetAllCategories: начало выполнения функции")
 	// Экранирование имени таблицы обратными кавычками
-	query := "SELECT category_id AS id, group_id, name, create_at, update_at, description FROM `categories`"
+	query := "SELECT category_id AS id, group_id, name, create_at, update_at, description FROM `categories` WHERE deleted_at IS NULL"
 	log.Printf("[INFO] GetAllCategories: выполнение запроса: %s", query)
 	rows, err := db.Query(query)
 	if err != nil {
@@ -62,7 +62,7 @@ func GetAllCategories(db *sql.DB) ([]Category, error) {
 func GetCategoryByID(db *sql.DB, id int) (Category, error) {
 	log.Printf("[INFO] GetCategoryByID: запрос категории с ID %d", id)
 	var cat Category
-	query := "SELECT category_id AS id, group_id, name, create_at, update_at, description FROM `categories` WHERE category_id = ?"
+	query := "SELECT category_id AS id, group_id, name, create_at, update_at, description FROM `categories` WHERE category_id = ? AND deleted_at IS NULL"
 	log.Printf("[INFO] GetCategoryByID: выполняется запрос: %s", query)
 	err := db.QueryRow(query, id).Scan(&cat.ID, &cat.GroupID, &cat.Name, &cat.CreateAt, &cat.UpdateAt, &cat.Description)
 	if err != nil {
@@ -75,62 +75,52 @@ func GetCategoryByID(db *sql.DB, id int) (Category, error) {
 
 // AddCategory добавляет новую категорию в базу данных.
 func AddCategory(db *sql.DB, groupID int, name, description string) error {
+	defer InvalidateCatalogTree()
 	log.Printf("[INFO] AddCategory: добавление категории '%s'", name)
 	// Вставка производится по group_id, name и description;
 	// временные метки генерируются базой через NOW().
 	query := "INSERT INTO `categories` (group_id, name, create_at, update_at, description) VALUES (?, ?, NOW(), NOW(), ?)"
 	log.Printf("[INFO] AddCategory: выполняется запрос: %s", query)
-	_, err := db.Exec(query, groupID, name, description)
+	res, err := db.Exec(query, groupID, name, description)
 	if err != nil {
 		log.Printf("[ERROR] AddCategory: ошибка выполнения запроса: %v", err)
 		return err
 	}
+	if id, err := res.LastInsertId(); err == nil {
+		categorySlugs.update(db, int(id), "")
+	}
 	log.Printf("[INFO] AddCategory: категория '%s' успешно добавлена", name)
 	return nil
 }
 
 // UpdateCategory обновляет существующую категорию по её ID.
 func UpdateCategory(db *sql.DB, id, groupID int, name, description string) (int64, error) {
+	defer InvalidateCatalogTree()
 	log.Printf("[INFO] UpdateCategory: обновление категории с ID %d", id)
-	query := "UPDATE `categories` SET group_id = ?, name = ?, update_at = NOW(), description = ? WHERE category_id = ?"
-	log.Printf("[INFO] UpdateCategory: выполняется запрос: %s", query)
-	result, err := db.Exec(query, groupID, name, description, id)
+	oldPath := categorySlugs.path(db, id)
+	rowsAffected, err := updateCategoryRow(db, id, groupID, name, description)
 	if err != nil {
 		log.Printf("[ERROR] UpdateCategory: ошибка выполнения запроса: %v", err)
 		return 0, err
 	}
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		log.Printf("[ERROR] UpdateCategory: ошибка получения количества затронутых строк: %v", err)
-		return 0, err
-	}
 	if rowsAffected == 0 {
 		log.Printf("[WARN] UpdateCategory: категория с ID %d не найдена", id)
 	} else {
 		log.Printf("[INFO] UpdateCategory: категория с ID %d успешно обновлена", id)
+		categorySlugs.update(db, id, oldPath)
 	}
 	return rowsAffected, nil
 }
 
-// DeleteCategory удаляет категорию из базы данных по её ID.
-func DeleteCategory(db *sql.DB, id int) (int64, error) {
+// updateCategoryRow записывает новые данные категории; используется также при переносе в другую группу.
+func updateCategoryRow(db dbExecutor, id, groupID int, name, description string) (int64, error) {
+	query := "UPDATE `categories` SET group_id = ?, name = ?, update_at = NOW(), description = ? WHERE category_id = ? AND deleted_at IS NULL"
+	return rowsAffected(db.Exec(query, groupID, name, description, id))
+}
+
+// DeleteCategory удаляет категорию по её ID в режиме opts (см. DeleteOptions).
+// Возвращает sql.ErrNoRows, если категория не найдена.
+func DeleteCategory(db *sql.DB, id int, opts DeleteOptions) (DeleteResult, error) {
 	log.Printf("[INFO] DeleteCategory: удаление категории с ID %d", id)
-	query := "DELETE FROM `categories` WHERE category_id = ?"
-	log.Printf("[INFO] DeleteCategory: выполняется запрос: %s", query)
-	result, err := db.Exec(query, id)
-	if err != nil {
-		log.Printf("[ERROR] DeleteCategory: ошибка выполнения запроса: %v", err)
-		return 0, err
-	}
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		log.Printf("[ERROR] DeleteCategory: ошибка получения количества затронутых строк: %v", err)
-		return 0, err
-	}
-	if rowsAffected == 0 {
-		log.Printf("[WARN] DeleteCategory: категория с ID %d не найдена", id)
-	} else {
-		log.Printf("[INFO] DeleteCategory: категория с ID %d успешно удалена", id)
-	}
-	return rowsAffected, nil
+	return categoryLevel.remove(db, id, opts)
 }
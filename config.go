@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/gorilla/sessions"
@@ -12,6 +14,12 @@ import (
 const (
 	// defaultDSN используется, если переменная окружения DSN не задана.
 	defaultDSN = "root:Admin12345@tcp(127.0.0.1:3306)/mydb?parseTime=true"
+	// defaultImageDir – каталог загруженных изображений, если переменная IMAGE_DIR не задана.
+	defaultImageDir = "uploads/images"
+	// defaultTrashRetentionDays – сколько дней записи хранятся в корзине, если TRASH_RETENTION_DAYS не задана.
+	defaultTrashRetentionDays = 30
+	// defaultPriceInboxMinutes – как часто проверяется входящий каталог прайс-листов, если PRICE_INBOX_INTERVAL не задана.
+	defaultPriceInboxMinutes = 5
 	// defaultSecretKey – статичный секретный ключ для сессий (используйте надёжное значение в продакшене).
 	defaultSecretKey = "123"
 )
@@ -56,6 +64,55 @@ func InitStore() {
 	log.Println("Хранилище сессий инициализировано со статичным секретным ключом")
 }
 
+// ImageDir возвращает каталог для хранения загруженных изображений.
+// Задаётся переменной окружения "IMAGE_DIR"; по умолчанию – uploads/images рядом с приложением.
+func ImageDir() string {
+	if dir := os.Getenv("IMAGE_DIR"); dir != "" {
+		return dir
+	}
+	return defaultImageDir
+}
+
+// TrashRetention возвращает срок хранения записей в корзине.
+// Задаётся переменной окружения "TRASH_RETENTION_DAYS" (целое число дней).
+func TrashRetention() time.Duration {
+	days := defaultTrashRetentionDays
+	if v := os.Getenv("TRASH_RETENTION_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			days = n
+		} else {
+			log.Printf("Неверное значение TRASH_RETENTION_DAYS=%q; используется %d", v, days)
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// RatesFile возвращает путь к файлу курсов валют, загружаемому при запуске приложения.
+// Задаётся переменной окружения "RATES_FILE"; пустая строка – курсы ведутся только вручную.
+func RatesFile() string {
+	return os.Getenv("RATES_FILE")
+}
+
+// PriceInboxDir возвращает входящий каталог прайс-листов поставщиков.
+// Задаётся переменной окружения "PRICE_INBOX_DIR"; пустая строка – прайсы загружаются только вручную.
+func PriceInboxDir() string {
+	return os.Getenv("PRICE_INBOX_DIR")
+}
+
+// PriceInboxInterval возвращает интервал проверки входящего каталога прайс-листов.
+// Задаётся переменной окружения "PRICE_INBOX_INTERVAL" (целое число минут).
+func PriceInboxInterval() time.Duration {
+	minutes := defaultPriceInboxMinutes
+	if v := os.Getenv("PRICE_INBOX_INTERVAL"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			minutes = n
+		} else {
+			log.Printf("Неверное значение PRICE_INBOX_INTERVAL=%q; используется %d", v, minutes)
+		}
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
 // CloseDB закрывает соединение с базой данных.
 func CloseDB() {
 	if DB != nil {
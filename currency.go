@@ -0,0 +1,430 @@
+package models
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// BaseCurrency – валюта, в которой хранятся цены продажи (parts.price); её курс всегда равен 1.
+const BaseCurrency = "RUB"
+
+// Источники курсов валют.
+const (
+	RateSourceManual = "manual" // задан администратором
+	RateSourceFile   = "file"   // загружен из файла курсов
+)
+
+// Ошибки валют и курсов.
+var (
+	ErrCurrencyCode  = errors.New("код валюты должен состоять из трёх латинских букв (ISO 4217)")
+	ErrCurrencyRate  = errors.New("курс валюты должен быть больше нуля")
+	ErrCurrencyBase  = errors.New("курс базовой валюты всегда равен 1")
+	ErrCurrencyStep  = errors.New("шаг округления должен быть больше нуля")
+	ErrCurrencyInUse = errors.New("валюта используется в базовых ценах запчастей")
+	ErrUnknownRate   = errors.New("курс валюты не задан")
+	ErrPriceBase     = errors.New("цена продажи задаётся базовой ценой в валюте; измените базовую цену или снимите её")
+	ErrRatesFile     = errors.New("файл курсов не содержит ни одной корректной строки")
+)
+
+// currencyCodePattern – трёхбуквенный код ISO 4217.
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// CurrencyRate – курс валюты к BaseCurrency и правило округления сумм в этой валюте.
+type CurrencyRate struct {
+	Code         string    `json:"code"`
+	Rate         Decimal   `json:"rate"`          // сколько единиц BaseCurrency стоит одна единица валюты
+	RoundingStep Decimal   `json:"rounding_step"` // шаг округления, например 0.01 или 10
+	RoundingMode string    `json:"rounding_mode"` // RoundHalfUp, RoundUp или RoundDown
+	Source       string    `json:"source"`
+	UpdateAt     time.Time `json:"update_at"`
+}
+
+// migrateCurrencies создаёт таблицу курсов валют, добавляет базовую цену запчасти в валюте закупки
+// и заводит запись базовой валюты.
+func migrateCurrencies(db *sql.DB) error {
+	err := execAll(db,
+		"CREATE TABLE IF NOT EXISTS currency_rates ("+
+			"code CHAR(3) PRIMARY KEY, "+
+			"rate DECIMAL(18,6) NOT NULL, "+
+			"rounding_step DECIMAL(12,4) NOT NULL DEFAULT 0.01, "+
+			"rounding_mode VARCHAR(8) NOT NULL DEFAULT '"+RoundHalfUp+"', "+
+			"source VARCHAR(16) NOT NULL DEFAULT '"+RateSourceManual+"', "+
+			"update_at DATETIME NOT NULL"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"INSERT IGNORE INTO currency_rates (code, rate, update_at) VALUES ('"+BaseCurrency+"', 1, NOW())",
+	)
+	if err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "parts", "base_price", "DECIMAL(14,4) NULL"); err != nil {
+		return err
+	}
+	return ensureColumn(db, "parts", "base_currency", "CHAR(3) NULL")
+}
+
+// Validate проверяет курс и подставляет правило округления по умолчанию.
+func (c *CurrencyRate) Validate() error {
+	c.Code = strings.ToUpper(strings.TrimSpace(c.Code))
+	if !currencyCodePattern.MatchString(c.Code) {
+		return ErrCurrencyCode
+	}
+	if c.Rate.Sign() <= 0 {
+		return ErrCurrencyRate
+	}
+	if c.Code == BaseCurrency && c.Rate.Cmp(DecimalFromFloat(1)) != 0 {
+		return ErrCurrencyBase
+	}
+	if c.RoundingStep.Sign() == 0 {
+		c.RoundingStep = DecimalFromFloat(0.01)
+	}
+	if c.RoundingStep.Sign() < 0 {
+		return ErrCurrencyStep
+	}
+	if c.RoundingMode == "" {
+		c.RoundingMode = RoundHalfUp
+	}
+	if !ValidRoundingMode(c.RoundingMode) {
+		return ErrRoundingMode
+	}
+	return nil
+}
+
+// GetCurrencyRates возвращает курсы всех валют; базовая валюта первой.
+func GetCurrencyRates(db *sql.DB) ([]CurrencyRate, error) {
+	query := "SELECT code, rate, rounding_step, rounding_mode, source, update_at FROM currency_rates " +
+		"ORDER BY code <> ?, code"
+	rows, err := db.Query(query, BaseCurrency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []CurrencyRate{}
+	for rows.Next() {
+		var c CurrencyRate
+		if err := rows.Scan(&c.Code, &c.Rate, &c.RoundingStep, &c.RoundingMode, &c.Source, &c.UpdateAt); err != nil {
+			return nil, err
+		}
+		rates = append(rates, c)
+	}
+	return rates, rows.Err()
+}
+
+// SetCurrencyRate создаёт или обновляет курс валюты и пересчитывает цены продажи запчастей,
+// базовая цена которых задана в этой валюте (при изменении базовой валюты – всех таких запчастей,
+// так как меняется её правило округления). Возвращает количество изменённых цен.
+func SetCurrencyRate(db *sql.DB, rate CurrencyRate, username string) (int, error) {
+	if err := rate.Validate(); err != nil {
+		return 0, err
+	}
+	if rate.Source == "" {
+		rate.Source = RateSourceManual
+	}
+	log.Printf("[INFO] SetCurrencyRate: %s = %s %s (шаг %s, %s)", rate.Code, rate.Rate, BaseCurrency, rate.RoundingStep, rate.RoundingMode)
+	query := "INSERT INTO currency_rates (code, rate, rounding_step, rounding_mode, source, update_at) VALUES (?, ?, ?, ?, ?, NOW()) " +
+		"ON DUPLICATE KEY UPDATE rate = VALUES(rate), rounding_step = VALUES(rounding_step), " +
+		"rounding_mode = VALUES(rounding_mode), source = VALUES(source), update_at = NOW()"
+	if _, err := db.Exec(query, rate.Code, rate.Rate, rate.RoundingStep, rate.RoundingMode, rate.Source); err != nil {
+		return 0, err
+	}
+	currency := rate.Code
+	if currency == BaseCurrency {
+		currency = ""
+	}
+	return RecalculateBasePrices(db, currency, username)
+}
+
+// DeleteCurrencyRate удаляет курс валюты. Базовую валюту и валюту, в которой заданы
+// базовые цены запчастей, удалить нельзя.
+func DeleteCurrencyRate(db *sql.DB, code string) (int64, error) {
+	code = strings.ToUpper(code)
+	if code == BaseCurrency {
+		return 0, ErrCurrencyBase
+	}
+	var used int
+	if err := db.QueryRow("SELECT COUNT(*) FROM parts WHERE base_currency = ?", code).Scan(&used); err != nil {
+		return 0, err
+	}
+	if used > 0 {
+		return 0, ErrCurrencyInUse
+	}
+	return rowsAffected(db.Exec("DELETE FROM currency_rates WHERE code = ?", code))
+}
+
+// ImportCurrencyRates загружает курсы из текстового файла. Каждая строка: код валюты, курс
+// и необязательный номинал, разделённые ";", табуляцией или пробелами, например
+// "EUR;98,45" или "JPY 63,12 100" (63,12 ₽ за 100 иен). Пустые строки, строки с "#"
+// и заголовки пропускаются; правила округления существующих валют сохраняются.
+// Возвращает количество загруженных курсов.
+func ImportCurrencyRates(db *sql.DB, r io.Reader, username string) (int, error) {
+	existing, err := GetCurrencyRates(db)
+	if err != nil {
+		return 0, err
+	}
+	rules := make(map[string]CurrencyRate, len(existing))
+	for _, c := range existing {
+		rules[c.Code] = c
+	}
+
+	var rates []CurrencyRate
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.FieldsFunc(text, func(r rune) bool { return r == ';' || r == '\t' || r == ' ' })
+		if len(fields) < 2 {
+			log.Printf("[WARN] ImportCurrencyRates: строка %d пропущена: %q", line, text)
+			continue
+		}
+		rate := CurrencyRate{Code: fields[0], Source: RateSourceFile}
+		if rate.Rate, err = ParseDecimal(fields[1]); err != nil {
+			log.Printf("[WARN] ImportCurrencyRates: строка %d пропущена: неверный курс %q", line, fields[1])
+			continue
+		}
+		if len(fields) > 2 {
+			nominal, err := ParseDecimal(fields[2])
+			if err != nil || nominal.Sign() <= 0 {
+				log.Printf("[WARN] ImportCurrencyRates: строка %d пропущена: неверный номинал %q", line, fields[2])
+				continue
+			}
+			rate.Rate = rate.Rate.Quo(nominal)
+		}
+		if rule, ok := rules[strings.ToUpper(rate.Code)]; ok {
+			rate.RoundingStep, rate.RoundingMode = rule.RoundingStep, rule.RoundingMode
+		}
+		if err := rate.Validate(); err != nil || rate.Code == BaseCurrency {
+			log.Printf("[WARN] ImportCurrencyRates: строка %d пропущена: %q", line, text)
+			continue
+		}
+		rates = append(rates, rate)
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	if len(rates) == 0 {
+		return 0, ErrRatesFile
+	}
+	for _, rate := range rates {
+		if _, err := SetCurrencyRate(db, rate, username); err != nil {
+			return 0, fmt.Errorf("курс %s: %w", rate.Code, err)
+		}
+	}
+	log.Printf("[INFO] ImportCurrencyRates: загружено курсов: %d", len(rates))
+	return len(rates), nil
+}
+
+// CurrencyConverter пересчитывает суммы между валютами по курсам, загруженным из базы.
+type CurrencyConverter struct {
+	rates map[string]CurrencyRate
+}
+
+// LoadCurrencyConverter загружает текущие курсы валют.
+func LoadCurrencyConverter(db *sql.DB) (*CurrencyConverter, error) {
+	rates, err := GetCurrencyRates(db)
+	if err != nil {
+		return nil, err
+	}
+	c := &CurrencyConverter{rates: make(map[string]CurrencyRate, len(rates))}
+	for _, rate := range rates {
+		c.rates[rate.Code] = rate
+	}
+	return c, nil
+}
+
+// Has сообщает, известен ли курс валюты.
+func (c *CurrencyConverter) Has(code string) bool {
+	_, ok := c.rates[code]
+	return ok
+}
+
+// Convert переводит сумму из валюты from в валюту to и округляет по правилу валюты to.
+func (c *CurrencyConverter) Convert(amount Decimal, from, to string) (Decimal, error) {
+	src, ok := c.rates[from]
+	if !ok {
+		return Decimal{}, fmt.Errorf("%w: %s", ErrUnknownRate, from)
+	}
+	dst, ok := c.rates[to]
+	if !ok {
+		return Decimal{}, fmt.Errorf("%w: %s", ErrUnknownRate, to)
+	}
+	return amount.Mul(src.Rate).Quo(dst.Rate).Round(dst.RoundingStep, dst.RoundingMode), nil
+}
+
+// FromBase переводит цену в BaseCurrency (например, Part.Price) в валюту to.
+func (c *CurrencyConverter) FromBase(price float64, to string) (float64, error) {
+	converted, err := c.Convert(DecimalFromFloat(price), BaseCurrency, to)
+	return converted.Float64(), err
+}
+
+// ToBase переводит сумму в валюте from в BaseCurrency без округления
+// (для границ фильтра по цене, заданных в валюте покупателя).
+func (c *CurrencyConverter) ToBase(amount float64, from string) (float64, error) {
+	converted, err := c.ToBaseExact(DecimalFromFloat(amount), from)
+	return converted.Float64(), err
+}
+
+// ToBaseExact переводит сумму в валюте from в BaseCurrency без округления
+// (для себестоимости, которую нельзя округлять по шагу цен продажи).
+func (c *CurrencyConverter) ToBaseExact(amount Decimal, from string) (Decimal, error) {
+	src, ok := c.rates[from]
+	if !ok {
+		return Decimal{}, fmt.Errorf("%w: %s", ErrUnknownRate, from)
+	}
+	return amount.Mul(src.Rate), nil
+}
+
+// PartBasePrice – базовая цена запчасти в валюте закупки.
+type PartBasePrice struct {
+	PartID    int      `json:"part_id"`
+	Price     *Decimal `json:"base_price"`    // nil – цена продажи задаётся напрямую
+	Currency  *string  `json:"base_currency"` // валюта базовой цены
+	CostPrice *Decimal `json:"cost_price"`    // себестоимость в BaseCurrency по последней приёмке; nil – не задана
+}
+
+// GetPartBasePrice возвращает базовую цену и себестоимость запчасти.
+// Возвращает sql.ErrNoRows, если запчасти нет.
+func GetPartBasePrice(db *sql.DB, partID int) (PartBasePrice, error) {
+	p := PartBasePrice{PartID: partID}
+	var price, cost sql.NullString
+	err := db.QueryRow("SELECT base_price, base_currency, cost_price FROM parts WHERE part_id = ? AND deleted_at IS NULL", partID).
+		Scan(&price, &p.Currency, &cost)
+	if err != nil {
+		return p, err
+	}
+	if cost.Valid {
+		d, err := ParseDecimal(cost.String)
+		if err != nil {
+			return p, err
+		}
+		p.CostPrice = &d
+	}
+	if !price.Valid {
+		return p, nil
+	}
+	d, err := ParseDecimal(price.String)
+	p.Price = &d
+	return p, err
+}
+
+// SetPartBasePrice задаёт базовую цену запчасти в валюте currency и сразу пересчитывает
+// цену продажи по текущему курсу. Пустая валюта снимает базовую цену: цена продажи
+// остаётся прежней и дальше задаётся вручную. Возвращает sql.ErrNoRows, если запчасти нет.
+func SetPartBasePrice(db *sql.DB, partID int, price Decimal, currency, username string) error {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		n, err := rowsAffected(db.Exec("UPDATE parts SET base_price = NULL, base_currency = NULL WHERE part_id = ? AND deleted_at IS NULL", partID))
+		if err == nil && n == 0 {
+			err = sql.ErrNoRows
+		}
+		return err
+	}
+	if price.Sign() < 0 {
+		return ErrPrice
+	}
+	converter, err := LoadCurrencyConverter(db)
+	if err != nil {
+		return err
+	}
+	if !converter.Has(currency) {
+		return fmt.Errorf("%w: %s", ErrUnknownRate, currency)
+	}
+	n, err := rowsAffected(db.Exec("UPDATE parts SET base_price = ?, base_currency = ? WHERE part_id = ? AND deleted_at IS NULL",
+		price, currency, partID))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	_, err = repriceParts(db, converter, "part_id = ?", []interface{}{partID}, username)
+	return err
+}
+
+// RecalculateBasePrices пересчитывает цены продажи запчастей с базовой ценой в валюте currency
+// (пусто – во всех валютах) по текущим курсам. Возвращает количество изменённых цен.
+func RecalculateBasePrices(db *sql.DB, currency, username string) (int, error) {
+	converter, err := LoadCurrencyConverter(db)
+	if err != nil {
+		return 0, err
+	}
+	cond, args := "base_currency IS NOT NULL", []interface{}{}
+	if currency != "" {
+		cond, args = "base_currency = ?", []interface{}{currency}
+	}
+	return repriceParts(db, converter, cond, args, username)
+}
+
+// repriceParts пересчитывает цену продажи выбранных условием cond запчастей из базовой цены
+// и записывает изменения в историю цен (источник PriceSourceCurrency).
+func repriceParts(db *sql.DB, converter *CurrencyConverter, cond string, args []interface{}, username string) (int, error) {
+	rows, err := db.Query("SELECT part_id, price, base_price, base_currency FROM parts "+
+		"WHERE deleted_at IS NULL AND base_price IS NOT NULL AND "+cond, args...)
+	if err != nil {
+		return 0, err
+	}
+	type change struct {
+		id         int
+		old, price float64
+	}
+	var changes []change
+	for rows.Next() {
+		var id int
+		var old float64
+		var base Decimal
+		var currency string
+		if err := rows.Scan(&id, &old, &base, &currency); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		price, err := converter.Convert(base, currency, BaseCurrency)
+		if err != nil {
+			log.Printf("[WARN] repriceParts: запчасть %d пропущена: %v", id, err)
+			continue
+		}
+		if price.Cmp(DecimalFromFloat(old)) != 0 {
+			changes = append(changes, change{id, old, price.Float64()})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(changes) == 0 {
+		return 0, nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	src := PriceSource{Source: PriceSourceCurrency, Batch: NewPriceBatch(PriceSourceCurrency), Username: username}
+	ids := make([]int, 0, len(changes))
+	for _, c := range changes {
+		if _, err := tx.Exec("UPDATE parts SET price = ?, update_at = NOW() WHERE part_id = ?", c.price, c.id); err != nil {
+			return 0, err
+		}
+		if err := RecordPriceChange(tx, c.id, &c.old, c.price, src); err != nil {
+			return 0, err
+		}
+		ids = append(ids, c.id)
+	}
+	if err := RefreshKitsFor(tx, ids...); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	InvalidateCatalogTree()
+	log.Printf("[INFO] repriceParts: пересчитано цен по курсам: %d", len(changes))
+	return len(changes), nil
+}
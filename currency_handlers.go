@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"AutoM/config"
+	"AutoM/models"
+
+	"github.com/gorilla/mux"
+)
+
+// currencySigns – символы валют для витрины; для остальных валют выводится код.
+var currencySigns = map[string]string{"RUB": "₽", "USD": "$", "EUR": "€", "CNY": "¥", "KZT": "₸", "BYN": "Br"}
+
+// currencySign возвращает символ валюты для вывода цены.
+func currencySign(code string) string {
+	if sign, ok := currencySigns[code]; ok {
+		return sign
+	}
+	return code
+}
+
+// requestCurrency разбирает GET-параметр currency. Для базовой валюты (и если параметр
+// не задан) конвертер не нужен и возвращается nil; для валюты без курса – ошибка.
+func requestCurrency(r *http.Request) (string, *models.CurrencyConverter, error) {
+	code := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("currency")))
+	if code == "" || code == models.BaseCurrency {
+		return models.BaseCurrency, nil, nil
+	}
+	converter, err := models.LoadCurrencyConverter(config.DB)
+	if err != nil {
+		return "", nil, err
+	}
+	if !converter.Has(code) {
+		return "", nil, fmt.Errorf("%w: %s", models.ErrUnknownRate, code)
+	}
+	return code, converter, nil
+}
+
+// apiCurrency – requestCurrency для API: неизвестная валюта – 400, ошибка базы – 500.
+func apiCurrency(w http.ResponseWriter, r *http.Request, handler string) (string, *models.CurrencyConverter, bool) {
+	code, converter, err := requestCurrency(r)
+	if errors.Is(err, models.ErrUnknownRate) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return "", nil, false
+	}
+	if err != nil {
+		log.Printf("[ERROR] %s: ошибка загрузки курсов валют: %v", handler, err)
+		http.Error(w, "Ошибка загрузки курсов валют", http.StatusInternalServerError)
+		return "", nil, false
+	}
+	return code, converter, true
+}
+
+// storefrontCurrency – requestCurrency для страниц витрины: при неизвестной валюте
+// или ошибке цены выводятся в базовой валюте.
+func storefrontCurrency(r *http.Request) (string, *models.CurrencyConverter) {
+	code, converter, err := requestCurrency(r)
+	if err != nil {
+		log.Printf("[WARN] Цены выводятся в %s: %v", models.BaseCurrency, err)
+		return models.BaseCurrency, nil
+	}
+	return code, converter
+}
+
+// convertPartItem переводит цену запчасти из базовой валюты в валюту code.
+// Конвертер nil означает базовую валюту: цена не меняется.
+func convertPartItem(converter *models.CurrencyConverter, code string, item *models.PartListItem) {
+	if converter == nil {
+		return
+	}
+	item.Price, _ = converter.FromBase(item.Price, code)
+	if item.ListPrice != nil {
+		listPrice, _ := converter.FromBase(*item.ListPrice, code)
+		item.ListPrice = &listPrice
+	}
+	item.Currency = code
+}
+
+// convertPartItems переводит цены списка запчастей в валюту code.
+func convertPartItems(converter *models.CurrencyConverter, code string, items []models.PartListItem) {
+	for i := range items {
+		convertPartItem(converter, code, &items[i])
+	}
+}
+
+// convertKitComponents переводит цены компонентов комплекта в валюту code.
+func convertKitComponents(converter *models.CurrencyConverter, code string, kit *models.Kit) {
+	if converter == nil || kit == nil {
+		return
+	}
+	for i := range kit.Components {
+		kit.Components[i].Price, _ = converter.FromBase(kit.Components[i].Price, code)
+	}
+}
+
+// priceBoundsToBase переводит границы фильтра по цене из валюты покупателя в базовую валюту.
+func priceBoundsToBase(converter *models.CurrencyConverter, code string, filter *models.PartFilter) {
+	if converter == nil {
+		return
+	}
+	for _, bound := range []*float64{filter.MinPrice, filter.MaxPrice} {
+		if bound != nil {
+			*bound, _ = converter.ToBase(*bound, code)
+		}
+	}
+}
+
+// GetCurrencyRates – курсы валют с правилами округления (API).
+func GetCurrencyRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := models.GetCurrencyRates(config.DB)
+	if err != nil {
+		log.Printf("[ERROR] GetCurrencyRates: ошибка получения курсов: %v", err)
+		http.Error(w, "Ошибка получения курсов валют", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetCurrencyRates", rates)
+}
+
+// respondRepriced отвечает количеством пересчитанных цен или статусом ошибки курса.
+func respondRepriced(w http.ResponseWriter, handler string, repriced int, err error) {
+	switch {
+	case errors.Is(err, models.ErrCurrencyCode), errors.Is(err, models.ErrCurrencyRate),
+		errors.Is(err, models.ErrCurrencyBase), errors.Is(err, models.ErrCurrencyStep),
+		errors.Is(err, models.ErrRoundingMode), errors.Is(err, models.ErrRatesFile):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err != nil:
+		log.Printf("[ERROR] %s: %v", handler, err)
+		http.Error(w, "Ошибка сохранения курса валюты", http.StatusInternalServerError)
+	default:
+		writeJSON(w, handler, map[string]int{"repriced": repriced})
+	}
+}
+
+// SetCurrencyRate – задание курса валюты {code} (API). Ожидается JSON
+// {"rate": "98.45", "rounding_step": "10", "rounding_mode": "up"}; курс – сколько рублей
+// стоит единица валюты. Цены запчастей с базовой ценой в этой валюте пересчитываются.
+func SetCurrencyRate(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	var rate models.CurrencyRate
+	if !decodeJSON(w, r, "SetCurrencyRate", &rate) {
+		return
+	}
+	rate.Code, rate.Source = mux.Vars(r)["code"], models.RateSourceManual
+	repriced, err := models.SetCurrencyRate(config.DB, rate, sessionUsername(r))
+	respondRepriced(w, "SetCurrencyRate", repriced, err)
+}
+
+// DeleteCurrencyRate – удаление курса валюты {code} (API).
+func DeleteCurrencyRate(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	code := mux.Vars(r)["code"]
+	rowsAffected, err := models.DeleteCurrencyRate(config.DB, code)
+	switch {
+	case errors.Is(err, models.ErrCurrencyBase), errors.Is(err, models.ErrCurrencyInUse):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case err != nil:
+		log.Printf("[ERROR] DeleteCurrencyRate: ошибка удаления курса %s: %v", code, err)
+		http.Error(w, "Ошибка удаления курса валюты", http.StatusInternalServerError)
+	case rowsAffected == 0:
+		http.Error(w, "Курс валюты не найден", http.StatusNotFound)
+	default:
+		log.Printf("[INFO] DeleteCurrencyRate: курс %s удалён", code)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// ImportCurrencyRates – загрузка курсов из текстового файла в поле формы "file" (API).
+// Формат строк – см. models.ImportCurrencyRates.
+func ImportCurrencyRates(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		http.Error(w, "Ошибка обработки формы: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Ошибка получения файла: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	log.Printf("[INFO] ImportCurrencyRates: загружен файл курсов %s, размер: %d байт", header.Filename, header.Size)
+
+	loaded, err := models.ImportCurrencyRates(config.DB, file, sessionUsername(r))
+	if err != nil {
+		respondRepriced(w, "ImportCurrencyRates", 0, err)
+		return
+	}
+	writeJSON(w, "ImportCurrencyRates", map[string]int{"loaded": loaded})
+}
+
+// GetPartBasePrice – базовая цена запчасти в валюте закупки (API).
+func GetPartBasePrice(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "GetPartBasePrice")
+	if !ok {
+		return
+	}
+	price, err := models.GetPartBasePrice(config.DB, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Запчасть не найдена", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] GetPartBasePrice: ошибка получения базовой цены запчасти %d: %v", id, err)
+		http.Error(w, "Ошибка получения базовой цены", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetPartBasePrice", price)
+}
+
+// SetPartBasePrice – задание базовой цены запчасти (API). Ожидается JSON
+// {"price": "12.50", "currency": "EUR"}; цена продажи сразу пересчитывается по курсу.
+// Пустая валюта снимает базовую цену.
+func SetPartBasePrice(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "SetPartBasePrice")
+	if !ok {
+		return
+	}
+	var req struct {
+		Price    models.Decimal `json:"price"`
+		Currency string         `json:"currency"`
+	}
+	if !decodeJSON(w, r, "SetPartBasePrice", &req) {
+		return
+	}
+	err := models.SetPartBasePrice(config.DB, id, req.Price, req.Currency, sessionUsername(r))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "Запчасть не найдена", http.StatusNotFound)
+	case errors.Is(err, models.ErrPrice), errors.Is(err, models.ErrUnknownRate):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err != nil:
+		log.Printf("[ERROR] SetPartBasePrice: ошибка сохранения базовой цены запчасти %d: %v", id, err)
+		http.Error(w, "Ошибка сохранения базовой цены", http.StatusInternalServerError)
+	default:
+		log.Printf("[INFO] SetPartBasePrice: базовая цена запчасти %d: %s %s", id, req.Price, req.Currency)
+		w.WriteHeader(http.StatusOK)
+	}
+}
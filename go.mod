@@ -15,7 +15,6 @@ require (
 	github.com/xuri/efp v0.0.0-20240408161823-9ad904a10d6d // indirect
 	github.com/xuri/nfp v0.0.0-20240318013403-ab9948c2c4a7 // indirect
 	golang.org/x/net v0.30.0 // indirect
-	golang.org/x/text v0.24.0 // indirect
 )
 
 require (
@@ -23,4 +22,5 @@ require (
 	github.com/gorilla/sessions v1.4.0
 	github.com/xuri/excelize/v2 v2.9.0
 	golang.org/x/crypto v0.37.0
+	golang.org/x/text v0.24.0
 )
@@ -20,7 +20,7 @@ type Group struct {
 func GetAllGroups(db *sql.DB) ([]Group, error) {
 	log.Println("[INFO] GetAllGroups: начало запроса")
 	// Экранирование имени таблицы для избежания проблем с зарезервированными словами
-	query := "SELECT group_id AS id, name, create_at, update_at FROM `groups_main`"
+	query := "SELECT group_id AS id, name, create_at, update_at FROM `groups_main` WHERE deleted_at IS NULL"
 	log.Printf("[INFO] GetAllGroups: выполняется запрос: %s", query)
 
 	rows, err := db.Query(query)
@@ -60,7 +60,7 @@ func GetAllGroups(db *sql.DB) ([]Group, error) {
 func GetGroupByID(db *sql.DB, id int) (Group, error) {
 	log.Printf("[INFO] GetGroupByID: запрос группы с ID %d", id)
 	var grp Group
-	query := "SELECT group_id AS id, name, create_at, update_at FROM `groups_main` WHERE group_id = ?"
+	query := "SELECT group_id AS id, name, create_at, update_at FROM `groups_main` WHERE group_id = ? AND deleted_at IS NULL"
 	log.Printf("[INFO] GetGroupByID: выполняется запрос: %s", query)
 	err := db.QueryRow(query, id).Scan(&grp.ID, &grp.Name, &grp.CreateAt, &grp.UpdateAt)
 	if err != nil {
@@ -73,23 +73,29 @@ func GetGroupByID(db *sql.DB, id int) (Group, error) {
 
 // AddGroup добавляет новую группу в базу данных.
 func AddGroup(db *sql.DB, name string) error {
+	defer InvalidateCatalogTree()
 	log.Printf("[INFO] AddGroup: добавление новой группы: '%s'", name)
 	// Вставка производится только по имени; временные метки генерируются базой
 	query := "INSERT INTO `groups_main` (name, create_at, update_at) VALUES (?, NOW(), NOW())"
 	log.Printf("[INFO] AddGroup: выполняется запрос: %s", query)
-	_, err := db.Exec(query, name)
+	res, err := db.Exec(query, name)
 	if err != nil {
 		log.Printf("[ERROR] AddGroup: ошибка выполнения запроса: %v", err)
 		return err
 	}
+	if id, err := res.LastInsertId(); err == nil {
+		groupSlugs.update(db, int(id), "")
+	}
 	log.Printf("[INFO] AddGroup: группа '%s' успешно добавлена", name)
 	return nil
 }
 
 // UpdateGroup обновляет данные группы по её ID.
 func UpdateGroup(db *sql.DB, id int, name string) (int64, error) {
+	defer InvalidateCatalogTree()
 	log.Printf("[INFO] UpdateGroup: обновление группы с ID %d", id)
-	query := "UPDATE `groups_main` SET name = ?, update_at = NOW() WHERE group_id = ?"
+	oldPath := groupSlugs.path(db, id)
+	query := "UPDATE `groups_main` SET name = ?, update_at = NOW() WHERE group_id = ? AND deleted_at IS NULL"
 	log.Printf("[INFO] UpdateGroup: выполняется запрос: %s", query)
 	result, err := db.Exec(query, name, id)
 	if err != nil {
@@ -105,29 +111,14 @@ func UpdateGroup(db *sql.DB, id int, name string) (int64, error) {
 		log.Printf("[WARN] UpdateGroup: группа с ID %d не найдена", id)
 	} else {
 		log.Printf("[INFO] UpdateGroup: группа с ID %d успешно обновлена", id)
+		groupSlugs.update(db, id, oldPath)
 	}
 	return rowsAffected, nil
 }
 
-// DeleteGroup удаляет группу из базы данных по её ID.
-func DeleteGroup(db *sql.DB, id int) (int64, error) {
+// DeleteGroup удаляет группу по её ID в режиме opts (см. DeleteOptions).
+// Возвращает sql.ErrNoRows, если группа не найдена.
+func DeleteGroup(db *sql.DB, id int, opts DeleteOptions) (DeleteResult, error) {
 	log.Printf("[INFO] DeleteGroup: удаление группы с ID %d", id)
-	query := "DELETE FROM `groups_main` WHERE group_id = ?"
-	log.Printf("[INFO] DeleteGroup: выполняется запрос: %s", query)
-	result, err := db.Exec(query, id)
-	if err != nil {
-		log.Printf("[ERROR] DeleteGroup: ошибка выполнения запроса: %v", err)
-		return 0, err
-	}
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		log.Printf("[ERROR] DeleteGroup: ошибка получения количества затронутых строк: %v", err)
-		return 0, err
-	}
-	if rowsAffected == 0 {
-		log.Printf("[WARN] DeleteGroup: группа с ID %d не найдена", id)
-	} else {
-		log.Printf("[INFO] DeleteGroup: группа с ID %d успешно удалена", id)
-	}
-	return rowsAffected, nil
+	return groupLevel.remove(db, id, opts)
 }
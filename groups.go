@@ -114,29 +114,9 @@ func UpdateGroup(w http.ResponseWriter, r *http.Request) {
 }
 
 // DeleteGroup – обработчик для удаления группы.
+// Параметр mode задаёт судьбу содержимого: restrict (по умолчанию) – отказать, если в группе
+// есть категории; cascade – удалить всё содержимое; reassign – перенести категории в группу target.
 func DeleteGroup(w http.ResponseWriter, r *http.Request) {
 	log.Println("[INFO] DeleteGroup: начало запроса")
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
-	if err != nil {
-		log.Printf("[ERROR] DeleteGroup: неверный ID, ошибка преобразования: %v", err)
-		http.Error(w, "Неверный ID", http.StatusBadRequest)
-		return
-	}
-
-	rowsAffected, err := models.DeleteGroup(config.DB, id)
-	if err != nil {
-		log.Printf("[ERROR] DeleteGroup: ошибка удаления группы: %v", err)
-		http.Error(w, "Ошибка удаления группы", http.StatusInternalServerError)
-		return
-	}
-
-	if rowsAffected == 0 {
-		log.Printf("[ERROR] DeleteGroup: группа с ID %d не найдена", id)
-		http.Error(w, "Группа не найдена", http.StatusNotFound)
-		return
-	}
-
-	log.Printf("[INFO] DeleteGroup: группа с ID %d успешно удалена", id)
-	w.WriteHeader(http.StatusOK)
+	deleteCatalogItem(w, r, "DeleteGroup", "Группа", models.DeleteGroup)
 }
@@ -0,0 +1,309 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+// Режимы удаления элементов каталога, у которых есть содержимое.
+const (
+	DeleteRestrict = "restrict" // отказать, если у элемента есть дочерние записи (по умолчанию)
+	DeleteCascade  = "cascade"  // удалить элемент вместе со всем содержимым
+	DeleteReassign = "reassign" // перенести дочерние записи в элемент Target того же уровня
+)
+
+// Ошибки удаления элементов каталога.
+var (
+	ErrDeleteMode     = errors.New("режим удаления должен быть restrict, cascade или reassign")
+	ErrDeleteNotEmpty = errors.New("элемент содержит дочерние записи; выберите режим cascade или reassign")
+	ErrDeleteTarget   = errors.New("для переноса укажите существующий элемент того же уровня, отличный от удаляемого")
+)
+
+// DeleteOptions – параметры удаления группы, категории или подкатегории.
+type DeleteOptions struct {
+	Mode   string // DeleteRestrict, DeleteCascade или DeleteReassign; пусто – DeleteRestrict
+	Target int    // получатель дочерних записей в режиме DeleteReassign
+}
+
+// Validate проверяет режим удаления и подставляет режим по умолчанию.
+func (o *DeleteOptions) Validate() error {
+	switch o.Mode {
+	case "":
+		o.Mode = DeleteRestrict
+	case DeleteRestrict, DeleteCascade:
+	case DeleteReassign:
+		if o.Target <= 0 {
+			return ErrDeleteTarget
+		}
+	default:
+		return ErrDeleteMode
+	}
+	return nil
+}
+
+// DeleteImpact – сколько записей затронет удаление элемента каталога.
+type DeleteImpact struct {
+	Categories    int  `json:"categories"`
+	Subcategories int  `json:"subcategories"`
+	Parts         int  `json:"parts"`
+	Restricted    bool `json:"restricted"` // удаление в режиме restrict будет отклонено
+}
+
+// DeleteResult – итог удаления элемента каталога.
+type DeleteResult struct {
+	Mode     string       `json:"mode"`
+	Target   int          `json:"target,omitempty"`
+	Affected DeleteImpact `json:"affected"` // перемещено в корзину (cascade) или перенесено (reassign)
+	// Значения характеристик при переносе запчастей в другую подкатегорию (reassign подкатегории).
+	AttributesKept    int `json:"attributes_kept,omitempty"`
+	AttributesDropped int `json:"attributes_dropped,omitempty"`
+}
+
+// dbExecutor – общие методы *sql.DB и *sql.Tx.
+type dbExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// catalogLevel описывает уровень иерархии каталога для удаления и восстановления.
+// Запросы потомков учитывают только записи, не находящиеся в корзине.
+type catalogLevel struct {
+	name          string     // для журнала
+	table         string     // таблица уровня
+	idColumn      string     // первичный ключ
+	parentTable   string     // таблица родителя; пусто – верхний уровень
+	parentColumn  string     // столбец ссылки на родителя
+	children      string     // запрос ID прямых потомков по ID элемента
+	childColumn   string     // столбец потомков, ссылающийся на элемент
+	childTable    string     // таблица потомков
+	categories    string     // запрос ID категорий внутри элемента; пусто – нет
+	subcategories string     // запрос ID подкатегорий внутри элемента
+	childSlugs    slugEntity // слаги потомков, адреса которых меняются при переносе
+	slugs         slugEntity // слаги самого уровня
+}
+
+var (
+	groupLevel = catalogLevel{
+		name: "группа", table: "`groups_main`", idColumn: "group_id",
+		children:      "SELECT category_id FROM `categories` WHERE group_id = ? AND deleted_at IS NULL",
+		childColumn:   "group_id",
+		childTable:    "`categories`",
+		categories:    "SELECT category_id FROM `categories` WHERE group_id = ? AND deleted_at IS NULL",
+		subcategories: "SELECT s.subcategory_id FROM subcategories s JOIN `categories` c ON c.category_id = s.category_id WHERE c.group_id = ? AND s.deleted_at IS NULL",
+		childSlugs:    categorySlugs,
+		slugs:         groupSlugs,
+	}
+	categoryLevel = catalogLevel{
+		name: "категория", table: "`categories`", idColumn: "category_id",
+		parentTable: "`groups_main`", parentColumn: "group_id",
+		children:      "SELECT subcategory_id FROM subcategories WHERE category_id = ? AND deleted_at IS NULL",
+		childColumn:   "category_id",
+		childTable:    "subcategories",
+		subcategories: "SELECT subcategory_id FROM subcategories WHERE category_id = ? AND deleted_at IS NULL",
+		childSlugs:    subcategorySlugs,
+		slugs:         categorySlugs,
+	}
+	subcategoryLevel = catalogLevel{
+		name: "подкатегория", table: "subcategories", idColumn: "subcategory_id",
+		parentTable: "`categories`", parentColumn: "category_id",
+		children:      "SELECT part_id FROM parts WHERE subcategory_id = ? AND deleted_at IS NULL",
+		childColumn:   "subcategory_id",
+		childTable:    "parts",
+		subcategories: "SELECT subcategory_id FROM subcategories WHERE subcategory_id = ?",
+		slugs:         subcategorySlugs,
+	}
+)
+
+// impact подсчитывает содержимое элемента. Возвращает sql.ErrNoRows, если элемента нет или он в корзине.
+func (l catalogLevel) impact(db dbExecutor, id int) (DeleteImpact, error) {
+	var exists int
+	query := "SELECT COUNT(*) FROM " + l.table + " WHERE " + l.idColumn + " = ? AND deleted_at IS NULL"
+	if err := db.QueryRow(query, id).Scan(&exists); err != nil {
+		return DeleteImpact{}, err
+	}
+	if exists == 0 {
+		return DeleteImpact{}, sql.ErrNoRows
+	}
+
+	var impact DeleteImpact
+	if l.categories != "" {
+		if err := db.QueryRow("SELECT COUNT(*) FROM ("+l.categories+") t", id).Scan(&impact.Categories); err != nil {
+			return DeleteImpact{}, err
+		}
+	}
+	if l != subcategoryLevel {
+		if err := db.QueryRow("SELECT COUNT(*) FROM ("+l.subcategories+") t", id).Scan(&impact.Subcategories); err != nil {
+			return DeleteImpact{}, err
+		}
+	}
+	query = "SELECT COUNT(*) FROM parts WHERE deleted_at IS NULL AND subcategory_id IN (" + l.subcategories + ")"
+	if err := db.QueryRow(query, id).Scan(&impact.Parts); err != nil {
+		return DeleteImpact{}, err
+	}
+	var children int
+	if err := db.QueryRow("SELECT COUNT(*) FROM ("+l.children+") t", id).Scan(&children); err != nil {
+		return DeleteImpact{}, err
+	}
+	impact.Restricted = children > 0
+	return impact, nil
+}
+
+// childIDs возвращает ID прямых потомков элемента.
+func (l catalogLevel) childIDs(db dbExecutor, id int) ([]int, error) {
+	rows, err := db.Query(l.children, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var childID int
+		if err := rows.Scan(&childID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, childID)
+	}
+	return ids, rows.Err()
+}
+
+// remove перемещает элемент каталога в корзину в заданном режиме в одной транзакции.
+// В режиме cascade всё содержимое получает ту же отметку deleted_at, что и сам элемент:
+// по ней RestoreCatalogItem восстанавливает содержимое вместе с элементом.
+func (l catalogLevel) remove(db *sql.DB, id int, opts DeleteOptions) (DeleteResult, error) {
+	if err := opts.Validate(); err != nil {
+		return DeleteResult{}, err
+	}
+	log.Printf("[INFO] Удаление: %s %d, режим %s, получатель %d", l.name, id, opts.Mode, opts.Target)
+	result := DeleteResult{Mode: opts.Mode}
+	deletedAt := time.Now().Truncate(time.Second)
+
+	// Адреса до переноса нужны для перенаправлений со старых адресов.
+	var oldPath string
+	oldChildPaths := make(map[int]string)
+	if opts.Mode == DeleteReassign {
+		result.Target = opts.Target
+		oldPath = l.slugs.path(db, id)
+		if l.childSlugs.table != "" {
+			ids, err := l.childIDs(db, id)
+			if err != nil {
+				return DeleteResult{}, err
+			}
+			for _, childID := range ids {
+				oldChildPaths[childID] = l.childSlugs.path(db, childID)
+			}
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return DeleteResult{}, err
+	}
+	defer tx.Rollback()
+
+	impact, err := l.impact(tx, id)
+	if err != nil {
+		return DeleteResult{}, err
+	}
+	switch opts.Mode {
+	case DeleteRestrict:
+		if impact.Restricted {
+			return DeleteResult{}, ErrDeleteNotEmpty
+		}
+	case DeleteCascade:
+		// Запчасти незавершённых заказов поставщикам и инвентаризаций в корзину не переносятся.
+		var inDocuments bool
+		query := "SELECT EXISTS(SELECT 1 FROM parts p WHERE p.deleted_at IS NULL AND p.subcategory_id IN (" +
+			l.subcategories + ") AND " + partInOpenDocuments + ")"
+		if err := tx.QueryRow(query, append([]interface{}{id}, openDocumentArgs()...)...).Scan(&inDocuments); err != nil {
+			return DeleteResult{}, err
+		}
+		if inDocuments {
+			return DeleteResult{}, ErrPartDocuments
+		}
+		// Запросы потомков читают ту же таблицу, поэтому оборачиваются в производную таблицу (ограничение MySQL).
+		queries := []string{
+			"UPDATE parts SET deleted_at = ? WHERE deleted_at IS NULL AND subcategory_id IN (SELECT subcategory_id FROM (" + l.subcategories + ") t)",
+		}
+		if l != subcategoryLevel {
+			queries = append(queries, "UPDATE subcategories SET deleted_at = ? WHERE subcategory_id IN (SELECT subcategory_id FROM ("+l.subcategories+") t)")
+		}
+		if l.categories != "" {
+			queries = append(queries, "UPDATE `categories` SET deleted_at = ? WHERE category_id IN (SELECT category_id FROM ("+l.categories+") t)")
+		}
+		for _, query := range queries {
+			if _, err := tx.Exec(query, deletedAt, id); err != nil {
+				return DeleteResult{}, err
+			}
+		}
+		if err := RefreshAllKits(tx); err != nil {
+			return DeleteResult{}, err
+		}
+	case DeleteReassign:
+		var exists int
+		query := "SELECT COUNT(*) FROM " + l.table + " WHERE " + l.idColumn + " = ? AND deleted_at IS NULL"
+		if err := tx.QueryRow(query, opts.Target).Scan(&exists); err != nil {
+			return DeleteResult{}, err
+		}
+		if exists == 0 || opts.Target == id {
+			return DeleteResult{}, ErrDeleteTarget
+		}
+		if l == subcategoryLevel {
+			// Значения характеристик сопоставляются с характеристиками новой подкатегории, как при слиянии.
+			partIDs := "SELECT part_id FROM parts WHERE subcategory_id = ? AND deleted_at IS NULL"
+			if result.AttributesKept, result.AttributesDropped, err = remapPartAttributes(tx, opts.Target, partIDs, id); err != nil {
+				return DeleteResult{}, err
+			}
+		}
+		query = "UPDATE " + l.childTable + " SET " + l.childColumn + " = ? WHERE " + l.childColumn + " = ? AND deleted_at IS NULL"
+		if _, err := tx.Exec(query, opts.Target, id); err != nil {
+			return DeleteResult{}, err
+		}
+		if l == subcategoryLevel {
+			if err := checkStockAlerts(tx, "p.subcategory_id = ?", opts.Target); err != nil {
+				return DeleteResult{}, err
+			}
+		}
+	}
+	if _, err := tx.Exec("UPDATE "+l.table+" SET deleted_at = ? WHERE "+l.idColumn+" = ?", deletedAt, id); err != nil {
+		return DeleteResult{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return DeleteResult{}, err
+	}
+	InvalidateCatalogTree()
+	if opts.Mode != DeleteRestrict {
+		result.Affected = impact
+		result.Affected.Restricted = false
+	}
+
+	if opts.Mode == DeleteReassign {
+		for childID, childPath := range oldChildPaths {
+			l.childSlugs.update(db, childID, childPath)
+		}
+		if newPath := l.slugs.path(db, opts.Target); oldPath != "" && newPath != "" {
+			if err := recordRedirect(db, oldPath, newPath); err != nil {
+				log.Printf("[ERROR] Ошибка записи перенаправления %s → %s: %v", oldPath, newPath, err)
+			}
+		}
+	}
+	log.Printf("[INFO] Удаление: %s %d перемещена в корзину, затронуто: %+v", l.name, id, result.Affected)
+	return result, nil
+}
+
+// GetGroupDeleteImpact возвращает содержимое группы, которое затронет её удаление.
+func GetGroupDeleteImpact(db *sql.DB, id int) (DeleteImpact, error) {
+	return groupLevel.impact(db, id)
+}
+
+// GetCategoryDeleteImpact возвращает содержимое категории, которое затронет её удаление.
+func GetCategoryDeleteImpact(db *sql.DB, id int) (DeleteImpact, error) {
+	return categoryLevel.impact(db, id)
+}
+
+// GetSubcategoryDeleteImpact возвращает запчасти подкатегории, которые затронет её удаление.
+func GetSubcategoryDeleteImpact(db *sql.DB, id int) (DeleteImpact, error) {
+	return subcategoryLevel.impact(db, id)
+}
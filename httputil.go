@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// pathID извлекает числовой параметр {id} из пути запроса.
+// При ошибке отвечает клиенту 400 и возвращает ok = false.
+func pathID(w http.ResponseWriter, r *http.Request, handler string) (int, bool) {
+	return pathInt(w, r, "id", handler)
+}
+
+// pathInt извлекает числовой параметр name из пути запроса.
+// При ошибке отвечает клиенту 400 и возвращает ok = false.
+func pathInt(w http.ResponseWriter, r *http.Request, name, handler string) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)[name])
+	if err != nil {
+		log.Printf("[ERROR] %s: неверный параметр %s, ошибка преобразования: %v", handler, name, err)
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// writeJSON кодирует значение в JSON-ответ и логирует ошибку кодирования.
+func writeJSON(w http.ResponseWriter, handler string, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("[ERROR] %s: ошибка кодирования JSON: %v", handler, err)
+	}
+}
+
+// decodeJSON декодирует тело запроса в dst. При ошибке отвечает клиенту 400.
+func decodeJSON(w http.ResponseWriter, r *http.Request, handler string, dst interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		log.Printf("[ERROR] %s: ошибка декодирования JSON: %v", handler, err)
+		http.Error(w, "Неверный формат данных", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// requireAdmin проверяет, что запрос выполняет администратор; иначе отвечает 403.
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	session, err := getSession(w, r)
+	if err != nil {
+		log.Printf("[ERROR] Не удалось получить сессию: %v", err)
+		http.Error(w, "Доступ запрещён", http.StatusForbidden)
+		return false
+	}
+	if isAdmin, ok := session.Values["is_admin"].(bool); !ok || !isAdmin {
+		http.Error(w, "Доступ запрещён", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// respondAffected завершает запрос на изменение/удаление: 500 при ошибке,
+// 404 с сообщением notFound, если запись не найдена, иначе 200.
+func respondAffected(w http.ResponseWriter, handler, notFound string, rowsAffected int64, err error) {
+	if err != nil {
+		log.Printf("[ERROR] %s: ошибка выполнения запроса: %v", handler, err)
+		http.Error(w, "Ошибка сохранения данных", http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected == 0 {
+		log.Printf("[WARN] %s: запись не найдена", handler)
+		http.Error(w, notFound, http.StatusNotFound)
+		return
+	}
+	log.Printf("[INFO] %s: запрос успешно выполнен", handler)
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"AutoM/config"
+)
+
+// sessionCookie возвращает cookie сессии с признаком администратора isAdmin.
+func sessionCookie(t *testing.T, isAdmin bool) *http.Cookie {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	session, err := config.Store.New(req, "session")
+	if err != nil {
+		t.Fatalf("создание сессии: %v", err)
+	}
+	session.Values["is_admin"] = isAdmin
+	rec := httptest.NewRecorder()
+	if err := session.Save(req, rec); err != nil {
+		t.Fatalf("сохранение сессии: %v", err)
+	}
+	return rec.Result().Cookies()[0]
+}
+
+func TestRequireAdmin(t *testing.T) {
+	config.InitStore()
+	tests := []struct {
+		name   string
+		cookie *http.Cookie
+		want   bool
+	}{
+		{"без сессии", nil, false},
+		{"покупатель", sessionCookie(t, false), false},
+		{"администратор", sessionCookie(t, true), true},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		if tt.cookie != nil {
+			req.AddCookie(tt.cookie)
+		}
+		rec := httptest.NewRecorder()
+		if got := requireAdmin(rec, req); got != tt.want {
+			t.Errorf("%s: requireAdmin = %v, ожидалось %v", tt.name, got, tt.want)
+		}
+		if !tt.want && rec.Code != http.StatusForbidden {
+			t.Errorf("%s: статус %d, ожидался 403", tt.name, rec.Code)
+		}
+	}
+}
+
+// adminOnlyHandlers – обработчики, изменяющие цены, остатки и закупки: без сессии администратора
+// они отвечают 403, не обращаясь к базе данных.
+var adminOnlyHandlers = map[string]http.HandlerFunc{
+	"AddPriceGroup":                 AddPriceGroup,
+	"UpdatePriceGroup":              UpdatePriceGroup,
+	"DeletePriceGroup":              DeletePriceGroup,
+	"SetPriceGroupCategory":         SetPriceGroupCategory,
+	"DeletePriceGroupCategory":      DeletePriceGroupCategory,
+	"SetPriceGroupPartPrice":        SetPriceGroupPartPrice,
+	"DeletePriceGroupPartPrice":     DeletePriceGroupPartPrice,
+	"SetUserPriceGroup":             SetUserPriceGroup,
+	"AddWarehouse":                  AddWarehouse,
+	"UpdateWarehouse":               UpdateWarehouse,
+	"DeleteWarehouse":               DeleteWarehouse,
+	"SetPartStock":                  SetPartStock,
+	"AddStockMovement":              AddStockMovement,
+	"TransferStock":                 TransferStock,
+	"ReconcileStock":                ReconcileStock,
+	"AddSupplier":                   AddSupplier,
+	"UpdateSupplier":                UpdateSupplier,
+	"DeleteSupplier":                DeleteSupplier,
+	"AddPurchaseOrder":              AddPurchaseOrder,
+	"UpdatePurchaseOrder":           UpdatePurchaseOrder,
+	"SendPurchaseOrder":             SendPurchaseOrder,
+	"ReceivePurchaseOrder":          ReceivePurchaseOrder,
+	"CancelPurchaseOrder":           CancelPurchaseOrder,
+	"CreateReorderPurchaseOrders":   CreateReorderPurchaseOrders,
+	"SetPartReorderPoint":           SetPartReorderPoint,
+	"DeletePartReorderPoint":        DeletePartReorderPoint,
+	"SetSubcategoryReorderPoint":    SetSubcategoryReorderPoint,
+	"DeleteSubcategoryReorderPoint": DeleteSubcategoryReorderPoint,
+	"SetPartKit":                    SetPartKit,
+	"DeletePartKit":                 DeletePartKit,
+	"SellPart":                      SellPart,
+	"BulkUpdatePrices":              BulkUpdatePrices,
+	"ImportCurrencyRates":           ImportCurrencyRates,
+	"SetCurrencyRate":               SetCurrencyRate,
+	"DeleteCurrencyRate":            DeleteCurrencyRate,
+	"SetPartBasePrice":              SetPartBasePrice,
+	"AddPart":                       AddPart,
+	"UpdatePart":                    UpdatePart,
+	"AddPriceListProfile":           AddPriceListProfile,
+	"UpdatePriceListProfile":        UpdatePriceListProfile,
+	"DeletePriceListProfile":        DeletePriceListProfile,
+	"ImportPriceList":               ImportPriceList,
+	"AddPartBarcode":                AddPartBarcode,
+	"DeletePartBarcode":             DeletePartBarcode,
+}
+
+func TestAdminOnlyHandlers(t *testing.T) {
+	config.InitStore()
+	customer := sessionCookie(t, false)
+	for name, handler := range adminOnlyHandlers {
+		for _, cookie := range []*http.Cookie{nil, customer} {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/", nil)
+			if cookie != nil {
+				req.AddCookie(cookie)
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			if rec.Code != http.StatusForbidden {
+				t.Errorf("%s (cookie: %v): статус %d, ожидался 403", name, cookie != nil, rec.Code)
+			}
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package models
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // регистрация декодера GIF
+	"image/jpeg"
+	_ "image/png" // регистрация декодера PNG
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// Ограничения на загружаемые изображения.
+const (
+	MaxImageBytes  = 10 << 20   // размер файла
+	MaxImagePixels = 40_000_000 // ширина × высота; защищает от «бомб» с огромными размерами
+)
+
+// PartImagesURLPrefix – адрес, по которому раздаются загруженные изображения запчастей.
+const PartImagesURLPrefix = "/images/parts/"
+
+// ThumbnailSize – размер миниатюры: изображение вписывается в квадрат MaxSide × MaxSide.
+type ThumbnailSize struct {
+	Name    string
+	MaxSide int
+}
+
+// ThumbnailSizes – создаваемые миниатюры, от большей к меньшей.
+var ThumbnailSizes = []ThumbnailSize{
+	{"large", 1000},
+	{"medium", 400},
+	{"small", 150},
+}
+
+// Ошибки проверки загружаемого изображения.
+var (
+	ErrImageType     = errors.New("допустимы изображения JPEG, PNG и GIF")
+	ErrImageTooLarge = errors.New("изображение слишком большое")
+	ErrImageDecode   = errors.New("файл повреждён или не является изображением")
+)
+
+// imageExtensions сопоставляет допустимые MIME-типы расширениям файлов.
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// imageFileName – допустимые имена файлов хранилища: ключ, необязательный размер миниатюры, расширение.
+var imageFileName = regexp.MustCompile(`^[0-9a-f]{32}(_[a-z]+)?\.(jpg|png|gif)$`)
+
+// ValidImageFileName сообщает, может ли имя принадлежать файлу хранилища изображений.
+// Проверка исключает выход за пределы каталога хранилища.
+func ValidImageFileName(name string) bool {
+	return imageFileName.MatchString(name)
+}
+
+// thumbnailURLs возвращает адреса миниатюр загруженного изображения.
+func thumbnailURLs(key string) map[string]string {
+	urls := make(map[string]string, len(ThumbnailSizes))
+	for _, size := range ThumbnailSizes {
+		urls[size.Name] = PartImagesURLPrefix + key + "_" + size.Name + ".jpg"
+	}
+	return urls
+}
+
+// StorePartImage проверяет загруженный файл, сохраняет оригинал и миниатюры в каталог dir
+// и возвращает описание изображения для AddPartImage (без PartID).
+// Тип определяется по содержимому файла, а не по имени или заголовкам запроса.
+func StorePartImage(dir string, data []byte) (PartImage, error) {
+	if len(data) > MaxImageBytes {
+		return PartImage{}, ErrImageTooLarge
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return PartImage{}, ErrImageType
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return PartImage{}, ErrImageDecode
+	}
+	if cfg.Width*cfg.Height > MaxImagePixels {
+		return PartImage{}, ErrImageTooLarge
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return PartImage{}, ErrImageDecode
+	}
+
+	keyBytes := make([]byte, 16)
+	if _, err := rand.Read(keyBytes); err != nil {
+		return PartImage{}, err
+	}
+	key := hex.EncodeToString(keyBytes)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return PartImage{}, err
+	}
+	written := []string{filepath.Join(dir, key+ext)}
+	if err := os.WriteFile(written[0], data, 0o644); err != nil {
+		return PartImage{}, err
+	}
+
+	// Каждая следующая миниатюра строится из предыдущей: это быстрее и не хуже по качеству.
+	thumb := flattenImage(src)
+	for _, size := range ThumbnailSizes {
+		thumb = resizeToFit(thumb, size.MaxSide)
+		path := filepath.Join(dir, key+"_"+size.Name+".jpg")
+		written = append(written, path)
+		if err := writeJPEG(path, thumb); err != nil {
+			for _, p := range written {
+				os.Remove(p)
+			}
+			return PartImage{}, err
+		}
+	}
+
+	log.Printf("[INFO] StorePartImage: сохранено изображение %s (%s, %dx%d)", key, contentType, cfg.Width, cfg.Height)
+	return PartImage{
+		Source:      ImageSourceUpload,
+		URL:         PartImagesURLPrefix + key + ext,
+		StorageKey:  key,
+		ContentType: contentType,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+	}, nil
+}
+
+// RemovePartImageFiles удаляет файлы загруженного изображения из каталога dir.
+// Для внешних изображений ничего не делает.
+func RemovePartImageFiles(dir string, img PartImage) {
+	if img.Source != ImageSourceUpload || img.StorageKey == "" {
+		return
+	}
+	files := []string{filepath.Base(img.URL)}
+	for _, size := range ThumbnailSizes {
+		files = append(files, img.StorageKey+"_"+size.Name+".jpg")
+	}
+	for _, name := range files {
+		if !ValidImageFileName(name) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+			log.Printf("[ERROR] RemovePartImageFiles: ошибка удаления %s: %v", name, err)
+		}
+	}
+}
+
+// writeJPEG записывает изображение в файл в формате JPEG.
+func writeJPEG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := jpeg.Encode(f, img, &jpeg.Options{Quality: 85}); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// flattenImage переводит изображение в RGBA на белом фоне: у JPEG-миниатюр нет прозрачности.
+func flattenImage(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Over)
+	return dst
+}
+
+// resizeToFit уменьшает изображение так, чтобы большая сторона не превышала maxSide.
+// Каждый пиксель результата – среднее по соответствующему блоку исходных пикселей.
+// Изображения меньше maxSide не увеличиваются.
+func resizeToFit(src *image.RGBA, maxSide int) *image.RGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if w <= maxSide && h <= maxSide {
+		return src
+	}
+	nw, nh := maxSide, h*maxSide/w
+	if h > w {
+		nw, nh = w*maxSide/h, maxSide
+	}
+	nw, nh = max(nw, 1), max(nh, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, nw, nh))
+	for y := 0; y < nh; y++ {
+		y0, y1 := y*h/nh, max((y+1)*h/nh, y*h/nh+1)
+		for x := 0; x < nw; x++ {
+			x0, x1 := x*w/nw, max((x+1)*w/nw, x*w/nw+1)
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += int(row[sx*4+c])
+					}
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			i := y*dst.Stride + x*4
+			for c := 0; c < 4; c++ {
+				dst.Pix[i+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,349 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+)
+
+// Способы расчёта цены комплекта.
+const (
+	KitPricingFixed      = "fixed"      // цена задаётся вручную в самой запчасти-комплекте
+	KitPricingComponents = "components" // сумма цен компонентов за вычетом скидки
+)
+
+// Ошибки комплектов и продажи.
+var (
+	ErrKitPricing        = errors.New("способ расчёта цены комплекта должен быть fixed или components")
+	ErrKitDiscount       = errors.New("скидка комплекта должна быть от 0 до 100 %")
+	ErrKitComponents     = errors.New("комплект должен содержать хотя бы одну запчасть с количеством больше нуля")
+	ErrKitComponent      = errors.New("компонентом комплекта может быть только существующая запчасть, которая сама не является комплектом")
+	ErrKitNested         = errors.New("запчасть входит в другой комплект и не может сама быть комплектом")
+	ErrSaleQuantity      = errors.New("количество должно быть больше нуля")
+	ErrInsufficientStock = errors.New("недостаточно товара на складе")
+)
+
+// KitComponent – запчасть в составе комплекта.
+type KitComponent struct {
+	PartID   int     `json:"part_id"`
+	Quantity int     `json:"quantity"` // сколько штук входит в один комплект
+	Name     string  `json:"name,omitempty"`
+	Price    float64 `json:"price,omitempty"`
+	Stock    int     `json:"stock"`          // остаток компонента на складе
+	Slug     *string `json:"slug,omitempty"` // для ссылки /part/{slug}
+	Deleted  bool    `json:"deleted"`        // компонент в корзине или удалён: комплект недоступен
+	// SubcategoryID – подкатегория компонента для расчёта цены группы.
+	SubcategoryID int `json:"-"`
+}
+
+// Kit – комплект: запчасть из таблицы parts, состоящая из других запчастей.
+// Цена (при KitPricingComponents) и количество комплекта хранятся в самой запчасти
+// и пересчитываются при изменении компонентов, поэтому комплекты выводятся в списках,
+// поиске и фильтрах наравне с обычными запчастями.
+type Kit struct {
+	PartID     int            `json:"part_id"`
+	Pricing    string         `json:"pricing"`
+	Discount   float64        `json:"discount"` // скидка в процентах при KitPricingComponents
+	Components []KitComponent `json:"components"`
+}
+
+// StockChange – изменение остатка запчасти на складе при продаже.
+type StockChange struct {
+	PartID      int `json:"part_id"`
+	WarehouseID int `json:"warehouse_id"`
+	Quantity    int `json:"quantity"`  // списано
+	Remaining   int `json:"remaining"` // остаток на складе после списания
+}
+
+// SaleResult – итог продажи запчасти или комплекта.
+type SaleResult struct {
+	PartID   int           `json:"part_id"`
+	Quantity int           `json:"quantity"`
+	IsKit    bool          `json:"is_kit"`
+	Deducted []StockChange `json:"deducted"` // списания по складам; для комплекта – по компонентам
+}
+
+// migrateKits создаёт таблицы комплектов и их состава.
+func migrateKits(db *sql.DB) error {
+	return execAll(db,
+		"CREATE TABLE IF NOT EXISTS part_kits ("+
+			"part_id INT PRIMARY KEY, "+
+			"pricing VARCHAR(16) NOT NULL DEFAULT 'components', "+
+			"discount DECIMAL(5,2) NOT NULL DEFAULT 0, "+
+			"update_at DATETIME NOT NULL"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"CREATE TABLE IF NOT EXISTS part_kit_components ("+
+			"kit_id INT NOT NULL, "+
+			"part_id INT NOT NULL, "+
+			"quantity INT NOT NULL, "+
+			"PRIMARY KEY (kit_id, part_id), "+
+			"KEY idx_kit_component (part_id), "+
+			"FOREIGN KEY (kit_id) REFERENCES part_kits (part_id) ON DELETE CASCADE"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+	)
+}
+
+// Validate проверяет описание комплекта и подставляет способ расчёта цены по умолчанию.
+// Повторяющиеся компоненты объединяются.
+func (k *Kit) Validate() error {
+	if k.Pricing == "" {
+		k.Pricing = KitPricingComponents
+	}
+	if k.Pricing != KitPricingFixed && k.Pricing != KitPricingComponents {
+		return ErrKitPricing
+	}
+	if k.Discount < 0 || k.Discount > 100 {
+		return ErrKitDiscount
+	}
+	merged := make(map[int]int)
+	var components []KitComponent
+	for _, c := range k.Components {
+		if c.PartID <= 0 || c.Quantity <= 0 {
+			return ErrKitComponents
+		}
+		if c.PartID == k.PartID {
+			return ErrKitComponent
+		}
+		if i, ok := merged[c.PartID]; ok {
+			components[i].Quantity += c.Quantity
+			continue
+		}
+		merged[c.PartID] = len(components)
+		components = append(components, KitComponent{PartID: c.PartID, Quantity: c.Quantity})
+	}
+	if len(components) == 0 {
+		return ErrKitComponents
+	}
+	k.Components = components
+	return nil
+}
+
+// GetKit возвращает состав комплекта. Возвращает sql.ErrNoRows, если запчасть не является комплектом.
+func GetKit(db *sql.DB, partID int) (Kit, error) {
+	kit := Kit{PartID: partID}
+	if err := db.QueryRow("SELECT pricing, discount FROM part_kits WHERE part_id = ?", partID).Scan(&kit.Pricing, &kit.Discount); err != nil {
+		return Kit{}, err
+	}
+	query := "SELECT c.part_id, c.quantity, COALESCE(p.name, ''), COALESCE(p.price, 0), COALESCE(p.quantity, 0), p.slug, " +
+		"p.part_id IS NULL OR p.deleted_at IS NOT NULL, COALESCE(p.subcategory_id, 0) FROM part_kit_components c " +
+		"LEFT JOIN parts p ON p.part_id = c.part_id WHERE c.kit_id = ? ORDER BY c.part_id"
+	rows, err := db.Query(query, partID)
+	if err != nil {
+		return Kit{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c KitComponent
+		if err := rows.Scan(&c.PartID, &c.Quantity, &c.Name, &c.Price, &c.Stock, &c.Slug, &c.Deleted, &c.SubcategoryID); err != nil {
+			return Kit{}, err
+		}
+		kit.Components = append(kit.Components, c)
+	}
+	return kit, rows.Err()
+}
+
+// SetKit делает запчасть комплектом или заменяет состав существующего комплекта,
+// после чего пересчитывает цену и количество комплекта.
+// Возвращает sql.ErrNoRows, если запчасти нет или она в корзине.
+func SetKit(db *sql.DB, kit Kit) error {
+	if err := kit.Validate(); err != nil {
+		return err
+	}
+	log.Printf("[INFO] SetKit: комплект %d, компонентов %d, цена %s", kit.PartID, len(kit.Components), kit.Pricing)
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists, nested int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM parts WHERE part_id = ? AND deleted_at IS NULL", kit.PartID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return sql.ErrNoRows
+	}
+	if err := tx.QueryRow("SELECT COUNT(*) FROM part_kit_components WHERE part_id = ?", kit.PartID).Scan(&nested); err != nil {
+		return err
+	}
+	if nested > 0 {
+		return ErrKitNested
+	}
+
+	ids := make([]interface{}, len(kit.Components))
+	for i, c := range kit.Components {
+		ids[i] = c.PartID
+	}
+	in := placeholders(len(ids))
+	var valid int
+	query := "SELECT COUNT(*) FROM parts WHERE part_id IN (" + in + ") AND deleted_at IS NULL " +
+		"AND part_id NOT IN (SELECT part_id FROM part_kits)"
+	if err := tx.QueryRow(query, ids...).Scan(&valid); err != nil {
+		return err
+	}
+	if valid != len(ids) {
+		return ErrKitComponent
+	}
+
+	query = "INSERT INTO part_kits (part_id, pricing, discount, update_at) VALUES (?, ?, ?, NOW()) " +
+		"ON DUPLICATE KEY UPDATE pricing = VALUES(pricing), discount = VALUES(discount), update_at = NOW()"
+	if _, err := tx.Exec(query, kit.PartID, kit.Pricing, kit.Discount); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM part_kit_components WHERE kit_id = ?", kit.PartID); err != nil {
+		return err
+	}
+	for _, c := range kit.Components {
+		if _, err := tx.Exec("INSERT INTO part_kit_components (kit_id, part_id, quantity) VALUES (?, ?, ?)", kit.PartID, c.PartID, c.Quantity); err != nil {
+			return err
+		}
+	}
+	if err := refreshKits(tx, "c.kit_id = ?", kit.PartID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	InvalidateCatalogTree()
+	return nil
+}
+
+// DeleteKit превращает комплект в обычную запчасть с последней рассчитанной ценой;
+// количество снова складывается из остатков самой запчасти по складам.
+func DeleteKit(db *sql.DB, partID int) (int64, error) {
+	log.Printf("[INFO] DeleteKit: удаление состава комплекта %d", partID)
+	n, err := rowsAffected(db.Exec("DELETE FROM part_kits WHERE part_id = ?", partID))
+	if err != nil || n == 0 {
+		return n, err
+	}
+	return n, syncPartQuantity(db, partID)
+}
+
+// refreshKits пересчитывает количество и цену комплектов, выбранных условием cond по c.kit_id.
+// Количество – сколько полных комплектов можно собрать из остатков компонентов;
+// компонент в корзине или удалённый делает комплект недоступным.
+// Изменения цены комплектов записываются в историю цен с источником PriceSourceKit.
+func refreshKits(db dbExecutor, cond string, args ...interface{}) error {
+	kits := "parts p JOIN part_kits k ON k.part_id = p.part_id JOIN (" +
+		"SELECT c.kit_id, MIN(FLOOR(IF(cp.deleted_at IS NULL, GREATEST(COALESCE(cp.quantity, 0), 0), 0) / c.quantity)) AS qty, " +
+		"SUM(COALESCE(cp.price, 0) * c.quantity) AS total " +
+		"FROM part_kit_components c LEFT JOIN parts cp ON cp.part_id = c.part_id WHERE " + cond + " GROUP BY c.kit_id" +
+		") t ON t.kit_id = p.part_id"
+	const price = "ROUND(t.total * (100 - k.discount) / 100, 2)"
+	query := "INSERT INTO part_price_history (part_id, old_price, new_price, source, create_at) " +
+		"SELECT p.part_id, p.price, " + price + ", '" + PriceSourceKit + "', NOW() FROM " + kits +
+		" WHERE k.pricing = '" + KitPricingComponents + "' AND p.price <> " + price
+	if _, err := db.Exec(query, args...); err != nil {
+		return err
+	}
+	query = "UPDATE " + kits + " SET p.quantity = t.qty, p.price = IF(k.pricing = '" + KitPricingComponents + "', " + price + ", p.price)"
+	_, err := db.Exec(query, args...)
+	return err
+}
+
+// RefreshKitsFor пересчитывает комплекты, в которые входят указанные запчасти,
+// а также сами эти запчасти, если они комплекты. Вызывается после изменения цены или остатка.
+func RefreshKitsFor(db dbExecutor, partIDs ...int) error {
+	if len(partIDs) == 0 {
+		return nil
+	}
+	ids := make([]interface{}, len(partIDs))
+	for i, id := range partIDs {
+		ids[i] = id
+	}
+	in := placeholders(len(ids))
+	cond := "(c.kit_id IN (" + in + ") OR c.kit_id IN (SELECT kit_id FROM part_kit_components WHERE part_id IN (" + in + ")))"
+	return refreshKits(db, cond, append(ids, ids...)...)
+}
+
+// RefreshAllKits пересчитывает все комплекты; используется после массовых изменений
+// (перемещения раздела каталога в корзину, восстановления, очистки корзины).
+func RefreshAllKits(db dbExecutor) error {
+	return refreshKits(db, "1 = 1")
+}
+
+// SellPart списывает quantity единиц запчасти со склада warehouseID; если склад не задан (0),
+// товар списывается по складам по порядку, начиная со склада по умолчанию.
+// Для комплекта списываются компоненты в количестве, указанном в составе, и пересчитываются
+// все затронутые комплекты. Списания записываются в журнал движений как продажи с основанием src.
+// Возвращает sql.ErrNoRows, если нет запчасти или склада,
+// и ErrInsufficientStock, если товара не хватает.
+func SellPart(db *sql.DB, partID, quantity, warehouseID int, src MovementSource) (SaleResult, error) {
+	if quantity <= 0 {
+		return SaleResult{}, ErrSaleQuantity
+	}
+	if err := src.validate(); err != nil {
+		return SaleResult{}, err
+	}
+	log.Printf("[INFO] SellPart: продажа запчасти %d, количество %d, склад %d", partID, quantity, warehouseID)
+	tx, err := db.Begin()
+	if err != nil {
+		return SaleResult{}, err
+	}
+	defer tx.Rollback()
+
+	var isKit int
+	query := "SELECT EXISTS(SELECT 1 FROM part_kits k WHERE k.part_id = p.part_id) FROM parts p " +
+		"WHERE p.part_id = ? AND p.deleted_at IS NULL FOR UPDATE"
+	if err := tx.QueryRow(query, partID).Scan(&isKit); err != nil {
+		return SaleResult{}, err
+	}
+	if warehouseID > 0 {
+		var exists int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM warehouses WHERE warehouse_id = ? LOCK IN SHARE MODE", warehouseID).Scan(&exists); err != nil {
+			return SaleResult{}, err
+		}
+		if exists == 0 {
+			return SaleResult{}, sql.ErrNoRows
+		}
+	}
+	result := SaleResult{PartID: partID, Quantity: quantity, IsKit: isKit == 1}
+
+	// Для обычной запчасти списывается она сама, для комплекта – его компоненты.
+	needs := []StockChange{{PartID: partID, Quantity: quantity}}
+	if result.IsKit {
+		query := "SELECT c.part_id, c.quantity * ?, p.part_id IS NULL OR p.deleted_at IS NOT NULL " +
+			"FROM part_kit_components c LEFT JOIN parts p ON p.part_id = c.part_id WHERE c.kit_id = ? ORDER BY c.part_id FOR UPDATE"
+		rows, err := tx.Query(query, quantity, partID)
+		if err != nil {
+			return SaleResult{}, err
+		}
+		needs = needs[:0]
+		for rows.Next() {
+			var change StockChange
+			var deleted bool
+			if err := rows.Scan(&change.PartID, &change.Quantity, &deleted); err != nil {
+				rows.Close()
+				return SaleResult{}, err
+			}
+			if deleted {
+				rows.Close()
+				return SaleResult{}, ErrInsufficientStock
+			}
+			needs = append(needs, change)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return SaleResult{}, err
+		}
+	}
+
+	changed := make([]int, 0, len(needs))
+	for _, need := range needs {
+		changes, err := deductStock(tx, need.PartID, need.Quantity, warehouseID, src)
+		if err != nil {
+			return SaleResult{}, err
+		}
+		result.Deducted = append(result.Deducted, changes...)
+		changed = append(changed, need.PartID)
+	}
+	if err := syncPartQuantity(tx, changed...); err != nil {
+		return SaleResult{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return SaleResult{}, err
+	}
+	InvalidateCatalogTree()
+	log.Printf("[INFO] SellPart: запчасть %d продана, списания: %+v", partID, result.Deducted)
+	return result, nil
+}
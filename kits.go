@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"AutoM/config"
+	"AutoM/models"
+)
+
+// GetPartKit – состав комплекта с ценами и остатками компонентов (API).
+func GetPartKit(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "GetPartKit")
+	if !ok {
+		return
+	}
+	kit, err := models.GetKit(config.DB, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Запчасть не является комплектом", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] GetPartKit: ошибка получения комплекта %d: %v", id, err)
+		http.Error(w, "Ошибка получения комплекта", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetPartKit", kit)
+}
+
+// SetPartKit – задание состава комплекта (API). Запчасть {id} становится комплектом.
+// Ожидается JSON {"pricing": "components", "discount": 10,
+// "components": [{"part_id": 5, "quantity": 1}, {"part_id": 8, "quantity": 2}]}.
+func SetPartKit(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "SetPartKit")
+	if !ok {
+		return
+	}
+	var kit models.Kit
+	if !decodeJSON(w, r, "SetPartKit", &kit) {
+		return
+	}
+	kit.PartID = id
+	err := models.SetKit(config.DB, kit)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "Запчасть не найдена", http.StatusNotFound)
+	case errors.Is(err, models.ErrKitPricing), errors.Is(err, models.ErrKitDiscount),
+		errors.Is(err, models.ErrKitComponents), errors.Is(err, models.ErrKitComponent):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, models.ErrKitNested):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case err != nil:
+		log.Printf("[ERROR] SetPartKit: ошибка сохранения комплекта %d: %v", id, err)
+		http.Error(w, "Ошибка сохранения комплекта", http.StatusInternalServerError)
+	default:
+		log.Printf("[INFO] SetPartKit: состав комплекта %d сохранён", id)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// DeletePartKit – удаление состава комплекта (API): запчасть становится обычной.
+func DeletePartKit(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "DeletePartKit")
+	if !ok {
+		return
+	}
+	rowsAffected, err := models.DeleteKit(config.DB, id)
+	if err != nil {
+		log.Printf("[ERROR] DeletePartKit: ошибка удаления комплекта %d: %v", id, err)
+		http.Error(w, "Ошибка удаления комплекта", http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected == 0 {
+		http.Error(w, "Комплект не найден", http.StatusNotFound)
+		return
+	}
+	log.Printf("[INFO] DeletePartKit: запчасть %d больше не является комплектом", id)
+	w.WriteHeader(http.StatusOK)
+}
+
+// SellPart – продажа запчасти или комплекта со списанием остатков (API).
+// Ожидается JSON {"quantity": 2, "warehouse_id": 3, "document": "Чек 1547"}; без склада товар
+// списывается по складам по порядку. Для комплекта списываются его компоненты.
+func SellPart(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "SellPart")
+	if !ok {
+		return
+	}
+	var req struct {
+		Quantity    int    `json:"quantity"`
+		WarehouseID int    `json:"warehouse_id"`
+		Document    string `json:"document"`
+	}
+	if !decodeJSON(w, r, "SellPart", &req) {
+		return
+	}
+	src := models.MovementSource{Username: sessionUsername(r), Document: req.Document}
+	result, err := models.SellPart(config.DB, id, req.Quantity, req.WarehouseID, src)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "Запчасть или склад не найдены", http.StatusNotFound)
+	case errors.Is(err, models.ErrSaleQuantity), errors.Is(err, models.ErrMovementSource):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, models.ErrInsufficientStock):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case err != nil:
+		log.Printf("[ERROR] SellPart: ошибка продажи запчасти %d: %v", id, err)
+		http.Error(w, "Ошибка продажи", http.StatusInternalServerError)
+	default:
+		writeJSON(w, "SellPart", result)
+	}
+}
@@ -2,16 +2,92 @@ package main
 
 import (
 	"AutoM/config"
+	"AutoM/models"
 	"AutoM/routes"
 	"log"
 	"net/http"
+	"os"
+	"time"
 )
 
+// trashPurgeInterval – как часто проверяется корзина на записи с истёкшим сроком хранения.
+const trashPurgeInterval = time.Hour
+
+// purgeTrashPeriodically безвозвратно удаляет записи корзины старше срока хранения
+// при запуске и затем каждые trashPurgeInterval.
+func purgeTrashPeriodically() {
+	retention := config.TrashRetention()
+	log.Printf("Очистка корзины: срок хранения %s", retention)
+	for {
+		_, images, err := models.PurgeTrash(config.DB, retention)
+		if err != nil {
+			log.Printf("Ошибка очистки корзины: %v", err)
+		}
+		for _, img := range images {
+			models.RemovePartImageFiles(config.ImageDir(), img)
+		}
+		time.Sleep(trashPurgeInterval)
+	}
+}
+
+// watchPriceInbox загружает прайс-листы поставщиков из каталога PRICE_INBOX_DIR,
+// если он задан, при запуске и затем каждые PRICE_INBOX_INTERVAL.
+func watchPriceInbox() {
+	dir := config.PriceInboxDir()
+	if dir == "" {
+		return
+	}
+	interval := config.PriceInboxInterval()
+	log.Printf("Входящий каталог прайс-листов: %s, проверка каждые %s", dir, interval)
+	for {
+		if _, err := models.ProcessPriceInbox(config.DB, dir); err != nil {
+			log.Printf("Ошибка обработки входящего каталога прайс-листов: %v", err)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// loadRatesFile загружает курсы валют из файла RATES_FILE, если он задан,
+// и пересчитывает цены запчастей с базовой ценой в валюте.
+func loadRatesFile() {
+	path := config.RatesFile()
+	if path == "" {
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		log.Printf("Ошибка открытия файла курсов %s: %v", path, err)
+		return
+	}
+	defer f.Close()
+	n, err := models.ImportCurrencyRates(config.DB, f, "")
+	if err != nil {
+		log.Printf("Ошибка загрузки курсов из %s: %v", path, err)
+		return
+	}
+	log.Printf("Загружено курсов валют из %s: %d", path, n)
+}
+
 func main() {
 	config.InitStore()
 	config.InitDB()
 	defer config.CloseDB()
 
+	if err := models.Migrate(config.DB); err != nil {
+		log.Fatalf("Ошибка обновления схемы базы данных: %v", err)
+	}
+	if err := models.SyncPartsSearchIndex(config.DB); err != nil {
+		log.Printf("Ошибка синхронизации поискового индекса: %v", err)
+	}
+	if err := models.SyncSlugs(config.DB); err != nil {
+		log.Printf("Ошибка назначения слагов каталога: %v", err)
+	}
+
+	loadRatesFile()
+
+	go purgeTrashPeriodically()
+	go watchPriceInbox()
+
 	router := routes.RegisterRoutes()
 	log.Println("Сервер запущен на порту :8080")
 	if err := http.ListenAndServe(":8080", router); err != nil {
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Правила округления денежных сумм.
+const (
+	RoundHalfUp = "half_up" // до ближайшего шага, половина – от нуля
+	RoundUp     = "up"      // вверх (от нуля) до шага
+	RoundDown   = "down"    // вниз (к нулю) до шага
+)
+
+// decimalDigits – сколько знаков после запятой сохраняется при выводе Decimal (как в столбцах DECIMAL(18,6)).
+const decimalDigits = 6
+
+// Ошибки денежных величин.
+var (
+	ErrDecimal      = errors.New("неверное десятичное число")
+	ErrRoundingMode = errors.New("правило округления должно быть half_up, up или down")
+)
+
+// Decimal – точное десятичное число для денежных расчётов. В отличие от float64
+// не накапливает погрешность при умножении на курс и округлении.
+// Нулевое значение – ноль. Значения неизменяемы: операции возвращают новые числа.
+type Decimal struct {
+	r *big.Rat
+}
+
+// rat возвращает дробь числа; nil соответствует нулю.
+func (d Decimal) rat() *big.Rat {
+	if d.r == nil {
+		return new(big.Rat)
+	}
+	return d.r
+}
+
+// ParseDecimal разбирает десятичное число; допускается запятая как разделитель дробной части.
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), " ", "")
+	s = strings.Replace(s, ",", ".", 1)
+	if s == "" || strings.ContainsAny(s, "/eE") {
+		return Decimal{}, ErrDecimal
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Decimal{}, ErrDecimal
+	}
+	return Decimal{r}, nil
+}
+
+// DecimalFromFloat переводит float64 в Decimal по кратчайшему десятичному представлению:
+// 0.1 становится ровно 0.1, а не 0.1000000000000000055….
+func DecimalFromFloat(f float64) Decimal {
+	d, _ := ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+	return d
+}
+
+// Sign возвращает -1, 0 или 1 в зависимости от знака числа.
+func (d Decimal) Sign() int {
+	return d.rat().Sign()
+}
+
+// Cmp сравнивает числа: -1, если d < o; 0, если равны; 1, если d > o.
+func (d Decimal) Cmp(o Decimal) int {
+	return d.rat().Cmp(o.rat())
+}
+
+// Add возвращает сумму d + o.
+func (d Decimal) Add(o Decimal) Decimal {
+	return Decimal{new(big.Rat).Add(d.rat(), o.rat())}
+}
+
+// Mul возвращает произведение d * o.
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{new(big.Rat).Mul(d.rat(), o.rat())}
+}
+
+// Quo возвращает частное d / o; o не должно быть нулём.
+func (d Decimal) Quo(o Decimal) Decimal {
+	return Decimal{new(big.Rat).Quo(d.rat(), o.rat())}
+}
+
+// Round округляет число до кратного step по правилу mode (RoundHalfUp, RoundUp, RoundDown).
+// Нулевой step означает округление до decimalDigits знаков.
+func (d Decimal) Round(step Decimal, mode string) Decimal {
+	if step.Sign() <= 0 {
+		step = Decimal{big.NewRat(1, 1_000_000)}
+	}
+	q := new(big.Rat).Quo(d.rat(), step.rat())
+	n, rem := new(big.Int).QuoRem(q.Num(), q.Denom(), new(big.Int))
+	if rem.Sign() != 0 {
+		away := false
+		switch mode {
+		case RoundUp:
+			away = true
+		case RoundDown:
+		default:
+			// |rem| * 2 >= denom – половина и больше округляется от нуля.
+			twice := new(big.Int).Abs(rem)
+			away = twice.Lsh(twice, 1).Cmp(q.Denom()) >= 0
+		}
+		if away {
+			n.Add(n, big.NewInt(int64(q.Num().Sign())))
+		}
+	}
+	return Decimal{new(big.Rat).Mul(new(big.Rat).SetInt(n), step.rat())}
+}
+
+// String возвращает число без лишних нулей в дробной части, например "98.45".
+func (d Decimal) String() string {
+	s := d.rat().FloatString(decimalDigits)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// Float64 возвращает ближайшее к числу значение float64 (для полей вроде Part.Price).
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// MarshalJSON выводит число как JSON-число с точной десятичной записью.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON принимает как JSON-число, так и строку ("98,45").
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrDecimal, s)
+	}
+	*d = parsed
+	return nil
+}
+
+// Scan читает значение столбца DECIMAL.
+func (d *Decimal) Scan(src interface{}) error {
+	var err error
+	switch v := src.(type) {
+	case []byte:
+		*d, err = ParseDecimal(string(v))
+	case string:
+		*d, err = ParseDecimal(v)
+	case float64:
+		*d = DecimalFromFloat(v)
+	case int64:
+		*d = Decimal{new(big.Rat).SetInt64(v)}
+	case nil:
+		*d = Decimal{}
+	default:
+		err = fmt.Errorf("%w: неподдерживаемый тип %T", ErrDecimal, src)
+	}
+	return err
+}
+
+// Value передаёт число в базу строкой, чтобы MySQL сохранил его без преобразования через float.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// ValidRoundingMode сообщает, поддерживается ли правило округления.
+func ValidRoundingMode(mode string) bool {
+	return mode == RoundHalfUp || mode == RoundUp || mode == RoundDown
+}
@@ -0,0 +1,61 @@
+package models
+
+import "testing"
+
+func TestDecimalRound(t *testing.T) {
+	tests := []struct {
+		value, step, mode, want string
+	}{
+		{"98.445", "0.01", RoundHalfUp, "98.45"},
+		{"98.444", "0.01", RoundHalfUp, "98.44"},
+		{"-98.445", "0.01", RoundHalfUp, "-98.45"},
+		{"98.441", "0.01", RoundUp, "98.45"},
+		{"-98.441", "0.01", RoundUp, "-98.45"},
+		{"98.449", "0.01", RoundDown, "98.44"},
+		{"-98.449", "0.01", RoundDown, "-98.44"},
+		{"1234", "10", RoundHalfUp, "1230"},
+		{"1235", "10", RoundHalfUp, "1240"},
+		{"1231", "50", RoundUp, "1250"},
+		{"1249", "50", RoundDown, "1200"},
+		{"1250", "50", RoundUp, "1250"},
+		{"0.1234567", "0", RoundHalfUp, "0.123457"},
+	}
+	for _, tt := range tests {
+		value, err := ParseDecimal(tt.value)
+		if err != nil {
+			t.Fatalf("ParseDecimal(%q): %v", tt.value, err)
+		}
+		step, err := ParseDecimal(tt.step)
+		if err != nil {
+			t.Fatalf("ParseDecimal(%q): %v", tt.step, err)
+		}
+		if got := value.Round(step, tt.mode).String(); got != tt.want {
+			t.Errorf("%s.Round(%s, %s) = %s, ожидалось %s", tt.value, tt.step, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in, want string
+		ok       bool
+	}{
+		{"98,45", "98.45", true},
+		{" 1 250.50 ", "1250.5", true},
+		{"-0.10", "-0.1", true},
+		{"", "", false},
+		{"1/3", "", false},
+		{"1e3", "", false},
+		{"abc", "", false},
+	}
+	for _, tt := range tests {
+		d, err := ParseDecimal(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseDecimal(%q): ошибка %v", tt.in, err)
+			continue
+		}
+		if tt.ok && d.String() != tt.want {
+			t.Errorf("ParseDecimal(%q) = %s, ожидалось %s", tt.in, d, tt.want)
+		}
+	}
+}
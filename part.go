@@ -3,52 +3,275 @@ package controllers
 import (
 	"AutoM/config"
 	"AutoM/models"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
 
-// GetAllParts – получение всех запчастей (API, JSON-вывод)
+// queryInt разбирает необязательный целочисленный GET-параметр.
+// Если параметр не задан, возвращается 0.
+func queryInt(r *http.Request, name string) (int, error) {
+	val := strings.TrimSpace(r.URL.Query().Get(name))
+	if val == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("неверное значение параметра %s", name)
+	}
+	return n, nil
+}
+
+// queryFloat разбирает необязательный GET-параметр с плавающей точкой.
+// Если параметр не задан, возвращается nil.
+func queryFloat(r *http.Request, name string) (*float64, error) {
+	val := strings.TrimSpace(r.URL.Query().Get(name))
+	if val == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(strings.Replace(val, ",", ".", 1), 64)
+	if err != nil || f < 0 {
+		return nil, fmt.Errorf("неверное значение параметра %s", name)
+	}
+	return &f, nil
+}
+
+// parseVehicleSelection собирает выбранный автомобиль из GET-параметров
+// make_id, model_id, generation_id, engine_id и year (модельный год).
+func parseVehicleSelection(r *http.Request) (models.VehicleSelection, error) {
+	var v models.VehicleSelection
+	var err error
+	if v.MakeID, err = queryInt(r, "make_id"); err != nil {
+		return v, err
+	}
+	if v.ModelID, err = queryInt(r, "model_id"); err != nil {
+		return v, err
+	}
+	if v.GenerationID, err = queryInt(r, "generation_id"); err != nil {
+		return v, err
+	}
+	if v.EngineID, err = queryInt(r, "engine_id"); err != nil {
+		return v, err
+	}
+	v.Year, err = queryInt(r, "year")
+	return v, err
+}
+
+// parsePartFilter собирает фильтр списка запчастей из GET-параметров:
+// subcategory_id, category_id, group_id, brand_id, original, min_price, max_price, in_stock,
+// make_id, model_id, generation_id, engine_id,
+// sort (price|name|created), order (asc|desc), limit, offset и фильтры по характеристикам attr.*.
+func parsePartFilter(r *http.Request) (models.PartFilter, error) {
+	var f models.PartFilter
+	var err error
+	q := r.URL.Query()
+
+	for name, dst := range map[string]*int{
+		"subcategory_id": &f.SubcategoryID,
+		"category_id":    &f.CategoryID,
+		"group_id":       &f.GroupID,
+		"brand_id":       &f.BrandID,
+		"limit":          &f.Limit,
+		"offset":         &f.Offset,
+	} {
+		if *dst, err = queryInt(r, name); err != nil {
+			return f, err
+		}
+	}
+	if f.Vehicle, err = parseVehicleSelection(r); err != nil {
+		return f, err
+	}
+	if f.MinPrice, err = queryFloat(r, "min_price"); err != nil {
+		return f, err
+	}
+	if f.MaxPrice, err = queryFloat(r, "max_price"); err != nil {
+		return f, err
+	}
+	if val := q.Get("in_stock"); val != "" {
+		if f.InStock, err = strconv.ParseBool(val); err != nil {
+			return f, fmt.Errorf("неверное значение параметра in_stock")
+		}
+	}
+	if val := q.Get("original"); val != "" {
+		if f.OriginalOnly, err = strconv.ParseBool(val); err != nil {
+			return f, fmt.Errorf("неверное значение параметра original")
+		}
+	}
+	if f.Sort = q.Get("sort"); f.Sort != "" && !models.ValidSortField(f.Sort) {
+		return f, fmt.Errorf("сортировка возможна только по полям price, name и created")
+	}
+	switch strings.ToLower(q.Get("order")) {
+	case "", "asc":
+	case "desc":
+		f.Desc = true
+	default:
+		return f, fmt.Errorf("параметр order должен быть asc или desc")
+	}
+	f.Attributes, err = parseAttributeFilters(r)
+	return f, err
+}
+
+// parseAttributeFilters собирает фильтры по характеристикам из параметров вида
+// attr.<code>=<значение> (параметр можно повторять для выбора нескольких значений),
+// attr.<code>.min=<число> и attr.<code>.max=<число>.
+func parseAttributeFilters(r *http.Request) ([]models.AttributeFilter, error) {
+	byCode := map[string]*models.AttributeFilter{}
+	var codes []string
+	get := func(code string) *models.AttributeFilter {
+		if af, ok := byCode[code]; ok {
+			return af
+		}
+		codes = append(codes, code)
+		byCode[code] = &models.AttributeFilter{Code: code}
+		return byCode[code]
+	}
+
+	for name, values := range r.URL.Query() {
+		key, ok := strings.CutPrefix(name, "attr.")
+		if !ok || key == "" {
+			continue
+		}
+		var err error
+		switch {
+		case strings.HasSuffix(key, ".min"):
+			af := get(strings.TrimSuffix(key, ".min"))
+			af.Min, err = queryFloat(r, name)
+		case strings.HasSuffix(key, ".max"):
+			af := get(strings.TrimSuffix(key, ".max"))
+			af.Max, err = queryFloat(r, name)
+		default:
+			af := get(key)
+			for _, v := range values {
+				if v = strings.TrimSpace(v); v != "" {
+					af.Values = append(af.Values, v)
+				}
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(codes)
+	filters := make([]models.AttributeFilter, 0, len(codes))
+	for _, code := range codes {
+		filters = append(filters, *byCode[code])
+	}
+	return filters, nil
+}
+
+// isPartFilterError сообщает, что фильтр списка запчастей отклонён из-за неверных параметров.
+func isPartFilterError(err error) bool {
+	return errors.Is(err, models.ErrUnknownAttribute) || errors.Is(err, models.ErrAttributeFilterNeed) ||
+		errors.Is(err, models.ErrAttributeValue)
+}
+
+// GetAllParts – получение списка запчастей с фильтрацией, сортировкой и пагинацией (API, JSON-вывод).
+// Ответ содержит страницу записей (items) и общее количество подходящих запчастей (total),
+// а при фильтре по подкатегории – сводку по её характеристикам (facets).
+// Параметр currency задаёт валюту цен; min_price и max_price тогда указываются в ней же.
+// Цены, фильтр и сортировка по цене учитывают группу цен вошедшего покупателя.
 func GetAllParts(w http.ResponseWriter, r *http.Request) {
 	log.Printf("[INFO] Начало запроса GetAllParts")
-	// Обновляем запрос: выбираем part_id как id и subcategory_id вместо category_id
-	query := "SELECT part_id AS id, name, description, price, image_url, subcategory_id, quantity, create_at, update_at FROM parts"
-	rows, err := config.DB.Query(query)
+	filter, err := parsePartFilter(r)
+	if err != nil {
+		log.Printf("[ERROR] GetAllParts: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	currency, converter, ok := apiCurrency(w, r, "GetAllParts")
+	if !ok {
+		return
+	}
+	priceBoundsToBase(converter, currency, &filter)
+	pricing := sessionPricing(r)
+	if pricing != nil {
+		filter.PriceGroupID = pricing.GroupID
+	}
+
+	page, err := models.ListParts(config.DB, filter)
+	if isPartFilterError(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
-		log.Printf("[ERROR] GetAllParts: ошибка запроса: %v", err)
+		log.Printf("[ERROR] GetAllParts: ошибка загрузки запчастей: %v", err)
 		http.Error(w, "Ошибка загрузки запчастей", http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
+	pricing.ApplyTo(page.Items)
+	convertPartItems(converter, currency, page.Items)
+	// Без остатков по складам список всё равно отдаётся: общий остаток есть в Quantity.
+	models.LoadStock(config.DB, page.Items)
+	log.Printf("[INFO] GetAllParts: успешно получено %d записей из %d", len(page.Items), page.Total)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(page); err != nil {
+		log.Printf("[ERROR] GetAllParts: ошибка кодирования JSON: %v", err)
+	}
+}
 
-	var parts []models.Part
-	i := 0
-	for rows.Next() {
-		i++
-		var part models.Part
-		if err := rows.Scan(&part.ID, &part.Name, &part.Description, &part.Price, &part.ImageURL, &part.SubcategoryID, &part.Quantity, &part.CreatedAt, &part.UpdatedAt); err != nil {
-			log.Printf("[ERROR] GetAllParts: ошибка сканирования строки %d: %v", i, err)
-			http.Error(w, "Ошибка обработки данных", http.StatusInternalServerError)
+// SearchParts – полнотекстовый поиск запчастей по названию и описанию (API).
+// Параметры: q – строка запроса (фразы можно брать в двойные кавычки),
+// limit – максимальное количество результатов (по умолчанию 50, не более 200),
+// currency – валюта цен.
+func SearchParts(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[INFO] Начало запроса SearchParts")
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		http.Error(w, "Не задан поисковый запрос", http.StatusBadRequest)
+		return
+	}
+	currency, converter, ok := apiCurrency(w, r, "SearchParts")
+	if !ok {
+		return
+	}
+	limit := 50
+	if val := r.URL.Query().Get("limit"); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n <= 0 {
+			log.Printf("[ERROR] SearchParts: неверное значение limit: %s", val)
+			http.Error(w, "Неверное значение limit", http.StatusBadRequest)
 			return
 		}
-		parts = append(parts, part)
+		limit = min(n, 200)
 	}
-	if err := rows.Err(); err != nil {
-		log.Printf("[ERROR] GetAllParts: ошибка итерации по строкам: %v", err)
-		http.Error(w, "Ошибка обработки данных", http.StatusInternalServerError)
+
+	results, err := models.SearchParts(config.DB, q, limit)
+	if err != nil {
+		log.Printf("[ERROR] SearchParts: ошибка поиска: %v", err)
+		http.Error(w, "Ошибка поиска запчастей", http.StatusInternalServerError)
 		return
 	}
-	log.Printf("[INFO] GetAllParts: успешно получено %d записей", len(parts))
+	if results == nil {
+		results = []models.PartSearchResult{}
+	}
+	sessionPricing(r).ApplyToSearch(results)
+	if converter != nil {
+		for i := range results {
+			res := &results[i]
+			res.Price, _ = converter.FromBase(res.Price, currency)
+			if res.ListPrice != nil {
+				listPrice, _ := converter.FromBase(*res.ListPrice, currency)
+				res.ListPrice = &listPrice
+			}
+			res.Currency = currency
+		}
+	}
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(parts); err != nil {
-		log.Printf("[ERROR] GetAllParts: ошибка кодирования JSON: %v", err)
+	if err := json.NewEncoder(w).Encode(results); err != nil {
+		log.Printf("[ERROR] SearchParts: ошибка кодирования JSON: %v", err)
 	}
 }
 
-// GetPartByID – получение запчасти по ID (API)
+// GetPartByID – получение запчасти по ID (API); параметр currency задаёт валюту цены.
 func GetPartByID(w http.ResponseWriter, r *http.Request) {
 	log.Printf("[INFO] Начало запроса GetPartByID")
 	vars := mux.Vars(r)
@@ -58,15 +281,22 @@ func GetPartByID(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Неверный ID", http.StatusBadRequest)
 		return
 	}
-	// Используем part_id AS id и subcategory_id вместо category_id
-	query := "SELECT part_id AS id, name, description, price, image_url, subcategory_id, quantity, create_at, update_at FROM parts WHERE part_id = ?"
-	row := config.DB.QueryRow(query, id)
-	var part models.Part
-	if err := row.Scan(&part.ID, &part.Name, &part.Description, &part.Price, &part.ImageURL, &part.SubcategoryID, &part.Quantity, &part.CreatedAt, &part.UpdatedAt); err != nil {
+	currency, converter, ok := apiCurrency(w, r, "GetPartByID")
+	if !ok {
+		return
+	}
+	// Запчасть возвращается вместе с брендом
+	part, err := models.GetPartListItem(config.DB, id)
+	if err != nil {
 		log.Printf("[ERROR] GetPartByID: запчасть с ID %d не найдена: %v", id, err)
 		http.Error(w, "Запчасть не найдена", http.StatusNotFound)
 		return
 	}
+	sessionPricing(r).ApplyToItem(&part)
+	convertPartItem(converter, currency, &part)
+	if part.Stock, err = models.GetPartStock(config.DB, id); err != nil {
+		log.Printf("[ERROR] GetPartByID: ошибка получения остатков запчасти %d по складам: %v", id, err)
+	}
 	log.Printf("[INFO] GetPartByID: успешно получена запчасть с ID %d", id)
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(part); err != nil {
@@ -74,29 +304,70 @@ func GetPartByID(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// partInput – данные запчасти при добавлении и обновлении: поля запчасти, бренд
+// и необязательный список артикулов и OEM-номеров (учитывается только при добавлении).
+type partInput struct {
+	models.Part
+	Quantity *int                `json:"quantity"` // nil – остаток не меняется
+	BrandID  *int                `json:"brand_id"` // nil – бренд не меняется, 0 – снять бренд
+	Numbers  []models.PartNumber `json:"numbers"`
+}
+
+// quantity возвращает начальный остаток новой запчасти: 0, если количество не передано.
+func (p partInput) quantity() int {
+	if p.Quantity == nil {
+		return 0
+	}
+	return *p.Quantity
+}
+
 // AddPart – добавление новой запчасти (API)
 func AddPart(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
 	log.Printf("[INFO] Начало запроса AddPart")
-	var part models.Part
+	var part partInput
 	if err := json.NewDecoder(r.Body).Decode(&part); err != nil {
 		log.Printf("[ERROR] AddPart: ошибка декодирования данных: %v", err)
 		http.Error(w, "Неверный формат данных", http.StatusBadRequest)
 		return
 	}
-	// Запрос обновлён: subcategory_id вместо category_id, используются NOW() для временных меток
-	query := "INSERT INTO parts (name, description, price, image_url, subcategory_id, quantity, create_at, update_at) VALUES (?, ?, ?, ?, ?, ?, NOW(), NOW())"
-	_, err := config.DB.Exec(query, part.Name, part.Description, part.Price, part.ImageURL, part.SubcategoryID, part.Quantity)
+	for _, n := range part.Numbers {
+		if msg := validPartNumber(n); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+	}
+	// Запчасть, её остаток, начальная цена, слаг, изображение и номера создаются одной транзакцией:
+	// при ошибке любого шага запчасть не добавляется.
+	newPart := part.Part
+	newPart.Quantity = part.quantity()
+	var brandID int
+	if part.BrandID != nil {
+		brandID = *part.BrandID
+	}
+	src := models.PriceSource{Source: models.PriceSourceCreate, Username: sessionUsername(r)}
+	stockSrc := models.MovementSource{Reason: "Начальный остаток", Username: sessionUsername(r)}
+	id, err := models.CreatePart(config.DB, newPart, brandID, part.Numbers, src, stockSrc)
+	if errors.Is(err, models.ErrStockQuantity) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
-		log.Printf("[ERROR] AddPart: ошибка выполнения запроса: %v", err)
+		log.Printf("[ERROR] AddPart: ошибка добавления запчасти: %v", err)
 		http.Error(w, "Ошибка добавления запчасти", http.StatusInternalServerError)
 		return
 	}
-	log.Printf("[INFO] AddPart: запчасть успешно добавлена: %s", part.Name)
+	log.Printf("[INFO] AddPart: запчасть %d успешно добавлена: %s", id, part.Name)
 	w.WriteHeader(http.StatusCreated)
 }
 
 // UpdatePart – обновление запчасти (API)
 func UpdatePart(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
 	log.Printf("[INFO] Начало запроса UpdatePart")
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
@@ -106,31 +377,53 @@ func UpdatePart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var part models.Part
+	var part partInput
 	if err := json.NewDecoder(r.Body).Decode(&part); err != nil {
 		log.Printf("[ERROR] UpdatePart: ошибка декодирования данных: %v", err)
 		http.Error(w, "Неверный формат данных", http.StatusBadRequest)
 		return
 	}
-	// Обновляем запрос: subcategory_id вместо category_id; используется WHERE part_id = ?
-	query := "UPDATE parts SET subcategory_id = ?, name = ?, price = ?, quantity = ?, description = ? WHERE part_id = ?"
-	res, err := config.DB.Exec(query, part.SubcategoryID, part.Name, part.Price, part.Quantity, part.Description, id)
-	if err != nil {
-		log.Printf("[ERROR] UpdatePart: ошибка выполнения запроса: %v", err)
-		http.Error(w, "Ошибка обновления запчасти", http.StatusInternalServerError)
-		return
-	}
-	rowsAffected, err := res.RowsAffected()
-	if err != nil {
-		log.Printf("[ERROR] UpdatePart: ошибка получения количества затронутых строк: %v", err)
+	oldPath := models.PartPath(config.DB, id)
+	oldPrice, err := models.GetPartPrice(config.DB, id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("[ERROR] UpdatePart: ошибка получения текущей цены: %v", err)
 		http.Error(w, "Ошибка обновления запчасти", http.StatusInternalServerError)
 		return
 	}
-	if rowsAffected == 0 {
+	// Общий остаток раскладывается по складам в той же транзакции, что и изменение полей:
+	// если его нельзя получить, изменив склад по умолчанию, запчасть не меняется.
+	// Изменение количества записывается в журнал движений как корректировка; без поля
+	// quantity остатки не трогаются.
+	fields := models.PartFields{SubcategoryID: part.SubcategoryID, Name: part.Name, Description: part.Description,
+		Price: part.Price, BrandID: part.BrandID}
+	stockSrc := models.MovementSource{Reason: "Изменение количества в карточке запчасти", Username: sessionUsername(r)}
+	switch err := models.UpdatePartFields(config.DB, id, fields, part.Quantity, stockSrc); {
+	case errors.Is(err, sql.ErrNoRows):
 		log.Printf("[WARN] UpdatePart: запчасть с ID %d не найдена", id)
 		http.Error(w, "Запчасть не найдена", http.StatusNotFound)
 		return
+	case errors.Is(err, models.ErrStockQuantity):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, models.ErrStockTotal), errors.Is(err, models.ErrPriceBase):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		log.Printf("[ERROR] UpdatePart: ошибка обновления запчасти %d: %v", id, err)
+		http.Error(w, "Ошибка обновления запчасти", http.StatusInternalServerError)
+		return
 	}
+	models.IndexPart(config.DB, id, part.Name, part.Description)
+	models.UpdatePartSlug(config.DB, id, oldPath)
+	src := models.PriceSource{Source: models.PriceSourceAPI, Username: sessionUsername(r)}
+	if err := models.RecordPriceChange(config.DB, id, &oldPrice, part.Price, src); err != nil {
+		log.Printf("[ERROR] UpdatePart: ошибка записи истории цены запчасти %d: %v", id, err)
+	}
+	// Остаток комплектов (и цена, если она считается по компонентам) пересчитывается после изменения.
+	if err := models.RefreshKitsFor(config.DB, id); err != nil {
+		log.Printf("[ERROR] UpdatePart: ошибка пересчёта комплектов с запчастью %d: %v", id, err)
+	}
+	models.InvalidateCatalogTree()
 	log.Printf("[INFO] UpdatePart: запчасть с ID %d успешно обновлена", id)
 	w.WriteHeader(http.StatusOK)
 }
@@ -145,17 +438,15 @@ func DeletePart(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Неверный ID", http.StatusBadRequest)
 		return
 	}
-	// Обновляем запрос: удаляем по part_id вместо id
-	query := "DELETE FROM parts WHERE part_id = ?"
-	res, err := config.DB.Exec(query, id)
-	if err != nil {
-		log.Printf("[ERROR] DeletePart: ошибка выполнения запроса: %v", err)
-		http.Error(w, "Ошибка удаления запчасти", http.StatusInternalServerError)
+	// Запчасть перемещается в корзину; безвозвратно её удалит очистка корзины.
+	rowsAffected, err := models.SoftDeletePart(config.DB, id)
+	if errors.Is(err, models.ErrPartDocuments) {
+		log.Printf("[WARN] DeletePart: запчасть %d не удалена: %v", id, err)
+		http.Error(w, err.Error(), http.StatusConflict)
 		return
 	}
-	rowsAffected, err := res.RowsAffected()
 	if err != nil {
-		log.Printf("[ERROR] DeletePart: ошибка получения количества затронутых строк: %v", err)
+		log.Printf("[ERROR] DeletePart: ошибка выполнения запроса: %v", err)
 		http.Error(w, "Ошибка удаления запчасти", http.StatusInternalServerError)
 		return
 	}
@@ -164,6 +455,6 @@ func DeletePart(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Запчасть не найдена", http.StatusNotFound)
 		return
 	}
-	log.Printf("[INFO] DeletePart: запчасть с ID %d успешно удалена", id)
+	log.Printf("[INFO] DeletePart: запчасть с ID %d перемещена в корзину", id)
 	w.WriteHeader(http.StatusOK)
 }
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"database/sql"
+	"log"
+	"strconv"
+)
+
+// CompatibleVehicle – автомобиль, к которому подходит запчасть, в виде для вывода покупателю.
+type CompatibleVehicle struct {
+	Make       string `json:"make"`
+	Model      string `json:"model"`
+	Generation string `json:"generation"`
+	YearFrom   *int   `json:"year_from"`
+	YearTo     *int   `json:"year_to"`
+	Engine     string `json:"engine"` // пусто – подходит ко всем двигателям поколения
+	Note       string `json:"note"`
+}
+
+// Years возвращает годы выпуска поколения, например "2017–2021" или "с 2018".
+func (v CompatibleVehicle) Years() string {
+	switch {
+	case v.YearFrom != nil && v.YearTo != nil:
+		return strconv.Itoa(*v.YearFrom) + "–" + strconv.Itoa(*v.YearTo)
+	case v.YearFrom != nil:
+		return "с " + strconv.Itoa(*v.YearFrom)
+	case v.YearTo != nil:
+		return "по " + strconv.Itoa(*v.YearTo)
+	}
+	return ""
+}
+
+// GetPartCompatibleVehicles возвращает автомобили, к которым подходит запчасть,
+// упорядоченные по марке, модели и году начала выпуска.
+func GetPartCompatibleVehicles(db *sql.DB, partID int) ([]CompatibleVehicle, error) {
+	query := "SELECT mk.name, md.name, g.name, g.year_from, g.year_to, COALESCE(e.name, ''), f.note " +
+		"FROM part_fitments f " +
+		"JOIN vehicle_generations g ON g.generation_id = f.generation_id " +
+		"JOIN vehicle_models md ON md.model_id = g.model_id " +
+		"JOIN vehicle_makes mk ON mk.make_id = md.make_id " +
+		"LEFT JOIN vehicle_engines e ON e.engine_id = f.engine_id " +
+		"WHERE f.part_id = ? ORDER BY mk.name, md.name, g.year_from, g.name, e.name"
+	rows, err := db.Query(query, partID)
+	if err != nil {
+		log.Printf("[ERROR] GetPartCompatibleVehicles: ошибка выполнения запроса: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []CompatibleVehicle
+	for rows.Next() {
+		var v CompatibleVehicle
+		if err := rows.Scan(&v.Make, &v.Model, &v.Generation, &v.YearFrom, &v.YearTo, &v.Engine, &v.Note); err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, rows.Err()
+}
+
+// GetPartAnalogs возвращает взаимозаменяемые запчасти: другие запчасти с теми же номерами
+// и запчасти, номера которых связаны с номерами этой запчасти через кросс-таблицу.
+func GetPartAnalogs(db *sql.DB, partID int) ([]PartListItem, error) {
+	numbers, err := GetPartNumbers(db, partID)
+	if err != nil {
+		return nil, err
+	}
+	if len(numbers) == 0 {
+		return nil, nil
+	}
+	normalized := make([]string, 0, len(numbers))
+	for _, n := range numbers {
+		normalized = append(normalized, n.Normalized)
+	}
+	analogs, err := crossAnalogs(db, normalized)
+	if err != nil {
+		return nil, err
+	}
+	normalized = append(normalized, analogs...)
+
+	query := partListSelect + " WHERE p.part_id <> ? AND p.deleted_at IS NULL AND p.part_id IN " +
+		"(SELECT part_id FROM part_numbers WHERE normalized IN (" + placeholders(len(normalized)) + ")) ORDER BY p.name"
+	args := append([]interface{}{partID}, stringArgs(normalized)...)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		log.Printf("[ERROR] GetPartAnalogs: ошибка выполнения запроса: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []PartListItem
+	for rows.Next() {
+		item, err := scanPartListItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,238 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+// Источники изображений запчастей.
+const (
+	ImageSourceUpload   = "upload"   // файл загружен через приложение и хранится локально
+	ImageSourceExternal = "external" // внешняя ссылка (ручной ввод или импорт из Excel)
+)
+
+// ErrImageOrder возвращается, если новый порядок изображений не совпадает с набором изображений запчасти.
+var ErrImageOrder = errors.New("порядок должен содержать все изображения запчасти")
+
+// PartImage описывает изображение запчасти (part_images).
+type PartImage struct {
+	ID          int               `json:"id"`                   // image_id – первичный ключ
+	PartID      int               `json:"part_id"`              // запчасть
+	Source      string            `json:"source"`               // upload или external
+	URL         string            `json:"url"`                  // адрес оригинала
+	StorageKey  string            `json:"-"`                    // имя файла в локальном хранилище (для upload)
+	ContentType string            `json:"content_type"`         // MIME-тип оригинала
+	Width       int               `json:"width"`                // размеры оригинала; 0 – неизвестны
+	Height      int               `json:"height"`               //
+	SortOrder   int               `json:"sort_order"`           // порядок в галерее
+	IsPrimary   bool              `json:"is_primary"`           // основное изображение
+	Thumbnails  map[string]string `json:"thumbnails,omitempty"` // адреса миниатюр по названию размера
+	CreateAt    time.Time         `json:"create_at"`            // время создания
+}
+
+// Thumb возвращает адрес миниатюры заданного размера;
+// для внешних изображений и неизвестных размеров – адрес оригинала.
+func (img PartImage) Thumb(size string) string {
+	if u, ok := img.Thumbnails[size]; ok {
+		return u
+	}
+	return img.URL
+}
+
+// migratePartImages создаёт таблицу изображений и переносит в неё
+// существующие ссылки из parts.image_url как внешние изображения.
+func migratePartImages(db *sql.DB) error {
+	return execAll(db,
+		"CREATE TABLE IF NOT EXISTS part_images ("+
+			"image_id INT AUTO_INCREMENT PRIMARY KEY, "+
+			"part_id INT NOT NULL, "+
+			"source VARCHAR(10) NOT NULL, "+
+			"url VARCHAR(1024) NOT NULL, "+
+			"storage_key VARCHAR(64) NOT NULL DEFAULT '', "+
+			"content_type VARCHAR(50) NOT NULL DEFAULT '', "+
+			"width INT NOT NULL DEFAULT 0, "+
+			"height INT NOT NULL DEFAULT 0, "+
+			"sort_order INT NOT NULL DEFAULT 0, "+
+			"is_primary TINYINT(1) NOT NULL DEFAULT 0, "+
+			"create_at DATETIME NOT NULL, "+
+			"KEY idx_image_part (part_id, sort_order)"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"INSERT INTO part_images (part_id, source, url, is_primary, create_at) "+
+			"SELECT p.part_id, '"+ImageSourceExternal+"', p.image_url, 1, NOW() FROM parts p "+
+			"WHERE p.image_url IS NOT NULL AND p.image_url <> '' "+
+			"AND NOT EXISTS (SELECT 1 FROM part_images i WHERE i.part_id = p.part_id)",
+	)
+}
+
+const partImageColumns = "image_id, part_id, source, url, storage_key, content_type, width, height, sort_order, is_primary, create_at"
+
+// scanPartImage считывает строку с полями partImageColumns и заполняет адреса миниатюр.
+func scanPartImage(row interface{ Scan(...interface{}) error }) (PartImage, error) {
+	var img PartImage
+	err := row.Scan(&img.ID, &img.PartID, &img.Source, &img.URL, &img.StorageKey, &img.ContentType,
+		&img.Width, &img.Height, &img.SortOrder, &img.IsPrimary, &img.CreateAt)
+	if err == nil && img.Source == ImageSourceUpload {
+		img.Thumbnails = thumbnailURLs(img.StorageKey)
+	}
+	return img, err
+}
+
+// GetPartImages возвращает изображения запчасти: основное первым, остальные по порядку.
+func GetPartImages(db *sql.DB, partID int) ([]PartImage, error) {
+	query := "SELECT " + partImageColumns + " FROM part_images WHERE part_id = ? ORDER BY is_primary DESC, sort_order, image_id"
+	rows, err := db.Query(query, partID)
+	if err != nil {
+		log.Printf("[ERROR] GetPartImages: ошибка выполнения запроса: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	images := []PartImage{}
+	for rows.Next() {
+		img, err := scanPartImage(rows)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, img)
+	}
+	return images, rows.Err()
+}
+
+// GetPartImage возвращает изображение запчасти по ID.
+func GetPartImage(db *sql.DB, partID, imageID int) (PartImage, error) {
+	query := "SELECT " + partImageColumns + " FROM part_images WHERE part_id = ? AND image_id = ?"
+	return scanPartImage(db.QueryRow(query, partID, imageID))
+}
+
+// AddPartImage добавляет изображение в конец галереи запчасти и возвращает его ID.
+// Первое изображение запчасти становится основным.
+func AddPartImage(db dbExecutor, img PartImage) (int, error) {
+	log.Printf("[INFO] AddPartImage: добавление изображения (%s) запчасти %d", img.Source, img.PartID)
+	var count, maxOrder int
+	err := db.QueryRow("SELECT COUNT(*), COALESCE(MAX(sort_order), 0) FROM part_images WHERE part_id = ?", img.PartID).
+		Scan(&count, &maxOrder)
+	if err != nil {
+		return 0, err
+	}
+	query := "INSERT INTO part_images (part_id, source, url, storage_key, content_type, width, height, sort_order, is_primary, create_at) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NOW())"
+	res, err := db.Exec(query, img.PartID, img.Source, img.URL, img.StorageKey, img.ContentType,
+		img.Width, img.Height, maxOrder+1, count == 0)
+	if err != nil {
+		log.Printf("[ERROR] AddPartImage: ошибка выполнения запроса: %v", err)
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if img.IsPrimary && count > 0 {
+		_, err = SetPrimaryPartImage(db, img.PartID, int(id))
+	} else if count == 0 {
+		err = syncPartImageURL(db, img.PartID)
+	}
+	return int(id), err
+}
+
+// EnsureExternalPartImage добавляет внешнюю ссылку в галерею запчасти, если такой ссылки там ещё нет.
+// Используется при создании запчасти и импорте из Excel, где изображение задаётся адресом.
+func EnsureExternalPartImage(db dbExecutor, partID int, url string) error {
+	if url == "" {
+		return nil
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM part_images WHERE part_id = ? AND url = ?", partID, url).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	_, err := AddPartImage(db, PartImage{PartID: partID, Source: ImageSourceExternal, URL: url})
+	return err
+}
+
+// SetPrimaryPartImage делает изображение основным.
+func SetPrimaryPartImage(db dbExecutor, partID, imageID int) (int64, error) {
+	log.Printf("[INFO] SetPrimaryPartImage: изображение %d запчасти %d", imageID, partID)
+	var exists int
+	err := db.QueryRow("SELECT COUNT(*) FROM part_images WHERE part_id = ? AND image_id = ?", partID, imageID).Scan(&exists)
+	if err != nil || exists == 0 {
+		return 0, err
+	}
+	if _, err := db.Exec("UPDATE part_images SET is_primary = (image_id = ?) WHERE part_id = ?", imageID, partID); err != nil {
+		return 0, err
+	}
+	return 1, syncPartImageURL(db, partID)
+}
+
+// ReorderPartImages задаёт порядок изображений галереи; ids должен содержать все изображения запчасти.
+func ReorderPartImages(db *sql.DB, partID int, ids []int) error {
+	log.Printf("[INFO] ReorderPartImages: новый порядок изображений запчасти %d: %v", partID, ids)
+	images, err := GetPartImages(db, partID)
+	if err != nil {
+		return err
+	}
+	known := make(map[int]bool, len(images))
+	for _, img := range images {
+		known[img.ID] = true
+	}
+	if len(ids) != len(images) {
+		return ErrImageOrder
+	}
+	for _, id := range ids {
+		if !known[id] {
+			return ErrImageOrder
+		}
+		delete(known, id) // повторы ID тоже ошибка
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for i, id := range ids {
+		if _, err := tx.Exec("UPDATE part_images SET sort_order = ? WHERE image_id = ?", i+1, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DeletePartImage удаляет изображение и возвращает удалённую запись, чтобы вызывающий
+// мог удалить файлы. Если удалено основное изображение, основным становится первое оставшееся.
+func DeletePartImage(db *sql.DB, partID, imageID int) (PartImage, error) {
+	log.Printf("[INFO] DeletePartImage: удаление изображения %d запчасти %d", imageID, partID)
+	img, err := GetPartImage(db, partID, imageID)
+	if err != nil {
+		return PartImage{}, err
+	}
+	if _, err := db.Exec("DELETE FROM part_images WHERE image_id = ?", imageID); err != nil {
+		return PartImage{}, err
+	}
+	if img.IsPrimary {
+		_, err = db.Exec("UPDATE part_images SET is_primary = 1 WHERE part_id = ? ORDER BY sort_order, image_id LIMIT 1", partID)
+		if err != nil {
+			return img, err
+		}
+	}
+	return img, syncPartImageURL(db, partID)
+}
+
+// syncPartImageURL записывает в parts.image_url адрес основного изображения
+// (для загруженных – средней миниатюры), чтобы списки запчастей показывали его без дополнительных запросов.
+func syncPartImageURL(db dbExecutor, partID int) error {
+	query := "SELECT " + partImageColumns + " FROM part_images WHERE part_id = ? ORDER BY is_primary DESC, sort_order, image_id LIMIT 1"
+	img, err := scanPartImage(db.QueryRow(query, partID))
+	var url interface{}
+	switch {
+	case err == nil:
+		url = img.Thumb("medium")
+	case err != sql.ErrNoRows:
+		return err
+	}
+	_, err = db.Exec("UPDATE parts SET image_url = ? WHERE part_id = ?", url, partID)
+	return err
+}
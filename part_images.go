@@ -0,0 +1,232 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"AutoM/config"
+	"AutoM/models"
+
+	"github.com/gorilla/mux"
+)
+
+// maxImagesPerUpload – сколько файлов можно загрузить одним запросом.
+const maxImagesPerUpload = 10
+
+// partImageCacheControl – загруженные файлы не меняются (имя – случайный ключ),
+// поэтому браузеру и прокси можно хранить их без повторной проверки.
+const partImageCacheControl = "public, max-age=31536000, immutable"
+
+// partExists проверяет наличие запчасти и отвечает 404/500, если её нет или запрос не удался.
+func partExists(w http.ResponseWriter, handler string, id int) bool {
+	if _, err := models.GetPartListItem(config.DB, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Запчасть не найдена", http.StatusNotFound)
+		} else {
+			log.Printf("[ERROR] %s: ошибка получения запчасти %d: %v", handler, id, err)
+			http.Error(w, "Ошибка получения запчасти", http.StatusInternalServerError)
+		}
+		return false
+	}
+	return true
+}
+
+// GetPartImages – галерея изображений запчасти (API): основное изображение первым.
+func GetPartImages(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "GetPartImages")
+	if !ok {
+		return
+	}
+	images, err := models.GetPartImages(config.DB, id)
+	if err != nil {
+		http.Error(w, "Ошибка загрузки изображений запчасти", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetPartImages", images)
+}
+
+// UploadPartImages – загрузка изображений запчасти (multipart/form-data).
+// Файлы передаются в поле image (можно несколько), внешняя ссылка – в поле url.
+// Поле primary делает первое добавленное изображение основным.
+// Тип файла определяется по содержимому; для каждого файла создаются миниатюры.
+func UploadPartImages(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "UploadPartImages")
+	if !ok || !partExists(w, "UploadPartImages", id) {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxImagesPerUpload*models.MaxImageBytes+1<<20)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		log.Printf("[ERROR] UploadPartImages: ошибка разбора формы: %v", err)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Слишком большой объём загрузки", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Неверный формат данных", http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	files := r.MultipartForm.File["image"]
+	url := strings.TrimSpace(r.FormValue("url"))
+	if len(files) == 0 && url == "" {
+		http.Error(w, "Не передано ни одного изображения", http.StatusBadRequest)
+		return
+	}
+	if len(files) > maxImagesPerUpload {
+		http.Error(w, "Слишком много файлов в одном запросе", http.StatusBadRequest)
+		return
+	}
+	if url != "" && !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		http.Error(w, "Ссылка на изображение должна начинаться с http:// или https://", http.StatusBadRequest)
+		return
+	}
+
+	// Сначала сохраняем и проверяем все файлы, чтобы ошибка в одном не оставила галерею наполовину обновлённой.
+	dir := config.ImageDir()
+	var stored []models.PartImage
+	cleanup := func() {
+		for _, img := range stored {
+			models.RemovePartImageFiles(dir, img)
+		}
+	}
+	for _, fh := range files {
+		f, err := fh.Open()
+		if err != nil {
+			cleanup()
+			http.Error(w, "Ошибка чтения файла", http.StatusBadRequest)
+			return
+		}
+		data, err := io.ReadAll(io.LimitReader(f, models.MaxImageBytes+1))
+		f.Close()
+		if err != nil {
+			cleanup()
+			http.Error(w, "Ошибка чтения файла", http.StatusBadRequest)
+			return
+		}
+		img, err := models.StorePartImage(dir, data)
+		if err != nil {
+			cleanup()
+			log.Printf("[WARN] UploadPartImages: файл %s отклонён: %v", fh.Filename, err)
+			switch {
+			case errors.Is(err, models.ErrImageTooLarge):
+				http.Error(w, fh.Filename+": "+err.Error(), http.StatusRequestEntityTooLarge)
+			case errors.Is(err, models.ErrImageType):
+				http.Error(w, fh.Filename+": "+err.Error(), http.StatusUnsupportedMediaType)
+			case errors.Is(err, models.ErrImageDecode):
+				http.Error(w, fh.Filename+": "+err.Error(), http.StatusBadRequest)
+			default:
+				http.Error(w, "Ошибка сохранения изображения", http.StatusInternalServerError)
+			}
+			return
+		}
+		stored = append(stored, img)
+	}
+	if url != "" {
+		stored = append(stored, models.PartImage{Source: models.ImageSourceExternal, URL: url})
+	}
+
+	primary := r.FormValue("primary") != ""
+	for i, img := range stored {
+		img.PartID, img.IsPrimary = id, primary && i == 0
+		if _, err := models.AddPartImage(config.DB, img); err != nil {
+			log.Printf("[ERROR] UploadPartImages: ошибка сохранения изображения запчасти %d: %v", id, err)
+			stored = stored[i:]
+			cleanup()
+			http.Error(w, "Ошибка сохранения изображения", http.StatusInternalServerError)
+			return
+		}
+	}
+	log.Printf("[INFO] UploadPartImages: запчасти %d добавлено изображений: %d", id, len(stored))
+
+	images, err := models.GetPartImages(config.DB, id)
+	if err != nil {
+		http.Error(w, "Ошибка загрузки изображений запчасти", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, "UploadPartImages", images)
+}
+
+// SetPrimaryPartImage – назначение основного изображения запчасти.
+func SetPrimaryPartImage(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "SetPrimaryPartImage")
+	if !ok {
+		return
+	}
+	imageID, ok := pathInt(w, r, "imageId", "SetPrimaryPartImage")
+	if !ok {
+		return
+	}
+	rowsAffected, err := models.SetPrimaryPartImage(config.DB, id, imageID)
+	respondAffected(w, "SetPrimaryPartImage", "Изображение не найдено", rowsAffected, err)
+}
+
+// ReorderPartImages – изменение порядка изображений галереи.
+// Ожидается JSON {"ids": [...]} со всеми изображениями запчасти в новом порядке.
+func ReorderPartImages(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "ReorderPartImages")
+	if !ok {
+		return
+	}
+	var req struct {
+		IDs []int `json:"ids"`
+	}
+	if !decodeJSON(w, r, "ReorderPartImages", &req) {
+		return
+	}
+	if err := models.ReorderPartImages(config.DB, id, req.IDs); err != nil {
+		if errors.Is(err, models.ErrImageOrder) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("[ERROR] ReorderPartImages: ошибка выполнения запроса: %v", err)
+		http.Error(w, "Ошибка сохранения данных", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeletePartImage – удаление изображения запчасти вместе с файлами.
+func DeletePartImage(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "DeletePartImage")
+	if !ok {
+		return
+	}
+	imageID, ok := pathInt(w, r, "imageId", "DeletePartImage")
+	if !ok {
+		return
+	}
+	img, err := models.DeletePartImage(config.DB, id, imageID)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Изображение не найдено", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] DeletePartImage: ошибка удаления изображения %d: %v", imageID, err)
+		http.Error(w, "Ошибка удаления изображения", http.StatusInternalServerError)
+		return
+	}
+	models.RemovePartImageFiles(config.ImageDir(), img)
+	models.InvalidateCatalogTree()
+	w.WriteHeader(http.StatusOK)
+}
+
+// ServePartImage отдаёт загруженное изображение или миниатюру с долгим кэшированием.
+func ServePartImage(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["file"]
+	if !models.ValidImageFileName(name) {
+		http.NotFound(w, r)
+		return
+	}
+	// Тип содержимого определит ServeFile по расширению.
+	w.Header().Del("Content-Type")
+	w.Header().Set("Cache-Control", partImageCacheControl)
+	http.ServeFile(w, r, filepath.Join(config.ImageDir(), name))
+}
@@ -0,0 +1,226 @@
+package models
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+)
+
+// Ограничения постраничной выдачи запчастей.
+const (
+	DefaultPartsLimit = 50
+	MaxPartsLimit     = 500
+)
+
+// partSortColumns сопоставляет допустимые значения параметра sort со столбцами таблицы parts.
+var partSortColumns = map[string]string{
+	"price":   "p.price",
+	"name":    "p.name",
+	"created": "p.create_at",
+}
+
+// PartFilter описывает фильтры, сортировку и пагинацию списка запчастей.
+// Нулевые значения означают отсутствие соответствующего фильтра.
+type PartFilter struct {
+	SubcategoryID int
+	CategoryID    int
+	GroupID       int
+	MinPrice      *float64
+	MaxPrice      *float64
+	InStock       bool
+	BrandID       int
+	OriginalOnly  bool              // только запчасти брендов с признаком is_original
+	Vehicle       VehicleSelection  // запчасти, применимые к выбранному автомобилю
+	Attributes    []AttributeFilter // условия по характеристикам подкатегории SubcategoryID
+	PriceGroupID  int               // MinPrice, MaxPrice и сортировка по цене – по ценам этой группы
+	Sort          string            // price, name или created
+	Desc          bool              // сортировка по убыванию
+	Limit         int
+	Offset        int
+}
+
+// PartListItem – запчасть в списке вместе с её брендом.
+type PartListItem struct {
+	Part
+	BrandID *int    `json:"brand_id"` // бренд запчасти (nil – не указан)
+	Brand   *string `json:"brand"`    // название бренда
+	Slug    *string `json:"slug"`     // слаг для адреса /part/{slug}
+	IsKit   bool    `json:"is_kit"`   // комплект: цена и количество рассчитываются по компонентам
+	// Currency – валюта поля Price: BaseCurrency или валюта, запрошенная параметром currency.
+	Currency string `json:"currency"`
+	// ListPrice – цена продажи, если для группы цен покупателя Price отличается от неё.
+	ListPrice *float64 `json:"list_price,omitempty"`
+	// Stock – остатки по складам; Quantity – их сумма.
+	Stock []PartStock `json:"stock,omitempty"`
+}
+
+// PartPage – страница списка запчастей с общим количеством подходящих записей.
+type PartPage struct {
+	Items  []PartListItem `json:"items"`
+	Total  int            `json:"total"`  // количество запчастей, подходящих под фильтры
+	Limit  int            `json:"limit"`  // размер страницы
+	Offset int            `json:"offset"` // смещение от начала выборки
+	// Facets – сводка по характеристикам подкатегории; заполняется, если задан фильтр по подкатегории.
+	Facets []AttributeFacet `json:"facets,omitempty"`
+}
+
+// partListSelect – выборка запчастей с брендом; условия добавляются к ней по псевдониму p.
+const partListSelect = "SELECT p.part_id AS id, p.name, p.description, p.price, p.image_url, p.subcategory_id, p.quantity, " +
+	"p.create_at, p.update_at, p.brand_id, b.name, p.slug, EXISTS(SELECT 1 FROM part_kits k WHERE k.part_id = p.part_id) " +
+	"FROM parts p LEFT JOIN brands b ON b.brand_id = p.brand_id"
+
+// scanPartListItem считывает строку выборки partListSelect.
+func scanPartListItem(row interface{ Scan(...interface{}) error }) (PartListItem, error) {
+	var item PartListItem
+	err := row.Scan(&item.ID, &item.Name, &item.Description, &item.Price, &item.ImageURL, &item.SubcategoryID,
+		&item.Quantity, &item.CreatedAt, &item.UpdatedAt, &item.BrandID, &item.Brand, &item.Slug, &item.IsKit)
+	item.Currency = BaseCurrency
+	return item, err
+}
+
+// GetPartListItem возвращает запчасть с брендом по её ID; запчасти в корзине не возвращаются.
+func GetPartListItem(db *sql.DB, id int) (PartListItem, error) {
+	return scanPartListItem(db.QueryRow(partListSelect+" WHERE p.part_id = ? AND p.deleted_at IS NULL", id))
+}
+
+// ValidSortField сообщает, поддерживается ли сортировка по указанному полю.
+func ValidSortField(field string) bool {
+	_, ok := partSortColumns[field]
+	return ok
+}
+
+// where формирует условие WHERE и его аргументы по заданным фильтрам.
+// Запчасти в корзине исключаются всегда.
+func (f PartFilter) where() (string, []interface{}) {
+	conds := []string{"p.deleted_at IS NULL"}
+	var args []interface{}
+	if f.SubcategoryID > 0 {
+		conds = append(conds, "p.subcategory_id = ?")
+		args = append(args, f.SubcategoryID)
+	}
+	if f.CategoryID > 0 {
+		conds = append(conds, "p.subcategory_id IN (SELECT subcategory_id FROM subcategories WHERE category_id = ?)")
+		args = append(args, f.CategoryID)
+	}
+	if f.GroupID > 0 {
+		conds = append(conds, "p.subcategory_id IN (SELECT s.subcategory_id FROM subcategories s "+
+			"JOIN `categories` c ON c.category_id = s.category_id WHERE c.group_id = ?)")
+		args = append(args, f.GroupID)
+	}
+	if f.MinPrice != nil {
+		price, priceArgs := f.priceColumn()
+		conds = append(conds, price+" >= ?")
+		args = append(append(args, priceArgs...), *f.MinPrice)
+	}
+	if f.MaxPrice != nil {
+		price, priceArgs := f.priceColumn()
+		conds = append(conds, price+" <= ?")
+		args = append(append(args, priceArgs...), *f.MaxPrice)
+	}
+	if f.InStock {
+		conds = append(conds, "p.quantity > 0")
+	}
+	if f.BrandID > 0 {
+		conds = append(conds, "p.brand_id = ?")
+		args = append(args, f.BrandID)
+	}
+	if f.OriginalOnly {
+		conds = append(conds, "p.brand_id IN (SELECT brand_id FROM brands WHERE is_original = 1)")
+	}
+	if cond, condArgs := f.Vehicle.condition(); cond != "" {
+		conds = append(conds, cond)
+		args = append(args, condArgs...)
+	}
+	for _, af := range f.Attributes {
+		cond, condArgs := af.condition()
+		conds = append(conds, cond)
+		args = append(args, condArgs...)
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// priceColumn возвращает выражение цены запчасти p: цену продажи или цену группы PriceGroupID.
+func (f PartFilter) priceColumn() (string, []interface{}) {
+	if f.PriceGroupID <= 0 {
+		return "p.price", nil
+	}
+	return priceGroupPriceSQL, []interface{}{f.PriceGroupID, f.PriceGroupID, f.PriceGroupID}
+}
+
+// orderBy формирует выражение ORDER BY и его аргументы. Для стабильной пагинации
+// последним ключом всегда идёт part_id.
+func (f PartFilter) orderBy() (string, []interface{}) {
+	column, ok := partSortColumns[f.Sort]
+	if !ok {
+		return " ORDER BY p.part_id", nil
+	}
+	var args []interface{}
+	if f.Sort == "price" {
+		column, args = f.priceColumn()
+	}
+	dir := " ASC"
+	if f.Desc {
+		dir = " DESC"
+	}
+	return " ORDER BY " + column + dir + ", p.part_id" + dir, args
+}
+
+// ListParts возвращает страницу запчастей, подходящих под фильтр,
+// вместе с общим количеством таких запчастей.
+func ListParts(db *sql.DB, f PartFilter) (PartPage, error) {
+	log.Printf("[INFO] ListParts: запрос запчастей с фильтром %+v", f)
+	if f.Limit <= 0 {
+		f.Limit = DefaultPartsLimit
+	}
+	if f.Limit > MaxPartsLimit {
+		f.Limit = MaxPartsLimit
+	}
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+	page := PartPage{Items: []PartListItem{}, Limit: f.Limit, Offset: f.Offset}
+
+	attrs, err := f.resolveAttributes(db)
+	if err != nil {
+		log.Printf("[WARN] ListParts: фильтр по характеристикам отклонён: %v", err)
+		return PartPage{}, err
+	}
+	if len(attrs) > 0 {
+		if page.Facets, err = attributeFacets(db, f, attrs); err != nil {
+			return PartPage{}, err
+		}
+	}
+
+	where, args := f.where()
+	if err := db.QueryRow("SELECT COUNT(*) FROM parts p"+where, args...).Scan(&page.Total); err != nil {
+		log.Printf("[ERROR] ListParts: ошибка подсчёта запчастей: %v", err)
+		return PartPage{}, err
+	}
+	if page.Total == 0 || f.Offset >= page.Total {
+		return page, nil
+	}
+
+	orderBy, orderArgs := f.orderBy()
+	query := partListSelect + where + orderBy + " LIMIT ? OFFSET ?"
+	rows, err := db.Query(query, append(append(args, orderArgs...), f.Limit, f.Offset)...)
+	if err != nil {
+		log.Printf("[ERROR] ListParts: ошибка выполнения запроса: %v", err)
+		return PartPage{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanPartListItem(rows)
+		if err != nil {
+			log.Printf("[ERROR] ListParts: ошибка сканирования строки: %v", err)
+			return PartPage{}, err
+		}
+		page.Items = append(page.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("[ERROR] ListParts: ошибка итерации по строкам: %v", err)
+		return PartPage{}, err
+	}
+	log.Printf("[INFO] ListParts: получено %d из %d запчастей", len(page.Items), page.Total)
+	return page, nil
+}
@@ -0,0 +1,347 @@
+package models
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Типы номеров запчастей.
+const (
+	NumberKindArticle = "article" // артикул производителя запчасти
+	NumberKindOEM     = "oem"     // оригинальный номер автопроизводителя
+)
+
+// maxCrossHops ограничивает глубину обхода кросс-таблицы, чтобы ошибочные
+// связи не превращали поиск аналогов в обход всей таблицы.
+const maxCrossHops = 3
+
+// PartNumber описывает артикул или OEM-номер запчасти (part_numbers).
+type PartNumber struct {
+	ID         int       `json:"id"`         // number_id – первичный ключ
+	PartID     int       `json:"part_id"`    // запчасть
+	Kind       string    `json:"kind"`       // article или oem
+	Brand      string    `json:"brand"`      // бренд, которому принадлежит номер
+	Number     string    `json:"number"`     // номер в исходном написании
+	Normalized string    `json:"normalized"` // номер для поиска (см. NormalizeNumber)
+	CreateAt   time.Time `json:"create_at"`  // время создания
+}
+
+// PartCross описывает связь двух взаимозаменяемых номеров разных брендов (part_crosses).
+type PartCross struct {
+	ID               int       `json:"id"`                // cross_id – первичный ключ
+	Brand            string    `json:"brand"`             // бренд первого номера
+	Number           string    `json:"number"`            // первый номер
+	AnalogBrand      string    `json:"analog_brand"`      // бренд аналога
+	AnalogNumber     string    `json:"analog_number"`     // номер аналога
+	Normalized       string    `json:"normalized"`        // первый номер для поиска
+	AnalogNormalized string    `json:"analog_normalized"` // номер аналога для поиска
+	CreateAt         time.Time `json:"create_at"`         // время создания
+}
+
+// PartNumberMatch – результат поиска по номеру: запчасть и её номера.
+type PartNumberMatch struct {
+	Part
+	Numbers []PartNumber `json:"numbers"`
+}
+
+// PartNumberLookup – ответ поиска по номеру: точные совпадения и аналоги.
+type PartNumberLookup struct {
+	Query      string            `json:"query"`      // номер, как его ввёл пользователь
+	Normalized string            `json:"normalized"` // нормализованный номер
+	Parts      []PartNumberMatch `json:"parts"`      // запчасти, у которых есть этот номер
+	Analogs    []PartNumberMatch `json:"analogs"`    // взаимозаменяемые запчасти по кросс-таблице
+}
+
+// cyrillicLookalikes переводит кириллические буквы, совпадающие по начертанию с латинскими,
+// в латиницу: номера часто набирают в русской раскладке.
+var cyrillicLookalikes = map[rune]rune{
+	'А': 'A', 'В': 'B', 'Е': 'E', 'К': 'K', 'М': 'M', 'Н': 'H',
+	'О': 'O', 'Р': 'P', 'С': 'C', 'Т': 'T', 'Х': 'X', 'У': 'Y',
+}
+
+// NormalizeNumber приводит номер к виду для поиска: удаляет пробелы, дефисы, точки
+// и прочие разделители, переводит в верхний регистр и заменяет кириллические
+// буквы-двойники латинскими. "0 986-494.294" и "0986494294" дают одинаковый результат.
+func NormalizeNumber(number string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(number) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			continue
+		}
+		if latin, ok := cyrillicLookalikes[r]; ok {
+			r = latin
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// migratePartNumbers создаёт таблицы номеров запчастей и кросс-таблицу аналогов.
+func migratePartNumbers(db *sql.DB) error {
+	return execAll(db,
+		"CREATE TABLE IF NOT EXISTS part_numbers ("+
+			"number_id INT AUTO_INCREMENT PRIMARY KEY, "+
+			"part_id INT NOT NULL, "+
+			"kind VARCHAR(10) NOT NULL, "+
+			"brand VARCHAR(100) NOT NULL DEFAULT '', "+
+			"number VARCHAR(64) NOT NULL, "+
+			"normalized VARCHAR(64) NOT NULL, "+
+			"create_at DATETIME NOT NULL, "+
+			"UNIQUE KEY uq_part_number (part_id, kind, normalized), "+
+			"KEY idx_number_normalized (normalized)"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"CREATE TABLE IF NOT EXISTS part_crosses ("+
+			"cross_id INT AUTO_INCREMENT PRIMARY KEY, "+
+			"brand VARCHAR(100) NOT NULL DEFAULT '', "+
+			"number VARCHAR(64) NOT NULL, "+
+			"normalized VARCHAR(64) NOT NULL, "+
+			"analog_brand VARCHAR(100) NOT NULL DEFAULT '', "+
+			"analog_number VARCHAR(64) NOT NULL, "+
+			"analog_normalized VARCHAR(64) NOT NULL, "+
+			"create_at DATETIME NOT NULL, "+
+			"UNIQUE KEY uq_cross (normalized, brand, analog_normalized, analog_brand), "+
+			"KEY idx_cross_analog (analog_normalized)"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+	)
+}
+
+// GetPartNumbers возвращает все номера запчасти.
+func GetPartNumbers(db *sql.DB, partID int) ([]PartNumber, error) {
+	numbers, err := getPartNumbersFor(db, []int{partID})
+	return numbers[partID], err
+}
+
+// getPartNumbersFor возвращает номера нескольких запчастей, сгруппированные по part_id.
+func getPartNumbersFor(db *sql.DB, partIDs []int) (map[int][]PartNumber, error) {
+	result := make(map[int][]PartNumber, len(partIDs))
+	if len(partIDs) == 0 {
+		return result, nil
+	}
+	query := "SELECT number_id, part_id, kind, brand, number, normalized, create_at FROM part_numbers " +
+		"WHERE part_id IN (" + placeholders(len(partIDs)) + ") ORDER BY part_id, kind, number_id"
+	rows, err := db.Query(query, intArgs(partIDs)...)
+	if err != nil {
+		log.Printf("[ERROR] getPartNumbersFor: ошибка выполнения запроса: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var n PartNumber
+		if err := rows.Scan(&n.ID, &n.PartID, &n.Kind, &n.Brand, &n.Number, &n.Normalized, &n.CreateAt); err != nil {
+			return nil, err
+		}
+		result[n.PartID] = append(result[n.PartID], n)
+	}
+	return result, rows.Err()
+}
+
+// AddPartNumber добавляет номер запчасти. Повторное добавление того же номера
+// обновляет его исходное написание и бренд.
+func AddPartNumber(db dbExecutor, n PartNumber) error {
+	query := "INSERT INTO part_numbers (part_id, kind, brand, number, normalized, create_at) VALUES (?, ?, ?, ?, ?, NOW()) " +
+		"ON DUPLICATE KEY UPDATE brand = VALUES(brand), number = VALUES(number)"
+	_, err := db.Exec(query, n.PartID, n.Kind, strings.TrimSpace(n.Brand), strings.TrimSpace(n.Number), NormalizeNumber(n.Number))
+	if err != nil {
+		log.Printf("[ERROR] AddPartNumber: ошибка добавления номера %s к запчасти %d: %v", n.Number, n.PartID, err)
+	}
+	return err
+}
+
+// DeletePartNumber удаляет номер запчасти.
+func DeletePartNumber(db *sql.DB, partID, numberID int) (int64, error) {
+	return rowsAffected(db.Exec("DELETE FROM part_numbers WHERE number_id = ? AND part_id = ?", numberID, partID))
+}
+
+// GetPartCrosses возвращает связи кросс-таблицы, в которых участвует номер.
+func GetPartCrosses(db *sql.DB, number string) ([]PartCross, error) {
+	normalized := NormalizeNumber(number)
+	query := "SELECT cross_id, brand, number, analog_brand, analog_number, normalized, analog_normalized, create_at " +
+		"FROM part_crosses WHERE normalized = ? OR analog_normalized = ? ORDER BY cross_id"
+	rows, err := db.Query(query, normalized, normalized)
+	if err != nil {
+		log.Printf("[ERROR] GetPartCrosses: ошибка выполнения запроса: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []PartCross
+	for rows.Next() {
+		var c PartCross
+		if err := rows.Scan(&c.ID, &c.Brand, &c.Number, &c.AnalogBrand, &c.AnalogNumber, &c.Normalized, &c.AnalogNormalized, &c.CreateAt); err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+// AddPartCross добавляет связь двух взаимозаменяемых номеров.
+func AddPartCross(db *sql.DB, c PartCross) error {
+	query := "INSERT IGNORE INTO part_crosses (brand, number, normalized, analog_brand, analog_number, analog_normalized, create_at) " +
+		"VALUES (?, ?, ?, ?, ?, ?, NOW())"
+	_, err := db.Exec(query, strings.TrimSpace(c.Brand), strings.TrimSpace(c.Number), NormalizeNumber(c.Number),
+		strings.TrimSpace(c.AnalogBrand), strings.TrimSpace(c.AnalogNumber), NormalizeNumber(c.AnalogNumber))
+	if err != nil {
+		log.Printf("[ERROR] AddPartCross: ошибка добавления связи %s – %s: %v", c.Number, c.AnalogNumber, err)
+	}
+	return err
+}
+
+// DeletePartCross удаляет связь кросс-таблицы.
+func DeletePartCross(db *sql.DB, id int) (int64, error) {
+	return rowsAffected(db.Exec("DELETE FROM part_crosses WHERE cross_id = ?", id))
+}
+
+// crossAnalogs возвращает номера, связанные с исходными через кросс-таблицу
+// (связи двусторонние и транзитивные, не более maxCrossHops шагов).
+// Исходные номера в результат не входят.
+func crossAnalogs(db *sql.DB, start []string) ([]string, error) {
+	seen := make(map[string]bool, len(start))
+	for _, n := range start {
+		seen[n] = true
+	}
+	frontier := start
+	var analogs []string
+	for hop := 0; hop < maxCrossHops && len(frontier) > 0; hop++ {
+		ph := placeholders(len(frontier))
+		query := "SELECT analog_normalized FROM part_crosses WHERE normalized IN (" + ph + ") " +
+			"UNION SELECT normalized FROM part_crosses WHERE analog_normalized IN (" + ph + ")"
+		args := append(stringArgs(frontier), stringArgs(frontier)...)
+		rows, err := db.Query(query, args...)
+		if err != nil {
+			log.Printf("[ERROR] crossAnalogs: ошибка выполнения запроса: %v", err)
+			return nil, err
+		}
+		var next []string
+		for rows.Next() {
+			var n string
+			if err := rows.Scan(&n); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if !seen[n] {
+				seen[n] = true
+				next = append(next, n)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		analogs = append(analogs, next...)
+		frontier = next
+	}
+	return analogs, nil
+}
+
+// partsByNormalizedNumbers возвращает запчасти, у которых есть хотя бы один из номеров,
+// исключая запчасти из exclude.
+func partsByNormalizedNumbers(db *sql.DB, numbers []string, exclude map[int]bool) ([]PartNumberMatch, error) {
+	if len(numbers) == 0 {
+		return []PartNumberMatch{}, nil
+	}
+	query := "SELECT DISTINCT p.part_id AS id, p.name, p.description, p.price, p.image_url, p.subcategory_id, p.quantity, p.create_at, p.update_at " +
+		"FROM parts p JOIN part_numbers n ON n.part_id = p.part_id WHERE p.deleted_at IS NULL AND n.normalized IN (" + placeholders(len(numbers)) + ") ORDER BY p.name"
+	rows, err := db.Query(query, stringArgs(numbers)...)
+	if err != nil {
+		log.Printf("[ERROR] partsByNormalizedNumbers: ошибка выполнения запроса: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	matches := []PartNumberMatch{}
+	var ids []int
+	for rows.Next() {
+		var m PartNumberMatch
+		if err := rows.Scan(&m.ID, &m.Name, &m.Description, &m.Price, &m.ImageURL, &m.SubcategoryID,
+			&m.Quantity, &m.CreatedAt, &m.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if exclude[m.ID] {
+			continue
+		}
+		matches = append(matches, m)
+		ids = append(ids, m.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	numbersByPart, err := getPartNumbersFor(db, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range matches {
+		matches[i].Numbers = numbersByPart[matches[i].ID]
+	}
+	return matches, nil
+}
+
+// FindPartsByNumber ищет запчасти по артикулу или OEM-номеру и их аналоги.
+// Аналогами считаются запчасти, номера которых связаны с номерами найденных
+// запчастей (или с самим искомым номером) через кросс-таблицу.
+func FindPartsByNumber(db *sql.DB, number string) (PartNumberLookup, error) {
+	lookup := PartNumberLookup{Query: number, Normalized: NormalizeNumber(number), Parts: []PartNumberMatch{}, Analogs: []PartNumberMatch{}}
+	log.Printf("[INFO] FindPartsByNumber: поиск по номеру %s (%s)", lookup.Query, lookup.Normalized)
+	if lookup.Normalized == "" {
+		return lookup, nil
+	}
+
+	direct, err := partsByNormalizedNumbers(db, []string{lookup.Normalized}, nil)
+	if err != nil {
+		return PartNumberLookup{}, err
+	}
+	lookup.Parts = direct
+
+	// Аналоги ищутся от искомого номера и от всех номеров найденных запчастей:
+	// так OEM-номер находит аналоги, заведённые в кросс-таблице по артикулу.
+	start := []string{lookup.Normalized}
+	found := make(map[int]bool, len(direct))
+	for _, m := range direct {
+		found[m.ID] = true
+		for _, n := range m.Numbers {
+			if n.Normalized != lookup.Normalized {
+				start = append(start, n.Normalized)
+			}
+		}
+	}
+	analogNumbers, err := crossAnalogs(db, start)
+	if err != nil {
+		return PartNumberLookup{}, err
+	}
+	if lookup.Analogs, err = partsByNormalizedNumbers(db, analogNumbers, found); err != nil {
+		return PartNumberLookup{}, err
+	}
+	log.Printf("[INFO] FindPartsByNumber: найдено запчастей %d, аналогов %d", len(lookup.Parts), len(lookup.Analogs))
+	return lookup, nil
+}
+
+// placeholders возвращает строку "?, ?, ..." из n параметров.
+func placeholders(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strings.Repeat("?, ", n-1) + "?"
+}
+
+// intArgs преобразует срез чисел в аргументы запроса.
+func intArgs(values []int) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return args
+}
+
+// stringArgs преобразует срез строк в аргументы запроса.
+func stringArgs(values []string) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return args
+}
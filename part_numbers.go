@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"AutoM/config"
+	"AutoM/models"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// GetPartsByNumber – поиск запчасти по артикулу или OEM-номеру вместе с аналогами (API).
+// Номер нормализуется: пробелы, дефисы и точки отбрасываются, регистр не учитывается.
+func GetPartsByNumber(w http.ResponseWriter, r *http.Request) {
+	number := mux.Vars(r)["number"]
+	if models.NormalizeNumber(number) == "" {
+		http.Error(w, "Не указан номер запчасти", http.StatusBadRequest)
+		return
+	}
+	lookup, err := models.FindPartsByNumber(config.DB, number)
+	if err != nil {
+		http.Error(w, "Ошибка поиска по номеру", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetPartsByNumber", lookup)
+}
+
+// GetPartNumbers – список артикулов и OEM-номеров запчасти (API).
+func GetPartNumbers(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "GetPartNumbers")
+	if !ok {
+		return
+	}
+	list, err := models.GetPartNumbers(config.DB, id)
+	if err != nil {
+		http.Error(w, "Ошибка загрузки номеров запчасти", http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []models.PartNumber{}
+	}
+	writeJSON(w, "GetPartNumbers", list)
+}
+
+// validPartNumber проверяет номер перед сохранением и возвращает текст ошибки для клиента.
+func validPartNumber(n models.PartNumber) string {
+	if n.Kind != models.NumberKindArticle && n.Kind != models.NumberKindOEM {
+		return "Тип номера должен быть article или oem"
+	}
+	if models.NormalizeNumber(n.Number) == "" {
+		return "Не указан номер запчасти"
+	}
+	return ""
+}
+
+// AddPartNumber – добавление артикула или OEM-номера запчасти.
+// Ожидается JSON с полями kind (article или oem), number и необязательным brand.
+func AddPartNumber(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "AddPartNumber")
+	if !ok {
+		return
+	}
+	var n models.PartNumber
+	if !decodeJSON(w, r, "AddPartNumber", &n) {
+		return
+	}
+	n.PartID = id
+	if msg := validPartNumber(n); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if err := models.AddPartNumber(config.DB, n); err != nil {
+		http.Error(w, "Ошибка добавления номера запчасти", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// DeletePartNumber – удаление номера запчасти.
+func DeletePartNumber(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "DeletePartNumber")
+	if !ok {
+		return
+	}
+	numberID, ok := pathInt(w, r, "numberId", "DeletePartNumber")
+	if !ok {
+		return
+	}
+	rows, err := models.DeletePartNumber(config.DB, id, numberID)
+	respondAffected(w, "DeletePartNumber", "Номер запчасти не найден", rows, err)
+}
+
+// GetPartCrosses – связи кросс-таблицы для номера из параметра number.
+func GetPartCrosses(w http.ResponseWriter, r *http.Request) {
+	number := r.URL.Query().Get("number")
+	if models.NormalizeNumber(number) == "" {
+		http.Error(w, "Не указан номер запчасти", http.StatusBadRequest)
+		return
+	}
+	list, err := models.GetPartCrosses(config.DB, number)
+	if err != nil {
+		http.Error(w, "Ошибка загрузки аналогов", http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []models.PartCross{}
+	}
+	writeJSON(w, "GetPartCrosses", list)
+}
+
+// AddPartCross – добавление связи двух взаимозаменяемых номеров.
+// Ожидается JSON с полями brand, number, analog_brand и analog_number.
+func AddPartCross(w http.ResponseWriter, r *http.Request) {
+	var c models.PartCross
+	if !decodeJSON(w, r, "AddPartCross", &c) {
+		return
+	}
+	if models.NormalizeNumber(c.Number) == "" || models.NormalizeNumber(c.AnalogNumber) == "" {
+		http.Error(w, "Необходимо указать number и analog_number", http.StatusBadRequest)
+		return
+	}
+	if models.NormalizeNumber(c.Number) == models.NormalizeNumber(c.AnalogNumber) &&
+		strings.EqualFold(strings.TrimSpace(c.Brand), strings.TrimSpace(c.AnalogBrand)) {
+		http.Error(w, "Номер не может быть аналогом самого себя", http.StatusBadRequest)
+		return
+	}
+	if err := models.AddPartCross(config.DB, c); err != nil {
+		http.Error(w, "Ошибка добавления аналога", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("[INFO] AddPartCross: добавлена связь %s – %s", c.Number, c.AnalogNumber)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// DeletePartCross – удаление связи кросс-таблицы.
+func DeletePartCross(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "DeletePartCross")
+	if !ok {
+		return
+	}
+	rows, err := models.DeletePartCross(config.DB, id)
+	respondAffected(w, "DeletePartCross", "Связь аналогов не найдена", rows, err)
+}
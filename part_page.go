@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+
+	"AutoM/config"
+	"AutoM/models"
+
+	"github.com/gorilla/mux"
+)
+
+// PartPageData – данные для шаблона страницы запчасти.
+type PartPageData struct {
+	Username string
+	IsAdmin  bool
+	Part     models.PartListItem
+	Location *models.CatalogLocation // раздел каталога запчасти; nil, если слаги раздела не назначены
+	Images   []models.PartImage      // галерея; первое изображение – основное
+	Numbers  []models.PartNumber     // артикулы и OEM-номера
+	Analogs  []models.PartListItem   // взаимозаменяемые запчасти
+	Vehicles []models.CompatibleVehicle
+	Kit      *models.Kit // состав комплекта; nil для обычной запчасти
+	Currency string      // валюта цен (GET-параметр currency)
+	JSONLD   template.JS // разметка schema.org Product/Offer и BreadcrumbList
+}
+
+// ListPrice возвращает цену продажи, если цена группы покупателя отличается от неё, иначе 0.
+func (d PartPageData) ListPrice() float64 {
+	if d.Part.ListPrice == nil {
+		return 0
+	}
+	return *d.Part.ListPrice
+}
+
+// CurrencySign возвращает символ валюты цен на странице.
+func (d PartPageData) CurrencySign() string {
+	return currencySign(d.Currency)
+}
+
+// InStock сообщает, есть ли запчасть в наличии.
+func (d PartPageData) InStock() bool {
+	return d.Part.Quantity > 0
+}
+
+// requestOrigin возвращает схему и хост запроса, например "https://automiks.ru",
+// с учётом заголовка X-Forwarded-Proto от обратного прокси.
+func requestOrigin(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "https" || proto == "http" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
+
+// absoluteURL дополняет относительный адрес схемой и хостом запроса.
+func absoluteURL(r *http.Request, path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return requestOrigin(r) + path
+}
+
+// partJSONLD формирует разметку schema.org для страницы запчасти:
+// Product с вложенным Offer (цена и наличие) и BreadcrumbList по разделам каталога.
+func partJSONLD(r *http.Request, data PartPageData) (template.JS, error) {
+	part := data.Part
+	pageURL := absoluteURL(r, r.URL.Path)
+
+	availability := "https://schema.org/OutOfStock"
+	if data.InStock() {
+		availability = "https://schema.org/InStock"
+	}
+	product := map[string]interface{}{
+		"@context":    "https://schema.org",
+		"@type":       "Product",
+		"name":        part.Name,
+		"description": part.Description,
+		"sku":         part.ID,
+		"url":         pageURL,
+		"offers": map[string]interface{}{
+			"@type":         "Offer",
+			"url":           pageURL,
+			"price":         part.Price,
+			"priceCurrency": data.Currency,
+			"availability":  availability,
+			"itemCondition": "https://schema.org/NewCondition",
+		},
+	}
+	if part.Brand != nil {
+		product["brand"] = map[string]string{"@type": "Brand", "name": *part.Brand}
+	}
+	if len(data.Images) > 0 {
+		images := make([]string, 0, len(data.Images))
+		for _, img := range data.Images {
+			images = append(images, absoluteURL(r, img.URL))
+		}
+		product["image"] = images
+	}
+	for _, n := range data.Numbers {
+		if n.Kind == "article" {
+			product["mpn"] = n.Number
+			break
+		}
+	}
+	if l := data.Location; l != nil {
+		product["category"] = l.GroupName + " > " + l.CategoryName + " > " + l.SubcategoryName
+	}
+	graph := []interface{}{product}
+
+	if l := data.Location; l != nil {
+		crumbs := []struct{ name, path string }{
+			{l.GroupName, "/catalog/" + l.GroupSlug},
+			{l.CategoryName, "/catalog/" + l.GroupSlug + "/" + l.CategorySlug},
+			{l.SubcategoryName, l.Path()},
+			{part.Name, r.URL.Path},
+		}
+		items := make([]map[string]interface{}, 0, len(crumbs))
+		for i, c := range crumbs {
+			items = append(items, map[string]interface{}{
+				"@type": "ListItem", "position": i + 1, "name": c.name, "item": absoluteURL(r, c.path),
+			})
+		}
+		graph = append(graph, map[string]interface{}{
+			"@context": "https://schema.org", "@type": "BreadcrumbList", "itemListElement": items,
+		})
+	}
+
+	// json.Marshal экранирует <, > и &, поэтому вывод безопасен внутри <script>.
+	b, err := json.Marshal(graph)
+	return template.JS(b), err
+}
+
+// PartPageHandler выводит страницу запчасти по адресу /part/{slug}: хлебные крошки
+// по разделам каталога, цену, наличие, галерею, номера, аналоги и применимость.
+// Цены выводятся в валюте из GET-параметра currency (по умолчанию – в рублях).
+// Для слагов, изменившихся после переименования, отправляется 301 на новый адрес.
+func PartPageHandler(w http.ResponseWriter, r *http.Request) {
+	slug := mux.Vars(r)["slug"]
+	id, err := models.GetPartIDBySlug(config.DB, slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		redirectOrNotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Ошибка поиска запчасти по слагу %s: %v", slug, err)
+		http.Error(w, "Ошибка получения запчасти", http.StatusInternalServerError)
+		return
+	}
+	part, err := models.GetPartListItem(config.DB, id)
+	if err != nil {
+		log.Printf("[ERROR] Ошибка получения запчасти %d: %v", id, err)
+		http.Error(w, "Ошибка получения запчасти", http.StatusInternalServerError)
+		return
+	}
+
+	data := PartPageData{Part: part}
+	location, err := models.GetSubcategoryLocation(config.DB, part.SubcategoryID)
+	switch {
+	case err == nil:
+		data.Location = &location
+	case !errors.Is(err, sql.ErrNoRows):
+		log.Printf("[ERROR] Ошибка получения раздела запчасти %d: %v", id, err)
+	}
+
+	// Дополнительные блоки страницы не обязательны: при ошибке страница выводится без них.
+	if data.Images, err = models.GetPartImages(config.DB, id); err != nil {
+		log.Printf("[ERROR] Ошибка получения изображений запчасти %d: %v", id, err)
+	}
+	if data.Numbers, err = models.GetPartNumbers(config.DB, id); err != nil {
+		log.Printf("[ERROR] Ошибка получения номеров запчасти %d: %v", id, err)
+	}
+	if data.Analogs, err = models.GetPartAnalogs(config.DB, id); err != nil {
+		log.Printf("[ERROR] Ошибка получения аналогов запчасти %d: %v", id, err)
+	}
+	if data.Vehicles, err = models.GetPartCompatibleVehicles(config.DB, id); err != nil {
+		log.Printf("[ERROR] Ошибка получения применимости запчасти %d: %v", id, err)
+	}
+	if data.Part.Stock, err = models.GetPartStock(config.DB, id); err != nil {
+		log.Printf("[ERROR] Ошибка получения остатков запчасти %d по складам: %v", id, err)
+	}
+	if part.IsKit {
+		if kit, err := models.GetKit(config.DB, id); err != nil {
+			log.Printf("[ERROR] Ошибка получения состава комплекта %d: %v", id, err)
+		} else {
+			data.Kit = &kit
+		}
+	}
+	pricing := sessionPricing(r)
+	pricing.ApplyToItem(&data.Part)
+	pricing.ApplyTo(data.Analogs)
+	pricing.ApplyToKit(data.Kit)
+	currency, converter := storefrontCurrency(r)
+	data.Currency = currency
+	convertPartItem(converter, currency, &data.Part)
+	convertPartItems(converter, currency, data.Analogs)
+	convertKitComponents(converter, currency, data.Kit)
+
+	if data.JSONLD, err = partJSONLD(r, data); err != nil {
+		log.Printf("[ERROR] Ошибка формирования JSON-LD запчасти %d: %v", id, err)
+	}
+
+	session, _ := config.Store.Get(r, "session")
+	if val, ok := session.Values["username"].(string); ok {
+		data.Username = strings.TrimSpace(val)
+	}
+	if val, ok := session.Values["is_admin"].(bool); ok {
+		data.IsAdmin = val
+	}
+	RenderTemplateCached(w, "part.html", data)
+}
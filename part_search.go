@@ -0,0 +1,174 @@
+package models
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+)
+
+// PartSearchResult – запчасть, найденная полнотекстовым поиском, с оценкой релевантности.
+type PartSearchResult struct {
+	Part
+	Relevance float64  `json:"relevance"`            // чем больше значение, тем точнее совпадение
+	Currency  string   `json:"currency"`             // валюта поля Price
+	ListPrice *float64 `json:"list_price,omitempty"` // цена продажи, если цена группы покупателя отличается
+}
+
+// migratePartsSearch создаёт таблицу поискового индекса запчастей.
+// В индексе хранятся основы слов названия и описания (см. StemText),
+// по которым MySQL строит FULLTEXT-индексы.
+func migratePartsSearch(db *sql.DB) error {
+	return execAll(db,
+		"CREATE TABLE IF NOT EXISTS `parts_search` ("+
+			"part_id INT NOT NULL PRIMARY KEY, "+
+			"name_stems TEXT NOT NULL, "+
+			"description_stems TEXT NOT NULL, "+
+			"FULLTEXT KEY ft_name (name_stems), "+
+			"FULLTEXT KEY ft_all (name_stems, description_stems)"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+	)
+}
+
+// IndexPart добавляет или обновляет запись запчасти в поисковом индексе.
+func IndexPart(db dbExecutor, partID int, name, description string) error {
+	query := "REPLACE INTO `parts_search` (part_id, name_stems, description_stems) VALUES (?, ?, ?)"
+	if _, err := db.Exec(query, partID, StemText(name), StemText(description)); err != nil {
+		log.Printf("[ERROR] IndexPart: ошибка индексации запчасти %d: %v", partID, err)
+		return err
+	}
+	return nil
+}
+
+// SyncPartsSearchIndex индексирует запчасти, отсутствующие в поисковом индексе,
+// и удаляет из индекса записи удалённых запчастей.
+// Вызывается при старте приложения и после массового импорта.
+func SyncPartsSearchIndex(db *sql.DB) error {
+	log.Println("[INFO] SyncPartsSearchIndex: синхронизация поискового индекса")
+	if _, err := db.Exec("DELETE ps FROM `parts_search` ps LEFT JOIN parts p ON p.part_id = ps.part_id WHERE p.part_id IS NULL"); err != nil {
+		log.Printf("[ERROR] SyncPartsSearchIndex: ошибка очистки индекса: %v", err)
+		return err
+	}
+
+	query := "SELECT p.part_id, p.name, COALESCE(p.description, '') FROM parts p " +
+		"LEFT JOIN `parts_search` ps ON ps.part_id = p.part_id WHERE ps.part_id IS NULL"
+	rows, err := db.Query(query)
+	if err != nil {
+		log.Printf("[ERROR] SyncPartsSearchIndex: ошибка выполнения запроса: %v", err)
+		return err
+	}
+	type pending struct {
+		id                int
+		name, description string
+	}
+	var batch []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.name, &p.description); err != nil {
+			rows.Close()
+			log.Printf("[ERROR] SyncPartsSearchIndex: ошибка сканирования строки: %v", err)
+			return err
+		}
+		batch = append(batch, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, p := range batch {
+		if err := IndexPart(db, p.id, p.name, p.description); err != nil {
+			return err
+		}
+	}
+	log.Printf("[INFO] SyncPartsSearchIndex: проиндексировано запчастей: %d", len(batch))
+	return nil
+}
+
+// buildBooleanQuery преобразует пользовательский запрос в выражение для
+// MATCH ... AGAINST (... IN BOOLEAN MODE). Фразы в двойных кавычках ищутся
+// как последовательность основ, остальные слова – как обязательные префиксы основ.
+// Слова короче minTokenLen игнорируются, так как их нет в FULLTEXT-индексе.
+func buildBooleanQuery(q string) string {
+	const minTokenLen = 3
+	var terms []string
+	parts := strings.Split(q, `"`)
+	for i, chunk := range parts {
+		// Нечётные элементы находятся внутри кавычек.
+		inPhrase := i%2 == 1 && i < len(parts)-1
+		var stems []string
+		for _, token := range Tokenize(chunk) {
+			if stem := StemRussian(token); len([]rune(stem)) >= minTokenLen {
+				stems = append(stems, stem)
+			}
+		}
+		if len(stems) == 0 {
+			continue
+		}
+		if inPhrase {
+			terms = append(terms, `+"`+strings.Join(stems, " ")+`"`)
+			continue
+		}
+		for _, stem := range stems {
+			terms = append(terms, "+"+stem+"*")
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+// likeEscaper экранирует символы шаблона LIKE (экранирующий символ MySQL по умолчанию – обратная косая черта).
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// escapeLike возвращает строку, которая в шаблоне LIKE совпадает только сама с собой.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// SearchParts выполняет полнотекстовый поиск запчастей по названию и описанию.
+// Совпадения в названии весят втрое больше совпадений в описании.
+// Если в запросе нет слов, пригодных для индекса, выполняется поиск подстроки.
+func SearchParts(db *sql.DB, q string, limit int) ([]PartSearchResult, error) {
+	log.Printf("[INFO] SearchParts: поиск по запросу '%s'", q)
+	const columns = "p.part_id AS id, p.name, p.description, p.price, p.image_url, p.subcategory_id, p.quantity, p.create_at, p.update_at"
+
+	var (
+		rows *sql.Rows
+		err  error
+	)
+	if expr := buildBooleanQuery(q); expr != "" {
+		query := "SELECT " + columns + ", " +
+			"MATCH(ps.name_stems) AGAINST(? IN BOOLEAN MODE) * 3 + MATCH(ps.name_stems, ps.description_stems) AGAINST(? IN BOOLEAN MODE) AS relevance " +
+			"FROM parts p JOIN `parts_search` ps ON ps.part_id = p.part_id " +
+			"WHERE MATCH(ps.name_stems, ps.description_stems) AGAINST(? IN BOOLEAN MODE) AND p.deleted_at IS NULL " +
+			"ORDER BY relevance DESC, p.name LIMIT ?"
+		log.Printf("[INFO] SearchParts: выражение полнотекстового поиска: %s", expr)
+		rows, err = db.Query(query, expr, expr, expr, limit)
+	} else {
+		like := "%" + escapeLike(strings.TrimSpace(q)) + "%"
+		query := "SELECT " + columns + ", 0 AS relevance FROM parts p " +
+			"WHERE (p.name LIKE ? OR p.description LIKE ?) AND p.deleted_at IS NULL ORDER BY p.name LIMIT ?"
+		rows, err = db.Query(query, like, like, limit)
+	}
+	if err != nil {
+		log.Printf("[ERROR] SearchParts: ошибка выполнения запроса: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []PartSearchResult
+	for rows.Next() {
+		var res PartSearchResult
+		if err := rows.Scan(&res.ID, &res.Name, &res.Description, &res.Price, &res.ImageURL, &res.SubcategoryID,
+			&res.Quantity, &res.CreatedAt, &res.UpdatedAt, &res.Relevance); err != nil {
+			log.Printf("[ERROR] SearchParts: ошибка сканирования строки: %v", err)
+			return nil, err
+		}
+		res.Currency = BaseCurrency
+		results = append(results, res)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("[ERROR] SearchParts: ошибка итерации по строкам: %v", err)
+		return nil, err
+	}
+	log.Printf("[INFO] SearchParts: найдено %d запчастей", len(results))
+	return results, nil
+}
@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPartInputQuantity(t *testing.T) {
+	tests := []struct {
+		body string
+		want *int
+	}{
+		{`{"name": "Колодки"}`, nil},
+		{`{"name": "Колодки", "quantity": 0}`, new(int)},
+		{`{"name": "Колодки", "quantity": 5}`, func() *int { n := 5; return &n }()},
+	}
+	for _, tt := range tests {
+		var part partInput
+		if err := json.Unmarshal([]byte(tt.body), &part); err != nil {
+			t.Fatalf("%s: %v", tt.body, err)
+		}
+		switch {
+		case tt.want == nil && part.Quantity != nil:
+			t.Errorf("%s: количество %d, ожидалось отсутствие", tt.body, *part.Quantity)
+		case tt.want != nil && (part.Quantity == nil || *part.Quantity != *tt.want):
+			t.Errorf("%s: количество %v, ожидалось %d", tt.body, part.Quantity, *tt.want)
+		}
+	}
+}
@@ -0,0 +1,439 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Ошибки групп цен.
+var (
+	ErrPriceGroupName   = errors.New("название группы цен не может быть пустым")
+	ErrPriceGroupMarkup = errors.New("наценка группы должна быть больше -100 %")
+	ErrPriceGroupExists = errors.New("группа цен с таким названием уже существует")
+)
+
+// PriceGroup – группа цен покупателей (розница, опт, СТО). Цена для группы рассчитывается
+// от цены продажи запчасти: явная цена запчасти для группы, иначе наценка категории,
+// иначе наценка группы. Покупатели без группы видят цену продажи.
+type PriceGroup struct {
+	ID         int                   `json:"id"`
+	Name       string                `json:"name"`
+	Markup     float64               `json:"markup"` // наценка в процентах; отрицательная – скидка
+	CreateAt   time.Time             `json:"create_at"`
+	Users      int                   `json:"users"`                // количество покупателей в группе
+	Categories []PriceGroupCategory  `json:"categories,omitempty"` // наценки по категориям (GetPriceGroup)
+	Parts      []PriceGroupPartPrice `json:"parts,omitempty"`      // явные цены запчастей (GetPriceGroup)
+}
+
+// PriceGroupCategory – наценка группы для запчастей категории вместо общей наценки группы.
+type PriceGroupCategory struct {
+	CategoryID int     `json:"category_id"`
+	Name       string  `json:"name,omitempty"`
+	Markup     float64 `json:"markup"`
+}
+
+// PriceGroupPartPrice – явная цена запчасти для группы.
+type PriceGroupPartPrice struct {
+	PartID int     `json:"part_id"`
+	Name   string  `json:"name,omitempty"`
+	Price  float64 `json:"price"`
+}
+
+// migratePriceGroups создаёт таблицы групп цен и добавляет пользователям группу.
+func migratePriceGroups(db *sql.DB) error {
+	err := execAll(db,
+		"CREATE TABLE IF NOT EXISTS price_groups ("+
+			"price_group_id INT AUTO_INCREMENT PRIMARY KEY, "+
+			"name VARCHAR(100) NOT NULL, "+
+			"markup DECIMAL(6,2) NOT NULL DEFAULT 0, "+
+			"create_at DATETIME NOT NULL, "+
+			"UNIQUE KEY uq_price_group_name (name)"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"CREATE TABLE IF NOT EXISTS price_group_categories ("+
+			"price_group_id INT NOT NULL, "+
+			"category_id INT NOT NULL, "+
+			"markup DECIMAL(6,2) NOT NULL, "+
+			"PRIMARY KEY (price_group_id, category_id), "+
+			"FOREIGN KEY (price_group_id) REFERENCES price_groups (price_group_id) ON DELETE CASCADE"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"CREATE TABLE IF NOT EXISTS price_group_parts ("+
+			"price_group_id INT NOT NULL, "+
+			"part_id INT NOT NULL, "+
+			"price DECIMAL(12,2) NOT NULL, "+
+			"PRIMARY KEY (price_group_id, part_id), "+
+			"KEY idx_price_group_part (part_id), "+
+			"FOREIGN KEY (price_group_id) REFERENCES price_groups (price_group_id) ON DELETE CASCADE"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+	)
+	if err != nil {
+		return err
+	}
+	return ensureColumn(db, "users", "price_group_id", "INT NULL")
+}
+
+// validatePriceGroupMarkup проверяет, что наценка не даёт нулевую или отрицательную цену.
+func validatePriceGroupMarkup(markup float64) error {
+	if markup <= -100 {
+		return ErrPriceGroupMarkup
+	}
+	return nil
+}
+
+// Validate проверяет название и наценку группы.
+func (g *PriceGroup) Validate() error {
+	g.Name = strings.TrimSpace(g.Name)
+	if g.Name == "" {
+		return ErrPriceGroupName
+	}
+	return validatePriceGroupMarkup(g.Markup)
+}
+
+// isDuplicateKey сообщает, что запись нарушает уникальный ключ (ошибка MySQL 1062).
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
+
+// GetPriceGroups возвращает все группы цен с количеством покупателей.
+func GetPriceGroups(db *sql.DB) ([]PriceGroup, error) {
+	query := "SELECT g.price_group_id, g.name, g.markup, g.create_at, " +
+		"(SELECT COUNT(*) FROM users u WHERE u.price_group_id = g.price_group_id) " +
+		"FROM price_groups g ORDER BY g.name"
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []PriceGroup{}
+	for rows.Next() {
+		var g PriceGroup
+		if err := rows.Scan(&g.ID, &g.Name, &g.Markup, &g.CreateAt, &g.Users); err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+// GetPriceGroup возвращает группу цен с наценками по категориям и явными ценами запчастей.
+func GetPriceGroup(db *sql.DB, id int) (PriceGroup, error) {
+	var g PriceGroup
+	query := "SELECT g.price_group_id, g.name, g.markup, g.create_at, " +
+		"(SELECT COUNT(*) FROM users u WHERE u.price_group_id = g.price_group_id) " +
+		"FROM price_groups g WHERE g.price_group_id = ?"
+	if err := db.QueryRow(query, id).Scan(&g.ID, &g.Name, &g.Markup, &g.CreateAt, &g.Users); err != nil {
+		return PriceGroup{}, err
+	}
+
+	rows, err := db.Query("SELECT gc.category_id, COALESCE(c.name, ''), gc.markup FROM price_group_categories gc "+
+		"LEFT JOIN `categories` c ON c.category_id = gc.category_id WHERE gc.price_group_id = ? ORDER BY c.name", id)
+	if err != nil {
+		return PriceGroup{}, err
+	}
+	for rows.Next() {
+		var c PriceGroupCategory
+		if err := rows.Scan(&c.CategoryID, &c.Name, &c.Markup); err != nil {
+			rows.Close()
+			return PriceGroup{}, err
+		}
+		g.Categories = append(g.Categories, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return PriceGroup{}, err
+	}
+
+	rows, err = db.Query("SELECT gp.part_id, COALESCE(p.name, ''), gp.price FROM price_group_parts gp "+
+		"LEFT JOIN parts p ON p.part_id = gp.part_id WHERE gp.price_group_id = ? ORDER BY p.name", id)
+	if err != nil {
+		return PriceGroup{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p PriceGroupPartPrice
+		if err := rows.Scan(&p.PartID, &p.Name, &p.Price); err != nil {
+			return PriceGroup{}, err
+		}
+		g.Parts = append(g.Parts, p)
+	}
+	return g, rows.Err()
+}
+
+// AddPriceGroup создаёт группу цен и возвращает её ID.
+func AddPriceGroup(db *sql.DB, g PriceGroup) (int64, error) {
+	if err := g.Validate(); err != nil {
+		return 0, err
+	}
+	res, err := db.Exec("INSERT INTO price_groups (name, markup, create_at) VALUES (?, ?, NOW())", g.Name, g.Markup)
+	if isDuplicateKey(err) {
+		return 0, ErrPriceGroupExists
+	}
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// UpdatePriceGroup изменяет название и наценку группы цен.
+func UpdatePriceGroup(db *sql.DB, g PriceGroup) (int64, error) {
+	if err := g.Validate(); err != nil {
+		return 0, err
+	}
+	n, err := rowsAffected(db.Exec("UPDATE price_groups SET name = ?, markup = ? WHERE price_group_id = ?", g.Name, g.Markup, g.ID))
+	if isDuplicateKey(err) {
+		return 0, ErrPriceGroupExists
+	}
+	if err == nil && n == 0 {
+		// MySQL не считает строку изменённой, если значения совпали.
+		var exists int
+		err = db.QueryRow("SELECT COUNT(*) FROM price_groups WHERE price_group_id = ?", g.ID).Scan(&exists)
+		n = int64(exists)
+	}
+	return n, err
+}
+
+// DeletePriceGroup удаляет группу цен; её покупатели переходят на цены продажи.
+func DeletePriceGroup(db *sql.DB, id int) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("UPDATE users SET price_group_id = NULL WHERE price_group_id = ?", id); err != nil {
+		return 0, err
+	}
+	n, err := rowsAffected(tx.Exec("DELETE FROM price_groups WHERE price_group_id = ?", id))
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// priceGroupExists проверяет наличие группы цен; возвращает sql.ErrNoRows, если её нет.
+func priceGroupExists(db *sql.DB, id int) error {
+	var exists int
+	if err := db.QueryRow("SELECT COUNT(*) FROM price_groups WHERE price_group_id = ?", id).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetPriceGroupCategory задаёт наценку группы для запчастей категории.
+// Возвращает sql.ErrNoRows, если нет группы или категории.
+func SetPriceGroupCategory(db *sql.DB, groupID, categoryID int, markup float64) error {
+	if err := validatePriceGroupMarkup(markup); err != nil {
+		return err
+	}
+	if err := priceGroupExists(db, groupID); err != nil {
+		return err
+	}
+	if ok, err := categoryLevel.liveExists(db, categoryID); err != nil || !ok {
+		if err == nil {
+			err = sql.ErrNoRows
+		}
+		return err
+	}
+	_, err := db.Exec("INSERT INTO price_group_categories (price_group_id, category_id, markup) VALUES (?, ?, ?) "+
+		"ON DUPLICATE KEY UPDATE markup = VALUES(markup)", groupID, categoryID, markup)
+	return err
+}
+
+// DeletePriceGroupCategory снимает наценку группы для категории.
+func DeletePriceGroupCategory(db *sql.DB, groupID, categoryID int) (int64, error) {
+	return rowsAffected(db.Exec("DELETE FROM price_group_categories WHERE price_group_id = ? AND category_id = ?", groupID, categoryID))
+}
+
+// SetPriceGroupPartPrice задаёт явную цену запчасти для группы.
+// Возвращает sql.ErrNoRows, если нет группы или запчасти.
+func SetPriceGroupPartPrice(db *sql.DB, groupID, partID int, price float64) error {
+	if price < 0 {
+		return ErrPrice
+	}
+	if err := priceGroupExists(db, groupID); err != nil {
+		return err
+	}
+	if _, err := GetPartListItem(db, partID); err != nil {
+		return err
+	}
+	_, err := db.Exec("INSERT INTO price_group_parts (price_group_id, part_id, price) VALUES (?, ?, ?) "+
+		"ON DUPLICATE KEY UPDATE price = VALUES(price)", groupID, partID, price)
+	return err
+}
+
+// DeletePriceGroupPartPrice снимает явную цену запчасти для группы.
+func DeletePriceGroupPartPrice(db *sql.DB, groupID, partID int) (int64, error) {
+	return rowsAffected(db.Exec("DELETE FROM price_group_parts WHERE price_group_id = ? AND part_id = ?", groupID, partID))
+}
+
+// SetUserPriceGroup назначает покупателю группу цен; groupID = 0 снимает группу.
+// Возвращает sql.ErrNoRows, если нет пользователя или группы.
+func SetUserPriceGroup(db *sql.DB, userID, groupID int) error {
+	var group interface{}
+	if groupID > 0 {
+		if err := priceGroupExists(db, groupID); err != nil {
+			return err
+		}
+		group = groupID
+	}
+	var exists int
+	if err := db.QueryRow("SELECT COUNT(*) FROM users WHERE id = ?", userID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return sql.ErrNoRows
+	}
+	_, err := db.Exec("UPDATE users SET price_group_id = ? WHERE id = ?", group, userID)
+	return err
+}
+
+// GetUserPriceGroupID возвращает группу цен пользователя; 0 – группа не назначена.
+func GetUserPriceGroupID(db *sql.DB, userID int) (int, error) {
+	var groupID sql.NullInt64
+	err := db.QueryRow("SELECT price_group_id FROM users WHERE id = ?", userID).Scan(&groupID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return int(groupID.Int64), err
+}
+
+// priceGroupPriceSQL – цена запчасти p для группы: явная цена, иначе цена продажи с наценкой
+// категории или группы, округлённая до копеек. Параметры – ID группы три раза.
+// Правило совпадает с PriceGroupPricing.Price.
+const priceGroupPriceSQL = "COALESCE(" +
+	"(SELECT gp.price FROM price_group_parts gp WHERE gp.price_group_id = ? AND gp.part_id = p.part_id), " +
+	"ROUND(p.price * (100 + COALESCE(" +
+	"(SELECT gc.markup FROM price_group_categories gc JOIN subcategories gs ON gs.category_id = gc.category_id " +
+	"WHERE gc.price_group_id = ? AND gs.subcategory_id = p.subcategory_id), " +
+	"(SELECT g.markup FROM price_groups g WHERE g.price_group_id = ?), 0)) / 100, 2))"
+
+// PriceGroupPricing рассчитывает цены запчастей для одной группы цен.
+// Значение nil означает покупателя без группы: цены не меняются.
+type PriceGroupPricing struct {
+	GroupID       int
+	markup        Decimal
+	bySubcategory map[int]Decimal // наценки категорий, развёрнутые по подкатегориям
+	byPart        map[int]float64 // явные цены запчастей
+}
+
+// LoadPriceGroupPricing загружает наценки и явные цены группы. Для groupID = 0
+// и удалённой группы возвращает nil.
+func LoadPriceGroupPricing(db *sql.DB, groupID int) (*PriceGroupPricing, error) {
+	if groupID <= 0 {
+		return nil, nil
+	}
+	p := &PriceGroupPricing{GroupID: groupID, bySubcategory: map[int]Decimal{}, byPart: map[int]float64{}}
+	var markup float64
+	err := db.QueryRow("SELECT markup FROM price_groups WHERE price_group_id = ?", groupID).Scan(&markup)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.markup = DecimalFromFloat(markup)
+
+	rows, err := db.Query("SELECT s.subcategory_id, gc.markup FROM price_group_categories gc "+
+		"JOIN subcategories s ON s.category_id = gc.category_id WHERE gc.price_group_id = ?", groupID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var subcategoryID int
+		if err := rows.Scan(&subcategoryID, &markup); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		p.bySubcategory[subcategoryID] = DecimalFromFloat(markup)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query("SELECT part_id, price FROM price_group_parts WHERE price_group_id = ?", groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var partID int
+		var price float64
+		if err := rows.Scan(&partID, &price); err != nil {
+			return nil, err
+		}
+		p.byPart[partID] = price
+	}
+	return p, rows.Err()
+}
+
+// Price возвращает цену запчасти для группы по цене продажи listPrice.
+func (p *PriceGroupPricing) Price(partID, subcategoryID int, listPrice float64) float64 {
+	if p == nil {
+		return listPrice
+	}
+	if price, ok := p.byPart[partID]; ok {
+		return price
+	}
+	markup, ok := p.bySubcategory[subcategoryID]
+	if !ok {
+		markup = p.markup
+	}
+	hundred := DecimalFromFloat(100)
+	price := DecimalFromFloat(listPrice).Mul(hundred.Add(markup)).Quo(hundred)
+	return price.Round(DecimalFromFloat(0.01), RoundHalfUp).Float64()
+}
+
+// apply заменяет цену запчасти ценой группы и возвращает прежнюю цену, если она изменилась.
+func (p *PriceGroupPricing) apply(partID, subcategoryID int, price *float64) *float64 {
+	groupPrice := p.Price(partID, subcategoryID, *price)
+	if groupPrice == *price {
+		return nil
+	}
+	listPrice := *price
+	*price = groupPrice
+	return &listPrice
+}
+
+// ApplyToItem заменяет цену запчасти ценой группы; прежняя цена сохраняется в ListPrice.
+func (p *PriceGroupPricing) ApplyToItem(item *PartListItem) {
+	if p == nil {
+		return
+	}
+	item.ListPrice = p.apply(item.ID, item.SubcategoryID, &item.Price)
+}
+
+// ApplyTo заменяет цены списка запчастей ценами группы.
+func (p *PriceGroupPricing) ApplyTo(items []PartListItem) {
+	for i := range items {
+		p.ApplyToItem(&items[i])
+	}
+}
+
+// ApplyToSearch заменяет цены результатов поиска ценами группы.
+func (p *PriceGroupPricing) ApplyToSearch(results []PartSearchResult) {
+	if p == nil {
+		return
+	}
+	for i := range results {
+		results[i].ListPrice = p.apply(results[i].ID, results[i].SubcategoryID, &results[i].Price)
+	}
+}
+
+// ApplyToKit заменяет цены компонентов комплекта ценами группы.
+func (p *PriceGroupPricing) ApplyToKit(kit *Kit) {
+	if p == nil || kit == nil {
+		return
+	}
+	for i := range kit.Components {
+		c := &kit.Components[i]
+		c.Price = p.Price(c.PartID, c.SubcategoryID, c.Price)
+	}
+}
@@ -0,0 +1,255 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"AutoM/config"
+	"AutoM/models"
+)
+
+// sessionPricing возвращает расчёт цен для группы цен вошедшего покупателя.
+// Для гостей, покупателей без группы и при ошибке возвращается nil – цены продажи.
+func sessionPricing(r *http.Request) *models.PriceGroupPricing {
+	session, _ := config.Store.Get(r, "session")
+	userID, ok := session.Values["user_id"].(int)
+	if !ok || userID <= 0 {
+		return nil
+	}
+	groupID, err := models.GetUserPriceGroupID(config.DB, userID)
+	if err != nil {
+		log.Printf("[ERROR] Ошибка получения группы цен пользователя %d: %v", userID, err)
+		return nil
+	}
+	pricing, err := models.LoadPriceGroupPricing(config.DB, groupID)
+	if err != nil {
+		log.Printf("[ERROR] Ошибка загрузки группы цен %d: %v", groupID, err)
+		return nil
+	}
+	return pricing
+}
+
+// respondPriceGroupError отвечает статусом ошибки изменения группы цен;
+// notFound – сообщение для sql.ErrNoRows.
+func respondPriceGroupError(w http.ResponseWriter, handler, notFound string, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, notFound, http.StatusNotFound)
+	case errors.Is(err, models.ErrPriceGroupName), errors.Is(err, models.ErrPriceGroupMarkup), errors.Is(err, models.ErrPrice):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, models.ErrPriceGroupExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("[ERROR] %s: %v", handler, err)
+		http.Error(w, "Ошибка сохранения группы цен", http.StatusInternalServerError)
+	}
+}
+
+// GetPriceGroups – список групп цен (API).
+func GetPriceGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := models.GetPriceGroups(config.DB)
+	if err != nil {
+		log.Printf("[ERROR] GetPriceGroups: ошибка получения групп цен: %v", err)
+		http.Error(w, "Ошибка получения групп цен", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetPriceGroups", groups)
+}
+
+// GetPriceGroup – группа цен с наценками по категориям и явными ценами запчастей (API).
+func GetPriceGroup(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "GetPriceGroup")
+	if !ok {
+		return
+	}
+	group, err := models.GetPriceGroup(config.DB, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Группа цен не найдена", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] GetPriceGroup: ошибка получения группы цен %d: %v", id, err)
+		http.Error(w, "Ошибка получения группы цен", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetPriceGroup", group)
+}
+
+// AddPriceGroup – создание группы цен (API). Ожидается JSON {"name": "Опт", "markup": -10}.
+func AddPriceGroup(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	var group models.PriceGroup
+	if !decodeJSON(w, r, "AddPriceGroup", &group) {
+		return
+	}
+	id, err := models.AddPriceGroup(config.DB, group)
+	if err != nil {
+		respondPriceGroupError(w, "AddPriceGroup", "Группа цен не найдена", err)
+		return
+	}
+	log.Printf("[INFO] AddPriceGroup: группа цен %d '%s' создана", id, group.Name)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, "AddPriceGroup", map[string]int64{"id": id})
+}
+
+// UpdatePriceGroup – изменение названия и наценки группы цен (API).
+func UpdatePriceGroup(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "UpdatePriceGroup")
+	if !ok {
+		return
+	}
+	var group models.PriceGroup
+	if !decodeJSON(w, r, "UpdatePriceGroup", &group) {
+		return
+	}
+	group.ID = id
+	rowsAffected, err := models.UpdatePriceGroup(config.DB, group)
+	if err != nil {
+		respondPriceGroupError(w, "UpdatePriceGroup", "Группа цен не найдена", err)
+		return
+	}
+	respondAffected(w, "UpdatePriceGroup", "Группа цен не найдена", rowsAffected, nil)
+}
+
+// DeletePriceGroup – удаление группы цен (API); её покупатели переходят на цены продажи.
+func DeletePriceGroup(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "DeletePriceGroup")
+	if !ok {
+		return
+	}
+	rowsAffected, err := models.DeletePriceGroup(config.DB, id)
+	respondAffected(w, "DeletePriceGroup", "Группа цен не найдена", rowsAffected, err)
+}
+
+// SetPriceGroupCategory – наценка группы цен для категории (API). Ожидается JSON {"markup": -15}.
+func SetPriceGroupCategory(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "SetPriceGroupCategory")
+	if !ok {
+		return
+	}
+	categoryID, ok := pathInt(w, r, "categoryId", "SetPriceGroupCategory")
+	if !ok {
+		return
+	}
+	var req struct {
+		Markup float64 `json:"markup"`
+	}
+	if !decodeJSON(w, r, "SetPriceGroupCategory", &req) {
+		return
+	}
+	if err := models.SetPriceGroupCategory(config.DB, id, categoryID, req.Markup); err != nil {
+		respondPriceGroupError(w, "SetPriceGroupCategory", "Группа цен или категория не найдена", err)
+		return
+	}
+	log.Printf("[INFO] SetPriceGroupCategory: группа цен %d, категория %d: наценка %.2f%%", id, categoryID, req.Markup)
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeletePriceGroupCategory – снятие наценки группы цен для категории (API).
+func DeletePriceGroupCategory(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "DeletePriceGroupCategory")
+	if !ok {
+		return
+	}
+	categoryID, ok := pathInt(w, r, "categoryId", "DeletePriceGroupCategory")
+	if !ok {
+		return
+	}
+	rowsAffected, err := models.DeletePriceGroupCategory(config.DB, id, categoryID)
+	respondAffected(w, "DeletePriceGroupCategory", "Наценка категории не найдена", rowsAffected, err)
+}
+
+// SetPriceGroupPartPrice – явная цена запчасти для группы цен (API). Ожидается JSON {"price": 1450}.
+func SetPriceGroupPartPrice(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "SetPriceGroupPartPrice")
+	if !ok {
+		return
+	}
+	partID, ok := pathInt(w, r, "partId", "SetPriceGroupPartPrice")
+	if !ok {
+		return
+	}
+	var req struct {
+		Price float64 `json:"price"`
+	}
+	if !decodeJSON(w, r, "SetPriceGroupPartPrice", &req) {
+		return
+	}
+	if err := models.SetPriceGroupPartPrice(config.DB, id, partID, req.Price); err != nil {
+		respondPriceGroupError(w, "SetPriceGroupPartPrice", "Группа цен или запчасть не найдена", err)
+		return
+	}
+	log.Printf("[INFO] SetPriceGroupPartPrice: группа цен %d, запчасть %d: цена %.2f", id, partID, req.Price)
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeletePriceGroupPartPrice – снятие явной цены запчасти для группы цен (API).
+func DeletePriceGroupPartPrice(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "DeletePriceGroupPartPrice")
+	if !ok {
+		return
+	}
+	partID, ok := pathInt(w, r, "partId", "DeletePriceGroupPartPrice")
+	if !ok {
+		return
+	}
+	rowsAffected, err := models.DeletePriceGroupPartPrice(config.DB, id, partID)
+	respondAffected(w, "DeletePriceGroupPartPrice", "Цена запчасти для группы не найдена", rowsAffected, err)
+}
+
+// SetUserPriceGroup – назначение покупателю группы цен (API).
+// Ожидается JSON {"price_group_id": 2}; null или 0 снимает группу.
+func SetUserPriceGroup(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "SetUserPriceGroup")
+	if !ok {
+		return
+	}
+	var req struct {
+		PriceGroupID *int `json:"price_group_id"`
+	}
+	if !decodeJSON(w, r, "SetUserPriceGroup", &req) {
+		return
+	}
+	groupID := 0
+	if req.PriceGroupID != nil {
+		groupID = *req.PriceGroupID
+	}
+	err := models.SetUserPriceGroup(config.DB, id, groupID)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Пользователь или группа цен не найдены", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] SetUserPriceGroup: ошибка назначения группы цен пользователю %d: %v", id, err)
+		http.Error(w, "Ошибка назначения группы цен", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("[INFO] SetUserPriceGroup: пользователю %d назначена группа цен %d", id, groupID)
+	w.WriteHeader(http.StatusOK)
+}
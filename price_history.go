@@ -0,0 +1,204 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Источники изменения цены запчасти.
+const (
+	PriceSourceAPI       = "api"       // изменение запчасти через API (UpdatePart)
+	PriceSourceCreate    = "create"    // начальная цена при добавлении запчасти через API
+	PriceSourceImport    = "import"    // импорт прайса; Batch – идентификатор загрузки
+	PriceSourceBulk      = "bulk"      // массовое изменение цен; Batch – идентификатор операции
+	PriceSourceKit       = "kit"       // пересчёт цены комплекта по компонентам
+	PriceSourceCurrency  = "currency"  // пересчёт из базовой цены в валюте по курсу
+	PriceSourcePriceList = "pricelist" // прайс-лист поставщика по формуле наценки; Batch – идентификатор загрузки
+)
+
+// Ошибки изменения цен.
+var (
+	ErrPrice      = errors.New("цена должна быть неотрицательным числом")
+	ErrBulkPrices = errors.New("не указаны цены для изменения")
+)
+
+// PriceSource описывает, откуда пришло изменение цены.
+type PriceSource struct {
+	Source   string // одна из констант PriceSource*
+	Batch    string // идентификатор загрузки или массовой операции
+	Username string // пользователь, выполнивший изменение
+}
+
+// NewPriceBatch возвращает идентификатор пакета изменений цен, например "import-20250311-154500".
+func NewPriceBatch(source string) string {
+	return fmt.Sprintf("%s-%s", source, time.Now().Format("20060102-150405"))
+}
+
+// PriceChange – запись истории цены запчасти.
+type PriceChange struct {
+	ID       int       `json:"id"`
+	PartID   int       `json:"part_id"`
+	OldPrice *float64  `json:"old_price"` // nil – начальная цена запчасти
+	NewPrice float64   `json:"new_price"`
+	Source   string    `json:"source"`
+	Batch    string    `json:"batch,omitempty"`
+	Username string    `json:"username,omitempty"`
+	CreateAt time.Time `json:"create_at"`
+}
+
+// PriceUpdate – новая цена запчасти в массовом изменении.
+type PriceUpdate struct {
+	PartID int     `json:"part_id"`
+	Price  float64 `json:"price"`
+}
+
+// PriceSkip – запчасть, цена которой не изменена массовым изменением, и причина.
+type PriceSkip struct {
+	PartID int    `json:"part_id"`
+	Reason string `json:"reason"`
+}
+
+// BulkPriceResult – итог массового изменения цен.
+type BulkPriceResult struct {
+	Changed int         `json:"changed"`
+	Batch   string      `json:"batch"`
+	Skipped []PriceSkip `json:"skipped"`
+}
+
+// migratePriceHistory создаёт таблицу истории цен запчастей.
+func migratePriceHistory(db *sql.DB) error {
+	return execAll(db,
+		"CREATE TABLE IF NOT EXISTS part_price_history ("+
+			"history_id INT AUTO_INCREMENT PRIMARY KEY, "+
+			"part_id INT NOT NULL, "+
+			"old_price DECIMAL(12,2) NULL, "+
+			"new_price DECIMAL(12,2) NOT NULL, "+
+			"source VARCHAR(16) NOT NULL, "+
+			"batch VARCHAR(64) NOT NULL DEFAULT '', "+
+			"username VARCHAR(100) NOT NULL DEFAULT '', "+
+			"create_at DATETIME NOT NULL, "+
+			"KEY idx_price_history_part (part_id, create_at), "+
+			"KEY idx_price_history_batch (batch)"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+	)
+}
+
+// RecordPriceChange записывает изменение цены запчасти. oldPrice = nil – начальная цена;
+// если цена не изменилась, запись не создаётся.
+func RecordPriceChange(db dbExecutor, partID int, oldPrice *float64, newPrice float64, src PriceSource) error {
+	if oldPrice != nil && *oldPrice == newPrice {
+		return nil
+	}
+	query := "INSERT INTO part_price_history (part_id, old_price, new_price, source, batch, username, create_at) " +
+		"VALUES (?, ?, ?, ?, ?, ?, NOW())"
+	_, err := db.Exec(query, partID, oldPrice, newPrice, src.Source, src.Batch, src.Username)
+	return err
+}
+
+// GetPartPrice возвращает текущую цену запчасти (в том числе находящейся в корзине).
+func GetPartPrice(db dbExecutor, partID int) (float64, error) {
+	var price float64
+	err := db.QueryRow("SELECT price FROM parts WHERE part_id = ?", partID).Scan(&price)
+	return price, err
+}
+
+// GetPriceHistory возвращает историю цены запчасти в хронологическом порядке.
+// Нулевые from и to не ограничивают период.
+func GetPriceHistory(db *sql.DB, partID int, from, to time.Time) ([]PriceChange, error) {
+	query := "SELECT history_id, part_id, old_price, new_price, source, batch, username, create_at " +
+		"FROM part_price_history WHERE part_id = ?"
+	args := []interface{}{partID}
+	if !from.IsZero() {
+		query += " AND create_at >= ?"
+		args = append(args, from)
+	}
+	if !to.IsZero() {
+		query += " AND create_at < ?"
+		args = append(args, to)
+	}
+	rows, err := db.Query(query+" ORDER BY create_at, history_id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []PriceChange{}
+	for rows.Next() {
+		var c PriceChange
+		if err := rows.Scan(&c.ID, &c.PartID, &c.OldPrice, &c.NewPrice, &c.Source, &c.Batch, &c.Username, &c.CreateAt); err != nil {
+			return nil, err
+		}
+		history = append(history, c)
+	}
+	return history, rows.Err()
+}
+
+// BulkUpdatePrices меняет цены нескольких запчастей в одной транзакции и записывает
+// изменения в историю под общим идентификатором пакета. Запчасти, которых нет или
+// которые в корзине, пропускаются. Пропускаются и запчасти с расчётной ценой – комплекты,
+// цена которых складывается из компонентов, и запчасти с базовой ценой в валюте: их цену
+// перезаписал бы следующий пересчёт; такие запчасти перечисляются в Skipped.
+func BulkUpdatePrices(db *sql.DB, updates []PriceUpdate, username string) (BulkPriceResult, error) {
+	if len(updates) == 0 {
+		return BulkPriceResult{}, ErrBulkPrices
+	}
+	for _, u := range updates {
+		if u.Price < 0 {
+			return BulkPriceResult{}, ErrPrice
+		}
+	}
+	src := PriceSource{Source: PriceSourceBulk, Batch: NewPriceBatch(PriceSourceBulk), Username: username}
+	log.Printf("[INFO] BulkUpdatePrices: пакет %s, цен: %d", src.Batch, len(updates))
+
+	tx, err := db.Begin()
+	if err != nil {
+		return BulkPriceResult{}, err
+	}
+	defer tx.Rollback()
+
+	result := BulkPriceResult{Batch: src.Batch, Skipped: []PriceSkip{}}
+	ids := make([]int, 0, len(updates))
+	query := "SELECT p.price, p.base_price IS NOT NULL, " +
+		"EXISTS(SELECT 1 FROM part_kits k WHERE k.part_id = p.part_id AND k.pricing = ?) " +
+		"FROM parts p WHERE p.part_id = ? AND p.deleted_at IS NULL FOR UPDATE"
+	for _, u := range updates {
+		var old float64
+		var hasBase, kit bool
+		err := tx.QueryRow(query, KitPricingComponents, u.PartID).Scan(&old, &hasBase, &kit)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return BulkPriceResult{}, err
+		}
+		switch {
+		case kit:
+			result.Skipped = append(result.Skipped, PriceSkip{PartID: u.PartID, Reason: "цена комплекта складывается из цен компонентов"})
+			continue
+		case hasBase:
+			result.Skipped = append(result.Skipped, PriceSkip{PartID: u.PartID, Reason: "цена продажи задаётся базовой ценой в валюте"})
+			continue
+		case old == u.Price:
+			continue
+		}
+		if _, err := tx.Exec("UPDATE parts SET price = ?, update_at = NOW() WHERE part_id = ?", u.Price, u.PartID); err != nil {
+			return BulkPriceResult{}, err
+		}
+		if err := RecordPriceChange(tx, u.PartID, &old, u.Price, src); err != nil {
+			return BulkPriceResult{}, err
+		}
+		result.Changed++
+		ids = append(ids, u.PartID)
+	}
+	if err := RefreshKitsFor(tx, ids...); err != nil {
+		return BulkPriceResult{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return BulkPriceResult{}, err
+	}
+	InvalidateCatalogTree()
+	return result, nil
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"AutoM/config"
+	"AutoM/models"
+)
+
+// queryDate разбирает необязательный GET-параметр с датой в формате 2006-01-02.
+// Если параметр не задан, возвращается нулевое время.
+func queryDate(r *http.Request, name string) (time.Time, error) {
+	val := strings.TrimSpace(r.URL.Query().Get(name))
+	if val == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, val, time.Local)
+	if err != nil {
+		return time.Time{}, errors.New("неверная дата в параметре " + name + ", ожидается ГГГГ-ММ-ДД")
+	}
+	return t, nil
+}
+
+// GetPartPriceHistory – история цены запчасти (API).
+// Параметры from и to (ГГГГ-ММ-ДД) ограничивают период; to включается целиком.
+func GetPartPriceHistory(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "GetPartPriceHistory")
+	if !ok {
+		return
+	}
+	from, err := queryDate(r, "from")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := queryDate(r, "to")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !to.IsZero() {
+		to = to.AddDate(0, 0, 1)
+	}
+	if !partExists(w, "GetPartPriceHistory", id) {
+		return
+	}
+	history, err := models.GetPriceHistory(config.DB, id, from, to)
+	if err != nil {
+		log.Printf("[ERROR] GetPartPriceHistory: ошибка получения истории цены запчасти %d: %v", id, err)
+		http.Error(w, "Ошибка получения истории цены", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetPartPriceHistory", history)
+}
+
+// BulkUpdatePrices – массовое изменение цен (API).
+// Ожидается JSON {"prices": [{"part_id": 1, "price": 990}, ...]}; в ответе skipped – запчасти
+// с расчётной ценой, которые не изменены.
+func BulkUpdatePrices(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	var req struct {
+		Prices []models.PriceUpdate `json:"prices"`
+	}
+	if !decodeJSON(w, r, "BulkUpdatePrices", &req) {
+		return
+	}
+	result, err := models.BulkUpdatePrices(config.DB, req.Prices, sessionUsername(r))
+	if errors.Is(err, models.ErrPrice) || errors.Is(err, models.ErrBulkPrices) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] BulkUpdatePrices: ошибка изменения цен: %v", err)
+		http.Error(w, "Ошибка изменения цен", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("[INFO] BulkUpdatePrices: пакет %s, изменено цен: %d, пропущено: %d", result.Batch, result.Changed, len(result.Skipped))
+	writeJSON(w, "BulkUpdatePrices", result)
+}
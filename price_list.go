@@ -0,0 +1,401 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Ошибки профилей прайс-листов поставщиков.
+var (
+	ErrPriceProfileName      = errors.New("название профиля не может быть пустым")
+	ErrPriceProfileExists    = errors.New("профиль с таким названием уже существует")
+	ErrPriceProfileColumns   = errors.New("столбцы указываются буквой (A, B, …, AA) или номером; столбцы артикула и цены обязательны")
+	ErrPriceProfileField     = errors.New("название, шаблон файла, лист, бренд и формула наценки не длиннее 100 символов")
+	ErrPriceProfilePattern   = errors.New("неверный шаблон имени файла; используйте * и ?, например avtodetal_*.xlsx")
+	ErrPriceProfileSeparator = errors.New("разделитель дробной части – запятая или точка (пусто – определять автоматически)")
+	ErrPriceProfileDelimiter = errors.New("разделитель столбцов CSV – один символ")
+	ErrPriceProfileHeader    = errors.New("число строк заголовка от 0 до 100")
+	ErrMarkupFormula         = errors.New("неверная формула наценки")
+)
+
+// PriceListProfile – правила разбора прайс-листа поставщика: где искать файл во входящем
+// каталоге, какие столбцы читать, как разбирать числа и валюту, как считать цену продажи
+// и что делать с артикулами, которых нет в каталоге.
+type PriceListProfile struct {
+	ID                  int       `json:"id"`
+	SupplierID          int       `json:"supplier_id"`
+	Supplier            string    `json:"supplier"`
+	Name                string    `json:"name"`
+	FilePattern         string    `json:"file_pattern"`      // шаблон имени файла во входящем каталоге; пусто – только ручная загрузка
+	Sheet               string    `json:"sheet"`             // лист XLSX; пусто – первый
+	HeaderRows          int       `json:"header_rows"`       // строк заголовка перед данными
+	Delimiter           string    `json:"delimiter"`         // разделитель столбцов CSV; пусто – ";"
+	DecimalSeparator    string    `json:"decimal_separator"` // "," или "."; пусто – определять по числу
+	Currency            string    `json:"currency"`          // валюта цен; пусто – валюта поставщика
+	ArticleColumn       string    `json:"article_column"`
+	BrandColumn         string    `json:"brand_column"`
+	NameColumn          string    `json:"name_column"`
+	PriceColumn         string    `json:"price_column"`
+	CurrencyColumn      string    `json:"currency_column"` // валюта строки, если прайс многовалютный
+	DefaultBrand        string    `json:"default_brand"`   // бренд строк без столбца бренда
+	MatchBrand          bool      `json:"match_brand"`     // артикул сопоставляется только с запчастью того же бренда
+	Markup              string    `json:"markup"`          // формула наценки, см. ParseMarkup
+	CreateSubcategoryID *int      `json:"create_subcategory_id"`
+	UpdateAt            time.Time `json:"update_at"`
+}
+
+// migratePriceListProfiles создаёт таблицы профилей прайс-листов и журнала их загрузок.
+func migratePriceListProfiles(db *sql.DB) error {
+	return execAll(db,
+		"CREATE TABLE IF NOT EXISTS price_list_profiles ("+
+			"profile_id INT AUTO_INCREMENT PRIMARY KEY, "+
+			"supplier_id INT NOT NULL, "+
+			"name VARCHAR(100) NOT NULL, "+
+			"file_pattern VARCHAR(100) NOT NULL DEFAULT '', "+
+			"sheet VARCHAR(100) NOT NULL DEFAULT '', "+
+			"header_rows INT NOT NULL DEFAULT 1, "+
+			"delimiter VARCHAR(4) NOT NULL DEFAULT ';', "+
+			"decimal_separator VARCHAR(1) NOT NULL DEFAULT '', "+
+			"currency CHAR(3) NOT NULL DEFAULT '', "+
+			"article_column VARCHAR(4) NOT NULL, "+
+			"brand_column VARCHAR(4) NOT NULL DEFAULT '', "+
+			"name_column VARCHAR(4) NOT NULL DEFAULT '', "+
+			"price_column VARCHAR(4) NOT NULL, "+
+			"currency_column VARCHAR(4) NOT NULL DEFAULT '', "+
+			"default_brand VARCHAR(100) NOT NULL DEFAULT '', "+
+			"match_brand TINYINT(1) NOT NULL DEFAULT 0, "+
+			"markup VARCHAR(100) NOT NULL DEFAULT '', "+
+			"create_subcategory_id INT NULL, "+
+			"update_at DATETIME NOT NULL, "+
+			"UNIQUE KEY uq_price_list_profile_name (name), "+
+			"KEY idx_price_list_profiles_supplier (supplier_id)"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"CREATE TABLE IF NOT EXISTS price_list_runs ("+
+			"run_id INT AUTO_INCREMENT PRIMARY KEY, "+
+			"profile_id INT NOT NULL, "+
+			"file_name VARCHAR(255) NOT NULL, "+
+			"source VARCHAR(16) NOT NULL, "+
+			"username VARCHAR(100) NOT NULL DEFAULT '', "+
+			"status VARCHAR(16) NOT NULL, "+
+			"error VARCHAR(255) NOT NULL DEFAULT '', "+
+			"rows_total INT NOT NULL DEFAULT 0, "+
+			"updated INT NOT NULL DEFAULT 0, "+
+			"unchanged INT NOT NULL DEFAULT 0, "+
+			"created INT NOT NULL DEFAULT 0, "+
+			"unmatched INT NOT NULL DEFAULT 0, "+
+			"skipped INT NOT NULL DEFAULT 0, "+
+			"report MEDIUMTEXT NULL, "+
+			"create_at DATETIME NOT NULL, "+
+			"KEY idx_price_list_runs_profile (profile_id, create_at)"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+	)
+}
+
+// columnIndex переводит обозначение столбца ("A", "ab", "3") в индекс с нуля; пусто – -1.
+func columnIndex(spec string) (int, error) {
+	spec = strings.ToUpper(strings.TrimSpace(spec))
+	if spec == "" {
+		return -1, nil
+	}
+	if n, err := strconv.Atoi(spec); err == nil {
+		if n < 1 || n > 16384 {
+			return 0, ErrPriceProfileColumns
+		}
+		return n - 1, nil
+	}
+	if len(spec) > 3 {
+		return 0, ErrPriceProfileColumns
+	}
+	n := 0
+	for _, r := range spec {
+		if r < 'A' || r > 'Z' {
+			return 0, ErrPriceProfileColumns
+		}
+		n = n*26 + int(r-'A'+1)
+	}
+	return n - 1, nil
+}
+
+// Validate обрезает пробелы и проверяет профиль, включая формулу наценки.
+func (p *PriceListProfile) Validate() error {
+	for _, f := range []*string{&p.Name, &p.FilePattern, &p.Sheet, &p.DecimalSeparator, &p.DefaultBrand, &p.Markup} {
+		*f = strings.TrimSpace(*f)
+	}
+	p.Currency = strings.ToUpper(strings.TrimSpace(p.Currency))
+	if p.Delimiter == "" {
+		p.Delimiter = ";"
+	}
+	if p.Delimiter == `\t` {
+		p.Delimiter = "\t"
+	}
+	columns := []*string{&p.ArticleColumn, &p.BrandColumn, &p.NameColumn, &p.PriceColumn, &p.CurrencyColumn}
+	for _, c := range columns {
+		*c = strings.ToUpper(strings.TrimSpace(*c))
+		if _, err := columnIndex(*c); err != nil {
+			return err
+		}
+	}
+	switch {
+	case p.Name == "":
+		return ErrPriceProfileName
+	case p.ArticleColumn == "" || p.PriceColumn == "":
+		return ErrPriceProfileColumns
+	case utf8.RuneCountInString(p.Name) > 100 || utf8.RuneCountInString(p.FilePattern) > 100 || utf8.RuneCountInString(p.Sheet) > 100 ||
+		utf8.RuneCountInString(p.DefaultBrand) > 100 || utf8.RuneCountInString(p.Markup) > 100:
+		return ErrPriceProfileField
+	case p.DecimalSeparator != "" && p.DecimalSeparator != "," && p.DecimalSeparator != ".":
+		return ErrPriceProfileSeparator
+	case utf8.RuneCountInString(p.Delimiter) != 1:
+		return ErrPriceProfileDelimiter
+	case p.HeaderRows < 0 || p.HeaderRows > 100:
+		return ErrPriceProfileHeader
+	case p.Currency != "" && !currencyCodePattern.MatchString(p.Currency):
+		return ErrCurrencyCode
+	}
+	if _, err := path.Match(strings.ToLower(p.FilePattern), ""); err != nil || strings.ContainsAny(p.FilePattern, `/\`) {
+		return ErrPriceProfilePattern
+	}
+	_, err := ParseMarkup(p.Markup)
+	return err
+}
+
+// MatchesFile сообщает, подходит ли имя файла входящего каталога под шаблон профиля (без учёта регистра).
+func (p PriceListProfile) MatchesFile(name string) bool {
+	if p.FilePattern == "" {
+		return false
+	}
+	ok, _ := path.Match(strings.ToLower(p.FilePattern), strings.ToLower(name))
+	return ok
+}
+
+// priceListProfileSelect – выборка профилей с названием поставщика.
+const priceListProfileSelect = "SELECT p.profile_id, p.supplier_id, COALESCE(s.name, ''), p.name, p.file_pattern, p.sheet, p.header_rows, " +
+	"p.delimiter, p.decimal_separator, p.currency, p.article_column, p.brand_column, p.name_column, p.price_column, " +
+	"p.currency_column, p.default_brand, p.match_brand, p.markup, p.create_subcategory_id, p.update_at " +
+	"FROM price_list_profiles p LEFT JOIN suppliers s ON s.supplier_id = p.supplier_id"
+
+// scanPriceListProfile читает строку priceListProfileSelect.
+func scanPriceListProfile(row interface{ Scan(...interface{}) error }) (PriceListProfile, error) {
+	var p PriceListProfile
+	err := row.Scan(&p.ID, &p.SupplierID, &p.Supplier, &p.Name, &p.FilePattern, &p.Sheet, &p.HeaderRows,
+		&p.Delimiter, &p.DecimalSeparator, &p.Currency, &p.ArticleColumn, &p.BrandColumn, &p.NameColumn, &p.PriceColumn,
+		&p.CurrencyColumn, &p.DefaultBrand, &p.MatchBrand, &p.Markup, &p.CreateSubcategoryID, &p.UpdateAt)
+	return p, err
+}
+
+// GetPriceListProfiles возвращает профили прайс-листов по поставщику и названию.
+func GetPriceListProfiles(db *sql.DB) ([]PriceListProfile, error) {
+	rows, err := db.Query(priceListProfileSelect + " ORDER BY s.name, p.name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profiles := []PriceListProfile{}
+	for rows.Next() {
+		p, err := scanPriceListProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, rows.Err()
+}
+
+// GetPriceListProfile возвращает профиль или sql.ErrNoRows.
+func GetPriceListProfile(db *sql.DB, id int) (PriceListProfile, error) {
+	return scanPriceListProfile(db.QueryRow(priceListProfileSelect+" WHERE p.profile_id = ?", id))
+}
+
+// SavePriceListProfile создаёт (p.ID = 0) или изменяет профиль прайс-листа. Возвращает ID профиля
+// или sql.ErrNoRows, если нет профиля, поставщика или подкатегории для новых запчастей.
+func SavePriceListProfile(db *sql.DB, p PriceListProfile) (int64, error) {
+	if err := p.Validate(); err != nil {
+		return 0, err
+	}
+	if _, err := GetSupplier(db, p.SupplierID); err != nil {
+		return 0, err
+	}
+	if p.Currency != "" {
+		if err := checkCurrencyRate(db, p.Currency); err != nil {
+			return 0, err
+		}
+	}
+	if p.CreateSubcategoryID != nil && *p.CreateSubcategoryID <= 0 {
+		p.CreateSubcategoryID = nil
+	}
+	if p.CreateSubcategoryID != nil {
+		if ok, err := subcategoryLevel.liveExists(db, *p.CreateSubcategoryID); err != nil || !ok {
+			if err == nil {
+				err = sql.ErrNoRows
+			}
+			return 0, err
+		}
+	}
+
+	args := []interface{}{p.SupplierID, p.Name, p.FilePattern, p.Sheet, p.HeaderRows, p.Delimiter, p.DecimalSeparator, p.Currency,
+		p.ArticleColumn, p.BrandColumn, p.NameColumn, p.PriceColumn, p.CurrencyColumn, p.DefaultBrand, p.MatchBrand, p.Markup,
+		p.CreateSubcategoryID}
+	if p.ID == 0 {
+		query := "INSERT INTO price_list_profiles (supplier_id, name, file_pattern, sheet, header_rows, delimiter, decimal_separator, " +
+			"currency, article_column, brand_column, name_column, price_column, currency_column, default_brand, match_brand, markup, " +
+			"create_subcategory_id, update_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW())"
+		res, err := db.Exec(query, args...)
+		if isDuplicateKey(err) {
+			return 0, ErrPriceProfileExists
+		}
+		if err != nil {
+			return 0, err
+		}
+		return res.LastInsertId()
+	}
+	query := "UPDATE price_list_profiles SET supplier_id = ?, name = ?, file_pattern = ?, sheet = ?, header_rows = ?, delimiter = ?, " +
+		"decimal_separator = ?, currency = ?, article_column = ?, brand_column = ?, name_column = ?, price_column = ?, " +
+		"currency_column = ?, default_brand = ?, match_brand = ?, markup = ?, create_subcategory_id = ?, update_at = NOW() " +
+		"WHERE profile_id = ?"
+	n, err := rowsAffected(db.Exec(query, append(args, p.ID)...))
+	if isDuplicateKey(err) {
+		return 0, ErrPriceProfileExists
+	}
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, sql.ErrNoRows
+	}
+	return int64(p.ID), nil
+}
+
+// DeletePriceListProfile удаляет профиль; журнал его загрузок сохраняется.
+func DeletePriceListProfile(db *sql.DB, id int) (int64, error) {
+	return rowsAffected(db.Exec("DELETE FROM price_list_profiles WHERE profile_id = ?", id))
+}
+
+// markupTier – ступень формулы наценки: для закупочной цены ниже Limit (nil – без ограничения)
+// цена продажи = закупка × (1 + Percent/100) + Fixed.
+type markupTier struct {
+	Limit   *Decimal
+	Percent Decimal
+	Fixed   Decimal
+}
+
+// Markup – формула наценки, разобранная ParseMarkup.
+type Markup []markupTier
+
+// ParseMarkup разбирает формулу наценки: ступени через ";", каждая –
+// "[граница:] процент% [+ надбавка]", суммы – в BaseCurrency. Например,
+// "500: 50% + 20; 2000: 35%; 25%" – закупка дешевле 500 ₽ продаётся с наценкой 50% и 20 ₽,
+// дешевле 2000 ₽ – 35%, остальное – 25%. Границы идут по возрастанию; если ступени
+// без границы нет, к дорогим позициям применяется последняя. Пустая формула – без наценки.
+func ParseMarkup(formula string) (Markup, error) {
+	var m Markup
+	if strings.TrimSpace(formula) == "" {
+		return m, nil
+	}
+	bad := func(part string) (Markup, error) {
+		return nil, fmt.Errorf("%w: %q", ErrMarkupFormula, strings.TrimSpace(part))
+	}
+	for _, part := range strings.Split(formula, ";") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		var tier markupTier
+		expr := part
+		if i := strings.Index(part, ":"); i >= 0 {
+			limit, err := ParseDecimal(part[:i])
+			if err != nil || limit.Sign() <= 0 {
+				return bad(part)
+			}
+			if len(m) > 0 && (m[len(m)-1].Limit == nil || m[len(m)-1].Limit.Cmp(limit) >= 0) {
+				return bad(part)
+			}
+			tier.Limit, expr = &limit, part[i+1:]
+		} else if len(m) > 0 && m[len(m)-1].Limit == nil {
+			return bad(part)
+		}
+		terms := strings.SplitN(expr, "+", 2)
+		percent := strings.TrimSpace(terms[0])
+		if !strings.HasSuffix(percent, "%") {
+			return bad(part)
+		}
+		var err error
+		if tier.Percent, err = ParseDecimal(strings.TrimSuffix(percent, "%")); err != nil || tier.Percent.Cmp(DecimalFromFloat(-100)) <= 0 {
+			return bad(part)
+		}
+		if len(terms) == 2 {
+			if tier.Fixed, err = ParseDecimal(terms[1]); err != nil || tier.Fixed.Sign() < 0 {
+				return bad(part)
+			}
+		}
+		m = append(m, tier)
+	}
+	return m, nil
+}
+
+// Apply возвращает цену продажи для закупочной цены cost без округления.
+func (m Markup) Apply(cost Decimal) Decimal {
+	if len(m) == 0 {
+		return cost
+	}
+	tier := m[len(m)-1]
+	for _, t := range m {
+		if t.Limit == nil || cost.Cmp(*t.Limit) < 0 {
+			tier = t
+			break
+		}
+	}
+	hundred := DecimalFromFloat(100)
+	return cost.Mul(hundred.Add(tier.Percent).Quo(hundred)).Add(tier.Fixed)
+}
+
+// currencySymbols – обозначения валют, встречающиеся в прайсах вместо кода ISO 4217.
+var currencySymbols = map[string]string{
+	"₽": "RUB", "Р": "RUB", "РУБ": "RUB", "РУБ.": "RUB", "RUR": "RUB",
+	"€": "EUR", "ЕВРО": "EUR", "$": "USD", "¥": "CNY", "ЮАНЬ": "CNY",
+}
+
+// parseCurrencyCell приводит обозначение валюты из прайса к коду ISO 4217.
+func parseCurrencyCell(s string) string {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if code, ok := currencySymbols[s]; ok {
+		return code
+	}
+	return s
+}
+
+// parsePriceCell разбирает цену из прайса: пробелы (в том числе неразрывные), апострофы
+// и обозначения валют отбрасываются; decimalSeparator – "," или "." (второй знак считается
+// разделителем разрядов), пусто – дробной частью считается часть после последнего
+// разделителя, если он один такой в числе.
+func parsePriceCell(s, decimalSeparator string) (Decimal, error) {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsDigit(r) || r == ',' || r == '.' || r == '-' {
+			b.WriteRune(r)
+		}
+	}
+	s = b.String()
+	sep := decimalSeparator
+	if sep == "" {
+		last := strings.LastIndexAny(s, ",.")
+		if last >= 0 && strings.Count(s, s[last:last+1]) == 1 {
+			sep = s[last : last+1]
+		}
+	}
+	switch sep {
+	case ",":
+		s = strings.Replace(strings.ReplaceAll(s, ".", ""), ",", ".", 1)
+	case ".":
+		s = strings.ReplaceAll(s, ",", "")
+	default:
+		s = strings.NewReplacer(",", "", ".", "").Replace(s)
+	}
+	return ParseDecimal(s)
+}
@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"AutoM/config"
+	"AutoM/models"
+)
+
+// respondPriceListError отвечает статусом ошибки профиля или загрузки прайс-листа;
+// notFound – сообщение для sql.ErrNoRows.
+func respondPriceListError(w http.ResponseWriter, handler, notFound string, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, notFound, http.StatusNotFound)
+	case errors.Is(err, models.ErrPriceProfileName), errors.Is(err, models.ErrPriceProfileColumns),
+		errors.Is(err, models.ErrPriceProfileField), errors.Is(err, models.ErrPriceProfilePattern),
+		errors.Is(err, models.ErrPriceProfileSeparator), errors.Is(err, models.ErrPriceProfileDelimiter),
+		errors.Is(err, models.ErrPriceProfileHeader), errors.Is(err, models.ErrMarkupFormula),
+		errors.Is(err, models.ErrCurrencyCode), errors.Is(err, models.ErrUnknownRate),
+		errors.Is(err, models.ErrPriceListFormat), errors.Is(err, models.ErrPriceListSheet),
+		errors.Is(err, models.ErrPriceListEmpty), errors.Is(err, models.ErrPriceListSize), errors.Is(err, models.ErrPriceListRead):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, models.ErrPriceProfileExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("[ERROR] %s: %v", handler, err)
+		http.Error(w, "Ошибка работы с прайс-листами", http.StatusInternalServerError)
+	}
+}
+
+// GetPriceListProfiles – профили прайс-листов поставщиков (API).
+func GetPriceListProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := models.GetPriceListProfiles(config.DB)
+	if err != nil {
+		log.Printf("[ERROR] GetPriceListProfiles: ошибка получения профилей: %v", err)
+		http.Error(w, "Ошибка получения профилей прайс-листов", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetPriceListProfiles", profiles)
+}
+
+// GetPriceListProfile – профиль прайс-листа по ID (API).
+func GetPriceListProfile(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "GetPriceListProfile")
+	if !ok {
+		return
+	}
+	profile, err := models.GetPriceListProfile(config.DB, id)
+	if err != nil {
+		respondPriceListError(w, "GetPriceListProfile", "Профиль прайс-листа не найден", err)
+		return
+	}
+	writeJSON(w, "GetPriceListProfile", profile)
+}
+
+// AddPriceListProfile – создание профиля прайс-листа (API). Ожидается JSON
+// {"supplier_id": 1, "name": "Автодеталь XLSX", "file_pattern": "avtodetal_*.xlsx", "sheet": "",
+// "header_rows": 1, "delimiter": ";", "decimal_separator": ",", "currency": "", "article_column": "A",
+// "brand_column": "B", "name_column": "C", "price_column": "E", "currency_column": "", "default_brand": "",
+// "match_brand": true, "markup": "500: 50% + 20; 2000: 35%; 25%", "create_subcategory_id": null}.
+func AddPriceListProfile(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	var profile models.PriceListProfile
+	if !decodeJSON(w, r, "AddPriceListProfile", &profile) {
+		return
+	}
+	profile.ID = 0
+	id, err := models.SavePriceListProfile(config.DB, profile)
+	if err != nil {
+		respondPriceListError(w, "AddPriceListProfile", "Поставщик или подкатегория не найдены", err)
+		return
+	}
+	log.Printf("[INFO] AddPriceListProfile: профиль прайс-листа %d '%s' создан", id, profile.Name)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, "AddPriceListProfile", map[string]int64{"id": id})
+}
+
+// UpdatePriceListProfile – изменение профиля прайс-листа (API).
+func UpdatePriceListProfile(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "UpdatePriceListProfile")
+	if !ok {
+		return
+	}
+	var profile models.PriceListProfile
+	if !decodeJSON(w, r, "UpdatePriceListProfile", &profile) {
+		return
+	}
+	profile.ID = id
+	if _, err := models.SavePriceListProfile(config.DB, profile); err != nil {
+		respondPriceListError(w, "UpdatePriceListProfile", "Профиль, поставщик или подкатегория не найдены", err)
+		return
+	}
+	log.Printf("[INFO] UpdatePriceListProfile: профиль прайс-листа %d изменён", id)
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeletePriceListProfile – удаление профиля прайс-листа; журнал загрузок сохраняется (API).
+func DeletePriceListProfile(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "DeletePriceListProfile")
+	if !ok {
+		return
+	}
+	n, err := models.DeletePriceListProfile(config.DB, id)
+	if err == nil && n == 0 {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		respondPriceListError(w, "DeletePriceListProfile", "Профиль прайс-листа не найден", err)
+		return
+	}
+	log.Printf("[INFO] DeletePriceListProfile: профиль прайс-листа %d удалён", id)
+	w.WriteHeader(http.StatusOK)
+}
+
+// ImportPriceList – загрузка прайс-листа поставщика по профилю (API). Ожидается multipart-форма
+// с файлом "file" (.xlsx, .xlsm, .csv, .txt); при dry_run=1 загрузка только проверяется
+// и не попадает в журнал. Возвращает запись журнала с отчётом.
+func ImportPriceList(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "ImportPriceList")
+	if !ok {
+		return
+	}
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		http.Error(w, "Ошибка обработки формы: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Ошибка получения файла: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	dryRun := r.FormValue("dry_run") == "1" || r.FormValue("dry_run") == "true"
+	log.Printf("[INFO] ImportPriceList: загружен прайс-лист %s для профиля %d, размер: %d байт, проверка: %t",
+		header.Filename, id, header.Size, dryRun)
+
+	run, err := models.ImportPriceList(config.DB, id, header.Filename, file,
+		models.PriceListImport{Source: models.PriceListUpload, Username: sessionUsername(r), DryRun: dryRun})
+	if err != nil {
+		respondPriceListError(w, "ImportPriceList", "Профиль прайс-листа не найден", err)
+		return
+	}
+	writeJSON(w, "ImportPriceList", run)
+}
+
+// GetPriceListRuns – журнал загрузок прайс-листов (API). Параметры profile_id и limit (по умолчанию 100).
+func GetPriceListRuns(w http.ResponseWriter, r *http.Request) {
+	profileID, err := queryInt(r, "profile_id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := queryInt(r, "limit")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	runs, err := models.GetPriceListRuns(config.DB, profileID, limit)
+	if err != nil {
+		log.Printf("[ERROR] GetPriceListRuns: ошибка получения журнала загрузок: %v", err)
+		http.Error(w, "Ошибка получения журнала загрузок прайс-листов", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetPriceListRuns", runs)
+}
+
+// GetPriceListRun – загрузка прайс-листа с отчётом по строкам (API).
+func GetPriceListRun(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "GetPriceListRun")
+	if !ok {
+		return
+	}
+	run, err := models.GetPriceListRun(config.DB, id)
+	if err != nil {
+		respondPriceListError(w, "GetPriceListRun", "Загрузка прайс-листа не найдена", err)
+		return
+	}
+	writeJSON(w, "GetPriceListRun", run)
+}
@@ -0,0 +1,717 @@
+package models
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Откуда пришёл прайс-лист.
+const (
+	PriceListUpload = "upload" // загружен администратором
+	PriceListInbox  = "inbox"  // найден во входящем каталоге
+)
+
+// Результаты загрузки прайс-листа.
+const (
+	PriceListRunOK     = "ok"
+	PriceListRunFailed = "failed"
+)
+
+// priceListMaxSize ограничивает размер прайс-листа.
+const priceListMaxSize = 50 << 20
+
+// inboxSettleTime – сколько файл во входящем каталоге должен пролежать без изменений,
+// чтобы считаться докопированным.
+const inboxSettleTime = 30 * time.Second
+
+// Ошибки загрузки прайс-листов.
+var (
+	ErrPriceListFormat = errors.New("поддерживаются прайс-листы .xlsx, .xlsm, .csv и .txt")
+	ErrPriceListSheet  = errors.New("в прайс-листе нет листа, указанного в профиле")
+	ErrPriceListEmpty  = errors.New("в прайс-листе нет строк с данными")
+	ErrPriceListSize   = errors.New("прайс-лист больше 50 МБ")
+	ErrPriceListRead   = errors.New("не удалось прочитать прайс-лист")
+)
+
+// PriceListRow – строка отчёта о загрузке прайс-листа.
+type PriceListRow struct {
+	Line     int      `json:"line"` // номер строки в файле
+	Article  string   `json:"article"`
+	Brand    string   `json:"brand,omitempty"`
+	Name     string   `json:"name,omitempty"`
+	PartID   int      `json:"part_id,omitempty"`
+	Cost     *Decimal `json:"cost,omitempty"`      // закупочная цена в BaseCurrency
+	OldPrice *float64 `json:"old_price,omitempty"` // цена продажи до загрузки
+	Price    *float64 `json:"price,omitempty"`     // цена продажи по формуле наценки
+	Reason   string   `json:"reason,omitempty"`    // почему строка пропущена или не сопоставлена
+}
+
+// PriceListReport – отчёт о загрузке: обновлённые, новые, несопоставленные и пропущенные строки.
+// Строки без изменений только подсчитываются.
+type PriceListReport struct {
+	Updated   []PriceListRow `json:"updated"`
+	Unchanged int            `json:"unchanged"`
+	Created   []PriceListRow `json:"created"`
+	Unmatched []PriceListRow `json:"unmatched"` // артикула нет в каталоге или он неоднозначен
+	Skipped   []PriceListRow `json:"skipped"`   // строка не разобрана или повторяется
+}
+
+// PriceListRun – запись журнала загрузок прайс-листов.
+type PriceListRun struct {
+	ID        int              `json:"id"`
+	ProfileID int              `json:"profile_id"`
+	Profile   string           `json:"profile"`
+	FileName  string           `json:"file_name"`
+	Source    string           `json:"source"`
+	Username  string           `json:"username,omitempty"`
+	Status    string           `json:"status"`
+	Error     string           `json:"error,omitempty"`
+	Rows      int              `json:"rows"`
+	Updated   int              `json:"updated"`
+	Unchanged int              `json:"unchanged"`
+	Created   int              `json:"created"`
+	Unmatched int              `json:"unmatched"`
+	Skipped   int              `json:"skipped"`
+	DryRun    bool             `json:"dry_run,omitempty"`
+	CreateAt  time.Time        `json:"create_at"`
+	Report    *PriceListReport `json:"report,omitempty"`
+}
+
+// PriceListImport – параметры загрузки прайс-листа.
+type PriceListImport struct {
+	Source   string // PriceListUpload или PriceListInbox
+	Username string
+	DryRun   bool // только построить отчёт, ничего не меняя
+}
+
+// priceListLine – разобранная строка прайс-листа.
+type priceListLine struct {
+	row        PriceListRow
+	normalized string
+	sale       Decimal
+}
+
+// readPriceTable читает ячейки прайс-листа. XLSX читается без форматирования чисел,
+// CSV – с разделителем профиля; CSV не в UTF-8 считается записанным в Windows-1251.
+func readPriceTable(p PriceListProfile, fileName string, r io.Reader) ([][]string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, priceListMaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > priceListMaxSize {
+		return nil, ErrPriceListSize
+	}
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".xlsx", ".xlsm":
+		f, err := excelize.OpenReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrPriceListRead, err)
+		}
+		defer f.Close()
+		sheet := p.Sheet
+		if sheet == "" {
+			sheet = f.GetSheetName(0)
+		} else if idx, err := f.GetSheetIndex(sheet); err != nil || idx < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrPriceListSheet, sheet)
+		}
+		rows, err := f.GetRows(sheet, excelize.Options{RawCellValue: true})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrPriceListRead, err)
+		}
+		return rows, nil
+	case ".csv", ".txt":
+		data = bytes.TrimPrefix(data, []byte("\ufeff"))
+		if !utf8.Valid(data) {
+			if data, err = charmap.Windows1251.NewDecoder().Bytes(data); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrPriceListRead, err)
+			}
+		}
+		reader := csv.NewReader(bytes.NewReader(data))
+		reader.Comma, _ = utf8.DecodeRuneInString(p.Delimiter)
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrPriceListRead, err)
+		}
+		return rows, nil
+	}
+	return nil, ErrPriceListFormat
+}
+
+// parsePriceLines разбирает строки данных прайс-листа и считает цену продажи по формуле наценки.
+// Пустые строки пропускаются молча, неразобранные попадают в отчёт.
+func parsePriceLines(p PriceListProfile, table [][]string, converter *CurrencyConverter, markup Markup, report *PriceListReport) ([]priceListLine, error) {
+	idx := make(map[string]int, 5)
+	for name, spec := range map[string]string{"article": p.ArticleColumn, "brand": p.BrandColumn, "name": p.NameColumn,
+		"price": p.PriceColumn, "currency": p.CurrencyColumn} {
+		i, err := columnIndex(spec)
+		if err != nil {
+			return nil, err
+		}
+		idx[name] = i
+	}
+	cell := func(row []string, name string) string {
+		if i := idx[name]; i >= 0 && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var lines []priceListLine
+	for n, cells := range table {
+		if n < p.HeaderRows {
+			continue
+		}
+		row := PriceListRow{Line: n + 1, Article: cell(cells, "article"), Brand: cell(cells, "brand"), Name: cell(cells, "name")}
+		price := cell(cells, "price")
+		if row.Article == "" && price == "" {
+			continue
+		}
+		if row.Brand == "" {
+			row.Brand = p.DefaultBrand
+		}
+		normalized := NormalizeNumber(row.Article)
+		if normalized == "" {
+			row.Reason = "нет артикула"
+			report.Skipped = append(report.Skipped, row)
+			continue
+		}
+		cost, err := parsePriceCell(price, p.DecimalSeparator)
+		if err != nil || cost.Sign() <= 0 {
+			row.Reason = fmt.Sprintf("неверная цена %q", price)
+			report.Skipped = append(report.Skipped, row)
+			continue
+		}
+		currency := p.Currency
+		if c := parseCurrencyCell(cell(cells, "currency")); c != "" {
+			currency = c
+		}
+		base, err := converter.ToBaseExact(cost, currency)
+		if err != nil {
+			row.Reason = err.Error()
+			report.Skipped = append(report.Skipped, row)
+			continue
+		}
+		sale, err := converter.Convert(markup.Apply(base), BaseCurrency, BaseCurrency)
+		if err != nil {
+			return nil, err
+		}
+		row.Cost = &base
+		lines = append(lines, priceListLine{row: row, normalized: normalized, sale: sale})
+	}
+	return lines, nil
+}
+
+// articleCandidate – запчасть каталога с артикулом из прайс-листа.
+type articleCandidate struct {
+	partID int
+	brand  string
+	isKit  bool
+}
+
+// findArticleCandidates ищет запчасти вне корзины по нормализованным артикулам.
+// Бренд кандидата – бренд артикула, а если он не указан – бренд запчасти.
+func findArticleCandidates(db *sql.DB, normalized []string) (map[string][]articleCandidate, error) {
+	result := make(map[string][]articleCandidate)
+	const chunk = 500
+	for start := 0; start < len(normalized); start += chunk {
+		end := start + chunk
+		if end > len(normalized) {
+			end = len(normalized)
+		}
+		part := normalized[start:end]
+		query := "SELECT n.normalized, n.part_id, COALESCE(NULLIF(n.brand, ''), b.name, ''), " +
+			"EXISTS (SELECT 1 FROM part_kits k WHERE k.part_id = n.part_id) " +
+			"FROM part_numbers n JOIN parts p ON p.part_id = n.part_id LEFT JOIN brands b ON b.brand_id = p.brand_id " +
+			"WHERE n.kind = ? AND p.deleted_at IS NULL AND n.normalized IN (" + placeholders(len(part)) + ")"
+		rows, err := db.Query(query, append([]interface{}{NumberKindArticle}, stringArgs(part)...)...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var normalizedNumber string
+			var c articleCandidate
+			if err := rows.Scan(&normalizedNumber, &c.partID, &c.brand, &c.isKit); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			result[normalizedNumber] = append(result[normalizedNumber], c)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// matchArticle выбирает запчасть для строки прайса. Кандидаты с тем же брендом
+// предпочтительнее; при matchBrand запчасть другого бренда не подходит.
+// Возвращает кандидата или причину, по которой строка не сопоставлена.
+func matchArticle(candidates []articleCandidate, brand string, matchBrand bool) (articleCandidate, string) {
+	if brand != "" {
+		var sameBrand []articleCandidate
+		for _, c := range candidates {
+			if strings.EqualFold(c.brand, brand) {
+				sameBrand = append(sameBrand, c)
+			}
+		}
+		if len(sameBrand) > 0 || matchBrand {
+			candidates = sameBrand
+		}
+	}
+	unique := make(map[int]articleCandidate)
+	for _, c := range candidates {
+		unique[c.partID] = c
+	}
+	switch len(unique) {
+	case 0:
+		return articleCandidate{}, "артикула нет в каталоге"
+	case 1:
+		for _, c := range unique {
+			return c, ""
+		}
+	}
+	return articleCandidate{}, fmt.Sprintf("артикул есть у %d запчастей; укажите бренд", len(unique))
+}
+
+// ImportPriceList загружает прайс-лист поставщика по профилю profileID: сопоставленным
+// по артикулу запчастям записываются себестоимость и цена продажи по формуле наценки
+// (цена запчастей с базовой ценой в валюте не меняется), для несопоставленных артикулов
+// при заданной подкатегории профиля создаются новые запчасти. Загрузка записывается
+// в журнал, кроме пробной (opts.DryRun), которая только строит отчёт.
+func ImportPriceList(db *sql.DB, profileID int, fileName string, r io.Reader, opts PriceListImport) (PriceListRun, error) {
+	profile, err := GetPriceListProfile(db, profileID)
+	if err != nil {
+		return PriceListRun{}, err
+	}
+	run := PriceListRun{ProfileID: profile.ID, Profile: profile.Name, FileName: fileName, Source: opts.Source,
+		Username: opts.Username, Status: PriceListRunOK, DryRun: opts.DryRun, CreateAt: time.Now()}
+	report, err := importPriceList(db, profile, fileName, r, opts)
+	if report != nil {
+		run.Report = report
+		run.Updated, run.Unchanged, run.Created = len(report.Updated), report.Unchanged, len(report.Created)
+		run.Unmatched, run.Skipped = len(report.Unmatched), len(report.Skipped)
+		run.Rows = run.Updated + run.Unchanged + run.Created + run.Unmatched + run.Skipped
+	}
+	if err != nil {
+		run.Status, run.Error = PriceListRunFailed, err.Error()
+		if utf8.RuneCountInString(run.Error) > 255 {
+			run.Error = string([]rune(run.Error)[:255])
+		}
+	}
+	if !opts.DryRun {
+		if saveErr := savePriceListRun(db, &run); saveErr != nil {
+			log.Printf("[ERROR] ImportPriceList: не удалось записать загрузку в журнал: %v", saveErr)
+		}
+	}
+	log.Printf("[INFO] ImportPriceList: профиль %d, файл %s: обновлено %d, без изменений %d, новых %d, не сопоставлено %d, пропущено %d",
+		profile.ID, fileName, run.Updated, run.Unchanged, run.Created, run.Unmatched, run.Skipped)
+	return run, err
+}
+
+// importPriceList выполняет загрузку прайс-листа и возвращает отчёт (в том числе частичный при ошибке).
+func importPriceList(db *sql.DB, p PriceListProfile, fileName string, r io.Reader, opts PriceListImport) (*PriceListReport, error) {
+	if p.Currency == "" {
+		if err := db.QueryRow("SELECT currency FROM suppliers WHERE supplier_id = ?", p.SupplierID).Scan(&p.Currency); err != nil {
+			return nil, err
+		}
+	}
+	markup, err := ParseMarkup(p.Markup)
+	if err != nil {
+		return nil, err
+	}
+	converter, err := LoadCurrencyConverter(db)
+	if err != nil {
+		return nil, err
+	}
+	table, err := readPriceTable(p, fileName, r)
+	if err != nil {
+		return nil, err
+	}
+	report := &PriceListReport{Updated: []PriceListRow{}, Created: []PriceListRow{}, Unmatched: []PriceListRow{}, Skipped: []PriceListRow{}}
+	lines, err := parsePriceLines(p, table, converter, markup, report)
+	if err != nil {
+		return report, err
+	}
+	if len(lines) == 0 && len(report.Skipped) == 0 {
+		return report, ErrPriceListEmpty
+	}
+
+	normalized := make([]string, 0, len(lines))
+	for _, line := range lines {
+		normalized = append(normalized, line.normalized)
+	}
+	candidates, err := findArticleCandidates(db, normalized)
+	if err != nil {
+		return report, err
+	}
+
+	var matched, unmatched []priceListLine
+	seenParts := make(map[int]int)  // запчасть → строка, где она встретилась
+	seenNew := make(map[string]int) // артикул и бренд новой запчасти → строка
+	for _, line := range lines {
+		c, reason := matchArticle(candidates[line.normalized], line.row.Brand, p.MatchBrand)
+		switch {
+		case reason != "":
+			key := line.normalized + "|" + strings.ToLower(line.row.Brand)
+			if first, ok := seenNew[key]; ok {
+				line.row.Reason = fmt.Sprintf("артикул повторяет строку %d", first)
+				report.Skipped = append(report.Skipped, line.row)
+				continue
+			}
+			seenNew[key] = line.row.Line
+			line.row.Reason = reason
+			unmatched = append(unmatched, line)
+		case c.isKit:
+			line.row.PartID, line.row.Reason = c.partID, "цена комплекта рассчитывается по компонентам"
+			report.Skipped = append(report.Skipped, line.row)
+		default:
+			line.row.PartID = c.partID
+			if first, ok := seenParts[c.partID]; ok {
+				line.row.Reason = fmt.Sprintf("запчасть уже обновлена строкой %d", first)
+				report.Skipped = append(report.Skipped, line.row)
+				continue
+			}
+			seenParts[c.partID] = line.row.Line
+			matched = append(matched, line)
+		}
+	}
+
+	src := PriceSource{Source: PriceSourcePriceList, Batch: NewPriceBatch(PriceSourcePriceList), Username: opts.Username}
+	if err := applyPriceListPrices(db, matched, src, opts.DryRun, report); err != nil {
+		return report, err
+	}
+	if p.CreateSubcategoryID == nil {
+		for _, line := range unmatched {
+			report.Unmatched = append(report.Unmatched, line.row)
+		}
+		return report, nil
+	}
+	return report, createPriceListParts(db, unmatched, *p.CreateSubcategoryID, src, opts.DryRun, report)
+}
+
+// applyPriceListPrices записывает сопоставленным запчастям себестоимость и цену продажи
+// в одной транзакции; изменения цен попадают в историю под общим пакетом src.Batch.
+func applyPriceListPrices(db *sql.DB, lines []priceListLine, src PriceSource, dryRun bool, report *PriceListReport) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var repriced []int
+	for _, line := range lines {
+		var old float64
+		var cost sql.NullString
+		var hasBase bool
+		err := tx.QueryRow("SELECT price, cost_price, base_price IS NOT NULL FROM parts WHERE part_id = ? FOR UPDATE", line.row.PartID).
+			Scan(&old, &cost, &hasBase)
+		if err != nil {
+			return err
+		}
+		row := line.row
+		price := line.sale.Float64()
+		row.OldPrice, row.Price = &old, &price
+		if hasBase {
+			row.Price = &old
+			row.Reason = "цена продажи задаётся базовой ценой в валюте; обновлена только себестоимость"
+		}
+		costChanged := !cost.Valid
+		if cost.Valid {
+			current, err := ParseDecimal(cost.String)
+			costChanged = err != nil || current.Cmp(*row.Cost) != 0
+		}
+		priceChanged := !hasBase && price != old
+		if !costChanged && !priceChanged {
+			report.Unchanged++
+			continue
+		}
+		report.Updated = append(report.Updated, row)
+		if dryRun {
+			continue
+		}
+		if _, err := tx.Exec("UPDATE parts SET cost_price = ? WHERE part_id = ?", *row.Cost, row.PartID); err != nil {
+			return err
+		}
+		if !priceChanged {
+			continue
+		}
+		if _, err := tx.Exec("UPDATE parts SET price = ?, update_at = NOW() WHERE part_id = ?", price, row.PartID); err != nil {
+			return err
+		}
+		if err := RecordPriceChange(tx, row.PartID, &old, price, src); err != nil {
+			return err
+		}
+		repriced = append(repriced, row.PartID)
+	}
+	if dryRun {
+		return nil
+	}
+	if err := RefreshKitsFor(tx, repriced...); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if len(repriced) > 0 {
+		InvalidateCatalogTree()
+	}
+	return nil
+}
+
+// createPriceListParts создаёт запчасти для несопоставленных артикулов в подкатегории subcategoryID:
+// название из прайса (или бренд и артикул), артикул, бренд, себестоимость и цена продажи.
+// Запчасти создаются без остатка; строки, которые не удалось создать, остаются несопоставленными.
+func createPriceListParts(db *sql.DB, lines []priceListLine, subcategoryID int, src PriceSource, dryRun bool, report *PriceListReport) error {
+	brandIDs := make(map[string]int) // кэш ID брендов по названию в нижнем регистре
+	for _, line := range lines {
+		row := line.row
+		row.Reason = ""
+		price := line.sale.Float64()
+		row.Price = &price
+		if row.Name == "" {
+			row.Name = strings.TrimSpace(row.Brand + " " + row.Article)
+		}
+		if dryRun {
+			report.Created = append(report.Created, row)
+			continue
+		}
+		brandID := 0
+		if row.Brand != "" {
+			key := strings.ToLower(row.Brand)
+			id, ok := brandIDs[key]
+			if !ok {
+				var err error
+				if id, err = EnsureBrand(db, row.Brand); err != nil {
+					return err
+				}
+				brandIDs[key] = id
+			}
+			brandID = id
+		}
+		id, err := createPriceListPart(db, row, brandID, subcategoryID, src)
+		if err != nil {
+			log.Printf("[ERROR] createPriceListParts: не удалось создать запчасть %s %s: %v", row.Brand, row.Article, err)
+			row.Reason = "не удалось создать запчасть"
+			report.Unmatched = append(report.Unmatched, row)
+			continue
+		}
+		row.PartID = id
+		report.Created = append(report.Created, row)
+	}
+	if len(report.Created) > 0 && !dryRun {
+		InvalidateCatalogTree()
+		return SyncPartsSearchIndex(db)
+	}
+	return nil
+}
+
+// createPriceListPart создаёт запчасть из строки прайса вместе с артикулом, себестоимостью
+// и начальной ценой в истории в одной транзакции: запчасть без артикула следующая загрузка
+// того же прайса не сопоставила бы и создала бы повторно.
+func createPriceListPart(db *sql.DB, row PriceListRow, brandID, subcategoryID int, src PriceSource) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	id, err := insertBrandedPart(tx, Part{Name: row.Name, Price: *row.Price, SubcategoryID: subcategoryID}, brandID, MovementSource{})
+	if err != nil {
+		return 0, err
+	}
+	if err := AddPartNumber(tx, PartNumber{PartID: id, Kind: NumberKindArticle, Brand: row.Brand, Number: row.Article}); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("UPDATE parts SET cost_price = ? WHERE part_id = ?", *row.Cost, id); err != nil {
+		return 0, err
+	}
+	if err := RecordPriceChange(tx, id, nil, *row.Price, src); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	partSlugs.update(db, id, "")
+	return id, nil
+}
+
+// savePriceListRun записывает загрузку прайс-листа в журнал вместе с отчётом.
+func savePriceListRun(db *sql.DB, run *PriceListRun) error {
+	var report interface{}
+	if run.Report != nil {
+		data, err := json.Marshal(run.Report)
+		if err != nil {
+			return err
+		}
+		report = string(data)
+	}
+	fileName := run.FileName
+	if utf8.RuneCountInString(fileName) > 255 {
+		fileName = string([]rune(fileName)[:255])
+	}
+	query := "INSERT INTO price_list_runs (profile_id, file_name, source, username, status, error, rows_total, updated, unchanged, " +
+		"created, unmatched, skipped, report, create_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	res, err := db.Exec(query, run.ProfileID, fileName, run.Source, run.Username, run.Status, run.Error, run.Rows,
+		run.Updated, run.Unchanged, run.Created, run.Unmatched, run.Skipped, report, run.CreateAt)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	run.ID = int(id)
+	return err
+}
+
+// priceListRunSelect – выборка журнала загрузок без отчёта.
+const priceListRunSelect = "SELECT r.run_id, r.profile_id, COALESCE(p.name, ''), r.file_name, r.source, r.username, r.status, r.error, " +
+	"r.rows_total, r.updated, r.unchanged, r.created, r.unmatched, r.skipped, r.create_at " +
+	"FROM price_list_runs r LEFT JOIN price_list_profiles p ON p.profile_id = r.profile_id"
+
+// scanPriceListRun читает строку priceListRunSelect.
+func scanPriceListRun(row interface{ Scan(...interface{}) error }) (PriceListRun, error) {
+	var run PriceListRun
+	err := row.Scan(&run.ID, &run.ProfileID, &run.Profile, &run.FileName, &run.Source, &run.Username, &run.Status, &run.Error,
+		&run.Rows, &run.Updated, &run.Unchanged, &run.Created, &run.Unmatched, &run.Skipped, &run.CreateAt)
+	return run, err
+}
+
+// GetPriceListRuns возвращает последние limit загрузок прайс-листов (profileID > 0 – одного профиля).
+func GetPriceListRuns(db *sql.DB, profileID, limit int) ([]PriceListRun, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	query, args := priceListRunSelect, []interface{}{}
+	if profileID > 0 {
+		query += " WHERE r.profile_id = ?"
+		args = append(args, profileID)
+	}
+	rows, err := db.Query(query+" ORDER BY r.create_at DESC, r.run_id DESC LIMIT ?", append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []PriceListRun{}
+	for rows.Next() {
+		run, err := scanPriceListRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// GetPriceListRun возвращает загрузку прайс-листа с отчётом или sql.ErrNoRows.
+func GetPriceListRun(db *sql.DB, id int) (PriceListRun, error) {
+	run, err := scanPriceListRun(db.QueryRow(priceListRunSelect+" WHERE r.run_id = ?", id))
+	if err != nil {
+		return run, err
+	}
+	var report sql.NullString
+	if err := db.QueryRow("SELECT report FROM price_list_runs WHERE run_id = ?", id).Scan(&report); err != nil {
+		return run, err
+	}
+	if report.Valid {
+		run.Report = &PriceListReport{}
+		err = json.Unmarshal([]byte(report.String), run.Report)
+	}
+	return run, err
+}
+
+// inboxWarned – файлы входящего каталога, для которых уже записано предупреждение
+// об отсутствии профиля: иначе оно повторялось бы при каждой проверке.
+var inboxWarned = struct {
+	sync.Mutex
+	names map[string]bool
+}{names: make(map[string]bool)}
+
+// ProcessPriceInbox загружает прайс-листы из входящего каталога dir. Файл загружается по первому
+// профилю, под шаблон имени которого он подходит, и переносится в подкаталог processed
+// (или failed, если загрузка не удалась) с отметкой времени в начале имени. Файлы без подходящего
+// профиля и ещё копируемые файлы остаются на месте. Возвращает записи журнала загрузок.
+func ProcessPriceInbox(db *sql.DB, dir string) ([]PriceListRun, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := GetPriceListProfiles(db)
+	if err != nil {
+		return nil, err
+	}
+	var runs []PriceListRun
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "~$") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < inboxSettleTime {
+			continue
+		}
+		var profile *PriceListProfile
+		for i := range profiles {
+			if profiles[i].MatchesFile(name) {
+				profile = &profiles[i]
+				break
+			}
+		}
+		if profile == nil {
+			inboxWarned.Lock()
+			if !inboxWarned.names[name] {
+				inboxWarned.names[name] = true
+				log.Printf("[WARN] ProcessPriceInbox: для файла %s нет профиля прайс-листа", name)
+			}
+			inboxWarned.Unlock()
+			continue
+		}
+
+		filePath := filepath.Join(dir, name)
+		f, err := os.Open(filePath)
+		if err != nil {
+			log.Printf("[ERROR] ProcessPriceInbox: не удалось открыть %s: %v", filePath, err)
+			continue
+		}
+		run, err := ImportPriceList(db, profile.ID, name, f, PriceListImport{Source: PriceListInbox})
+		f.Close()
+		target := "processed"
+		if err != nil {
+			log.Printf("[ERROR] ProcessPriceInbox: ошибка загрузки %s по профилю %d: %v", name, profile.ID, err)
+			target = "failed"
+		}
+		if err := moveInboxFile(dir, target, name); err != nil {
+			// Файл, который не удалось убрать, загрузился бы снова при следующей проверке.
+			return runs, fmt.Errorf("перенос %s в %s: %w", name, target, err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// moveInboxFile переносит файл входящего каталога в подкаталог sub с отметкой времени в имени.
+func moveInboxFile(dir, sub, name string) error {
+	target := filepath.Join(dir, sub)
+	if err := os.MkdirAll(target, 0o755); err != nil {
+		return err
+	}
+	return os.Rename(filepath.Join(dir, name), filepath.Join(target, time.Now().Format("20060102-150405")+"_"+name))
+}
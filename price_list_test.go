@@ -0,0 +1,55 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMarkup(t *testing.T) {
+	m, err := ParseMarkup("500: 50% + 20; 2000: 35%; 25%")
+	if err != nil {
+		t.Fatalf("ParseMarkup: %v", err)
+	}
+	tests := []struct{ cost, want string }{
+		{"100", "170"}, // 100 × 1,5 + 20
+		{"499.99", "769.985"},
+		{"500", "675"}, // граница входит в следующую ступень
+		{"1999", "2698.65"},
+		{"2000", "2500"},
+		{"10000", "12500"},
+	}
+	for _, tt := range tests {
+		cost, _ := ParseDecimal(tt.cost)
+		if got := m.Apply(cost).String(); got != tt.want {
+			t.Errorf("Apply(%s) = %s, ожидалось %s", tt.cost, got, tt.want)
+		}
+	}
+
+	// Без ступени без границы к дорогим позициям применяется последняя.
+	m, err = ParseMarkup("1000: 40%")
+	if err != nil {
+		t.Fatalf("ParseMarkup: %v", err)
+	}
+	if got := m.Apply(DecimalFromFloat(5000)).String(); got != "7000" {
+		t.Errorf("Apply(5000) = %s, ожидалось 7000", got)
+	}
+	// Пустая формула – без наценки.
+	if m, err = ParseMarkup(" "); err != nil || m.Apply(DecimalFromFloat(99.9)).String() != "99.9" {
+		t.Errorf("ParseMarkup(пусто): %v", err)
+	}
+}
+
+func TestParseMarkupErrors(t *testing.T) {
+	for _, formula := range []string{
+		"50",                  // нет знака процента
+		"2000: 35%; 500: 50%", // границы не по возрастанию
+		"25%; 500: 50%",       // ступень после ступени без границы
+		"0: 10%",              // граница должна быть положительной
+		"-100%",               // цена продажи стала бы нулевой
+		"10% + -5",            // отрицательная надбавка
+	} {
+		if _, err := ParseMarkup(formula); !errors.Is(err, ErrMarkupFormula) {
+			t.Errorf("ParseMarkup(%q): ошибка %v, ожидалась ErrMarkupFormula", formula, err)
+		}
+	}
+}
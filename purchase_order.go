@@ -0,0 +1,511 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Состояния заказа поставщику: черновик → отправлен → частично получен → получен.
+// Отменить можно черновик, отправленный и частично полученный заказ (недопоставка больше не ожидается).
+const (
+	PurchaseDraft     = "draft"     // заказ собирается, строки можно менять
+	PurchaseSent      = "sent"      // отправлен поставщику, ожидается поставка
+	PurchasePartial   = "partial"   // получена часть товара
+	PurchaseReceived  = "received"  // получен полностью
+	PurchaseCancelled = "cancelled" // отменён; полученный товар остаётся на складе
+)
+
+// Ошибки заказов поставщикам.
+var (
+	ErrPurchaseStatus  = errors.New("действие недоступно в текущем состоянии заказа")
+	ErrPurchaseEmpty   = errors.New("в заказе нет строк")
+	ErrPurchaseItem    = errors.New("в строке заказа количество должно быть больше нуля, цена – не отрицательной, а запчасть – встречаться один раз")
+	ErrPurchaseNote    = errors.New("комментарий заказа не длиннее 255 символов")
+	ErrReceiptItem     = errors.New("запчасть не входит в заказ")
+	ErrReceiptQuantity = errors.New("принимаемое количество должно быть больше нуля и не больше недопоставленного по заказу")
+	ErrReceiptInvoice  = errors.New("номер накладной поставщика не длиннее 100 символов")
+	ErrReceiptNothing  = errors.New("по заказу нечего принимать")
+	ErrReceiptPart     = errors.New("запчасть нельзя оприходовать: она в корзине или является комплектом")
+)
+
+// PurchaseOrder – заказ поставщику на пополнение склада.
+type PurchaseOrder struct {
+	ID          int                 `json:"id"`
+	SupplierID  int                 `json:"supplier_id"`
+	Supplier    string              `json:"supplier"`
+	WarehouseID int                 `json:"warehouse_id"` // склад приёмки; 0 при создании – склад по умолчанию
+	Warehouse   string              `json:"warehouse"`
+	Status      string              `json:"status"`
+	Currency    string              `json:"currency"` // валюта цен; пусто при создании – валюта поставщика
+	Note        string              `json:"note"`
+	CreatedBy   string              `json:"created_by,omitempty"`
+	CreateAt    time.Time           `json:"create_at"`
+	SentAt      *time.Time          `json:"sent_at"`
+	ClosedAt    *time.Time          `json:"closed_at"` // получен полностью или отменён
+	Total       Decimal             `json:"total"`     // сумма заказа в валюте заказа
+	Items       []PurchaseOrderItem `json:"items,omitempty"`
+}
+
+// Document возвращает документ, под которым поступления по заказу записываются в журнал движений.
+func (po PurchaseOrder) Document() string {
+	return fmt.Sprintf("Заказ поставщику №%d", po.ID)
+}
+
+// PurchaseOrderItem – строка заказа поставщику.
+type PurchaseOrderItem struct {
+	PartID   int     `json:"part_id"`
+	Name     string  `json:"name"`
+	Quantity int     `json:"quantity"` // заказано
+	Received int     `json:"received"` // получено
+	Price    Decimal `json:"price"`    // цена за единицу в валюте заказа
+}
+
+// Remaining возвращает недопоставленное количество.
+func (i PurchaseOrderItem) Remaining() int {
+	if i.Received >= i.Quantity {
+		return 0
+	}
+	return i.Quantity - i.Received
+}
+
+// PurchaseOrderFilter – условия выборки заказов; нулевые поля не ограничивают выборку.
+type PurchaseOrderFilter struct {
+	SupplierID int
+	Status     string
+}
+
+// ReceiptLine – принимаемое количество запчасти.
+type ReceiptLine struct {
+	PartID   int `json:"part_id"`
+	Quantity int `json:"quantity"`
+}
+
+// GoodsReceipt – приёмка товара по заказу поставщику.
+type GoodsReceipt struct {
+	Lines       []ReceiptLine `json:"lines"`        // пусто – принять всё недопоставленное
+	WarehouseID int           `json:"warehouse_id"` // 0 – склад заказа
+	Invoice     string        `json:"invoice"`      // номер накладной поставщика
+	UpdateCost  bool          `json:"update_cost"`  // записать цену заказа в себестоимость запчастей
+	Username    string        `json:"-"`
+}
+
+// migratePurchaseOrders создаёт таблицы заказов поставщикам и их строк
+// и добавляет запчастям себестоимость по последней приёмке.
+func migratePurchaseOrders(db *sql.DB) error {
+	err := execAll(db,
+		"CREATE TABLE IF NOT EXISTS purchase_orders ("+
+			"po_id INT AUTO_INCREMENT PRIMARY KEY, "+
+			"supplier_id INT NOT NULL, "+
+			"warehouse_id INT NOT NULL, "+
+			"status VARCHAR(16) NOT NULL DEFAULT '"+PurchaseDraft+"', "+
+			"currency CHAR(3) NOT NULL, "+
+			"note VARCHAR(255) NOT NULL DEFAULT '', "+
+			"created_by VARCHAR(100) NOT NULL DEFAULT '', "+
+			"create_at DATETIME NOT NULL, "+
+			"sent_at DATETIME NULL, "+
+			"closed_at DATETIME NULL, "+
+			"KEY idx_purchase_orders_supplier (supplier_id, create_at), "+
+			"KEY idx_purchase_orders_status (status, create_at)"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"CREATE TABLE IF NOT EXISTS purchase_order_items ("+
+			"po_id INT NOT NULL, "+
+			"part_id INT NOT NULL, "+
+			"quantity INT NOT NULL, "+
+			"received INT NOT NULL DEFAULT 0, "+
+			"price DECIMAL(14,4) NOT NULL DEFAULT 0, "+
+			"PRIMARY KEY (po_id, part_id), "+
+			"KEY idx_purchase_order_items_part (part_id), "+
+			"FOREIGN KEY (po_id) REFERENCES purchase_orders (po_id) ON DELETE CASCADE"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+	)
+	if err != nil {
+		return err
+	}
+	return ensureColumn(db, "parts", "cost_price", "DECIMAL(14,4) NULL")
+}
+
+// purchaseOrderSelect – выборка заказов с названиями поставщика и склада и суммой.
+const purchaseOrderSelect = "SELECT o.po_id, o.supplier_id, COALESCE(s.name, ''), o.warehouse_id, COALESCE(w.name, ''), " +
+	"o.status, o.currency, o.note, o.created_by, o.create_at, o.sent_at, o.closed_at, " +
+	"(SELECT COALESCE(SUM(i.quantity * i.price), 0) FROM purchase_order_items i WHERE i.po_id = o.po_id) " +
+	"FROM purchase_orders o LEFT JOIN suppliers s ON s.supplier_id = o.supplier_id " +
+	"LEFT JOIN warehouses w ON w.warehouse_id = o.warehouse_id"
+
+// scanPurchaseOrder читает строку purchaseOrderSelect.
+func scanPurchaseOrder(row interface{ Scan(...interface{}) error }) (PurchaseOrder, error) {
+	var po PurchaseOrder
+	err := row.Scan(&po.ID, &po.SupplierID, &po.Supplier, &po.WarehouseID, &po.Warehouse,
+		&po.Status, &po.Currency, &po.Note, &po.CreatedBy, &po.CreateAt, &po.SentAt, &po.ClosedAt, &po.Total)
+	return po, err
+}
+
+// GetPurchaseOrders возвращает заказы поставщикам без строк, новые первыми.
+func GetPurchaseOrders(db *sql.DB, filter PurchaseOrderFilter) ([]PurchaseOrder, error) {
+	var conds []string
+	var args []interface{}
+	if filter.SupplierID > 0 {
+		conds = append(conds, "o.supplier_id = ?")
+		args = append(args, filter.SupplierID)
+	}
+	if filter.Status != "" {
+		conds = append(conds, "o.status = ?")
+		args = append(args, filter.Status)
+	}
+	query := purchaseOrderSelect
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	rows, err := db.Query(query+" ORDER BY o.create_at DESC, o.po_id DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []PurchaseOrder{}
+	for rows.Next() {
+		po, err := scanPurchaseOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, po)
+	}
+	return orders, rows.Err()
+}
+
+// getPurchaseOrderItems возвращает строки заказа; lock блокирует их до конца транзакции.
+func getPurchaseOrderItems(db dbExecutor, id int, lock bool) ([]PurchaseOrderItem, error) {
+	query := "SELECT i.part_id, COALESCE(p.name, ''), i.quantity, i.received, i.price FROM purchase_order_items i " +
+		"LEFT JOIN parts p ON p.part_id = i.part_id WHERE i.po_id = ? ORDER BY p.name, i.part_id"
+	if lock {
+		query += " FOR UPDATE"
+	}
+	rows, err := db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []PurchaseOrderItem{}
+	for rows.Next() {
+		var item PurchaseOrderItem
+		if err := rows.Scan(&item.PartID, &item.Name, &item.Quantity, &item.Received, &item.Price); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// GetPurchaseOrder возвращает заказ со строками или sql.ErrNoRows.
+func GetPurchaseOrder(db *sql.DB, id int) (PurchaseOrder, error) {
+	po, err := scanPurchaseOrder(db.QueryRow(purchaseOrderSelect+" WHERE o.po_id = ?", id))
+	if err != nil {
+		return po, err
+	}
+	po.Items, err = getPurchaseOrderItems(db, id, false)
+	return po, err
+}
+
+// lockPurchaseOrder блокирует заказ и проверяет, что он в одном из состояний statuses.
+// Возвращает заказ без строк.
+func lockPurchaseOrder(tx dbExecutor, id int, statuses ...string) (PurchaseOrder, error) {
+	po := PurchaseOrder{ID: id}
+	err := tx.QueryRow("SELECT o.supplier_id, COALESCE(s.name, ''), o.warehouse_id, o.status, o.currency FROM purchase_orders o "+
+		"LEFT JOIN suppliers s ON s.supplier_id = o.supplier_id WHERE o.po_id = ? FOR UPDATE", id).
+		Scan(&po.SupplierID, &po.Supplier, &po.WarehouseID, &po.Status, &po.Currency)
+	if err != nil {
+		return po, err
+	}
+	for _, status := range statuses {
+		if po.Status == status {
+			return po, nil
+		}
+	}
+	return po, ErrPurchaseStatus
+}
+
+// validatePurchaseItems проверяет строки заказа.
+func validatePurchaseItems(items []PurchaseOrderItem) error {
+	seen := make(map[int]bool, len(items))
+	for _, item := range items {
+		if item.Quantity <= 0 || item.Price.Sign() < 0 || seen[item.PartID] {
+			return ErrPurchaseItem
+		}
+		seen[item.PartID] = true
+	}
+	return nil
+}
+
+// SavePurchaseOrder создаёт черновик заказа (po.ID = 0) или изменяет черновик: шапку и строки целиком.
+// Склад 0 – склад по умолчанию, пустая валюта – валюта поставщика. Запчасти должны быть вне корзины
+// и не быть комплектами. Возвращает ID заказа; sql.ErrNoRows, если нет заказа, поставщика,
+// склада или запчасти, и ErrPurchaseStatus, если заказ уже отправлен.
+func SavePurchaseOrder(db *sql.DB, po PurchaseOrder, username string) (int64, error) {
+	po.Note = strings.TrimSpace(po.Note)
+	po.Currency = strings.ToUpper(strings.TrimSpace(po.Currency))
+	if utf8.RuneCountInString(po.Note) > 255 {
+		return 0, ErrPurchaseNote
+	}
+	if err := validatePurchaseItems(po.Items); err != nil {
+		return 0, err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var supplierCurrency string
+	if err := tx.QueryRow("SELECT currency FROM suppliers WHERE supplier_id = ?", po.SupplierID).Scan(&supplierCurrency); err != nil {
+		return 0, err
+	}
+	if po.Currency == "" {
+		po.Currency = supplierCurrency
+	}
+	if err := checkCurrencyRate(tx, po.Currency); err != nil {
+		return 0, err
+	}
+	if po.WarehouseID == 0 {
+		if po.WarehouseID, err = defaultWarehouseID(tx); err != nil {
+			return 0, err
+		}
+	}
+	for _, item := range po.Items {
+		if _, err := stockTarget(tx, item.PartID, po.WarehouseID); err != nil {
+			return 0, err
+		}
+	}
+
+	id := int64(po.ID)
+	if po.ID == 0 {
+		res, err := tx.Exec("INSERT INTO purchase_orders (supplier_id, warehouse_id, status, currency, note, created_by, create_at) "+
+			"VALUES (?, ?, ?, ?, ?, ?, NOW())", po.SupplierID, po.WarehouseID, PurchaseDraft, po.Currency, po.Note, username)
+		if err != nil {
+			return 0, err
+		}
+		if id, err = res.LastInsertId(); err != nil {
+			return 0, err
+		}
+	} else {
+		if _, err := lockPurchaseOrder(tx, po.ID, PurchaseDraft); err != nil {
+			return 0, err
+		}
+		_, err := tx.Exec("UPDATE purchase_orders SET supplier_id = ?, warehouse_id = ?, currency = ?, note = ? WHERE po_id = ?",
+			po.SupplierID, po.WarehouseID, po.Currency, po.Note, po.ID)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec("DELETE FROM purchase_order_items WHERE po_id = ?", po.ID); err != nil {
+			return 0, err
+		}
+	}
+	if len(po.Items) > 0 {
+		query := "INSERT INTO purchase_order_items (po_id, part_id, quantity, price) VALUES " +
+			strings.TrimSuffix(strings.Repeat("(?, ?, ?, ?), ", len(po.Items)), ", ")
+		args := make([]interface{}, 0, 4*len(po.Items))
+		for _, item := range po.Items {
+			args = append(args, id, item.PartID, item.Quantity, item.Price)
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	log.Printf("[INFO] SavePurchaseOrder: заказ %d: поставщик %d, склад %d, строк %d", id, po.SupplierID, po.WarehouseID, len(po.Items))
+	return id, nil
+}
+
+// SendPurchaseOrder отмечает черновик заказа отправленным поставщику.
+func SendPurchaseOrder(db *sql.DB, id int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := lockPurchaseOrder(tx, id, PurchaseDraft); err != nil {
+		return err
+	}
+	var items int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM purchase_order_items WHERE po_id = ?", id).Scan(&items); err != nil {
+		return err
+	}
+	if items == 0 {
+		return ErrPurchaseEmpty
+	}
+	if _, err := tx.Exec("UPDATE purchase_orders SET status = ?, sent_at = NOW() WHERE po_id = ?", PurchaseSent, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CancelPurchaseOrder отменяет заказ, по которому ещё ожидается поставка.
+// Уже полученный товар остаётся на складе.
+func CancelPurchaseOrder(db *sql.DB, id int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := lockPurchaseOrder(tx, id, PurchaseDraft, PurchaseSent, PurchasePartial); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE purchase_orders SET status = ?, closed_at = NOW() WHERE po_id = ?", PurchaseCancelled, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// stockablePart сообщает, можно ли оприходовать запчасть: она есть, не в корзине и не является комплектом.
+func stockablePart(tx dbExecutor, partID int) (bool, error) {
+	var ok bool
+	query := "SELECT EXISTS(SELECT 1 FROM parts p WHERE p.part_id = ? AND p.deleted_at IS NULL " +
+		"AND NOT EXISTS (SELECT 1 FROM part_kits k WHERE k.part_id = p.part_id))"
+	err := tx.QueryRow(query, partID).Scan(&ok)
+	return ok, err
+}
+
+// ReceivePurchaseOrder принимает товар по отправленному или частично полученному заказу:
+// остатки на складе приёмки увеличиваются поступлениями в журнале движений (документ –
+// номер заказа), полученное количество записывается в строки заказа. При rc.UpdateCost
+// цена заказа, пересчитанная в BaseCurrency по текущему курсу, становится себестоимостью
+// запчасти. Без rc.Lines строки с запчастями в корзине и комплектами пропускаются, в явно
+// указанных строках такие запчасти дают ErrReceiptPart. Возвращает новое состояние заказа.
+func ReceivePurchaseOrder(db *sql.DB, id int, rc GoodsReceipt) (string, error) {
+	rc.Invoice = strings.TrimSpace(rc.Invoice)
+	if utf8.RuneCountInString(rc.Invoice) > 100 {
+		return "", ErrReceiptInvoice
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	po, err := lockPurchaseOrder(tx, id, PurchaseSent, PurchasePartial)
+	if err != nil {
+		return "", err
+	}
+	if rc.WarehouseID == 0 {
+		rc.WarehouseID = po.WarehouseID
+	}
+	items, err := getPurchaseOrderItems(tx, id, true)
+	if err != nil {
+		return "", err
+	}
+	byPart := make(map[int]*PurchaseOrderItem, len(items))
+	for i := range items {
+		byPart[items[i].PartID] = &items[i]
+	}
+
+	// Строки приёмки: указанные явно (повторы складываются) или всё недопоставленное.
+	// При приёмке всего недопоставленного строки с запчастями в корзине и комплектами
+	// пропускаются и остаются недопоставленными; указанные явно – отклоняют приёмку.
+	receive := make(map[int]int)
+	var order []int
+	if len(rc.Lines) == 0 {
+		for _, item := range items {
+			if item.Remaining() <= 0 {
+				continue
+			}
+			ok, err := stockablePart(tx, item.PartID)
+			if err != nil {
+				return "", err
+			}
+			if !ok {
+				log.Printf("[WARN] ReceivePurchaseOrder: строка с запчастью %d (%s) заказа %d пропущена: %v",
+					item.PartID, item.Name, id, ErrReceiptPart)
+				continue
+			}
+			receive[item.PartID] = item.Remaining()
+			order = append(order, item.PartID)
+		}
+	}
+	for _, line := range rc.Lines {
+		item, ok := byPart[line.PartID]
+		if !ok {
+			return "", fmt.Errorf("%w: %d", ErrReceiptItem, line.PartID)
+		}
+		if ok, err := stockablePart(tx, line.PartID); err != nil || !ok {
+			if err == nil {
+				err = fmt.Errorf("%w: %s (%d)", ErrReceiptPart, item.Name, line.PartID)
+			}
+			return "", err
+		}
+		if _, seen := receive[line.PartID]; !seen {
+			order = append(order, line.PartID)
+		}
+		receive[line.PartID] += line.Quantity
+		if line.Quantity <= 0 || receive[line.PartID] > item.Remaining() {
+			return "", fmt.Errorf("%w: запчасть %d", ErrReceiptQuantity, line.PartID)
+		}
+	}
+	if len(order) == 0 {
+		return "", ErrReceiptNothing
+	}
+
+	var converter *CurrencyConverter
+	if rc.UpdateCost {
+		if converter, err = LoadCurrencyConverter(db); err != nil {
+			return "", err
+		}
+	}
+	reason := "Поступление от поставщика " + po.Supplier
+	if rc.Invoice != "" {
+		reason += ", накладная " + rc.Invoice
+	}
+	src := MovementSource{Reason: reason, Username: rc.Username, Document: po.Document()}
+	if err := src.validate(); err != nil {
+		return "", err
+	}
+	for _, partID := range order {
+		warehouseID, err := stockTarget(tx, partID, rc.WarehouseID)
+		if err != nil {
+			return "", err
+		}
+		if _, err := recordMovement(tx, partID, warehouseID, MovementReceipt, receive[partID], src); err != nil {
+			return "", err
+		}
+		if _, err := tx.Exec("UPDATE purchase_order_items SET received = received + ? WHERE po_id = ? AND part_id = ?",
+			receive[partID], id, partID); err != nil {
+			return "", err
+		}
+		byPart[partID].Received += receive[partID]
+		if converter != nil {
+			cost, err := converter.ToBaseExact(byPart[partID].Price, po.Currency)
+			if err != nil {
+				return "", err
+			}
+			if _, err := tx.Exec("UPDATE parts SET cost_price = ? WHERE part_id = ?", cost, partID); err != nil {
+				return "", err
+			}
+		}
+	}
+	if err := syncPartQuantity(tx, order...); err != nil {
+		return "", err
+	}
+
+	status := PurchaseReceived
+	for _, item := range items {
+		if item.Remaining() > 0 {
+			status = PurchasePartial
+			break
+		}
+	}
+	query := "UPDATE purchase_orders SET status = ?, closed_at = IF(? = '" + PurchaseReceived + "', NOW(), NULL) WHERE po_id = ?"
+	if _, err := tx.Exec(query, status, status, id); err != nil {
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	InvalidateCatalogTree()
+	log.Printf("[INFO] ReceivePurchaseOrder: заказ %d: принято строк %d на склад %d, состояние %s", id, len(order), rc.WarehouseID, status)
+	return status, nil
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+// testSentOrder создаёт поставщика и отправленный заказ на склад по умолчанию со строками
+// partID → количество; всё созданное удаляется по окончании теста.
+func testSentOrder(t *testing.T, db *sql.DB, lines map[int]int) int {
+	t.Helper()
+	warehouseID, err := defaultWarehouseID(db)
+	if err != nil {
+		t.Fatalf("склад по умолчанию: %v", err)
+	}
+	res, err := db.Exec("INSERT INTO suppliers (name, currency, create_at) VALUES ('Тестовый поставщик', ?, NOW())", BaseCurrency)
+	if err != nil {
+		t.Fatalf("поставщик: %v", err)
+	}
+	supplierID, _ := res.LastInsertId()
+	t.Cleanup(func() { db.Exec("DELETE FROM suppliers WHERE supplier_id = ?", supplierID) })
+	res, err = db.Exec("INSERT INTO purchase_orders (supplier_id, warehouse_id, status, currency, create_at, sent_at) VALUES (?, ?, ?, ?, NOW(), NOW())",
+		supplierID, warehouseID, PurchaseSent, BaseCurrency)
+	if err != nil {
+		t.Fatalf("заказ: %v", err)
+	}
+	poID, _ := res.LastInsertId()
+	t.Cleanup(func() { db.Exec("DELETE FROM purchase_orders WHERE po_id = ?", poID) })
+	for partID, quantity := range lines {
+		if _, err := db.Exec("INSERT INTO purchase_order_items (po_id, part_id, quantity, price) VALUES (?, ?, ?, 10)", poID, partID, quantity); err != nil {
+			t.Fatalf("строка заказа: %v", err)
+		}
+	}
+	return int(poID)
+}
+
+func TestReceivePurchaseOrderSkipsTrashedParts(t *testing.T) {
+	db := testDB(t)
+	liveID, _ := testPart(t, db, 100, 2)
+	trashedID, _ := testPart(t, db, 100, 0)
+	poID := testSentOrder(t, db, map[int]int{liveID: 5, trashedID: 3})
+
+	// Запчасть из незавершённого заказа нельзя переместить в корзину.
+	if _, err := SoftDeletePart(db, trashedID); !errors.Is(err, ErrPartDocuments) {
+		t.Errorf("удаление запчасти из заказа: ошибка %v, ожидалась ErrPartDocuments", err)
+	}
+	// Запчасть, попавшая в корзину раньше, не должна мешать приёмке остальных строк.
+	if _, err := db.Exec("UPDATE parts SET deleted_at = NOW() WHERE part_id = ?", trashedID); err != nil {
+		t.Fatalf("перемещение в корзину: %v", err)
+	}
+
+	explicit := GoodsReceipt{Lines: []ReceiptLine{{PartID: trashedID, Quantity: 1}}}
+	if _, err := ReceivePurchaseOrder(db, poID, explicit); !errors.Is(err, ErrReceiptPart) {
+		t.Errorf("явная строка с запчастью в корзине: ошибка %v, ожидалась ErrReceiptPart", err)
+	}
+
+	status, err := ReceivePurchaseOrder(db, poID, GoodsReceipt{Username: "test"})
+	if err != nil {
+		t.Fatalf("приёмка всего недопоставленного: %v", err)
+	}
+	if status != PurchasePartial {
+		t.Errorf("состояние заказа %q, ожидалось %q", status, PurchasePartial)
+	}
+	if quantity, movements := partQuantity(t, db, liveID); quantity != 7 || movements != 2 {
+		t.Errorf("после приёмки: остаток %d, движений %d; ожидалось 7 и 2", quantity, movements)
+	}
+	if quantity, movements := partQuantity(t, db, trashedID); quantity != 0 || movements != 0 {
+		t.Errorf("пропущенная строка: остаток %d, движений %d; ожидалось 0 и 0", quantity, movements)
+	}
+
+	// Оставшаяся строка – запчасть в корзине, принимать больше нечего.
+	if _, err := ReceivePurchaseOrder(db, poID, GoodsReceipt{}); !errors.Is(err, ErrReceiptNothing) {
+		t.Errorf("повторная приёмка: ошибка %v, ожидалась ErrReceiptNothing", err)
+	}
+}
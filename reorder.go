@@ -0,0 +1,449 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Ошибки точек заказа.
+var (
+	ErrReorderValue   = errors.New("минимальный остаток и партия заказа не могут быть отрицательными")
+	ErrReorderNothing = errors.New("нет запчастей ниже минимального остатка, которые нужно заказать у известного поставщика")
+)
+
+// reorderNote – комментарий черновиков заказов, созданных по минимальным остаткам.
+const reorderNote = "Пополнение до минимального остатка"
+
+// ReorderPoint – точка заказа запчасти или подкатегории: при остатке ниже MinStock
+// поднимается оповещение, а запчасть попадает в отчёт о нехватке. У запчасти пустые
+// значения наследуются от подкатегории.
+type ReorderPoint struct {
+	MinStock   *int      `json:"min_stock"`             // минимальный остаток; 0 – не следить
+	ReorderQty *int      `json:"reorder_qty"`           // партия заказа; 0 – до минимального остатка
+	SupplierID *int      `json:"supplier_id,omitempty"` // основной поставщик запчасти; пусто – поставщик последнего заказа
+	UpdateAt   time.Time `json:"update_at"`
+}
+
+// LowStockItem – строка отчёта о нехватке: запчасть с остатком ниже минимального.
+type LowStockItem struct {
+	PartID        int    `json:"part_id"`
+	Name          string `json:"name"`
+	SubcategoryID int    `json:"subcategory_id"`
+	Quantity      int    `json:"quantity"`
+	MinStock      int    `json:"min_stock"`
+	ReorderQty    int    `json:"reorder_qty"`
+	OnOrder       int    `json:"on_order"` // ожидается по незакрытым заказам поставщикам
+	ToOrder       int    `json:"to_order"` // сколько заказать с учётом ожидаемого и партии
+	SupplierID    *int   `json:"supplier_id"`
+	Supplier      string `json:"supplier"`
+}
+
+// LowStockFilter – отбор отчёта о нехватке; нулевые поля не ограничивают выборку.
+type LowStockFilter struct {
+	SubcategoryID int
+	SupplierID    int
+}
+
+// StockAlert – оповещение о нехватке запчасти. Открывается, когда остаток опускается
+// ниже минимального, и закрывается сам, когда остаток восстанавливается.
+type StockAlert struct {
+	ID         int        `json:"id"`
+	PartID     int        `json:"part_id"`
+	Name       string     `json:"name"`
+	Quantity   int        `json:"quantity"`  // остаток в момент оповещения
+	MinStock   int        `json:"min_stock"` // минимальный остаток в момент оповещения
+	CreateAt   time.Time  `json:"create_at"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+}
+
+// ReorderOrder – черновик заказа поставщику, созданный по отчёту о нехватке.
+type ReorderOrder struct {
+	ID         int64  `json:"id"`
+	SupplierID int    `json:"supplier_id"`
+	Supplier   string `json:"supplier"`
+	Lines      int    `json:"lines"`
+}
+
+// ReorderResult – результат создания заказов по минимальным остаткам.
+type ReorderResult struct {
+	Orders     []ReorderOrder `json:"orders"`
+	NoSupplier []LowStockItem `json:"no_supplier"` // запчасти, для которых поставщик неизвестен
+}
+
+// migrateReorderPoints создаёт точки заказа запчастей и подкатегорий и журнал оповещений о нехватке.
+func migrateReorderPoints(db *sql.DB) error {
+	return execAll(db,
+		"CREATE TABLE IF NOT EXISTS part_reorder_points ("+
+			"part_id INT PRIMARY KEY, "+
+			"min_stock INT NULL, "+
+			"reorder_qty INT NULL, "+
+			"supplier_id INT NULL, "+
+			"update_at DATETIME NOT NULL"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"CREATE TABLE IF NOT EXISTS subcategory_reorder_points ("+
+			"subcategory_id INT PRIMARY KEY, "+
+			"min_stock INT NULL, "+
+			"reorder_qty INT NULL, "+
+			"update_at DATETIME NOT NULL"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"CREATE TABLE IF NOT EXISTS stock_alerts ("+
+			"alert_id INT AUTO_INCREMENT PRIMARY KEY, "+
+			"part_id INT NOT NULL, "+
+			"quantity INT NOT NULL, "+
+			"min_stock INT NOT NULL, "+
+			"create_at DATETIME NOT NULL, "+
+			"resolved_at DATETIME NULL, "+
+			"KEY idx_stock_alerts_part (part_id, resolved_at), "+
+			"KEY idx_stock_alerts_create (create_at)"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+	)
+}
+
+// reorderJoin присоединяет к запчастям p их точки заказа: r – запчасти, sr – подкатегории.
+const reorderJoin = " LEFT JOIN part_reorder_points r ON r.part_id = p.part_id " +
+	"LEFT JOIN subcategory_reorder_points sr ON sr.subcategory_id = p.subcategory_id"
+
+// Действующие минимальный остаток и партия заказа запчасти p (см. reorderJoin).
+const (
+	minStockExpr   = "COALESCE(r.min_stock, sr.min_stock, 0)"
+	reorderQtyExpr = "COALESCE(r.reorder_qty, sr.reorder_qty, 0)"
+)
+
+// openPurchaseStatuses – заказы поставщикам, по которым ещё ожидается поставка (черновики
+// тоже учитываются, чтобы повторное создание заказов не задваивало их).
+const openPurchaseStatuses = "'" + PurchaseDraft + "', '" + PurchaseSent + "', '" + PurchasePartial + "'"
+
+// validate проверяет, что значения точки заказа не отрицательные.
+func (rp ReorderPoint) validate() error {
+	for _, v := range []*int{rp.MinStock, rp.ReorderQty} {
+		if v != nil && *v < 0 {
+			return ErrReorderValue
+		}
+	}
+	return nil
+}
+
+// GetPartReorderPoint возвращает точку заказа запчасти (пустую, если она не задана)
+// или sql.ErrNoRows, если запчасти нет.
+func GetPartReorderPoint(db *sql.DB, partID int) (ReorderPoint, error) {
+	var rp ReorderPoint
+	var updateAt sql.NullTime
+	query := "SELECT r.min_stock, r.reorder_qty, r.supplier_id, r.update_at FROM parts p " +
+		"LEFT JOIN part_reorder_points r ON r.part_id = p.part_id WHERE p.part_id = ? AND p.deleted_at IS NULL"
+	err := db.QueryRow(query, partID).Scan(&rp.MinStock, &rp.ReorderQty, &rp.SupplierID, &updateAt)
+	rp.UpdateAt = updateAt.Time
+	return rp, err
+}
+
+// SetPartReorderPoint задаёт точку заказа запчасти и сразу проверяет её остаток.
+// Возвращает sql.ErrNoRows, если нет запчасти или поставщика, и ErrStockKit для комплекта.
+func SetPartReorderPoint(db *sql.DB, partID int, rp ReorderPoint) error {
+	if err := rp.validate(); err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := stockTarget(tx, partID, 0); err != nil {
+		return err
+	}
+	if rp.SupplierID != nil {
+		if _, err := scanSupplier(tx.QueryRow(supplierSelect+" WHERE supplier_id = ?", *rp.SupplierID)); err != nil {
+			return err
+		}
+	}
+	query := "INSERT INTO part_reorder_points (part_id, min_stock, reorder_qty, supplier_id, update_at) VALUES (?, ?, ?, ?, NOW()) " +
+		"ON DUPLICATE KEY UPDATE min_stock = VALUES(min_stock), reorder_qty = VALUES(reorder_qty), " +
+		"supplier_id = VALUES(supplier_id), update_at = NOW()"
+	if _, err := tx.Exec(query, partID, rp.MinStock, rp.ReorderQty, rp.SupplierID); err != nil {
+		return err
+	}
+	if err := checkStockAlerts(tx, "p.part_id = ?", partID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	log.Printf("[INFO] SetPartReorderPoint: точка заказа запчасти %d изменена", partID)
+	return nil
+}
+
+// DeletePartReorderPoint удаляет точку заказа запчасти: дальше действуют значения подкатегории.
+func DeletePartReorderPoint(db *sql.DB, partID int) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	n, err := rowsAffected(tx.Exec("DELETE FROM part_reorder_points WHERE part_id = ?", partID))
+	if err != nil {
+		return 0, err
+	}
+	if err := checkStockAlerts(tx, "p.part_id = ?", partID); err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// GetSubcategoryReorderPoint возвращает точку заказа подкатегории по умолчанию (пустую, если она
+// не задана) или sql.ErrNoRows, если подкатегории нет.
+func GetSubcategoryReorderPoint(db *sql.DB, subcategoryID int) (ReorderPoint, error) {
+	var rp ReorderPoint
+	var updateAt sql.NullTime
+	query := "SELECT r.min_stock, r.reorder_qty, r.update_at FROM subcategories s " +
+		"LEFT JOIN subcategory_reorder_points r ON r.subcategory_id = s.subcategory_id " +
+		"WHERE s.subcategory_id = ? AND s.deleted_at IS NULL"
+	err := db.QueryRow(query, subcategoryID).Scan(&rp.MinStock, &rp.ReorderQty, &updateAt)
+	rp.UpdateAt = updateAt.Time
+	return rp, err
+}
+
+// SetSubcategoryReorderPoint задаёт точку заказа по умолчанию для запчастей подкатегории
+// и проверяет их остатки. Поставщик подкатегории не задаётся и игнорируется.
+// Возвращает sql.ErrNoRows, если подкатегории нет.
+func SetSubcategoryReorderPoint(db *sql.DB, subcategoryID int, rp ReorderPoint) error {
+	if err := rp.validate(); err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if ok, err := subcategoryLevel.liveExists(tx, subcategoryID); err != nil || !ok {
+		if err == nil {
+			err = sql.ErrNoRows
+		}
+		return err
+	}
+	query := "INSERT INTO subcategory_reorder_points (subcategory_id, min_stock, reorder_qty, update_at) VALUES (?, ?, ?, NOW()) " +
+		"ON DUPLICATE KEY UPDATE min_stock = VALUES(min_stock), reorder_qty = VALUES(reorder_qty), update_at = NOW()"
+	if _, err := tx.Exec(query, subcategoryID, rp.MinStock, rp.ReorderQty); err != nil {
+		return err
+	}
+	if err := checkStockAlerts(tx, "p.subcategory_id = ?", subcategoryID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	log.Printf("[INFO] SetSubcategoryReorderPoint: точка заказа подкатегории %d изменена", subcategoryID)
+	return nil
+}
+
+// DeleteSubcategoryReorderPoint удаляет точку заказа подкатегории по умолчанию.
+func DeleteSubcategoryReorderPoint(db *sql.DB, subcategoryID int) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	n, err := rowsAffected(tx.Exec("DELETE FROM subcategory_reorder_points WHERE subcategory_id = ?", subcategoryID))
+	if err != nil {
+		return 0, err
+	}
+	if err := checkStockAlerts(tx, "p.subcategory_id = ?", subcategoryID); err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// checkStockAlerts сверяет остатки запчастей, отобранных условием cond по запчасти p, с минимальными:
+// для запчасти, остаток которой опустился ниже минимального, открывает оповещение, а оповещение
+// запчасти с восстановленным остатком (или убранной в корзину) закрывает. Комплекты не проверяются –
+// их остаток следует из компонентов. Вызывается в той же транзакции, что и изменение остатка.
+func checkStockAlerts(db dbExecutor, cond string, args ...interface{}) error {
+	query := "SELECT p.part_id, p.name, p.quantity, " + minStockExpr + ", p.deleted_at IS NULL, " +
+		"(SELECT MAX(a.alert_id) FROM stock_alerts a WHERE a.part_id = p.part_id AND a.resolved_at IS NULL) " +
+		"FROM parts p" + reorderJoin + " WHERE (" + cond + ") AND NOT EXISTS (SELECT 1 FROM part_kits k WHERE k.part_id = p.part_id)"
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	type partState struct {
+		id, quantity, minStock int
+		name                   string
+		live                   bool
+		alert                  sql.NullInt64
+	}
+	var states []partState
+	for rows.Next() {
+		var s partState
+		if err := rows.Scan(&s.id, &s.name, &s.quantity, &s.minStock, &s.live, &s.alert); err != nil {
+			rows.Close()
+			return err
+		}
+		states = append(states, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, s := range states {
+		low := s.live && s.quantity < s.minStock
+		switch {
+		case low && !s.alert.Valid:
+			_, err := db.Exec("INSERT INTO stock_alerts (part_id, quantity, min_stock, create_at) VALUES (?, ?, ?, NOW())",
+				s.id, s.quantity, s.minStock)
+			if err != nil {
+				return err
+			}
+			log.Printf("[WARN] checkStockAlerts: остаток запчасти %d '%s' – %d, ниже минимального %d", s.id, s.name, s.quantity, s.minStock)
+		case !low && s.alert.Valid:
+			if _, err := db.Exec("UPDATE stock_alerts SET resolved_at = NOW() WHERE alert_id = ?", s.alert.Int64); err != nil {
+				return err
+			}
+			log.Printf("[INFO] checkStockAlerts: остаток запчасти %d восстановлен (%d)", s.id, s.quantity)
+		}
+	}
+	return nil
+}
+
+// CheckPartStockAlerts сверяет с минимальными остатки запчастей partIDs – например, только что
+// созданных с нулевым остатком.
+func CheckPartStockAlerts(db dbExecutor, partIDs ...int) error {
+	if len(partIDs) == 0 {
+		return nil
+	}
+	return checkStockAlerts(db, "p.part_id IN ("+placeholders(len(partIDs))+")", intArgs(partIDs)...)
+}
+
+// GetStockAlerts возвращает последние limit оповещений о нехватке; open – только незакрытые
+// по запчастям вне корзины.
+func GetStockAlerts(db *sql.DB, open bool, limit int) ([]StockAlert, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 200
+	}
+	query := "SELECT a.alert_id, a.part_id, COALESCE(p.name, ''), a.quantity, a.min_stock, a.create_at, a.resolved_at " +
+		"FROM stock_alerts a LEFT JOIN parts p ON p.part_id = a.part_id"
+	if open {
+		query += " WHERE a.resolved_at IS NULL AND p.deleted_at IS NULL"
+	}
+	rows, err := db.Query(query+" ORDER BY a.create_at DESC, a.alert_id DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := []StockAlert{}
+	for rows.Next() {
+		var a StockAlert
+		if err := rows.Scan(&a.ID, &a.PartID, &a.Name, &a.Quantity, &a.MinStock, &a.CreateAt, &a.ResolvedAt); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, rows.Err()
+}
+
+// GetLowStock возвращает запчасти вне корзины с остатком ниже минимального, по поставщику и названию.
+// Поставщик запчасти – основной из точки заказа или поставщик последнего неотменённого заказа.
+// К заказу предлагается недостающее до минимума с учётом ожидаемого по открытым заказам,
+// но не меньше партии заказа.
+func GetLowStock(db dbExecutor, filter LowStockFilter) ([]LowStockItem, error) {
+	lastSupplier := "(SELECT o.supplier_id FROM purchase_order_items i JOIN purchase_orders o ON o.po_id = i.po_id " +
+		"WHERE i.part_id = p.part_id AND o.status <> '" + PurchaseCancelled + "' ORDER BY o.create_at DESC, o.po_id DESC LIMIT 1)"
+	onOrder := "(SELECT COALESCE(SUM(i.quantity - i.received), 0) FROM purchase_order_items i JOIN purchase_orders o ON o.po_id = i.po_id " +
+		"WHERE i.part_id = p.part_id AND o.status IN (" + openPurchaseStatuses + "))"
+	query := "SELECT t.part_id, t.name, t.subcategory_id, t.quantity, t.min_stock, t.reorder_qty, t.on_order, s.supplier_id, COALESCE(s.name, '') FROM (" +
+		"SELECT p.part_id, p.name, p.subcategory_id, p.quantity, " + minStockExpr + " AS min_stock, " + reorderQtyExpr + " AS reorder_qty, " +
+		onOrder + " AS on_order, COALESCE(r.supplier_id, " + lastSupplier + ") AS supplier_id " +
+		"FROM parts p" + reorderJoin + " WHERE p.deleted_at IS NULL AND NOT EXISTS (SELECT 1 FROM part_kits k WHERE k.part_id = p.part_id)" +
+		") t LEFT JOIN suppliers s ON s.supplier_id = t.supplier_id WHERE t.quantity < t.min_stock"
+	var args []interface{}
+	if filter.SubcategoryID > 0 {
+		query += " AND t.subcategory_id = ?"
+		args = append(args, filter.SubcategoryID)
+	}
+	if filter.SupplierID > 0 {
+		query += " AND s.supplier_id = ?"
+		args = append(args, filter.SupplierID)
+	}
+	rows, err := db.Query(query+" ORDER BY s.name IS NULL, s.name, t.name", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []LowStockItem{}
+	for rows.Next() {
+		var item LowStockItem
+		err := rows.Scan(&item.PartID, &item.Name, &item.SubcategoryID, &item.Quantity, &item.MinStock, &item.ReorderQty,
+			&item.OnOrder, &item.SupplierID, &item.Supplier)
+		if err != nil {
+			return nil, err
+		}
+		if need := item.MinStock - item.Quantity - item.OnOrder; need > 0 {
+			item.ToOrder = need
+			if item.ReorderQty > need {
+				item.ToOrder = item.ReorderQty
+			}
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// CreateReorderPurchaseOrders создаёт по отчёту о нехватке черновики заказов поставщикам –
+// по одному на поставщика (supplierID > 0 – только для него) на склад по умолчанию.
+// Цена строки – цена последнего заказа этой запчасти у поставщика в его валюте, иначе 0.
+// Запчасти без известного поставщика возвращаются отдельно; ErrReorderNothing – если заказывать нечего.
+func CreateReorderPurchaseOrders(db *sql.DB, supplierID int, username string) (ReorderResult, error) {
+	result := ReorderResult{Orders: []ReorderOrder{}, NoSupplier: []LowStockItem{}}
+	items, err := GetLowStock(db, LowStockFilter{SupplierID: supplierID})
+	if err != nil {
+		return result, err
+	}
+	var suppliers []int
+	bySupplier := make(map[int][]LowStockItem)
+	names := make(map[int]string)
+	for _, item := range items {
+		switch {
+		case item.ToOrder == 0:
+			continue
+		case item.SupplierID == nil:
+			result.NoSupplier = append(result.NoSupplier, item)
+			continue
+		}
+		id := *item.SupplierID
+		if _, ok := bySupplier[id]; !ok {
+			suppliers = append(suppliers, id)
+		}
+		bySupplier[id] = append(bySupplier[id], item)
+		names[id] = item.Supplier
+	}
+	if len(suppliers) == 0 {
+		return result, ErrReorderNothing
+	}
+
+	lastPrice := "SELECT i.price FROM purchase_order_items i JOIN purchase_orders o ON o.po_id = i.po_id " +
+		"JOIN suppliers s ON s.supplier_id = o.supplier_id WHERE i.part_id = ? AND o.supplier_id = ? AND o.currency = s.currency " +
+		"AND o.status <> '" + PurchaseCancelled + "' ORDER BY o.create_at DESC, o.po_id DESC LIMIT 1"
+	for _, id := range suppliers {
+		po := PurchaseOrder{SupplierID: id, Note: reorderNote}
+		for _, item := range bySupplier[id] {
+			line := PurchaseOrderItem{PartID: item.PartID, Quantity: item.ToOrder}
+			if err := db.QueryRow(lastPrice, item.PartID, id).Scan(&line.Price); err != nil && err != sql.ErrNoRows {
+				return result, err
+			}
+			po.Items = append(po.Items, line)
+		}
+		orderID, err := SavePurchaseOrder(db, po, username)
+		if err != nil {
+			return result, fmt.Errorf("заказ поставщику %d: %w", id, err)
+		}
+		result.Orders = append(result.Orders, ReorderOrder{ID: orderID, SupplierID: id, Supplier: names[id], Lines: len(po.Items)})
+		log.Printf("[INFO] CreateReorderPurchaseOrders: создан черновик заказа %d поставщику %d, строк: %d", orderID, id, len(po.Items))
+	}
+	return result, nil
+}
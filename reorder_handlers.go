@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"AutoM/config"
+	"AutoM/models"
+)
+
+// respondReorderError отвечает статусом ошибки точки заказа или создания заказов по нехватке;
+// notFound – сообщение для sql.ErrNoRows.
+func respondReorderError(w http.ResponseWriter, handler, notFound string, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, notFound, http.StatusNotFound)
+	case errors.Is(err, models.ErrReorderValue), errors.Is(err, models.ErrStockKit), errors.Is(err, models.ErrUnknownRate):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, models.ErrReorderNothing):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("[ERROR] %s: %v", handler, err)
+		http.Error(w, "Ошибка работы с точками заказа", http.StatusInternalServerError)
+	}
+}
+
+// GetPartReorderPoint – точка заказа запчасти (API); пустые значения наследуются от подкатегории.
+func GetPartReorderPoint(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "GetPartReorderPoint")
+	if !ok {
+		return
+	}
+	point, err := models.GetPartReorderPoint(config.DB, id)
+	if err != nil {
+		respondReorderError(w, "GetPartReorderPoint", "Запчасть не найдена", err)
+		return
+	}
+	writeJSON(w, "GetPartReorderPoint", point)
+}
+
+// SetPartReorderPoint – точка заказа запчасти (API). Ожидается JSON
+// {"min_stock": 4, "reorder_qty": 10, "supplier_id": 2}; null – значение подкатегории
+// (для поставщика – поставщик последнего заказа).
+func SetPartReorderPoint(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "SetPartReorderPoint")
+	if !ok {
+		return
+	}
+	var point models.ReorderPoint
+	if !decodeJSON(w, r, "SetPartReorderPoint", &point) {
+		return
+	}
+	if err := models.SetPartReorderPoint(config.DB, id, point); err != nil {
+		respondReorderError(w, "SetPartReorderPoint", "Запчасть или поставщик не найдены", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeletePartReorderPoint – удаление точки заказа запчасти (API).
+func DeletePartReorderPoint(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "DeletePartReorderPoint")
+	if !ok {
+		return
+	}
+	n, err := models.DeletePartReorderPoint(config.DB, id)
+	if err == nil && n == 0 {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		respondReorderError(w, "DeletePartReorderPoint", "Точка заказа запчасти не задана", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetSubcategoryReorderPoint – точка заказа подкатегории по умолчанию (API).
+func GetSubcategoryReorderPoint(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "GetSubcategoryReorderPoint")
+	if !ok {
+		return
+	}
+	point, err := models.GetSubcategoryReorderPoint(config.DB, id)
+	if err != nil {
+		respondReorderError(w, "GetSubcategoryReorderPoint", "Подкатегория не найдена", err)
+		return
+	}
+	writeJSON(w, "GetSubcategoryReorderPoint", point)
+}
+
+// SetSubcategoryReorderPoint – точка заказа по умолчанию для запчастей подкатегории (API).
+// Ожидается JSON {"min_stock": 2, "reorder_qty": 6}.
+func SetSubcategoryReorderPoint(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "SetSubcategoryReorderPoint")
+	if !ok {
+		return
+	}
+	var point models.ReorderPoint
+	if !decodeJSON(w, r, "SetSubcategoryReorderPoint", &point) {
+		return
+	}
+	if err := models.SetSubcategoryReorderPoint(config.DB, id, point); err != nil {
+		respondReorderError(w, "SetSubcategoryReorderPoint", "Подкатегория не найдена", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeleteSubcategoryReorderPoint – удаление точки заказа подкатегории (API).
+func DeleteSubcategoryReorderPoint(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "DeleteSubcategoryReorderPoint")
+	if !ok {
+		return
+	}
+	n, err := models.DeleteSubcategoryReorderPoint(config.DB, id)
+	if err == nil && n == 0 {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		respondReorderError(w, "DeleteSubcategoryReorderPoint", "Точка заказа подкатегории не задана", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetLowStock – отчёт о запчастях с остатком ниже минимального (API).
+// Параметры subcategory_id и supplier_id.
+func GetLowStock(w http.ResponseWriter, r *http.Request) {
+	var filter models.LowStockFilter
+	var err error
+	if filter.SubcategoryID, err = queryInt(r, "subcategory_id"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.SupplierID, err = queryInt(r, "supplier_id"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	items, err := models.GetLowStock(config.DB, filter)
+	if err != nil {
+		log.Printf("[ERROR] GetLowStock: ошибка построения отчёта о нехватке: %v", err)
+		http.Error(w, "Ошибка построения отчёта о нехватке", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetLowStock", items)
+}
+
+// CreateReorderPurchaseOrders – черновики заказов поставщикам по отчёту о нехватке (API).
+// Параметр supplier_id ограничивает заказ одним поставщиком.
+func CreateReorderPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	supplierID, err := queryInt(r, "supplier_id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	result, err := models.CreateReorderPurchaseOrders(config.DB, supplierID, sessionUsername(r))
+	if err != nil {
+		respondReorderError(w, "CreateReorderPurchaseOrders", "Поставщик не найден", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, "CreateReorderPurchaseOrders", result)
+}
+
+// GetStockAlerts – оповещения о нехватке (API). Параметры open=1 (только незакрытые) и limit.
+func GetStockAlerts(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryInt(r, "limit")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	open := r.URL.Query().Get("open") == "1" || r.URL.Query().Get("open") == "true"
+	alerts, err := models.GetStockAlerts(config.DB, open, limit)
+	if err != nil {
+		log.Printf("[ERROR] GetStockAlerts: ошибка получения оповещений: %v", err)
+		http.Error(w, "Ошибка получения оповещений о нехватке", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetStockAlerts", alerts)
+}
@@ -46,13 +46,124 @@ func RegisterRoutes() *mux.Router {
 	api := router.PathPrefix("/api/v1").Subrouter()
 	log.Println("[INFO] Регистрация API маршрутов под префиксом /api/v1")
 
-	// Маршруты для запчастей:
+	// Маршруты для запчастей (поиск регистрируется раньше /parts/{id}):
 	api.HandleFunc("/parts", controllers.GetAllParts).Methods("GET")
+	api.HandleFunc("/parts/search", controllers.SearchParts).Methods("GET")
+	api.HandleFunc("/parts/by-number/{number}", controllers.GetPartsByNumber).Methods("GET")
+	api.HandleFunc("/parts/by-barcode/{code}", controllers.GetPartByBarcode).Methods("GET")
 	api.HandleFunc("/parts/{id}", controllers.GetPartByID).Methods("GET")
 	api.HandleFunc("/parts", controllers.AddPart).Methods("POST")
 	api.HandleFunc("/parts/{id}", controllers.UpdatePart).Methods("PUT")
 	api.HandleFunc("/parts/{id}", controllers.DeletePart).Methods("DELETE")
 
+	// Артикулы и OEM-номера запчастей, кросс-таблица аналогов:
+	api.HandleFunc("/parts/{id}/numbers", controllers.GetPartNumbers).Methods("GET")
+	api.HandleFunc("/parts/{id}/numbers", controllers.AddPartNumber).Methods("POST")
+	api.HandleFunc("/parts/{id}/numbers/{numberId}", controllers.DeletePartNumber).Methods("DELETE")
+	api.HandleFunc("/crosses", controllers.GetPartCrosses).Methods("GET")
+	api.HandleFunc("/crosses", controllers.AddPartCross).Methods("POST")
+	api.HandleFunc("/crosses/{id}", controllers.DeletePartCross).Methods("DELETE")
+
+	// История цен и массовое изменение цен:
+	api.HandleFunc("/parts/{id}/price-history", controllers.GetPartPriceHistory).Methods("GET")
+	api.HandleFunc("/parts/prices/bulk", controllers.BulkUpdatePrices).Methods("POST")
+
+	// Курсы валют и базовые цены запчастей в валюте закупки:
+	api.HandleFunc("/currency-rates", controllers.GetCurrencyRates).Methods("GET")
+	api.HandleFunc("/currency-rates/import", controllers.ImportCurrencyRates).Methods("POST")
+	api.HandleFunc("/currency-rates/{code}", controllers.SetCurrencyRate).Methods("PUT")
+	api.HandleFunc("/currency-rates/{code}", controllers.DeleteCurrencyRate).Methods("DELETE")
+	api.HandleFunc("/parts/{id}/base-price", controllers.GetPartBasePrice).Methods("GET")
+	api.HandleFunc("/parts/{id}/base-price", controllers.SetPartBasePrice).Methods("PUT")
+
+	// Группы цен покупателей: наценки по категориям, явные цены запчастей и назначение пользователям:
+	api.HandleFunc("/price-groups", controllers.GetPriceGroups).Methods("GET")
+	api.HandleFunc("/price-groups", controllers.AddPriceGroup).Methods("POST")
+	api.HandleFunc("/price-groups/{id}", controllers.GetPriceGroup).Methods("GET")
+	api.HandleFunc("/price-groups/{id}", controllers.UpdatePriceGroup).Methods("PUT")
+	api.HandleFunc("/price-groups/{id}", controllers.DeletePriceGroup).Methods("DELETE")
+	api.HandleFunc("/price-groups/{id}/categories/{categoryId}", controllers.SetPriceGroupCategory).Methods("PUT")
+	api.HandleFunc("/price-groups/{id}/categories/{categoryId}", controllers.DeletePriceGroupCategory).Methods("DELETE")
+	api.HandleFunc("/price-groups/{id}/parts/{partId}", controllers.SetPriceGroupPartPrice).Methods("PUT")
+	api.HandleFunc("/price-groups/{id}/parts/{partId}", controllers.DeletePriceGroupPartPrice).Methods("DELETE")
+	api.HandleFunc("/users/{id}/price-group", controllers.SetUserPriceGroup).Methods("PUT")
+
+	// Склады и остатки запчастей по складам:
+	api.HandleFunc("/warehouses", controllers.GetWarehouses).Methods("GET")
+	api.HandleFunc("/warehouses", controllers.AddWarehouse).Methods("POST")
+	api.HandleFunc("/warehouses/{id}", controllers.UpdateWarehouse).Methods("PUT")
+	api.HandleFunc("/warehouses/{id}", controllers.DeleteWarehouse).Methods("DELETE")
+	api.HandleFunc("/parts/{id}/stock", controllers.GetPartStock).Methods("GET")
+	api.HandleFunc("/parts/{id}/stock/{warehouseId}", controllers.SetPartStock).Methods("PUT")
+
+	// Журнал движений товара: поступления, возвраты, корректировки, перемещения и сверка остатков:
+	api.HandleFunc("/stock-movements", controllers.GetStockMovements).Methods("GET")
+	api.HandleFunc("/stock-movements", controllers.AddStockMovement).Methods("POST")
+	api.HandleFunc("/stock-transfers", controllers.TransferStock).Methods("POST")
+	api.HandleFunc("/stock/discrepancies", controllers.GetStockDiscrepancies).Methods("GET")
+	api.HandleFunc("/stock/reconcile", controllers.ReconcileStock).Methods("POST")
+
+	// Точки заказа, отчёт о нехватке и оповещения:
+	api.HandleFunc("/parts/{id}/reorder-point", controllers.GetPartReorderPoint).Methods("GET")
+	api.HandleFunc("/parts/{id}/reorder-point", controllers.SetPartReorderPoint).Methods("PUT")
+	api.HandleFunc("/parts/{id}/reorder-point", controllers.DeletePartReorderPoint).Methods("DELETE")
+	api.HandleFunc("/subcategories/{id}/reorder-point", controllers.GetSubcategoryReorderPoint).Methods("GET")
+	api.HandleFunc("/subcategories/{id}/reorder-point", controllers.SetSubcategoryReorderPoint).Methods("PUT")
+	api.HandleFunc("/subcategories/{id}/reorder-point", controllers.DeleteSubcategoryReorderPoint).Methods("DELETE")
+	api.HandleFunc("/stock/low", controllers.GetLowStock).Methods("GET")
+	api.HandleFunc("/stock/low/purchase-orders", controllers.CreateReorderPurchaseOrders).Methods("POST")
+	api.HandleFunc("/stock-alerts", controllers.GetStockAlerts).Methods("GET")
+
+	// Штрихкоды запчастей (EAN-13, EAN-8, UPC-A):
+	api.HandleFunc("/parts/{id}/barcodes", controllers.GetPartBarcodes).Methods("GET")
+	api.HandleFunc("/parts/{id}/barcodes", controllers.AddPartBarcode).Methods("POST")
+	api.HandleFunc("/parts/{id}/barcodes/{barcodeId}", controllers.DeletePartBarcode).Methods("DELETE")
+
+	// Инвентаризации: подсчёт, утверждение с корректировками и отмена:
+	api.HandleFunc("/stocktakes", controllers.GetStocktakes).Methods("GET")
+	api.HandleFunc("/stocktakes", controllers.OpenStocktake).Methods("POST")
+	api.HandleFunc("/stocktakes/{id}", controllers.GetStocktake).Methods("GET")
+	api.HandleFunc("/stocktakes/{id}/items/{partId}", controllers.SetStocktakeCount).Methods("PUT")
+	api.HandleFunc("/stocktakes/{id}/approve", controllers.ApproveStocktake).Methods("POST")
+	api.HandleFunc("/stocktakes/{id}/cancel", controllers.CancelStocktake).Methods("POST")
+
+	// Поставщики и заказы поставщикам с приёмкой товара на склад:
+	api.HandleFunc("/suppliers", controllers.GetSuppliers).Methods("GET")
+	api.HandleFunc("/suppliers", controllers.AddSupplier).Methods("POST")
+	api.HandleFunc("/suppliers/{id}", controllers.GetSupplier).Methods("GET")
+	api.HandleFunc("/suppliers/{id}", controllers.UpdateSupplier).Methods("PUT")
+	api.HandleFunc("/suppliers/{id}", controllers.DeleteSupplier).Methods("DELETE")
+	api.HandleFunc("/purchase-orders", controllers.GetPurchaseOrders).Methods("GET")
+	api.HandleFunc("/purchase-orders", controllers.AddPurchaseOrder).Methods("POST")
+	api.HandleFunc("/purchase-orders/{id}", controllers.GetPurchaseOrder).Methods("GET")
+	api.HandleFunc("/purchase-orders/{id}", controllers.UpdatePurchaseOrder).Methods("PUT")
+	api.HandleFunc("/purchase-orders/{id}/send", controllers.SendPurchaseOrder).Methods("POST")
+	api.HandleFunc("/purchase-orders/{id}/receive", controllers.ReceivePurchaseOrder).Methods("POST")
+	api.HandleFunc("/purchase-orders/{id}/cancel", controllers.CancelPurchaseOrder).Methods("POST")
+
+	// Профили прайс-листов поставщиков, загрузка прайсов и журнал загрузок:
+	api.HandleFunc("/price-list-profiles", controllers.GetPriceListProfiles).Methods("GET")
+	api.HandleFunc("/price-list-profiles", controllers.AddPriceListProfile).Methods("POST")
+	api.HandleFunc("/price-list-profiles/{id}", controllers.GetPriceListProfile).Methods("GET")
+	api.HandleFunc("/price-list-profiles/{id}", controllers.UpdatePriceListProfile).Methods("PUT")
+	api.HandleFunc("/price-list-profiles/{id}", controllers.DeletePriceListProfile).Methods("DELETE")
+	api.HandleFunc("/price-list-profiles/{id}/import", controllers.ImportPriceList).Methods("POST")
+	api.HandleFunc("/price-list-runs", controllers.GetPriceListRuns).Methods("GET")
+	api.HandleFunc("/price-list-runs/{id}", controllers.GetPriceListRun).Methods("GET")
+
+	// Комплекты из запчастей и продажа со списанием остатков:
+	api.HandleFunc("/parts/{id}/kit", controllers.GetPartKit).Methods("GET")
+	api.HandleFunc("/parts/{id}/kit", controllers.SetPartKit).Methods("PUT")
+	api.HandleFunc("/parts/{id}/kit", controllers.DeletePartKit).Methods("DELETE")
+	api.HandleFunc("/parts/{id}/sell", controllers.SellPart).Methods("POST")
+
+	// Галерея изображений запчасти:
+	api.HandleFunc("/parts/{id}/images", controllers.GetPartImages).Methods("GET")
+	api.HandleFunc("/parts/{id}/images", controllers.UploadPartImages).Methods("POST")
+	api.HandleFunc("/parts/{id}/images/order", controllers.ReorderPartImages).Methods("PUT")
+	api.HandleFunc("/parts/{id}/images/{imageId}/primary", controllers.SetPrimaryPartImage).Methods("PUT")
+	api.HandleFunc("/parts/{id}/images/{imageId}", controllers.DeletePartImage).Methods("DELETE")
+
 	// Маршруты для пользователей:
 	api.HandleFunc("/users", controllers.GetAllUsers).Methods("GET")
 	api.HandleFunc("/users/{id}", controllers.GetUserByID).Methods("GET")
@@ -65,6 +176,27 @@ func RegisterRoutes() *mux.Router {
 	api.HandleFunc("/groups", controllers.AddGroup).Methods("POST")
 	api.HandleFunc("/groups/{id}", controllers.UpdateGroup).Methods("PUT")
 	api.HandleFunc("/groups/{id}", controllers.DeleteGroup).Methods("DELETE")
+	api.HandleFunc("/groups/{id}/delete-preview", controllers.GroupDeletePreview).Methods("GET")
+
+	// Корзина: удалённые группы, категории, подкатегории и запчасти:
+	api.HandleFunc("/trash", controllers.GetTrash).Methods("GET")
+	api.HandleFunc("/trash/{type}/{id}/restore", controllers.RestoreFromTrash).Methods("POST")
+
+	// Реорганизация каталога: слияние и перенос с записью в журнал:
+	api.HandleFunc("/subcategories/{id}/merge", controllers.MergeSubcategory).Methods("POST")
+	api.HandleFunc("/categories/{id}/move", controllers.MoveCategory).Methods("POST")
+	api.HandleFunc("/parts/move", controllers.MoveParts).Methods("POST")
+	api.HandleFunc("/catalog/audit", controllers.GetCatalogAudit).Methods("GET")
+
+	// Дерево каталога с количеством запчастей:
+	api.HandleFunc("/catalog/tree", controllers.GetCatalogTree).Methods("GET")
+
+	// Маршруты для брендов:
+	api.HandleFunc("/brands", controllers.GetAllBrands).Methods("GET")
+	api.HandleFunc("/brands/{id}", controllers.GetBrandByID).Methods("GET")
+	api.HandleFunc("/brands", controllers.AddBrand).Methods("POST")
+	api.HandleFunc("/brands/{id}", controllers.UpdateBrand).Methods("PUT")
+	api.HandleFunc("/brands/{id}", controllers.DeleteBrand).Methods("DELETE")
 
 	// Маршруты для подкатегорий (обновленный ресурс "subcategories"):
 	api.HandleFunc("/subcategories", controllers.GetAllSubcategories).Methods("GET")
@@ -72,6 +204,15 @@ func RegisterRoutes() *mux.Router {
 	api.HandleFunc("/subcategories", controllers.AddSubcategory).Methods("POST")
 	api.HandleFunc("/subcategories/{id}", controllers.UpdateSubcategory).Methods("PUT")
 	api.HandleFunc("/subcategories/{id}", controllers.DeleteSubcategory).Methods("DELETE")
+	api.HandleFunc("/subcategories/{id}/delete-preview", controllers.SubcategoryDeletePreview).Methods("GET")
+
+	// Характеристики подкатегорий и их значения у запчастей:
+	api.HandleFunc("/subcategories/{id}/attributes", controllers.GetSubcategoryAttributes).Methods("GET")
+	api.HandleFunc("/subcategories/{id}/attributes", controllers.AddSubcategoryAttribute).Methods("POST")
+	api.HandleFunc("/attributes/{id}", controllers.UpdateAttribute).Methods("PUT")
+	api.HandleFunc("/attributes/{id}", controllers.DeleteAttribute).Methods("DELETE")
+	api.HandleFunc("/parts/{id}/attributes", controllers.GetPartAttributes).Methods("GET")
+	api.HandleFunc("/parts/{id}/attributes", controllers.SetPartAttributes).Methods("PUT")
 
 	// Новые маршруты для категорий:
 	api.HandleFunc("/categories", controllers.GetAllCategories).Methods("GET")
@@ -79,6 +220,36 @@ func RegisterRoutes() *mux.Router {
 	api.HandleFunc("/categories", controllers.AddCategory).Methods("POST")
 	api.HandleFunc("/categories/{id}", controllers.UpdateCategory).Methods("PUT")
 	api.HandleFunc("/categories/{id}", controllers.DeleteCategory).Methods("DELETE")
+	api.HandleFunc("/categories/{id}/delete-preview", controllers.CategoryDeletePreview).Methods("GET")
+
+	// Маршруты справочника автомобилей и применимости запчастей:
+	vehicles := api.PathPrefix("/vehicles").Subrouter()
+	vehicles.HandleFunc("/makes", controllers.GetVehicleMakes).Methods("GET")
+	vehicles.HandleFunc("/makes/{id}", controllers.GetVehicleMakeByID).Methods("GET")
+	vehicles.HandleFunc("/makes", controllers.AddVehicleMake).Methods("POST")
+	vehicles.HandleFunc("/makes/{id}", controllers.UpdateVehicleMake).Methods("PUT")
+	vehicles.HandleFunc("/makes/{id}", controllers.DeleteVehicleMake).Methods("DELETE")
+	vehicles.HandleFunc("/models", controllers.GetVehicleModels).Methods("GET")
+	vehicles.HandleFunc("/models/{id}", controllers.GetVehicleModelByID).Methods("GET")
+	vehicles.HandleFunc("/models", controllers.AddVehicleModel).Methods("POST")
+	vehicles.HandleFunc("/models/{id}", controllers.UpdateVehicleModel).Methods("PUT")
+	vehicles.HandleFunc("/models/{id}", controllers.DeleteVehicleModel).Methods("DELETE")
+	vehicles.HandleFunc("/generations", controllers.GetVehicleGenerations).Methods("GET")
+	vehicles.HandleFunc("/generations/{id}", controllers.GetVehicleGenerationByID).Methods("GET")
+	vehicles.HandleFunc("/generations", controllers.AddVehicleGeneration).Methods("POST")
+	vehicles.HandleFunc("/generations/{id}", controllers.UpdateVehicleGeneration).Methods("PUT")
+	vehicles.HandleFunc("/generations/{id}", controllers.DeleteVehicleGeneration).Methods("DELETE")
+	vehicles.HandleFunc("/engines", controllers.GetVehicleEngines).Methods("GET")
+	vehicles.HandleFunc("/engines/{id}", controllers.GetVehicleEngineByID).Methods("GET")
+	vehicles.HandleFunc("/engines", controllers.AddVehicleEngine).Methods("POST")
+	vehicles.HandleFunc("/engines/{id}", controllers.UpdateVehicleEngine).Methods("PUT")
+	vehicles.HandleFunc("/engines/{id}", controllers.DeleteVehicleEngine).Methods("DELETE")
+	vehicles.HandleFunc("/fitments", controllers.GetPartFitments).Methods("GET")
+	vehicles.HandleFunc("/fitments", controllers.AddPartFitment).Methods("POST")
+	vehicles.HandleFunc("/fitments/{id}", controllers.DeletePartFitment).Methods("DELETE")
+
+	// Расшифровка VIN:
+	api.HandleFunc("/vin/{vin}", controllers.DecodeVIN).Methods("GET")
 
 	// ----------------------- HTML-маршруты -----------------------
 	pages := router.PathPrefix("").Subrouter()
@@ -86,6 +257,10 @@ func RegisterRoutes() *mux.Router {
 	log.Println("[INFO] Регистрация HTML маршрутов")
 	pages.HandleFunc("/", controllers.HomeHandler).Methods("GET")
 	pages.HandleFunc("/products", controllers.ProductsHandler).Methods("GET")
+	pages.HandleFunc("/catalog/{group}", controllers.CatalogPageHandler).Methods("GET")
+	pages.HandleFunc("/catalog/{group}/{category}", controllers.CatalogPageHandler).Methods("GET")
+	pages.HandleFunc("/catalog/{group}/{category}/{subcategory}", controllers.CatalogPageHandler).Methods("GET")
+	pages.HandleFunc("/part/{slug}", controllers.PartPageHandler).Methods("GET")
 	pages.HandleFunc("/register", controllers.RegisterPageHandler).Methods("GET")
 	pages.HandleFunc("/login", controllers.LoginPageHandler).Methods("GET")
 	pages.HandleFunc("/about", controllers.AboutPageHandler).Methods("GET")
@@ -93,11 +268,22 @@ func RegisterRoutes() *mux.Router {
 	pages.HandleFunc("/cabinet", controllers.PersonalCabinetHandler).Methods("GET")
 	pages.HandleFunc("/logout", controllers.LogoutHandler).Methods("GET")
 	pages.HandleFunc("/admin", controllers.AdminPageHandler).Methods("GET")
+	pages.HandleFunc("/admin/stocktakes/{id}", controllers.StocktakePageHandler).Methods("GET")
 
 	// Маршруты для работы с Excel-файлами:
 	router.HandleFunc("/admin/import", controllers.AdminImportPartsHandler).Methods("POST")
 	router.HandleFunc("/admin/edit_excel", controllers.AdminEditExcelHandler).Methods("GET")
 	router.HandleFunc("/admin/save_excel_edits", controllers.AdminSaveExcelEditsHandler).Methods("POST")
+	router.HandleFunc("/admin/stocktakes/{id}/sheet.xlsx", controllers.StocktakeSheetHandler).Methods("GET")
+	router.HandleFunc("/admin/stocktakes/{id}/report.xlsx", controllers.StocktakeReportHandler).Methods("GET")
+	router.HandleFunc("/admin/stocktakes/{id}/upload", controllers.StocktakeUploadHandler).Methods("POST")
+
+	// Этикетки со штрихкодами для печати:
+	router.HandleFunc("/admin/labels.pdf", controllers.BarcodeLabelsPDFHandler).Methods("GET")
+	router.HandleFunc("/admin/labels.png", controllers.BarcodeLabelsPNGHandler).Methods("GET")
+
+	// Загруженные изображения запчастей и их миниатюры:
+	router.HandleFunc("/images/parts/{file}", controllers.ServePartImage).Methods("GET", "HEAD")
 
 	// Обслуживание статических файлов из .well-known
 	router.PathPrefix("/.well-known/").Handler(http.FileServer(http.Dir(".")))
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"database/sql"
+	"log"
+)
+
+// migration описывает один шаг изменения схемы базы данных.
+// Каждый шаг должен быть идемпотентным, так как выполняется при каждом запуске.
+type migration struct {
+	name  string
+	apply func(db *sql.DB) error
+}
+
+// migrations – упорядоченный список шагов изменения схемы.
+// Новые шаги добавляются только в конец списка.
+var migrations = []migration{
+	{"parts_search", migratePartsSearch},
+	{"vehicles", migrateVehicles},
+	{"part_numbers", migratePartNumbers},
+	{"brands", migrateBrands},
+	{"attributes", migrateAttributes},
+	{"slugs", migrateSlugs},
+	{"part_images", migratePartImages},
+	{"soft_delete", migrateSoftDelete},
+	{"catalog_audit", migrateCatalogAudit},
+	{"kits", migrateKits},
+	{"price_history", migratePriceHistory},
+	{"currencies", migrateCurrencies},
+	{"price_groups", migratePriceGroups},
+	{"warehouses", migrateWarehouses},
+	{"stock_movements", migrateStockMovements},
+	{"stocktakes", migrateStocktakes},
+	{"suppliers", migrateSuppliers},
+	{"purchase_orders", migratePurchaseOrders},
+	{"price_list_profiles", migratePriceListProfiles},
+	{"reorder_points", migrateReorderPoints},
+	{"barcodes", migrateBarcodes},
+}
+
+// Migrate последовательно применяет все шаги изменения схемы.
+// Вызывается при старте приложения сразу после подключения к базе.
+func Migrate(db *sql.DB) error {
+	log.Println("[INFO] Migrate: проверка схемы базы данных")
+	for _, m := range migrations {
+		if err := m.apply(db); err != nil {
+			log.Printf("[ERROR] Migrate: ошибка шага '%s': %v", m.name, err)
+			return err
+		}
+	}
+	log.Printf("[INFO] Migrate: схема актуальна, проверено шагов: %d", len(migrations))
+	return nil
+}
+
+// execAll выполняет набор DDL-запросов по порядку.
+func execAll(db *sql.DB, queries ...string) error {
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tableExists проверяет наличие таблицы в текущей базе.
+func tableExists(db *sql.DB, table string) (bool, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?"
+	if err := db.QueryRow(query, table).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// columnExists проверяет наличие столбца в таблице текущей базы.
+func columnExists(db *sql.DB, table, column string) (bool, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?"
+	if err := db.QueryRow(query, table, column).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ensureColumn добавляет столбец в таблицу, если его ещё нет.
+// MySQL не поддерживает ADD COLUMN IF NOT EXISTS, поэтому наличие проверяется отдельно.
+func ensureColumn(db *sql.DB, table, column, definition string) error {
+	exists, err := columnExists(db, table, column)
+	if err != nil || exists {
+		return err
+	}
+	log.Printf("[INFO] Migrate: добавление столбца %s.%s", table, column)
+	_, err = db.Exec("ALTER TABLE `" + table + "` ADD COLUMN `" + column + "` " + definition)
+	return err
+}
+
+// ensureIndex создаёт индекс, если его ещё нет.
+func ensureIndex(db *sql.DB, table, index, columns string) error {
+	var count int
+	query := "SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?"
+	if err := db.QueryRow(query, table, index).Scan(&count); err != nil || count > 0 {
+		return err
+	}
+	log.Printf("[INFO] Migrate: создание индекса %s.%s", table, index)
+	_, err := db.Exec("CREATE INDEX `" + index + "` ON `" + table + "` (" + columns + ")")
+	return err
+}
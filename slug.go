@@ -0,0 +1,370 @@
+package models
+
+import (
+	"database/sql"
+	"log"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxSlugLength – максимальная длина слага без суффикса уникальности.
+const maxSlugLength = 120
+
+// translit – транслитерация кириллицы латиницей (упрощённая схема, принятая в адресах сайтов).
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g",
+}
+
+// Slugify формирует слаг из названия: кириллица транслитерируется, латиница и цифры
+// сохраняются, остальные символы заменяются дефисом. "Тормозные колодки" → "tormoznye-kolodki".
+func Slugify(name string) string {
+	var b strings.Builder
+	pendingDash := false
+	for _, r := range strings.ToLower(name) {
+		var part string
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			part = string(r)
+		case unicode.Is(unicode.Cyrillic, r):
+			part = translit[r]
+			if part == "" {
+				continue // твёрдый и мягкий знаки не дают разделителя
+			}
+		default:
+			pendingDash = b.Len() > 0
+			continue
+		}
+		if pendingDash {
+			b.WriteByte('-')
+			pendingDash = false
+		}
+		b.WriteString(part)
+	}
+	slug := b.String()
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
+		if i := strings.LastIndexByte(slug, '-'); i > maxSlugLength/2 {
+			slug = slug[:i]
+		}
+		slug = strings.TrimRight(slug, "-")
+	}
+	return slug
+}
+
+// slugEntity описывает таблицу, записи которой имеют слаги.
+// Слаг уникален в пределах scopeColumn (например, категории в пределах группы).
+type slugEntity struct {
+	table       string
+	idColumn    string
+	scopeColumn string // пусто – слаг уникален во всей таблице
+	fallback    string // префикс слага для названий без букв и цифр
+	pathQuery   string // запрос полного адреса записи по её ID
+}
+
+var (
+	groupSlugs = slugEntity{"groups_main", "group_id", "", "group",
+		"SELECT CONCAT('/catalog/', g.slug) FROM `groups_main` g WHERE g.group_id = ?"}
+	categorySlugs = slugEntity{"categories", "category_id", "group_id", "category",
+		"SELECT CONCAT('/catalog/', g.slug, '/', c.slug) FROM `categories` c " +
+			"JOIN `groups_main` g ON g.group_id = c.group_id WHERE c.category_id = ?"}
+	subcategorySlugs = slugEntity{"subcategories", "subcategory_id", "category_id", "subcategory",
+		"SELECT CONCAT('/catalog/', g.slug, '/', c.slug, '/', s.slug) FROM subcategories s " +
+			"JOIN `categories` c ON c.category_id = s.category_id " +
+			"JOIN `groups_main` g ON g.group_id = c.group_id WHERE s.subcategory_id = ?"}
+	partSlugs = slugEntity{"parts", "part_id", "", "part",
+		"SELECT CONCAT('/part/', p.slug) FROM parts p WHERE p.part_id = ?"}
+)
+
+// migrateSlugs добавляет столбцы слагов в таблицы каталога и создаёт таблицу перенаправлений.
+func migrateSlugs(db *sql.DB) error {
+	for _, e := range []slugEntity{groupSlugs, categorySlugs, subcategorySlugs, partSlugs} {
+		if err := ensureColumn(db, e.table, "slug", "VARCHAR(160) NULL"); err != nil {
+			return err
+		}
+		columns := "slug"
+		if e.scopeColumn != "" {
+			columns = e.scopeColumn + ", slug"
+		}
+		if err := ensureIndex(db, e.table, "idx_"+e.table+"_slug", columns); err != nil {
+			return err
+		}
+	}
+	return execAll(db,
+		"CREATE TABLE IF NOT EXISTS slug_redirects ("+
+			"old_path VARCHAR(255) NOT NULL PRIMARY KEY, "+
+			"new_path VARCHAR(255) NOT NULL, "+
+			"create_at DATETIME NOT NULL"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+	)
+}
+
+// path возвращает текущий адрес записи; пустая строка – записи нет или слаг ещё не назначен.
+func (e slugEntity) path(db dbExecutor, id int) string {
+	var path sql.NullString
+	if err := db.QueryRow(e.pathQuery, id).Scan(&path); err != nil && err != sql.ErrNoRows {
+		log.Printf("[ERROR] slugEntity.path: ошибка получения адреса %s %d: %v", e.table, id, err)
+	}
+	return path.String
+}
+
+// refresh назначает записи слаг по её текущему названию.
+// Существующий слаг сохраняется, если он по-прежнему соответствует названию и уникален.
+func (e slugEntity) refresh(db dbExecutor, id int) error {
+	scope := "0"
+	if e.scopeColumn != "" {
+		scope = e.scopeColumn
+	}
+	var name, current string
+	var scopeID int
+	query := "SELECT name, COALESCE(slug, ''), " + scope + " FROM `" + e.table + "` WHERE " + e.idColumn + " = ?"
+	if err := db.QueryRow(query, id).Scan(&name, &current, &scopeID); err != nil {
+		return err
+	}
+
+	base := Slugify(name)
+	if base == "" {
+		base = e.fallback + "-" + strconv.Itoa(id)
+	}
+	candidates := []string{base}
+	if current != "" && current != base && strings.HasPrefix(current, base+"-") {
+		// base-2, base-3 – уже выбранный ранее вариант того же названия
+		if _, err := strconv.Atoi(strings.TrimPrefix(current, base+"-")); err == nil {
+			candidates = []string{current, base}
+		}
+	}
+	for n := 2; len(candidates) < 100; n++ {
+		candidates = append(candidates, base+"-"+strconv.Itoa(n))
+	}
+
+	exists := "SELECT COUNT(*) FROM `" + e.table + "` WHERE slug = ? AND " + e.idColumn + " <> ?"
+	if e.scopeColumn != "" {
+		exists += " AND " + e.scopeColumn + " = ?"
+	}
+	for _, slug := range candidates {
+		args := []interface{}{slug, id}
+		if e.scopeColumn != "" {
+			args = append(args, scopeID)
+		}
+		var count int
+		if err := db.QueryRow(exists, args...).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		if slug == current {
+			return nil
+		}
+		_, err := db.Exec("UPDATE `"+e.table+"` SET slug = ? WHERE "+e.idColumn+" = ?", slug, id)
+		return err
+	}
+	// Все варианты заняты: добавляем ID, он уникален.
+	_, err := db.Exec("UPDATE `"+e.table+"` SET slug = ? WHERE "+e.idColumn+" = ?", base+"-"+strconv.Itoa(id), id)
+	return err
+}
+
+// update обновляет слаг записи после изменения и, если адрес изменился,
+// записывает перенаправление со старого адреса. Ошибки только логируются:
+// сбой при назначении слага не должен отменять само изменение.
+func (e slugEntity) update(db *sql.DB, id int, oldPath string) {
+	if err := e.refresh(db, id); err != nil {
+		log.Printf("[ERROR] Ошибка назначения слага %s %d: %v", e.table, id, err)
+		return
+	}
+	if newPath := e.path(db, id); oldPath != "" && newPath != "" && newPath != oldPath {
+		if err := recordRedirect(db, oldPath, newPath); err != nil {
+			log.Printf("[ERROR] Ошибка записи перенаправления %s → %s: %v", oldPath, newPath, err)
+		}
+	}
+}
+
+// recordRedirect сохраняет перенаправление со старого адреса на новый.
+// Ранее записанные перенаправления на старый адрес (и адреса внутри него)
+// переводятся на новый, чтобы не возникало цепочек.
+func recordRedirect(db *sql.DB, oldPath, newPath string) error {
+	log.Printf("[INFO] recordRedirect: %s → %s", oldPath, newPath)
+	query := "UPDATE slug_redirects SET new_path = CONCAT(?, SUBSTRING(new_path, ?)) WHERE new_path = ? OR new_path LIKE ?"
+	if _, err := db.Exec(query, newPath, len(oldPath)+1, oldPath, oldPath+"/%"); err != nil {
+		return err
+	}
+	if _, err := db.Exec("DELETE FROM slug_redirects WHERE old_path = new_path"); err != nil {
+		return err
+	}
+	_, err := db.Exec("INSERT INTO slug_redirects (old_path, new_path, create_at) VALUES (?, ?, NOW()) "+
+		"ON DUPLICATE KEY UPDATE new_path = VALUES(new_path), create_at = NOW()", oldPath, newPath)
+	return err
+}
+
+// LookupRedirect ищет перенаправление для адреса, которого нет в каталоге.
+// Если записи для самого адреса нет, проверяются родительские адреса: после
+// переименования группы старые адреса её категорий и подкатегорий ведут на новые.
+func LookupRedirect(db *sql.DB, path string) (string, bool, error) {
+	path = strings.TrimRight(path, "/")
+	for prefix, rest := path, ""; strings.Count(prefix, "/") >= 2; {
+		var target string
+		err := db.QueryRow("SELECT new_path FROM slug_redirects WHERE old_path = ?", prefix).Scan(&target)
+		if err == nil {
+			return target + rest, true, nil
+		}
+		if err != sql.ErrNoRows {
+			return "", false, err
+		}
+		i := strings.LastIndexByte(prefix, '/')
+		prefix, rest = prefix[:i], prefix[i:]+rest
+	}
+	return "", false, nil
+}
+
+// SyncSlugs назначает слаги записям каталога, у которых их ещё нет
+// (например, созданным до появления слагов или импортированным напрямую в базу).
+func SyncSlugs(db *sql.DB) error {
+	for _, e := range []slugEntity{groupSlugs, categorySlugs, subcategorySlugs, partSlugs} {
+		rows, err := db.Query("SELECT " + e.idColumn + " FROM `" + e.table + "` WHERE slug IS NULL OR slug = ''")
+		if err != nil {
+			return err
+		}
+		var ids []int
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := e.refresh(db, id); err != nil {
+				return err
+			}
+		}
+		if len(ids) > 0 {
+			log.Printf("[INFO] SyncSlugs: назначено слагов в %s: %d", e.table, len(ids))
+		}
+	}
+	return nil
+}
+
+// slugsByID возвращает назначенные слаги записей таблицы.
+func (e slugEntity) slugsByID(db *sql.DB) (map[int]string, error) {
+	rows, err := db.Query("SELECT " + e.idColumn + ", slug FROM `" + e.table + "` WHERE slug IS NOT NULL AND slug <> ''")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	slugs := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var slug string
+		if err := rows.Scan(&id, &slug); err != nil {
+			return nil, err
+		}
+		slugs[id] = slug
+	}
+	return slugs, rows.Err()
+}
+
+// PartPath возвращает адрес страницы запчасти (/part/{slug}).
+func PartPath(db *sql.DB, id int) string {
+	return partSlugs.path(db, id)
+}
+
+// UpdatePartSlug обновляет слаг запчасти после добавления или изменения названия.
+// oldPath – адрес до изменения (пустой для новой запчасти).
+func UpdatePartSlug(db *sql.DB, id int, oldPath string) {
+	partSlugs.update(db, id, oldPath)
+}
+
+// GetPartIDBySlug возвращает ID запчасти по слагу.
+func GetPartIDBySlug(db *sql.DB, slug string) (int, error) {
+	var id int
+	err := db.QueryRow("SELECT part_id FROM parts WHERE slug = ? AND deleted_at IS NULL", slug).Scan(&id)
+	return id, err
+}
+
+// CatalogLocation – раздел каталога, найденный по адресу: группа и, при наличии,
+// категория и подкатегория.
+type CatalogLocation struct {
+	GroupID         int
+	GroupName       string
+	GroupSlug       string
+	CategoryID      int
+	CategoryName    string
+	CategorySlug    string
+	SubcategoryID   int
+	SubcategoryName string
+	SubcategorySlug string
+}
+
+// Path возвращает адрес раздела каталога.
+func (l CatalogLocation) Path() string {
+	path := "/catalog/" + l.GroupSlug
+	if l.CategorySlug != "" {
+		path += "/" + l.CategorySlug
+	}
+	if l.SubcategorySlug != "" {
+		path += "/" + l.SubcategorySlug
+	}
+	return path
+}
+
+// Title возвращает название самого узкого раздела.
+func (l CatalogLocation) Title() string {
+	switch {
+	case l.SubcategoryName != "":
+		return l.SubcategoryName
+	case l.CategoryName != "":
+		return l.CategoryName
+	}
+	return l.GroupName
+}
+
+// ResolveCatalogPath находит раздел каталога по слагам. Пустые слаги категории
+// и подкатегории означают раздел уровнем выше. Если раздела нет, возвращается sql.ErrNoRows.
+func ResolveCatalogPath(db *sql.DB, groupSlug, categorySlug, subcategorySlug string) (CatalogLocation, error) {
+	l := CatalogLocation{GroupSlug: groupSlug, CategorySlug: categorySlug, SubcategorySlug: subcategorySlug}
+	err := db.QueryRow("SELECT group_id, name FROM `groups_main` WHERE slug = ? AND deleted_at IS NULL", groupSlug).Scan(&l.GroupID, &l.GroupName)
+	if err != nil || categorySlug == "" {
+		return l, err
+	}
+	err = db.QueryRow("SELECT category_id, name FROM `categories` WHERE group_id = ? AND slug = ? AND deleted_at IS NULL", l.GroupID, categorySlug).
+		Scan(&l.CategoryID, &l.CategoryName)
+	if err != nil || subcategorySlug == "" {
+		return l, err
+	}
+	err = db.QueryRow("SELECT subcategory_id, name FROM subcategories WHERE category_id = ? AND slug = ? AND deleted_at IS NULL", l.CategoryID, subcategorySlug).
+		Scan(&l.SubcategoryID, &l.SubcategoryName)
+	return l, err
+}
+
+// GetSubcategoryLocation возвращает раздел каталога для подкатегории по её ID.
+func GetSubcategoryLocation(db *sql.DB, subcategoryID int) (CatalogLocation, error) {
+	var l CatalogLocation
+	query := "SELECT g.group_id, g.name, g.slug, c.category_id, c.name, c.slug, s.subcategory_id, s.name, s.slug " +
+		"FROM subcategories s JOIN `categories` c ON c.category_id = s.category_id " +
+		"JOIN `groups_main` g ON g.group_id = c.group_id WHERE s.subcategory_id = ? " +
+		"AND g.slug IS NOT NULL AND c.slug IS NOT NULL AND s.slug IS NOT NULL " +
+		"AND g.deleted_at IS NULL AND c.deleted_at IS NULL AND s.deleted_at IS NULL"
+	err := db.QueryRow(query, subcategoryID).Scan(&l.GroupID, &l.GroupName, &l.GroupSlug,
+		&l.CategoryID, &l.CategoryName, &l.CategorySlug, &l.SubcategoryID, &l.SubcategoryName, &l.SubcategorySlug)
+	return l, err
+}
+
+// GetCategoryLocationByName возвращает раздел каталога для категории по её названию.
+func GetCategoryLocationByName(db *sql.DB, name string) (CatalogLocation, error) {
+	var l CatalogLocation
+	query := "SELECT g.group_id, g.name, g.slug, c.category_id, c.name, c.slug " +
+		"FROM `categories` c JOIN `groups_main` g ON g.group_id = c.group_id " +
+		"WHERE c.name = ? AND g.slug IS NOT NULL AND c.slug IS NOT NULL AND g.deleted_at IS NULL AND c.deleted_at IS NULL " +
+		"ORDER BY c.category_id LIMIT 1"
+	err := db.QueryRow(query, name).Scan(&l.GroupID, &l.GroupName, &l.GroupSlug, &l.CategoryID, &l.CategoryName, &l.CategorySlug)
+	return l, err
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct{ name, want string }{
+		{"Тормозные колодки", "tormoznye-kolodki"},
+		{"Масляный фильтр OC 90", "maslyanyy-filtr-oc-90"},
+		{"  Щётка / стеклоочистителя!! ", "shchetka-stekloochistitelya"},
+		{"Объём", "obem"},
+		{"---", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Slugify(tt.name); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, ожидалось %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSlugifyLength(t *testing.T) {
+	// Длинный слаг обрезается по границе слова, а не посреди него.
+	got := Slugify(strings.Repeat("колодка ", 30))
+	if len(got) > maxSlugLength || strings.HasSuffix(got, "-") || !strings.HasSuffix(got, "kolodka") {
+		t.Errorf("Slugify: неверно обрезан длинный слаг %q (%d)", got, len(got))
+	}
+}
@@ -0,0 +1,190 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Реализация алгоритма Snowball (Портер) для русского языка.
+// Используется для построения поискового индекса и разбора поисковых запросов,
+// чтобы "колодки", "колодок" и "колодка" находили одни и те же запчасти.
+
+var (
+	// Окончания деепричастий совершенного вида.
+	perfectiveGerund1 = []string{"вшись", "вши", "в"}
+	perfectiveGerund2 = []string{"ившись", "ывшись", "ивши", "ывши", "ив", "ыв"}
+
+	// Окончания прилагательных.
+	adjectiveEndings = []string{
+		"ими", "ыми", "его", "ого", "ему", "ому",
+		"ее", "ие", "ые", "ое", "ей", "ий", "ый", "ой", "ем", "им", "ым", "ом",
+		"их", "ых", "ую", "юю", "ая", "яя", "ою", "ею",
+	}
+
+	// Окончания причастий.
+	participle1 = []string{"ем", "нн", "вш", "ющ", "щ"}
+	participle2 = []string{"ивш", "ывш", "ующ"}
+
+	// Возвратные окончания.
+	reflexiveEndings = []string{"ся", "сь"}
+
+	// Окончания глаголов.
+	verb1 = []string{"ете", "йте", "ешь", "нно", "ла", "на", "ли", "ем", "ло", "но", "ет", "ют", "ны", "ть", "й", "л", "н"}
+	verb2 = []string{
+		"ейте", "уйте", "ила", "ыла", "ена", "ите", "или", "ыли", "ило", "ыло", "ено",
+		"ует", "уют", "ены", "ить", "ыть", "ишь", "ей", "уй", "ил", "ыл", "им", "ым",
+		"ен", "ят", "ит", "ыт", "ую", "ю",
+	}
+
+	// Окончания существительных.
+	nounEndings = []string{
+		"иями", "ями", "ами", "ией", "иям", "ием", "иях",
+		"ев", "ов", "ие", "ье", "еи", "ии", "ей", "ой", "ий", "ям", "ем", "ам", "ом", "ах", "ях", "ию", "ью", "ия", "ья",
+		"а", "е", "и", "й", "о", "у", "ы", "ь", "ю", "я",
+	}
+
+	// Словообразовательные и превосходные окончания.
+	derivationalEndings = []string{"ость", "ост"}
+	superlativeEndings  = []string{"ейше", "ейш"}
+)
+
+// isRussianVowel сообщает, является ли руна гласной в смысле алгоритма.
+func isRussianVowel(r rune) bool {
+	switch r {
+	case 'а', 'е', 'и', 'о', 'у', 'ы', 'э', 'ю', 'я':
+		return true
+	}
+	return false
+}
+
+// StemRussian возвращает основу русского слова.
+// Слова, содержащие не только кириллицу (артикулы, латинские бренды), возвращаются без изменений,
+// только в нижнем регистре.
+func StemRussian(word string) string {
+	word = strings.ReplaceAll(strings.ToLower(word), "ё", "е")
+	w := []rune(word)
+	for _, r := range w {
+		if r < 'а' || r > 'я' {
+			return word
+		}
+	}
+
+	// RV – часть слова после первой гласной, R2 – вторая область R по Портеру.
+	rv := len(w)
+	for i, r := range w {
+		if isRussianVowel(r) {
+			rv = i + 1
+			break
+		}
+	}
+	r2 := regionAfter(w, regionAfter(w, 0))
+	if rv >= len(w) {
+		return word
+	}
+
+	// Шаг 1.
+	if !removeEnding(&w, rv, perfectiveGerund1, true) && !removeEnding(&w, rv, perfectiveGerund2, false) {
+		removeEnding(&w, rv, reflexiveEndings, false)
+		if !removeAdjectival(&w, rv) &&
+			!removeEnding(&w, rv, verb1, true) && !removeEnding(&w, rv, verb2, false) {
+			removeEnding(&w, rv, nounEndings, false)
+		}
+	}
+
+	// Шаг 2.
+	removeEnding(&w, rv, []string{"и"}, false)
+
+	// Шаг 3.
+	removeEnding(&w, r2, derivationalEndings, false)
+
+	// Шаг 4: "нн" -> "н", удаление превосходной степени или мягкого знака.
+	if removeEnding(&w, rv, superlativeEndings, false) || hasSuffix(w[rv:], []rune("нн")) {
+		if hasSuffix(w[rv:], []rune("нн")) {
+			w = w[:len(w)-1]
+		}
+	} else {
+		removeEnding(&w, rv, []string{"ь"}, false)
+	}
+
+	return string(w)
+}
+
+// regionAfter возвращает начало области R: позицию после первой согласной,
+// следующей за гласной, начиная поиск с позиции from.
+func regionAfter(w []rune, from int) int {
+	for i := from + 1; i < len(w); i++ {
+		if !isRussianVowel(w[i]) && isRussianVowel(w[i-1]) {
+			return i + 1
+		}
+	}
+	return len(w)
+}
+
+// removeEnding удаляет самое длинное из окончаний, целиком лежащее в области,
+// начинающейся с позиции start. Если afterAorYa = true, перед окончанием
+// должна стоять буква "а" или "я" (сама буква при этом остаётся).
+func removeEnding(w *[]rune, start int, endings []string, afterAorYa bool) bool {
+	best := 0
+	for _, ending := range endings {
+		e := []rune(ending)
+		n := len(e)
+		if n <= best || len(*w)-n < start || !hasSuffix(*w, e) {
+			continue
+		}
+		if afterAorYa {
+			pos := len(*w) - n - 1
+			if pos < start || ((*w)[pos] != 'а' && (*w)[pos] != 'я') {
+				continue
+			}
+		}
+		best = n
+	}
+	if best == 0 {
+		return false
+	}
+	*w = (*w)[:len(*w)-best]
+	return true
+}
+
+// removeAdjectival удаляет окончание прилагательного вместе с предшествующим
+// суффиксом причастия, если он есть.
+func removeAdjectival(w *[]rune, rv int) bool {
+	if !removeEnding(w, rv, adjectiveEndings, false) {
+		return false
+	}
+	if !removeEnding(w, rv, participle1, true) {
+		removeEnding(w, rv, participle2, false)
+	}
+	return true
+}
+
+// hasSuffix сообщает, оканчивается ли слово последовательностью рун suffix.
+func hasSuffix(w, suffix []rune) bool {
+	if len(suffix) > len(w) {
+		return false
+	}
+	off := len(w) - len(suffix)
+	for i, r := range suffix {
+		if w[off+i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// Tokenize разбивает текст на слова из букв и цифр в нижнем регистре.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// StemText возвращает строку из основ всех слов текста, разделённых пробелами.
+// Результат сохраняется в поисковом индексе запчастей.
+func StemText(text string) string {
+	tokens := Tokenize(text)
+	for i, token := range tokens {
+		tokens[i] = StemRussian(token)
+	}
+	return strings.Join(tokens, " ")
+}
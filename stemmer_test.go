@@ -0,0 +1,42 @@
+package models
+
+import "testing"
+
+func TestStemRussian(t *testing.T) {
+	// Ожидаемые основы совпадают с эталонной реализацией Snowball для русского языка.
+	tests := []struct{ word, want string }{
+		{"колодки", "колодк"},
+		{"колодка", "колодк"},
+		{"фильтры", "фильтр"},
+		{"фильтров", "фильтр"},
+		{"амортизаторов", "амортизатор"},
+		{"тормозные", "тормозн"},
+		{"масляный", "маслян"},
+		{"передние", "передн"},
+		{"свечи", "свеч"},
+		{"подшипник", "подшипник"},
+		{"ёлка", "елк"},
+		// Артикулы и латинские бренды не сокращаются.
+		{"OC90", "oc90"},
+		{"Bosch", "bosch"},
+	}
+	for _, tt := range tests {
+		if got := StemRussian(tt.word); got != tt.want {
+			t.Errorf("StemRussian(%q) = %q, ожидалось %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"колодки", "колодки"},
+		{"100%", `100\%`},
+		{"OC_90", `OC\_90`},
+		{`A\B`, `A\\B`},
+	}
+	for _, tt := range tests {
+		if got := escapeLike(tt.in); got != tt.want {
+			t.Errorf("escapeLike(%q) = %q, ожидалось %q", tt.in, got, tt.want)
+		}
+	}
+}
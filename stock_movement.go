@@ -0,0 +1,352 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Виды движения товара. Quantity движения положительно при поступлении на склад
+// и отрицательно при списании.
+const (
+	MovementReceipt    = "receipt"    // поступление от поставщика, импорт прайса
+	MovementSale       = "sale"       // продажа (SellPart)
+	MovementAdjustment = "adjustment" // корректировка: ручное изменение остатка, инвентаризация
+	MovementTransfer   = "transfer"   // перемещение между складами: пара движений с общим документом
+	MovementReturn     = "return"     // возврат от покупателя
+)
+
+// Ошибки движений товара.
+var (
+	ErrMovementKind      = errors.New("вид движения должен быть receipt, return или adjustment; продажа и перемещение выполняются отдельными операциями")
+	ErrMovementQuantity  = errors.New("количество движения должно быть больше нуля (для корректировки – не равно нулю)")
+	ErrMovementSource    = errors.New("основание не длиннее 255 символов, документ – не длиннее 100")
+	ErrTransferWarehouse = errors.New("склады перемещения должны различаться")
+)
+
+// MovementSource описывает основание движения товара.
+type MovementSource struct {
+	Reason   string // причина, например "Пересорт" или "Импорт из Excel"
+	Username string // пользователь, выполнивший операцию
+	Document string // номер накладной, чека, акта или имя файла импорта
+}
+
+// validate обрезает пробелы и проверяет длину полей основания.
+func (src *MovementSource) validate() error {
+	src.Reason, src.Document = strings.TrimSpace(src.Reason), strings.TrimSpace(src.Document)
+	if utf8.RuneCountInString(src.Reason) > 255 || utf8.RuneCountInString(src.Document) > 100 {
+		return ErrMovementSource
+	}
+	return nil
+}
+
+// StockMovement – запись журнала движений товара. Журнал только дополняется:
+// остатки в part_stock – накопленная сумма движений по запчасти и складу.
+type StockMovement struct {
+	ID          int       `json:"id"`
+	PartID      int       `json:"part_id"`
+	PartName    string    `json:"part_name"`
+	WarehouseID int       `json:"warehouse_id"`
+	Warehouse   string    `json:"warehouse"`
+	Kind        string    `json:"kind"`
+	Quantity    int       `json:"quantity"` // со знаком: + поступление, − списание
+	Balance     int       `json:"balance"`  // остаток на складе после движения
+	Reason      string    `json:"reason,omitempty"`
+	Username    string    `json:"username,omitempty"`
+	Document    string    `json:"document,omitempty"`
+	CreateAt    time.Time `json:"create_at"`
+}
+
+// StockMovementFilter – условия выборки журнала движений; нулевые поля не ограничивают выборку.
+type StockMovementFilter struct {
+	PartID      int
+	WarehouseID int
+	Kind        string
+	Document    string
+	From, To    time.Time // To не включается
+	Limit       int
+	Offset      int
+}
+
+// StockDiscrepancy – расхождение остатка на складе с суммой движений по журналу.
+type StockDiscrepancy struct {
+	PartID      int    `json:"part_id"`
+	PartName    string `json:"part_name"`
+	WarehouseID int    `json:"warehouse_id"`
+	Warehouse   string `json:"warehouse"`
+	Stock       int    `json:"stock"`  // остаток в part_stock
+	Ledger      int    `json:"ledger"` // сумма движений
+}
+
+// migrateStockMovements создаёт журнал движений и записывает в него начальные остатки
+// складов, по которым ещё нет движений.
+func migrateStockMovements(db *sql.DB) error {
+	return execAll(db,
+		"CREATE TABLE IF NOT EXISTS stock_movements ("+
+			"movement_id INT AUTO_INCREMENT PRIMARY KEY, "+
+			"part_id INT NOT NULL, "+
+			"warehouse_id INT NOT NULL, "+
+			"kind VARCHAR(16) NOT NULL, "+
+			"quantity INT NOT NULL, "+
+			"balance INT NOT NULL, "+
+			"reason VARCHAR(255) NOT NULL DEFAULT '', "+
+			"username VARCHAR(100) NOT NULL DEFAULT '', "+
+			"document VARCHAR(100) NOT NULL DEFAULT '', "+
+			"create_at DATETIME NOT NULL, "+
+			"KEY idx_stock_movements_part (part_id, warehouse_id, create_at), "+
+			"KEY idx_stock_movements_document (document), "+
+			"KEY idx_stock_movements_date (create_at)"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"INSERT INTO stock_movements (part_id, warehouse_id, kind, quantity, balance, reason, create_at) "+
+			"SELECT s.part_id, s.warehouse_id, '"+MovementAdjustment+"', s.quantity, s.quantity, 'Начальный остаток', NOW() "+
+			"FROM part_stock s WHERE s.quantity <> 0 AND NOT EXISTS "+
+			"(SELECT 1 FROM stock_movements m WHERE m.part_id = s.part_id AND m.warehouse_id = s.warehouse_id)",
+	)
+}
+
+// lockStock блокирует строку остатка запчасти на складе и возвращает остаток (0, если строки нет).
+func lockStock(tx dbExecutor, partID, warehouseID int) (int, error) {
+	var quantity int
+	err := tx.QueryRow("SELECT quantity FROM part_stock WHERE part_id = ? AND warehouse_id = ? FOR UPDATE", partID, warehouseID).Scan(&quantity)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return quantity, err
+}
+
+// recordMovement меняет остаток запчасти на складе на delta и записывает движение в журнал.
+// Возвращает новый остаток или ErrInsufficientStock, если он стал бы отрицательным.
+// Общий остаток запчасти (parts.quantity) пересчитывает вызывающий код.
+func recordMovement(tx dbExecutor, partID, warehouseID int, kind string, delta int, src MovementSource) (int, error) {
+	current, err := lockStock(tx, partID, warehouseID)
+	if err != nil {
+		return 0, err
+	}
+	balance := current + delta
+	if balance < 0 {
+		return 0, ErrInsufficientStock
+	}
+	query := "INSERT INTO part_stock (part_id, warehouse_id, quantity) VALUES (?, ?, ?) " +
+		"ON DUPLICATE KEY UPDATE quantity = VALUES(quantity)"
+	if _, err := tx.Exec(query, partID, warehouseID, balance); err != nil {
+		return 0, err
+	}
+	query = "INSERT INTO stock_movements (part_id, warehouse_id, kind, quantity, balance, reason, username, document, create_at) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, NOW())"
+	if _, err := tx.Exec(query, partID, warehouseID, kind, delta, balance, src.Reason, src.Username, src.Document); err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
+
+// AddStockMovement проводит поступление, возврат или корректировку (m.Quantity со знаком)
+// запчасти m.PartID на складе m.WarehouseID (0 – склад по умолчанию) и возвращает новый остаток.
+// Возвращает sql.ErrNoRows, если нет запчасти или склада, и ErrInsufficientStock,
+// если корректировка уводит остаток в минус.
+func AddStockMovement(db *sql.DB, m StockMovement) (int, error) {
+	switch m.Kind {
+	case MovementReceipt, MovementReturn:
+		if m.Quantity <= 0 {
+			return 0, ErrMovementQuantity
+		}
+	case MovementAdjustment:
+		if m.Quantity == 0 {
+			return 0, ErrMovementQuantity
+		}
+	default:
+		return 0, ErrMovementKind
+	}
+	src := MovementSource{Reason: m.Reason, Username: m.Username, Document: m.Document}
+	if err := src.validate(); err != nil {
+		return 0, err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	warehouseID, err := stockTarget(tx, m.PartID, m.WarehouseID)
+	if err != nil {
+		return 0, err
+	}
+	balance, err := recordMovement(tx, m.PartID, warehouseID, m.Kind, m.Quantity, src)
+	if err != nil {
+		return 0, err
+	}
+	if err := syncPartQuantity(tx, m.PartID); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	InvalidateCatalogTree()
+	log.Printf("[INFO] AddStockMovement: запчасть %d, склад %d: %s %+d, остаток %d", m.PartID, warehouseID, m.Kind, m.Quantity, balance)
+	return balance, nil
+}
+
+// TransferStock перемещает quantity единиц запчасти со склада fromID на склад toID
+// (0 – склад по умолчанию). В журнал записываются два движения с общим документом;
+// если документ не указан, он формируется автоматически. Общий остаток не меняется.
+func TransferStock(db *sql.DB, partID, fromID, toID, quantity int, src MovementSource) (string, error) {
+	if quantity <= 0 {
+		return "", ErrMovementQuantity
+	}
+	if err := src.validate(); err != nil {
+		return "", err
+	}
+	if src.Document == "" {
+		src.Document = "transfer-" + time.Now().Format("20060102-150405")
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	if fromID, err = stockTarget(tx, partID, fromID); err != nil {
+		return "", err
+	}
+	if toID, err = stockTarget(tx, partID, toID); err != nil {
+		return "", err
+	}
+	if fromID == toID {
+		return "", ErrTransferWarehouse
+	}
+	if _, err := recordMovement(tx, partID, fromID, MovementTransfer, -quantity, src); err != nil {
+		return "", err
+	}
+	if _, err := recordMovement(tx, partID, toID, MovementTransfer, quantity, src); err != nil {
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	InvalidateCatalogTree()
+	log.Printf("[INFO] TransferStock: запчасть %d: %d шт. со склада %d на склад %d, документ %s", partID, quantity, fromID, toID, src.Document)
+	return src.Document, nil
+}
+
+// GetStockMovements возвращает журнал движений, новые записи первыми.
+func GetStockMovements(db *sql.DB, f StockMovementFilter) ([]StockMovement, error) {
+	query := "SELECT m.movement_id, m.part_id, COALESCE(p.name, ''), m.warehouse_id, COALESCE(w.name, ''), m.kind, " +
+		"m.quantity, m.balance, m.reason, m.username, m.document, m.create_at FROM stock_movements m " +
+		"LEFT JOIN parts p ON p.part_id = m.part_id LEFT JOIN warehouses w ON w.warehouse_id = m.warehouse_id WHERE 1 = 1"
+	var args []interface{}
+	if f.PartID > 0 {
+		query += " AND m.part_id = ?"
+		args = append(args, f.PartID)
+	}
+	if f.WarehouseID > 0 {
+		query += " AND m.warehouse_id = ?"
+		args = append(args, f.WarehouseID)
+	}
+	if f.Kind != "" {
+		query += " AND m.kind = ?"
+		args = append(args, f.Kind)
+	}
+	if f.Document != "" {
+		query += " AND m.document = ?"
+		args = append(args, f.Document)
+	}
+	if !f.From.IsZero() {
+		query += " AND m.create_at >= ?"
+		args = append(args, f.From)
+	}
+	if !f.To.IsZero() {
+		query += " AND m.create_at < ?"
+		args = append(args, f.To)
+	}
+	limit := f.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	query += " ORDER BY m.create_at DESC, m.movement_id DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, f.Offset)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movements := []StockMovement{}
+	for rows.Next() {
+		var m StockMovement
+		if err := rows.Scan(&m.ID, &m.PartID, &m.PartName, &m.WarehouseID, &m.Warehouse, &m.Kind,
+			&m.Quantity, &m.Balance, &m.Reason, &m.Username, &m.Document, &m.CreateAt); err != nil {
+			return nil, err
+		}
+		movements = append(movements, m)
+	}
+	return movements, rows.Err()
+}
+
+// stockDiscrepancySQL выбирает пары запчасть–склад, у которых остаток не равен сумме
+// движений: и строки остатков, и движения по складам без строки остатка.
+const stockDiscrepancySQL = "SELECT d.part_id, p.name, d.warehouse_id, w.name, d.stock, d.ledger FROM (" +
+	"SELECT s.part_id, s.warehouse_id, s.quantity AS stock, COALESCE((SELECT SUM(m.quantity) FROM stock_movements m " +
+	"WHERE m.part_id = s.part_id AND m.warehouse_id = s.warehouse_id), 0) AS ledger FROM part_stock s " +
+	"UNION ALL " +
+	"SELECT m.part_id, m.warehouse_id, 0, SUM(m.quantity) FROM stock_movements m WHERE NOT EXISTS " +
+	"(SELECT 1 FROM part_stock s WHERE s.part_id = m.part_id AND s.warehouse_id = m.warehouse_id) " +
+	"GROUP BY m.part_id, m.warehouse_id" +
+	") d JOIN parts p ON p.part_id = d.part_id JOIN warehouses w ON w.warehouse_id = d.warehouse_id " +
+	"WHERE d.stock <> d.ledger ORDER BY d.part_id, d.warehouse_id"
+
+// GetStockDiscrepancies возвращает расхождения остатков с журналом движений.
+func GetStockDiscrepancies(db dbExecutor) ([]StockDiscrepancy, error) {
+	rows, err := db.Query(stockDiscrepancySQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	discrepancies := []StockDiscrepancy{}
+	for rows.Next() {
+		var d StockDiscrepancy
+		if err := rows.Scan(&d.PartID, &d.PartName, &d.WarehouseID, &d.Warehouse, &d.Stock, &d.Ledger); err != nil {
+			return nil, err
+		}
+		discrepancies = append(discrepancies, d)
+	}
+	return discrepancies, rows.Err()
+}
+
+// ReconcileStock приводит остатки по складам к сумме движений журнала, пересчитывает
+// общие остатки запчастей и комплектов и возвращает исправленные расхождения.
+func ReconcileStock(db *sql.DB) ([]StockDiscrepancy, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	discrepancies, err := GetStockDiscrepancies(tx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0, len(discrepancies))
+	for _, d := range discrepancies {
+		query := "INSERT INTO part_stock (part_id, warehouse_id, quantity) VALUES (?, ?, ?) " +
+			"ON DUPLICATE KEY UPDATE quantity = VALUES(quantity)"
+		if _, err := tx.Exec(query, d.PartID, d.WarehouseID, d.Ledger); err != nil {
+			return nil, err
+		}
+		ids = append(ids, d.PartID)
+		log.Printf("[WARN] ReconcileStock: запчасть %d, склад %d: остаток %d исправлен по журналу на %d", d.PartID, d.WarehouseID, d.Stock, d.Ledger)
+	}
+	if err := syncPartQuantity(tx, ids...); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	if len(discrepancies) > 0 {
+		InvalidateCatalogTree()
+	}
+	return discrepancies, nil
+}
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"AutoM/config"
+	"AutoM/models"
+)
+
+// respondMovementError отвечает статусом ошибки проведения движения товара.
+func respondMovementError(w http.ResponseWriter, handler string, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "Запчасть или склад не найдены", http.StatusNotFound)
+	case errors.Is(err, models.ErrMovementKind), errors.Is(err, models.ErrMovementQuantity), errors.Is(err, models.ErrMovementSource),
+		errors.Is(err, models.ErrTransferWarehouse), errors.Is(err, models.ErrStockKit):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, models.ErrInsufficientStock):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("[ERROR] %s: %v", handler, err)
+		http.Error(w, "Ошибка проведения движения товара", http.StatusInternalServerError)
+	}
+}
+
+// GetStockMovements – журнал движений товара (API). Параметры part_id, warehouse_id,
+// kind, document, from и to (ГГГГ-ММ-ДД, to включается целиком), limit и offset.
+func GetStockMovements(w http.ResponseWriter, r *http.Request) {
+	var filter models.StockMovementFilter
+	var err error
+	for _, param := range []struct {
+		name string
+		dst  *int
+	}{
+		{"part_id", &filter.PartID}, {"warehouse_id", &filter.WarehouseID}, {"limit", &filter.Limit}, {"offset", &filter.Offset},
+	} {
+		if *param.dst, err = queryInt(r, param.name); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if filter.From, err = queryDate(r, "from"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.To, err = queryDate(r, "to"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !filter.To.IsZero() {
+		filter.To = filter.To.AddDate(0, 0, 1)
+	}
+	filter.Kind = strings.TrimSpace(r.URL.Query().Get("kind"))
+	filter.Document = strings.TrimSpace(r.URL.Query().Get("document"))
+
+	movements, err := models.GetStockMovements(config.DB, filter)
+	if err != nil {
+		log.Printf("[ERROR] GetStockMovements: ошибка получения журнала движений: %v", err)
+		http.Error(w, "Ошибка получения журнала движений", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetStockMovements", movements)
+}
+
+// AddStockMovement – поступление, возврат или корректировка остатка (API). Ожидается JSON
+// {"part_id": 1, "warehouse_id": 2, "kind": "receipt", "quantity": 10, "reason": "...", "document": "ТН-15"};
+// для корректировки quantity со знаком. Возвращает новый остаток на складе.
+func AddStockMovement(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	var m models.StockMovement
+	if !decodeJSON(w, r, "AddStockMovement", &m) {
+		return
+	}
+	m.Username = sessionUsername(r)
+	balance, err := models.AddStockMovement(config.DB, m)
+	if err != nil {
+		respondMovementError(w, "AddStockMovement", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, "AddStockMovement", map[string]int{"balance": balance})
+}
+
+// TransferStock – перемещение запчасти между складами (API). Ожидается JSON
+// {"part_id": 1, "from_warehouse_id": 1, "to_warehouse_id": 3, "quantity": 2, "reason": "...", "document": "..."}.
+func TransferStock(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	var req struct {
+		PartID          int    `json:"part_id"`
+		FromWarehouseID int    `json:"from_warehouse_id"`
+		ToWarehouseID   int    `json:"to_warehouse_id"`
+		Quantity        int    `json:"quantity"`
+		Reason          string `json:"reason"`
+		Document        string `json:"document"`
+	}
+	if !decodeJSON(w, r, "TransferStock", &req) {
+		return
+	}
+	src := models.MovementSource{Reason: req.Reason, Username: sessionUsername(r), Document: req.Document}
+	document, err := models.TransferStock(config.DB, req.PartID, req.FromWarehouseID, req.ToWarehouseID, req.Quantity, src)
+	if err != nil {
+		respondMovementError(w, "TransferStock", err)
+		return
+	}
+	writeJSON(w, "TransferStock", map[string]string{"document": document})
+}
+
+// GetStockDiscrepancies – расхождения остатков по складам с журналом движений (API).
+func GetStockDiscrepancies(w http.ResponseWriter, r *http.Request) {
+	discrepancies, err := models.GetStockDiscrepancies(config.DB)
+	if err != nil {
+		log.Printf("[ERROR] GetStockDiscrepancies: ошибка сверки остатков: %v", err)
+		http.Error(w, "Ошибка сверки остатков", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetStockDiscrepancies", discrepancies)
+}
+
+// ReconcileStock – исправление остатков по журналу движений (API).
+// Возвращает исправленные расхождения.
+func ReconcileStock(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	fixed, err := models.ReconcileStock(config.DB)
+	if err != nil {
+		log.Printf("[ERROR] ReconcileStock: ошибка исправления остатков: %v", err)
+		http.Error(w, "Ошибка исправления остатков", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("[INFO] ReconcileStock: исправлено расхождений: %d", len(fixed))
+	writeJSON(w, "ReconcileStock", map[string]interface{}{"fixed": fixed})
+}
@@ -0,0 +1,391 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Состояния инвентаризации.
+const (
+	StocktakeOpen      = "open"      // идёт подсчёт
+	StocktakeApproved  = "approved"  // расхождения проведены корректировками
+	StocktakeCancelled = "cancelled" // отменена без изменения остатков
+)
+
+// Ошибки инвентаризации.
+var (
+	ErrStocktakeClosed = errors.New("инвентаризация уже утверждена или отменена")
+	ErrStocktakeItem   = errors.New("запчасть не входит в инвентаризацию")
+	ErrStocktakeCount  = errors.New("фактическое количество не может быть отрицательным")
+	ErrStocktakeNote   = errors.New("комментарий инвентаризации не длиннее 255 символов")
+)
+
+// Stocktake – инвентаризация склада целиком или одной подкатегории на складе.
+type Stocktake struct {
+	ID            int        `json:"id"`
+	WarehouseID   int        `json:"warehouse_id"`
+	Warehouse     string     `json:"warehouse"`
+	SubcategoryID *int       `json:"subcategory_id"` // nil – весь склад
+	Subcategory   string     `json:"subcategory,omitempty"`
+	Status        string     `json:"status"`
+	Note          string     `json:"note,omitempty"`
+	CreatedBy     string     `json:"created_by,omitempty"`
+	CreateAt      time.Time  `json:"create_at"`
+	ClosedBy      string     `json:"closed_by,omitempty"`
+	ClosedAt      *time.Time `json:"closed_at"`
+	Items         int        `json:"items"`   // запчастей в инвентаризации
+	Counted       int        `json:"counted"` // из них посчитано
+}
+
+// Document возвращает документ, под которым корректировки инвентаризации записываются в журнал движений.
+func (s Stocktake) Document() string {
+	return fmt.Sprintf("Инвентаризация №%d", s.ID)
+}
+
+// StocktakeItem – строка инвентаризации. Расхождение считается от текущего остатка
+// на складе (его сумма по складам – parts.quantity), а не от остатка на момент открытия:
+// продажи во время подсчёта не дают ложных расхождений.
+type StocktakeItem struct {
+	PartID    int        `json:"part_id"`
+	Name      string     `json:"name"`
+	Article   string     `json:"article,omitempty"`
+	Price     float64    `json:"price"`
+	Expected  int        `json:"expected"` // остаток на складе при открытии
+	Stock     int        `json:"stock"`    // текущий остаток на складе
+	Counted   *int       `json:"counted"`  // nil – ещё не посчитано
+	CountedBy string     `json:"counted_by,omitempty"`
+	CountedAt *time.Time `json:"counted_at"`
+}
+
+// Difference возвращает расхождение факта с текущим остатком (0, если не посчитано).
+func (i StocktakeItem) Difference() int {
+	if i.Counted == nil {
+		return 0
+	}
+	return *i.Counted - i.Stock
+}
+
+// migrateStocktakes создаёт таблицы инвентаризаций и их строк.
+func migrateStocktakes(db *sql.DB) error {
+	return execAll(db,
+		"CREATE TABLE IF NOT EXISTS stocktakes ("+
+			"stocktake_id INT AUTO_INCREMENT PRIMARY KEY, "+
+			"warehouse_id INT NOT NULL, "+
+			"subcategory_id INT NULL, "+
+			"status VARCHAR(16) NOT NULL DEFAULT 'open', "+
+			"note VARCHAR(255) NOT NULL DEFAULT '', "+
+			"created_by VARCHAR(100) NOT NULL DEFAULT '', "+
+			"create_at DATETIME NOT NULL, "+
+			"closed_by VARCHAR(100) NOT NULL DEFAULT '', "+
+			"closed_at DATETIME NULL, "+
+			"KEY idx_stocktakes_status (status, create_at)"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"CREATE TABLE IF NOT EXISTS stocktake_items ("+
+			"stocktake_id INT NOT NULL, "+
+			"part_id INT NOT NULL, "+
+			"expected INT NOT NULL, "+
+			"counted INT NULL, "+
+			"counted_by VARCHAR(100) NOT NULL DEFAULT '', "+
+			"counted_at DATETIME NULL, "+
+			"PRIMARY KEY (stocktake_id, part_id), "+
+			"FOREIGN KEY (stocktake_id) REFERENCES stocktakes (stocktake_id) ON DELETE CASCADE"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+	)
+}
+
+// OpenStocktake открывает инвентаризацию склада warehouseID (0 – склад по умолчанию);
+// subcategoryID > 0 ограничивает её одной подкатегорией. В инвентаризацию попадают
+// все запчасти вне корзины, кроме комплектов. Возвращает ID инвентаризации
+// или sql.ErrNoRows, если нет склада или подкатегории.
+func OpenStocktake(db *sql.DB, warehouseID, subcategoryID int, note, username string) (int64, error) {
+	note = strings.TrimSpace(note)
+	if len([]rune(note)) > 255 {
+		return 0, ErrStocktakeNote
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if warehouseID == 0 {
+		if warehouseID, err = defaultWarehouseID(tx); err != nil {
+			return 0, err
+		}
+	} else {
+		var exists int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM warehouses WHERE warehouse_id = ? LOCK IN SHARE MODE", warehouseID).Scan(&exists); err != nil {
+			return 0, err
+		}
+		if exists == 0 {
+			return 0, sql.ErrNoRows
+		}
+	}
+	var subcategory interface{}
+	if subcategoryID > 0 {
+		if ok, err := subcategoryLevel.liveExists(tx, subcategoryID); err != nil || !ok {
+			if err == nil {
+				err = sql.ErrNoRows
+			}
+			return 0, err
+		}
+		subcategory = subcategoryID
+	}
+
+	res, err := tx.Exec("INSERT INTO stocktakes (warehouse_id, subcategory_id, status, note, created_by, create_at) VALUES (?, ?, ?, ?, ?, NOW())",
+		warehouseID, subcategory, StocktakeOpen, note, username)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	query := "INSERT INTO stocktake_items (stocktake_id, part_id, expected) " +
+		"SELECT ?, p.part_id, COALESCE(s.quantity, 0) FROM parts p " +
+		"LEFT JOIN part_stock s ON s.part_id = p.part_id AND s.warehouse_id = ? " +
+		"WHERE p.deleted_at IS NULL AND NOT EXISTS (SELECT 1 FROM part_kits k WHERE k.part_id = p.part_id)"
+	args := []interface{}{id, warehouseID}
+	if subcategoryID > 0 {
+		query += " AND p.subcategory_id = ?"
+		args = append(args, subcategoryID)
+	}
+	items, err := rowsAffected(tx.Exec(query, args...))
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	log.Printf("[INFO] OpenStocktake: инвентаризация %d: склад %d, подкатегория %d, запчастей %d", id, warehouseID, subcategoryID, items)
+	return id, nil
+}
+
+// stocktakeSelect – выборка инвентаризаций с названиями склада и подкатегории и счётчиками строк.
+const stocktakeSelect = "SELECT t.stocktake_id, t.warehouse_id, COALESCE(w.name, ''), t.subcategory_id, COALESCE(sc.name, ''), " +
+	"t.status, t.note, t.created_by, t.create_at, t.closed_by, t.closed_at, " +
+	"(SELECT COUNT(*) FROM stocktake_items i WHERE i.stocktake_id = t.stocktake_id), " +
+	"(SELECT COUNT(*) FROM stocktake_items i WHERE i.stocktake_id = t.stocktake_id AND i.counted IS NOT NULL) " +
+	"FROM stocktakes t LEFT JOIN warehouses w ON w.warehouse_id = t.warehouse_id " +
+	"LEFT JOIN subcategories sc ON sc.subcategory_id = t.subcategory_id"
+
+// scanStocktake читает строку stocktakeSelect.
+func scanStocktake(row interface{ Scan(...interface{}) error }) (Stocktake, error) {
+	var s Stocktake
+	err := row.Scan(&s.ID, &s.WarehouseID, &s.Warehouse, &s.SubcategoryID, &s.Subcategory,
+		&s.Status, &s.Note, &s.CreatedBy, &s.CreateAt, &s.ClosedBy, &s.ClosedAt, &s.Items, &s.Counted)
+	return s, err
+}
+
+// GetStocktakes возвращает инвентаризации, новые первыми.
+func GetStocktakes(db *sql.DB) ([]Stocktake, error) {
+	rows, err := db.Query(stocktakeSelect + " ORDER BY t.create_at DESC, t.stocktake_id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stocktakes := []Stocktake{}
+	for rows.Next() {
+		s, err := scanStocktake(rows)
+		if err != nil {
+			return nil, err
+		}
+		stocktakes = append(stocktakes, s)
+	}
+	return stocktakes, rows.Err()
+}
+
+// GetStocktake возвращает инвентаризацию или sql.ErrNoRows.
+func GetStocktake(db *sql.DB, id int) (Stocktake, error) {
+	return scanStocktake(db.QueryRow(stocktakeSelect+" WHERE t.stocktake_id = ?", id))
+}
+
+// GetStocktakeItems возвращает строки инвентаризации по названию запчасти.
+// При onlyDiscrepancies – только посчитанные строки, факт которых расходится с остатком.
+func GetStocktakeItems(db *sql.DB, id int, onlyDiscrepancies bool) ([]StocktakeItem, error) {
+	query := "SELECT i.part_id, COALESCE(p.name, ''), COALESCE((SELECT n.number FROM part_numbers n " +
+		"WHERE n.part_id = i.part_id AND n.kind = '" + NumberKindArticle + "' ORDER BY n.number_id LIMIT 1), ''), " +
+		"COALESCE(p.price, 0), i.expected, COALESCE(s.quantity, 0), i.counted, i.counted_by, i.counted_at " +
+		"FROM stocktake_items i JOIN stocktakes t ON t.stocktake_id = i.stocktake_id " +
+		"LEFT JOIN parts p ON p.part_id = i.part_id " +
+		"LEFT JOIN part_stock s ON s.part_id = i.part_id AND s.warehouse_id = t.warehouse_id " +
+		"WHERE i.stocktake_id = ?"
+	if onlyDiscrepancies {
+		query += " AND i.counted IS NOT NULL AND i.counted <> COALESCE(s.quantity, 0)"
+	}
+	rows, err := db.Query(query+" ORDER BY p.name, i.part_id", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []StocktakeItem{}
+	for rows.Next() {
+		var item StocktakeItem
+		if err := rows.Scan(&item.PartID, &item.Name, &item.Article, &item.Price, &item.Expected, &item.Stock,
+			&item.Counted, &item.CountedBy, &item.CountedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// lockOpenStocktake блокирует инвентаризацию и проверяет, что она открыта.
+func lockOpenStocktake(tx dbExecutor, id int) (warehouseID int, err error) {
+	var status string
+	if err := tx.QueryRow("SELECT warehouse_id, status FROM stocktakes WHERE stocktake_id = ? FOR UPDATE", id).Scan(&warehouseID, &status); err != nil {
+		return 0, err
+	}
+	if status != StocktakeOpen {
+		return 0, ErrStocktakeClosed
+	}
+	return warehouseID, nil
+}
+
+// SetStocktakeCounts записывает фактические количества запчастей (ID запчасти → факт;
+// nil снимает подсчёт). Запчасти, не входящие в инвентаризацию, пропускаются;
+// возвращаются количество записанных строк и ID пропущенных запчастей.
+func SetStocktakeCounts(db *sql.DB, id int, counts map[int]*int, username string) (int, []int, error) {
+	for _, counted := range counts {
+		if counted != nil && *counted < 0 {
+			return 0, nil, ErrStocktakeCount
+		}
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+	if _, err := lockOpenStocktake(tx, id); err != nil {
+		return 0, nil, err
+	}
+
+	saved, skipped := 0, []int{}
+	for partID, counted := range counts {
+		query := "UPDATE stocktake_items SET counted = ?, counted_by = ?, counted_at = IF(? IS NULL, NULL, NOW()) " +
+			"WHERE stocktake_id = ? AND part_id = ?"
+		n, err := rowsAffected(tx.Exec(query, counted, username, counted, id, partID))
+		if err != nil {
+			return 0, nil, err
+		}
+		if n == 0 {
+			// Строка не изменилась либо её нет: различаем по наличию.
+			var exists int
+			if err := tx.QueryRow("SELECT COUNT(*) FROM stocktake_items WHERE stocktake_id = ? AND part_id = ?", id, partID).Scan(&exists); err != nil {
+				return 0, nil, err
+			}
+			if exists == 0 {
+				skipped = append(skipped, partID)
+				continue
+			}
+		}
+		saved++
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, nil, err
+	}
+	return saved, skipped, nil
+}
+
+// SetStocktakeCount записывает факт одной запчасти. Возвращает ErrStocktakeItem,
+// если запчасть не входит в инвентаризацию, и sql.ErrNoRows, если инвентаризации нет.
+func SetStocktakeCount(db *sql.DB, id, partID int, counted *int, username string) error {
+	_, skipped, err := SetStocktakeCounts(db, id, map[int]*int{partID: counted}, username)
+	if err == nil && len(skipped) > 0 {
+		err = ErrStocktakeItem
+	}
+	return err
+}
+
+// ApproveStocktake утверждает инвентаризацию: для каждой посчитанной запчасти, факт которой
+// расходится с текущим остатком на складе, в журнал движений записывается корректировка.
+// Непосчитанные строки, запчасти в корзине и ставшие комплектами пропускаются.
+// Возвращает количество проведённых корректировок.
+func ApproveStocktake(db *sql.DB, id int, username string) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	warehouseID, err := lockOpenStocktake(tx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	query := "SELECT i.part_id, i.counted FROM stocktake_items i JOIN parts p ON p.part_id = i.part_id " +
+		"WHERE i.stocktake_id = ? AND i.counted IS NOT NULL AND p.deleted_at IS NULL " +
+		"AND NOT EXISTS (SELECT 1 FROM part_kits k WHERE k.part_id = i.part_id) ORDER BY i.part_id"
+	rows, err := tx.Query(query, id)
+	if err != nil {
+		return 0, err
+	}
+	counts := make(map[int]int)
+	var ids []int
+	for rows.Next() {
+		var partID, counted int
+		if err := rows.Scan(&partID, &counted); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		counts[partID] = counted
+		ids = append(ids, partID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	src := MovementSource{Reason: "Инвентаризация", Username: username, Document: Stocktake{ID: id}.Document()}
+	var adjusted []int
+	for _, partID := range ids {
+		current, err := lockStock(tx, partID, warehouseID)
+		if err != nil {
+			return 0, err
+		}
+		if counts[partID] == current {
+			continue
+		}
+		if _, err := recordMovement(tx, partID, warehouseID, MovementAdjustment, counts[partID]-current, src); err != nil {
+			return 0, err
+		}
+		adjusted = append(adjusted, partID)
+	}
+	if err := syncPartQuantity(tx, adjusted...); err != nil {
+		return 0, err
+	}
+	query = "UPDATE stocktakes SET status = ?, closed_by = ?, closed_at = NOW() WHERE stocktake_id = ?"
+	if _, err := tx.Exec(query, StocktakeApproved, username, id); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	if len(adjusted) > 0 {
+		InvalidateCatalogTree()
+	}
+	log.Printf("[INFO] ApproveStocktake: инвентаризация %d утверждена, корректировок: %d", id, len(adjusted))
+	return len(adjusted), nil
+}
+
+// CancelStocktake отменяет открытую инвентаризацию без изменения остатков.
+func CancelStocktake(db *sql.DB, id int, username string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := lockOpenStocktake(tx, id); err != nil {
+		return err
+	}
+	query := "UPDATE stocktakes SET status = ?, closed_by = ?, closed_at = NOW() WHERE stocktake_id = ?"
+	if _, err := tx.Exec(query, StocktakeCancelled, username, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
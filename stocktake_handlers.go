@@ -0,0 +1,393 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"AutoM/config"
+	"AutoM/models"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// stocktakeSheetColumns – столбцы листа подсчёта. Загружаемый обратно лист читается
+// по первому (ID) и пятому (Факт) столбцам.
+var stocktakeSheetColumns = []interface{}{"ID", "Артикул", "Название", "Учётный остаток", "Факт"}
+
+// stocktakeSheetName возвращает название листа подсчёта; по нему загрузка узнаёт инвентаризацию.
+func stocktakeSheetName(id int) string {
+	return fmt.Sprintf("Инвентаризация %d", id)
+}
+
+// respondStocktakeError отвечает статусом ошибки инвентаризации; notFound – сообщение для sql.ErrNoRows.
+func respondStocktakeError(w http.ResponseWriter, handler, notFound string, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, notFound, http.StatusNotFound)
+	case errors.Is(err, models.ErrStocktakeItem), errors.Is(err, models.ErrStocktakeCount), errors.Is(err, models.ErrStocktakeNote):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, models.ErrStocktakeClosed):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("[ERROR] %s: %v", handler, err)
+		http.Error(w, "Ошибка инвентаризации", http.StatusInternalServerError)
+	}
+}
+
+// GetStocktakes – список инвентаризаций (API).
+func GetStocktakes(w http.ResponseWriter, r *http.Request) {
+	stocktakes, err := models.GetStocktakes(config.DB)
+	if err != nil {
+		log.Printf("[ERROR] GetStocktakes: ошибка получения инвентаризаций: %v", err)
+		http.Error(w, "Ошибка получения инвентаризаций", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetStocktakes", stocktakes)
+}
+
+// OpenStocktake – открытие инвентаризации (API). Ожидается JSON
+// {"warehouse_id": 2, "subcategory_id": 15, "note": "..."}; 0 – склад по умолчанию и весь склад.
+func OpenStocktake(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	var req struct {
+		WarehouseID   int    `json:"warehouse_id"`
+		SubcategoryID int    `json:"subcategory_id"`
+		Note          string `json:"note"`
+	}
+	if !decodeJSON(w, r, "OpenStocktake", &req) {
+		return
+	}
+	id, err := models.OpenStocktake(config.DB, req.WarehouseID, req.SubcategoryID, req.Note, sessionUsername(r))
+	if err != nil {
+		respondStocktakeError(w, "OpenStocktake", "Склад или подкатегория не найдены", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, "OpenStocktake", map[string]int64{"id": id})
+}
+
+// GetStocktake – инвентаризация со строками (API). С параметром discrepancies=1
+// возвращаются только строки с расхождениями.
+func GetStocktake(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "GetStocktake")
+	if !ok {
+		return
+	}
+	stocktake, err := models.GetStocktake(config.DB, id)
+	if err != nil {
+		respondStocktakeError(w, "GetStocktake", "Инвентаризация не найдена", err)
+		return
+	}
+	items, err := models.GetStocktakeItems(config.DB, id, r.URL.Query().Get("discrepancies") == "1")
+	if err != nil {
+		respondStocktakeError(w, "GetStocktake", "Инвентаризация не найдена", err)
+		return
+	}
+	writeJSON(w, "GetStocktake", map[string]interface{}{"stocktake": stocktake, "items": items})
+}
+
+// SetStocktakeCount – факт запчасти {partId} в инвентаризации (API).
+// Ожидается JSON {"counted": 7}; null снимает подсчёт.
+func SetStocktakeCount(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "SetStocktakeCount")
+	if !ok {
+		return
+	}
+	partID, ok := pathInt(w, r, "partId", "SetStocktakeCount")
+	if !ok {
+		return
+	}
+	var req struct {
+		Counted *int `json:"counted"`
+	}
+	if !decodeJSON(w, r, "SetStocktakeCount", &req) {
+		return
+	}
+	if err := models.SetStocktakeCount(config.DB, id, partID, req.Counted, sessionUsername(r)); err != nil {
+		respondStocktakeError(w, "SetStocktakeCount", "Инвентаризация не найдена", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// ApproveStocktake – утверждение инвентаризации с проведением корректировок (API).
+func ApproveStocktake(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "ApproveStocktake")
+	if !ok {
+		return
+	}
+	adjusted, err := models.ApproveStocktake(config.DB, id, sessionUsername(r))
+	if err != nil {
+		respondStocktakeError(w, "ApproveStocktake", "Инвентаризация не найдена", err)
+		return
+	}
+	writeJSON(w, "ApproveStocktake", map[string]int{"adjusted": adjusted})
+}
+
+// CancelStocktake – отмена инвентаризации без изменения остатков (API).
+func CancelStocktake(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "CancelStocktake")
+	if !ok {
+		return
+	}
+	if err := models.CancelStocktake(config.DB, id, sessionUsername(r)); err != nil {
+		respondStocktakeError(w, "CancelStocktake", "Инвентаризация не найдена", err)
+		return
+	}
+	log.Printf("[INFO] CancelStocktake: инвентаризация %d отменена", id)
+	w.WriteHeader(http.StatusOK)
+}
+
+// StocktakePageData – данные страницы подсчёта.
+type StocktakePageData struct {
+	Stocktake models.Stocktake
+	Items     []models.StocktakeItem
+}
+
+// Open сообщает, можно ли ещё вводить факт.
+func (d StocktakePageData) Open() bool {
+	return d.Stocktake.Status == models.StocktakeOpen
+}
+
+// StocktakePageHandler – страница подсчёта для телефона (/admin/stocktakes/{id}).
+func StocktakePageHandler(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "StocktakePageHandler")
+	if !ok {
+		return
+	}
+	stocktake, err := models.GetStocktake(config.DB, id)
+	if err != nil {
+		respondStocktakeError(w, "StocktakePageHandler", "Инвентаризация не найдена", err)
+		return
+	}
+	items, err := models.GetStocktakeItems(config.DB, id, false)
+	if err != nil {
+		respondStocktakeError(w, "StocktakePageHandler", "Инвентаризация не найдена", err)
+		return
+	}
+	RenderTemplateCached(w, "stocktake.html", StocktakePageData{Stocktake: stocktake, Items: items})
+}
+
+// writeXLSX отдаёт книгу Excel как вложение с именем filename.
+func writeXLSX(w http.ResponseWriter, handler string, f *excelize.File, filename string) {
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	if err := f.Write(w); err != nil {
+		log.Printf("[ERROR] %s: ошибка записи Excel файла: %v", handler, err)
+	}
+}
+
+// newStocktakeBook создаёт книгу с одним листом name и строкой заголовков.
+func newStocktakeBook(name string, header []interface{}) (*excelize.File, error) {
+	f := excelize.NewFile()
+	if err := f.SetSheetName(f.GetSheetName(0), name); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.SetSheetRow(name, "A1", &header); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.SetColWidth(name, "C", "C", 50); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// StocktakeSheetHandler – лист подсчёта инвентаризации в Excel. Заполненный лист
+// загружается обратно через StocktakeUploadHandler.
+func StocktakeSheetHandler(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "StocktakeSheetHandler")
+	if !ok {
+		return
+	}
+	if _, err := models.GetStocktake(config.DB, id); err != nil {
+		respondStocktakeError(w, "StocktakeSheetHandler", "Инвентаризация не найдена", err)
+		return
+	}
+	items, err := models.GetStocktakeItems(config.DB, id, false)
+	if err != nil {
+		respondStocktakeError(w, "StocktakeSheetHandler", "Инвентаризация не найдена", err)
+		return
+	}
+	sheet := stocktakeSheetName(id)
+	f, err := newStocktakeBook(sheet, stocktakeSheetColumns)
+	if err != nil {
+		log.Printf("[ERROR] StocktakeSheetHandler: ошибка создания Excel файла: %v", err)
+		http.Error(w, "Ошибка формирования Excel файла", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	for i, item := range items {
+		row := []interface{}{item.PartID, item.Article, item.Name, item.Stock, nil}
+		if item.Counted != nil {
+			row[4] = *item.Counted
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		if err := f.SetSheetRow(sheet, cell, &row); err != nil {
+			log.Printf("[ERROR] StocktakeSheetHandler: ошибка записи строки %d: %v", i+2, err)
+			http.Error(w, "Ошибка формирования Excel файла", http.StatusInternalServerError)
+			return
+		}
+	}
+	writeXLSX(w, "StocktakeSheetHandler", f, fmt.Sprintf("stocktake-%d.xlsx", id))
+}
+
+// parseCount разбирает количество из ячейки Excel: целое число, допускается "7.0" и "7,0".
+func parseCount(val string) (int, error) {
+	val = strings.TrimSpace(val)
+	if n, err := strconv.Atoi(val); err == nil {
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(strings.Replace(val, ",", ".", 1), 64)
+	if err != nil || f != float64(int(f)) {
+		return 0, fmt.Errorf("неверное количество %q", val)
+	}
+	return int(f), nil
+}
+
+// StocktakeUploadHandler – загрузка заполненного листа подсчёта (поле формы "file").
+// Строки с пустым фактом пропускаются. Отвечает JSON с количеством записанных строк.
+func StocktakeUploadHandler(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "StocktakeUploadHandler")
+	if !ok {
+		return
+	}
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		http.Error(w, "Ошибка обработки формы: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Ошибка получения файла: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	log.Printf("[INFO] StocktakeUploadHandler: инвентаризация %d, файл %s, размер: %d байт", id, header.Filename, header.Size)
+
+	f, err := excelize.OpenReader(file)
+	if err != nil {
+		http.Error(w, "Ошибка чтения Excel файла: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer f.Close()
+	sheet := stocktakeSheetName(id)
+	if idx, err := f.GetSheetIndex(sheet); err != nil || idx < 0 {
+		http.Error(w, "В файле нет листа «"+sheet+"»: загрузите лист подсчёта этой инвентаризации", http.StatusBadRequest)
+		return
+	}
+	rows, err := f.GetRows(sheet)
+	if err != nil {
+		http.Error(w, "Ошибка получения данных из Excel файла: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	counts := make(map[int]*int)
+	var invalid []string
+	for i, row := range rows {
+		if i == 0 || len(row) < 5 || strings.TrimSpace(row[4]) == "" {
+			continue // заголовок или строка без факта
+		}
+		partID, err := strconv.Atoi(strings.TrimSpace(row[0]))
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("строка %d: неверный ID", i+1))
+			continue
+		}
+		counted, err := parseCount(row[4])
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("строка %d: %v", i+1, err))
+			continue
+		}
+		counts[partID] = &counted
+	}
+	if len(invalid) > 0 {
+		http.Error(w, "Ошибки в файле: "+strings.Join(invalid, "; "), http.StatusBadRequest)
+		return
+	}
+	saved, skipped, err := models.SetStocktakeCounts(config.DB, id, counts, sessionUsername(r))
+	if err != nil {
+		respondStocktakeError(w, "StocktakeUploadHandler", "Инвентаризация не найдена", err)
+		return
+	}
+	log.Printf("[INFO] StocktakeUploadHandler: инвентаризация %d: записано %d, пропущено %d", id, saved, len(skipped))
+	writeJSON(w, "StocktakeUploadHandler", map[string]interface{}{"saved": saved, "skipped": skipped})
+}
+
+// StocktakeReportHandler – отчёт о расхождениях инвентаризации в Excel.
+func StocktakeReportHandler(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "StocktakeReportHandler")
+	if !ok {
+		return
+	}
+	if _, err := models.GetStocktake(config.DB, id); err != nil {
+		respondStocktakeError(w, "StocktakeReportHandler", "Инвентаризация не найдена", err)
+		return
+	}
+	items, err := models.GetStocktakeItems(config.DB, id, true)
+	if err != nil {
+		respondStocktakeError(w, "StocktakeReportHandler", "Инвентаризация не найдена", err)
+		return
+	}
+	const sheet = "Расхождения"
+	header := []interface{}{"ID", "Артикул", "Название", "Учётный остаток", "Факт", "Расхождение", "Цена", "Сумма расхождения"}
+	f, err := newStocktakeBook(sheet, header)
+	if err != nil {
+		log.Printf("[ERROR] StocktakeReportHandler: ошибка создания Excel файла: %v", err)
+		http.Error(w, "Ошибка формирования Excel файла", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	var totalQty int
+	var totalSum float64
+	for i, item := range items {
+		diff := item.Difference()
+		sum := float64(diff) * item.Price
+		totalQty, totalSum = totalQty+diff, totalSum+sum
+		row := []interface{}{item.PartID, item.Article, item.Name, item.Stock, *item.Counted, diff, item.Price, sum}
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		if err := f.SetSheetRow(sheet, cell, &row); err != nil {
+			log.Printf("[ERROR] StocktakeReportHandler: ошибка записи строки %d: %v", i+2, err)
+			http.Error(w, "Ошибка формирования Excel файла", http.StatusInternalServerError)
+			return
+		}
+	}
+	total := []interface{}{nil, nil, "Итого", nil, nil, totalQty, nil, totalSum}
+	cell, _ := excelize.CoordinatesToCellName(1, len(items)+2)
+	if err := f.SetSheetRow(sheet, cell, &total); err != nil {
+		log.Printf("[ERROR] StocktakeReportHandler: ошибка записи итогов: %v", err)
+		http.Error(w, "Ошибка формирования Excel файла", http.StatusInternalServerError)
+		return
+	}
+	writeXLSX(w, "StocktakeReportHandler", f, fmt.Sprintf("stocktake-%d-report.xlsx", id))
+}
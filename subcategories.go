@@ -88,22 +88,7 @@ func UpdateSubcategory(w http.ResponseWriter, r *http.Request) {
 }
 
 // DeleteSubcategory – HTTP-обработчик для удаления записи из subcategories.
+// Параметры mode и target – как у DeleteGroup; при reassign запчасти переносятся в подкатегорию target.
 func DeleteSubcategory(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
-	if err != nil {
-		http.Error(w, "Неверный ID", http.StatusBadRequest)
-		return
-	}
-	rowsAffected, err := models.DeleteSubcategory(config.DB, id)
-	if err != nil {
-		log.Printf("[ERROR] DeleteSubcategory: %v", err)
-		http.Error(w, "Ошибка удаления записи", http.StatusInternalServerError)
-		return
-	}
-	if rowsAffected == 0 {
-		http.Error(w, "Запись не найдена", http.StatusNotFound)
-		return
-	}
-	w.WriteHeader(http.StatusOK)
+	deleteCatalogItem(w, r, "DeleteSubcategory", "Подкатегория", models.DeleteSubcategory)
 }
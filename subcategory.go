@@ -17,7 +17,7 @@ type Subcategory struct {
 
 // GetAllSubcategories возвращает список всех подкатегорий.
 func GetAllSubcategories(db *sql.DB) ([]Subcategory, error) {
-	query := "SELECT subcategory_id, category_id, name, create_at, update_at FROM subcategories"
+	query := "SELECT subcategory_id, category_id, name, create_at, update_at FROM subcategories WHERE deleted_at IS NULL"
 	rows, err := db.Query(query)
 	if err != nil {
 		return nil, err
@@ -38,7 +38,7 @@ func GetAllSubcategories(db *sql.DB) ([]Subcategory, error) {
 // GetSubcategoryByID возвращает подкатегорию по её subcategory_id.
 func GetSubcategoryByID(db *sql.DB, id int) (Subcategory, error) {
 	var s Subcategory
-	query := "SELECT subcategory_id, category_id, name, create_at, update_at FROM subcategories WHERE subcategory_id = ?"
+	query := "SELECT subcategory_id, category_id, name, create_at, update_at FROM subcategories WHERE subcategory_id = ? AND deleted_at IS NULL"
 	err := db.QueryRow(query, id).Scan(&s.SubcategoryID, &s.CategoryID, &s.Name, &s.CreateAt, &s.UpdateAt)
 	if err != nil {
 		return Subcategory{}, err
@@ -49,27 +49,36 @@ func GetSubcategoryByID(db *sql.DB, id int) (Subcategory, error) {
 // AddSubcategory добавляет новую подкатегорию в базу данных.
 // Временные метки создаются с помощью NOW().
 func AddSubcategory(db *sql.DB, categoryID int, name string) error {
+	defer InvalidateCatalogTree()
 	query := "INSERT INTO subcategories (category_id, name, create_at, update_at) VALUES (?, ?, NOW(), NOW())"
-	_, err := db.Exec(query, categoryID, name)
-	return err
+	res, err := db.Exec(query, categoryID, name)
+	if err != nil {
+		return err
+	}
+	if id, err := res.LastInsertId(); err == nil {
+		subcategorySlugs.update(db, int(id), "")
+	}
+	return nil
 }
 
 // UpdateSubcategory обновляет информацию о подкатегории по её subcategory_id.
 func UpdateSubcategory(db *sql.DB, subcategoryID int, categoryID int, name string) (int64, error) {
-	query := "UPDATE subcategories SET category_id = ?, name = ?, update_at = NOW() WHERE subcategory_id = ?"
+	defer InvalidateCatalogTree()
+	oldPath := subcategorySlugs.path(db, subcategoryID)
+	query := "UPDATE subcategories SET category_id = ?, name = ?, update_at = NOW() WHERE subcategory_id = ? AND deleted_at IS NULL"
 	result, err := db.Exec(query, categoryID, name, subcategoryID)
 	if err != nil {
 		return 0, err
 	}
-	return result.RowsAffected()
+	rowsAffected, err := result.RowsAffected()
+	if err == nil && rowsAffected > 0 {
+		subcategorySlugs.update(db, subcategoryID, oldPath)
+	}
+	return rowsAffected, err
 }
 
-// DeleteSubcategory удаляет подкатегорию по её subcategory_id.
-func DeleteSubcategory(db *sql.DB, subcategoryID int) (int64, error) {
-	query := "DELETE FROM subcategories WHERE subcategory_id = ?"
-	result, err := db.Exec(query, subcategoryID)
-	if err != nil {
-		return 0, err
-	}
-	return result.RowsAffected()
+// DeleteSubcategory удаляет подкатегорию по её subcategory_id в режиме opts (см. DeleteOptions).
+// Возвращает sql.ErrNoRows, если подкатегория не найдена.
+func DeleteSubcategory(db *sql.DB, subcategoryID int, opts DeleteOptions) (DeleteResult, error) {
+	return subcategoryLevel.remove(db, subcategoryID, opts)
 }
@@ -0,0 +1,200 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Ошибки поставщиков.
+var (
+	ErrSupplierName   = errors.New("название поставщика не может быть пустым")
+	ErrSupplierExists = errors.New("поставщик с таким названием уже существует")
+	ErrSupplierINN    = errors.New("ИНН должен состоять из 10 или 12 цифр")
+	ErrSupplierKPP    = errors.New("КПП должен состоять из 9 цифр")
+	ErrSupplierEmail  = errors.New("неверный адрес электронной почты")
+	ErrSupplierField  = errors.New("название, контакт, телефон и e-mail не длиннее 100 символов, адрес и комментарий – не длиннее 255")
+	ErrSupplierInUse  = errors.New("у поставщика есть заказы; удалить его нельзя")
+)
+
+var (
+	innPattern = regexp.MustCompile(`^(\d{10}|\d{12})$`)
+	kppPattern = regexp.MustCompile(`^\d{9}$`)
+)
+
+// Supplier – поставщик запчастей: реквизиты, контакты и валюта закупочных цен.
+type Supplier struct {
+	ID            int       `json:"id"`
+	Name          string    `json:"name"`
+	INN           string    `json:"inn"`
+	KPP           string    `json:"kpp"`
+	Address       string    `json:"address"` // юридический адрес
+	ContactPerson string    `json:"contact_person"`
+	Phone         string    `json:"phone"`
+	Email         string    `json:"email"`
+	Currency      string    `json:"currency"` // валюта цен в заказах по умолчанию
+	Note          string    `json:"note"`
+	CreateAt      time.Time `json:"create_at"`
+}
+
+// migrateSuppliers создаёт таблицу поставщиков.
+func migrateSuppliers(db *sql.DB) error {
+	return execAll(db,
+		"CREATE TABLE IF NOT EXISTS suppliers ("+
+			"supplier_id INT AUTO_INCREMENT PRIMARY KEY, "+
+			"name VARCHAR(100) NOT NULL, "+
+			"inn VARCHAR(12) NOT NULL DEFAULT '', "+
+			"kpp VARCHAR(9) NOT NULL DEFAULT '', "+
+			"address VARCHAR(255) NOT NULL DEFAULT '', "+
+			"contact_person VARCHAR(100) NOT NULL DEFAULT '', "+
+			"phone VARCHAR(100) NOT NULL DEFAULT '', "+
+			"email VARCHAR(100) NOT NULL DEFAULT '', "+
+			"currency CHAR(3) NOT NULL DEFAULT '"+BaseCurrency+"', "+
+			"note VARCHAR(255) NOT NULL DEFAULT '', "+
+			"create_at DATETIME NOT NULL, "+
+			"UNIQUE KEY uq_supplier_name (name)"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+	)
+}
+
+// Validate обрезает пробелы, проверяет реквизиты и подставляет базовую валюту.
+func (s *Supplier) Validate() error {
+	for _, f := range []*string{&s.Name, &s.INN, &s.KPP, &s.Address, &s.ContactPerson, &s.Phone, &s.Email, &s.Note} {
+		*f = strings.TrimSpace(*f)
+	}
+	s.Currency = strings.ToUpper(strings.TrimSpace(s.Currency))
+	if s.Currency == "" {
+		s.Currency = BaseCurrency
+	}
+	switch {
+	case s.Name == "":
+		return ErrSupplierName
+	case utf8.RuneCountInString(s.Name) > 100 || utf8.RuneCountInString(s.ContactPerson) > 100 ||
+		utf8.RuneCountInString(s.Phone) > 100 || utf8.RuneCountInString(s.Email) > 100 ||
+		utf8.RuneCountInString(s.Address) > 255 || utf8.RuneCountInString(s.Note) > 255:
+		return ErrSupplierField
+	case s.INN != "" && !innPattern.MatchString(s.INN):
+		return ErrSupplierINN
+	case s.KPP != "" && !kppPattern.MatchString(s.KPP):
+		return ErrSupplierKPP
+	case s.Email != "" && !strings.Contains(s.Email, "@"):
+		return ErrSupplierEmail
+	case !currencyCodePattern.MatchString(s.Currency):
+		return ErrCurrencyCode
+	}
+	return nil
+}
+
+// supplierSelect – выборка поставщиков.
+const supplierSelect = "SELECT supplier_id, name, inn, kpp, address, contact_person, phone, email, currency, note, create_at FROM suppliers"
+
+// scanSupplier читает строку supplierSelect.
+func scanSupplier(row interface{ Scan(...interface{}) error }) (Supplier, error) {
+	var s Supplier
+	err := row.Scan(&s.ID, &s.Name, &s.INN, &s.KPP, &s.Address, &s.ContactPerson, &s.Phone, &s.Email, &s.Currency, &s.Note, &s.CreateAt)
+	return s, err
+}
+
+// GetSuppliers возвращает поставщиков по названию.
+func GetSuppliers(db *sql.DB) ([]Supplier, error) {
+	rows, err := db.Query(supplierSelect + " ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suppliers := []Supplier{}
+	for rows.Next() {
+		s, err := scanSupplier(rows)
+		if err != nil {
+			return nil, err
+		}
+		suppliers = append(suppliers, s)
+	}
+	return suppliers, rows.Err()
+}
+
+// GetSupplier возвращает поставщика или sql.ErrNoRows.
+func GetSupplier(db *sql.DB, id int) (Supplier, error) {
+	return scanSupplier(db.QueryRow(supplierSelect+" WHERE supplier_id = ?", id))
+}
+
+// checkCurrencyRate проверяет, что курс валюты задан; иначе возвращает ErrUnknownRate.
+func checkCurrencyRate(db dbExecutor, currency string) error {
+	var exists int
+	if err := db.QueryRow("SELECT COUNT(*) FROM currency_rates WHERE code = ?", currency).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownRate, currency)
+	}
+	return nil
+}
+
+// AddSupplier создаёт поставщика и возвращает его ID.
+func AddSupplier(db *sql.DB, s Supplier) (int64, error) {
+	if err := s.Validate(); err != nil {
+		return 0, err
+	}
+	if err := checkCurrencyRate(db, s.Currency); err != nil {
+		return 0, err
+	}
+	query := "INSERT INTO suppliers (name, inn, kpp, address, contact_person, phone, email, currency, note, create_at) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NOW())"
+	res, err := db.Exec(query, s.Name, s.INN, s.KPP, s.Address, s.ContactPerson, s.Phone, s.Email, s.Currency, s.Note)
+	if isDuplicateKey(err) {
+		return 0, ErrSupplierExists
+	}
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// UpdateSupplier изменяет реквизиты поставщика. Валюта уже созданных заказов не меняется.
+// Возвращает sql.ErrNoRows, если поставщика нет.
+func UpdateSupplier(db *sql.DB, s Supplier) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	if err := checkCurrencyRate(db, s.Currency); err != nil {
+		return err
+	}
+	query := "UPDATE suppliers SET name = ?, inn = ?, kpp = ?, address = ?, contact_person = ?, phone = ?, email = ?, " +
+		"currency = ?, note = ? WHERE supplier_id = ?"
+	_, err := db.Exec(query, s.Name, s.INN, s.KPP, s.Address, s.ContactPerson, s.Phone, s.Email, s.Currency, s.Note, s.ID)
+	if isDuplicateKey(err) {
+		return ErrSupplierExists
+	}
+	if err != nil {
+		return err
+	}
+	// MySQL не считает строку изменённой, если значения совпали, поэтому наличие проверяется отдельно.
+	_, err = GetSupplier(db, s.ID)
+	return err
+}
+
+// DeleteSupplier удаляет поставщика без заказов. Возвращает sql.ErrNoRows, если поставщика нет.
+func DeleteSupplier(db *sql.DB, id int) error {
+	var orders int
+	if err := db.QueryRow("SELECT COUNT(*) FROM purchase_orders WHERE supplier_id = ?", id).Scan(&orders); err != nil {
+		return err
+	}
+	if orders > 0 {
+		return ErrSupplierInUse
+	}
+	n, err := rowsAffected(db.Exec("DELETE FROM suppliers WHERE supplier_id = ?", id))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	log.Printf("[INFO] DeleteSupplier: поставщик %d удалён", id)
+	return nil
+}
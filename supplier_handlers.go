@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"AutoM/config"
+	"AutoM/models"
+)
+
+// respondSupplierError отвечает статусом ошибки поставщика или заказа поставщику;
+// notFound – сообщение для sql.ErrNoRows.
+func respondSupplierError(w http.ResponseWriter, handler, notFound string, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, notFound, http.StatusNotFound)
+	case errors.Is(err, models.ErrSupplierName), errors.Is(err, models.ErrSupplierINN), errors.Is(err, models.ErrSupplierKPP),
+		errors.Is(err, models.ErrSupplierEmail), errors.Is(err, models.ErrSupplierField), errors.Is(err, models.ErrCurrencyCode),
+		errors.Is(err, models.ErrUnknownRate), errors.Is(err, models.ErrPurchaseItem), errors.Is(err, models.ErrPurchaseNote),
+		errors.Is(err, models.ErrPurchaseEmpty), errors.Is(err, models.ErrReceiptItem), errors.Is(err, models.ErrReceiptQuantity),
+		errors.Is(err, models.ErrReceiptInvoice), errors.Is(err, models.ErrReceiptNothing), errors.Is(err, models.ErrStockKit),
+		errors.Is(err, models.ErrMovementSource):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, models.ErrSupplierExists), errors.Is(err, models.ErrSupplierInUse), errors.Is(err, models.ErrPurchaseStatus),
+		errors.Is(err, models.ErrReceiptPart):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("[ERROR] %s: %v", handler, err)
+		http.Error(w, "Ошибка работы с поставщиками", http.StatusInternalServerError)
+	}
+}
+
+// GetSuppliers – список поставщиков (API).
+func GetSuppliers(w http.ResponseWriter, r *http.Request) {
+	suppliers, err := models.GetSuppliers(config.DB)
+	if err != nil {
+		log.Printf("[ERROR] GetSuppliers: ошибка получения поставщиков: %v", err)
+		http.Error(w, "Ошибка получения поставщиков", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetSuppliers", suppliers)
+}
+
+// GetSupplier – поставщик по ID (API).
+func GetSupplier(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "GetSupplier")
+	if !ok {
+		return
+	}
+	supplier, err := models.GetSupplier(config.DB, id)
+	if err != nil {
+		respondSupplierError(w, "GetSupplier", "Поставщик не найден", err)
+		return
+	}
+	writeJSON(w, "GetSupplier", supplier)
+}
+
+// AddSupplier – создание поставщика (API). Ожидается JSON {"name": "ООО Автодеталь",
+// "inn": "7701234567", "kpp": "770101001", "address": "...", "contact_person": "...",
+// "phone": "...", "email": "...", "currency": "EUR", "note": "..."}; пустая валюта – BaseCurrency.
+func AddSupplier(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	var supplier models.Supplier
+	if !decodeJSON(w, r, "AddSupplier", &supplier) {
+		return
+	}
+	id, err := models.AddSupplier(config.DB, supplier)
+	if err != nil {
+		respondSupplierError(w, "AddSupplier", "Поставщик не найден", err)
+		return
+	}
+	log.Printf("[INFO] AddSupplier: поставщик %d '%s' создан", id, supplier.Name)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, "AddSupplier", map[string]int64{"id": id})
+}
+
+// UpdateSupplier – изменение реквизитов поставщика (API).
+func UpdateSupplier(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "UpdateSupplier")
+	if !ok {
+		return
+	}
+	var supplier models.Supplier
+	if !decodeJSON(w, r, "UpdateSupplier", &supplier) {
+		return
+	}
+	supplier.ID = id
+	if err := models.UpdateSupplier(config.DB, supplier); err != nil {
+		respondSupplierError(w, "UpdateSupplier", "Поставщик не найден", err)
+		return
+	}
+	log.Printf("[INFO] UpdateSupplier: поставщик %d изменён", id)
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeleteSupplier – удаление поставщика без заказов (API).
+func DeleteSupplier(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "DeleteSupplier")
+	if !ok {
+		return
+	}
+	if err := models.DeleteSupplier(config.DB, id); err != nil {
+		respondSupplierError(w, "DeleteSupplier", "Поставщик не найден", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetPurchaseOrders – список заказов поставщикам (API). Параметры supplier_id и status.
+func GetPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	supplierID, err := queryInt(r, "supplier_id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter := models.PurchaseOrderFilter{SupplierID: supplierID, Status: strings.TrimSpace(r.URL.Query().Get("status"))}
+	orders, err := models.GetPurchaseOrders(config.DB, filter)
+	if err != nil {
+		log.Printf("[ERROR] GetPurchaseOrders: ошибка получения заказов поставщикам: %v", err)
+		http.Error(w, "Ошибка получения заказов поставщикам", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetPurchaseOrders", orders)
+}
+
+// GetPurchaseOrder – заказ поставщику со строками (API).
+func GetPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "GetPurchaseOrder")
+	if !ok {
+		return
+	}
+	order, err := models.GetPurchaseOrder(config.DB, id)
+	if err != nil {
+		respondSupplierError(w, "GetPurchaseOrder", "Заказ поставщику не найден", err)
+		return
+	}
+	writeJSON(w, "GetPurchaseOrder", order)
+}
+
+// AddPurchaseOrder – создание черновика заказа поставщику (API). Ожидается JSON
+// {"supplier_id": 1, "warehouse_id": 0, "currency": "", "note": "...",
+// "items": [{"part_id": 5, "quantity": 10, "price": "12.50"}]}; цены – в валюте заказа.
+func AddPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	var order models.PurchaseOrder
+	if !decodeJSON(w, r, "AddPurchaseOrder", &order) {
+		return
+	}
+	order.ID = 0
+	id, err := models.SavePurchaseOrder(config.DB, order, sessionUsername(r))
+	if err != nil {
+		respondSupplierError(w, "AddPurchaseOrder", "Поставщик, склад или запчасть не найдены", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, "AddPurchaseOrder", map[string]int64{"id": id})
+}
+
+// UpdatePurchaseOrder – изменение черновика заказа поставщику (API); строки заменяются целиком.
+func UpdatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "UpdatePurchaseOrder")
+	if !ok {
+		return
+	}
+	var order models.PurchaseOrder
+	if !decodeJSON(w, r, "UpdatePurchaseOrder", &order) {
+		return
+	}
+	order.ID = id
+	if _, err := models.SavePurchaseOrder(config.DB, order, sessionUsername(r)); err != nil {
+		respondSupplierError(w, "UpdatePurchaseOrder", "Заказ, поставщик, склад или запчасть не найдены", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// SendPurchaseOrder – отметка об отправке заказа поставщику (API).
+func SendPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "SendPurchaseOrder")
+	if !ok {
+		return
+	}
+	if err := models.SendPurchaseOrder(config.DB, id); err != nil {
+		respondSupplierError(w, "SendPurchaseOrder", "Заказ поставщику не найден", err)
+		return
+	}
+	log.Printf("[INFO] SendPurchaseOrder: заказ %d отправлен поставщику", id)
+	w.WriteHeader(http.StatusOK)
+}
+
+// ReceivePurchaseOrder – приёмка товара по заказу поставщику (API). Ожидается JSON
+// {"lines": [{"part_id": 5, "quantity": 4}], "warehouse_id": 0, "invoice": "ТН-102", "update_cost": true};
+// без lines принимается всё недопоставленное. Возвращает новое состояние заказа.
+func ReceivePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "ReceivePurchaseOrder")
+	if !ok {
+		return
+	}
+	var receipt models.GoodsReceipt
+	if !decodeJSON(w, r, "ReceivePurchaseOrder", &receipt) {
+		return
+	}
+	receipt.Username = sessionUsername(r)
+	status, err := models.ReceivePurchaseOrder(config.DB, id, receipt)
+	if err != nil {
+		respondSupplierError(w, "ReceivePurchaseOrder", "Заказ, склад или запчасть не найдены", err)
+		return
+	}
+	writeJSON(w, "ReceivePurchaseOrder", map[string]string{"status": status})
+}
+
+// CancelPurchaseOrder – отмена заказа поставщику (API).
+func CancelPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "CancelPurchaseOrder")
+	if !ok {
+		return
+	}
+	if err := models.CancelPurchaseOrder(config.DB, id); err != nil {
+		respondSupplierError(w, "CancelPurchaseOrder", "Заказ поставщику не найден", err)
+		return
+	}
+	log.Printf("[INFO] CancelPurchaseOrder: заказ %d отменён", id)
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,352 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"sort"
+	"time"
+)
+
+// Типы записей корзины.
+const (
+	TrashGroup       = "group"
+	TrashCategory    = "category"
+	TrashSubcategory = "subcategory"
+	TrashPart        = "part"
+)
+
+// Ошибки корзины.
+var (
+	ErrTrashType     = errors.New("тип записи должен быть group, category, subcategory или part")
+	ErrRestoreParent = errors.New("родительский элемент находится в корзине; сначала восстановите его")
+	ErrPartDocuments = errors.New("запчасть есть в незавершённом заказе поставщику или инвентаризации; получите, отмените или утвердите их")
+)
+
+// partInOpenDocuments – условие на запчасть p: она есть в незавершённом заказе поставщику
+// или в открытой инвентаризации. Аргументы – openDocumentArgs.
+const partInOpenDocuments = "(EXISTS (SELECT 1 FROM purchase_order_items i JOIN purchase_orders o ON o.po_id = i.po_id " +
+	"WHERE i.part_id = p.part_id AND o.status IN (?, ?, ?)) OR EXISTS (SELECT 1 FROM stocktake_items si " +
+	"JOIN stocktakes st ON st.stocktake_id = si.stocktake_id WHERE si.part_id = p.part_id AND st.status = ?))"
+
+// openDocumentArgs возвращает аргументы условия partInOpenDocuments.
+func openDocumentArgs() []interface{} {
+	return []interface{}{PurchaseDraft, PurchaseSent, PurchasePartial, StocktakeOpen}
+}
+
+// TrashItem – запись в корзине.
+type TrashItem struct {
+	Type       string    `json:"type"`        // group, category, subcategory или part
+	ID         int       `json:"id"`          // ID записи
+	Name       string    `json:"name"`        // название
+	ParentID   *int      `json:"parent_id"`   // родитель (для группы – nil)
+	ParentName *string   `json:"parent_name"` // название родителя
+	DeletedAt  time.Time `json:"deleted_at"`  // время удаления
+}
+
+// trashLevels сопоставляет тип записи корзины уровню каталога.
+var trashLevels = map[string]catalogLevel{
+	TrashGroup:       groupLevel,
+	TrashCategory:    categoryLevel,
+	TrashSubcategory: subcategoryLevel,
+}
+
+// trashQueries – выборка записей корзины по типам.
+var trashQueries = map[string]string{
+	TrashGroup: "SELECT g.group_id, g.name, NULL, NULL, g.deleted_at FROM `groups_main` g WHERE g.deleted_at IS NOT NULL",
+	TrashCategory: "SELECT c.category_id, c.name, g.group_id, g.name, c.deleted_at FROM `categories` c " +
+		"LEFT JOIN `groups_main` g ON g.group_id = c.group_id WHERE c.deleted_at IS NOT NULL",
+	TrashSubcategory: "SELECT s.subcategory_id, s.name, c.category_id, c.name, s.deleted_at FROM subcategories s " +
+		"LEFT JOIN `categories` c ON c.category_id = s.category_id WHERE s.deleted_at IS NOT NULL",
+	TrashPart: "SELECT p.part_id, p.name, s.subcategory_id, s.name, p.deleted_at FROM parts p " +
+		"LEFT JOIN subcategories s ON s.subcategory_id = p.subcategory_id WHERE p.deleted_at IS NOT NULL",
+}
+
+// migrateSoftDelete добавляет отметку удаления в таблицы каталога.
+func migrateSoftDelete(db *sql.DB) error {
+	for _, table := range []string{"groups_main", "categories", "subcategories", "parts"} {
+		if err := ensureColumn(db, table, "deleted_at", "DATETIME NULL"); err != nil {
+			return err
+		}
+		if err := ensureIndex(db, table, "idx_"+table+"_deleted", "deleted_at"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidTrashType сообщает, поддерживается ли тип записи корзины.
+func ValidTrashType(typ string) bool {
+	_, ok := trashQueries[typ]
+	return ok
+}
+
+// GetTrash возвращает записи корзины указанного типа (пусто – всех типов), недавно удалённые первыми.
+func GetTrash(db *sql.DB, typ string) ([]TrashItem, error) {
+	types := []string{TrashGroup, TrashCategory, TrashSubcategory, TrashPart}
+	if typ != "" {
+		if !ValidTrashType(typ) {
+			return nil, ErrTrashType
+		}
+		types = []string{typ}
+	}
+	items := []TrashItem{}
+	for _, t := range types {
+		rows, err := db.Query(trashQueries[t] + " ORDER BY 5 DESC")
+		if err != nil {
+			log.Printf("[ERROR] GetTrash: ошибка выполнения запроса (%s): %v", t, err)
+			return nil, err
+		}
+		for rows.Next() {
+			item := TrashItem{Type: t}
+			if err := rows.Scan(&item.ID, &item.Name, &item.ParentID, &item.ParentName, &item.DeletedAt); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	// Записи разных типов сливаются по времени удаления.
+	sort.SliceStable(items, func(i, j int) bool { return items[i].DeletedAt.After(items[j].DeletedAt) })
+	return items, nil
+}
+
+// SoftDeletePart перемещает запчасть в корзину. Запчасть из незавершённого заказа поставщику
+// или открытой инвентаризации не удаляется: возвращается ErrPartDocuments.
+func SoftDeletePart(db *sql.DB, id int) (int64, error) {
+	log.Printf("[INFO] SoftDeletePart: перемещение запчасти %d в корзину", id)
+	defer InvalidateCatalogTree()
+	var inDocuments bool
+	query := "SELECT " + partInOpenDocuments + " FROM parts p WHERE p.part_id = ?"
+	err := db.QueryRow(query, append(openDocumentArgs(), id)...).Scan(&inDocuments)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	if inDocuments {
+		return 0, ErrPartDocuments
+	}
+	n, err := rowsAffected(db.Exec("UPDATE parts SET deleted_at = NOW() WHERE part_id = ? AND deleted_at IS NULL", id))
+	if err == nil && n > 0 {
+		// Комплект с компонентом в корзине становится недоступным.
+		err = RefreshKitsFor(db, id)
+	}
+	return n, err
+}
+
+// RestoreFromTrash восстанавливает запись корзины. Родитель записи должен быть восстановлен заранее.
+// Если cascade = true, вместе с группой, категорией или подкатегорией восстанавливается содержимое,
+// удалённое одновременно с ней (каскадное удаление); удалённое раньше по отдельности остаётся в корзине.
+// Возвращает sql.ErrNoRows, если записи нет в корзине.
+func RestoreFromTrash(db *sql.DB, typ string, id int, cascade bool) error {
+	log.Printf("[INFO] RestoreFromTrash: восстановление %s %d (каскадно: %v)", typ, id, cascade)
+	if typ == TrashPart {
+		return restorePart(db, id)
+	}
+	l, ok := trashLevels[typ]
+	if !ok {
+		return ErrTrashType
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var deletedAt time.Time
+	var parentID sql.NullInt64
+	parent := "NULL"
+	if l.parentColumn != "" {
+		parent = l.parentColumn
+	}
+	query := "SELECT deleted_at, " + parent + " FROM " + l.table + " WHERE " + l.idColumn + " = ? AND deleted_at IS NOT NULL"
+	if err := tx.QueryRow(query, id).Scan(&deletedAt, &parentID); err != nil {
+		return err
+	}
+	if l.parentTable != "" {
+		if err := requireLiveParent(tx, l.parentTable, l.parentColumn, parentID.Int64); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("UPDATE "+l.table+" SET deleted_at = NULL WHERE "+l.idColumn+" = ?", id); err != nil {
+		return err
+	}
+
+	if cascade {
+		// Сверху вниз: сначала категории, затем подкатегории восстановленных категорий, затем запчасти.
+		var queries []string
+		if typ == TrashGroup {
+			queries = append(queries, "UPDATE `categories` SET deleted_at = NULL WHERE deleted_at = ? AND group_id = ?",
+				"UPDATE subcategories SET deleted_at = NULL WHERE deleted_at = ? AND category_id IN "+
+					"(SELECT category_id FROM `categories` WHERE group_id = ? AND deleted_at IS NULL)")
+		}
+		if typ == TrashCategory {
+			queries = append(queries, "UPDATE subcategories SET deleted_at = NULL WHERE deleted_at = ? AND category_id = ?")
+		}
+		queries = append(queries, "UPDATE parts SET deleted_at = NULL WHERE deleted_at = ? AND subcategory_id IN ("+
+			"SELECT subcategory_id FROM ("+l.subcategories+") t)")
+		for _, query := range queries {
+			if _, err := tx.Exec(query, deletedAt, id); err != nil {
+				return err
+			}
+		}
+		cond := "p.deleted_at IS NULL AND p.subcategory_id IN (SELECT subcategory_id FROM (" + l.subcategories + ") t)"
+		if err := checkStockAlerts(tx, cond, id); err != nil {
+			return err
+		}
+		if err := RefreshAllKits(tx); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	InvalidateCatalogTree()
+	return nil
+}
+
+// restorePart восстанавливает запчасть, если её подкатегория не в корзине.
+func restorePart(db *sql.DB, id int) error {
+	var subcategoryID int
+	err := db.QueryRow("SELECT subcategory_id FROM parts WHERE part_id = ? AND deleted_at IS NOT NULL", id).Scan(&subcategoryID)
+	if err != nil {
+		return err
+	}
+	if err := requireLiveParent(db, "subcategories", "subcategory_id", int64(subcategoryID)); err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("UPDATE parts SET deleted_at = NULL WHERE part_id = ?", id); err != nil {
+		return err
+	}
+	if err := checkStockAlerts(tx, "p.part_id = ?", id); err != nil {
+		return err
+	}
+	if err := RefreshKitsFor(tx, id); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	InvalidateCatalogTree()
+	return nil
+}
+
+// requireLiveParent возвращает ErrRestoreParent, если родитель находится в корзине.
+// Родитель, удалённый безвозвратно, также не позволяет восстановить запись.
+func requireLiveParent(db dbExecutor, table, idColumn string, id int64) error {
+	var live int
+	query := "SELECT COUNT(*) FROM " + table + " WHERE " + idColumn + " = ? AND deleted_at IS NULL"
+	if err := db.QueryRow(query, id).Scan(&live); err != nil {
+		return err
+	}
+	if live == 0 {
+		return ErrRestoreParent
+	}
+	return nil
+}
+
+// purgeParts безвозвратно удаляет запчасти, выбранные запросом partIDs, вместе с их номерами,
+// характеристиками, применимостью, изображениями и записями поискового индекса.
+// Возвращает удалённые изображения, чтобы вызывающий удалил их файлы.
+func purgeParts(tx dbExecutor, partIDs string, args ...interface{}) ([]PartImage, error) {
+	rows, err := tx.Query("SELECT "+partImageColumns+" FROM part_images WHERE part_id IN ("+partIDs+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	var images []PartImage
+	for rows.Next() {
+		img, err := scanPartImage(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		images = append(images, img)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Состав комплекта удаляется каскадно вместе с part_kits. Компоненты чужих комплектов
+	// и запчасти из незавершённых документов сюда не попадают (см. purgeableParts). Строки
+	// завершённых заказов, инвентаризаций и журнала движений остаются как история.
+	for _, table := range []string{"part_images", "part_numbers", "part_attribute_values", "part_fitments", "`parts_search`", "part_kits",
+		"price_group_parts", "part_stock", "part_barcodes", "part_reorder_points", "stock_alerts"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE part_id IN ("+partIDs+")", args...); err != nil {
+			return nil, err
+		}
+	}
+	_, err = tx.Exec("DELETE FROM parts WHERE part_id IN (SELECT part_id FROM ("+partIDs+") t)", args...)
+	return images, err
+}
+
+// purgeableParts выбирает запчасти, удалённые раньше срока (аргументы: срок дважды и openDocumentArgs).
+// Пропускаются компоненты комплектов, которые сами не удаляются, и запчасти из незавершённых
+// заказов поставщикам и инвентаризаций: без них комплект и документ нельзя было бы провести.
+const purgeableParts = "SELECT p.part_id FROM parts p WHERE p.deleted_at < ? AND NOT EXISTS (SELECT 1 FROM part_kit_components c " +
+	"JOIN parts k ON k.part_id = c.kit_id WHERE c.part_id = p.part_id AND (k.deleted_at IS NULL OR k.deleted_at >= ?)) " +
+	"AND NOT " + partInOpenDocuments
+
+// PurgeTrash безвозвратно удаляет записи, находящиеся в корзине дольше retention.
+// Элементы каталога, в которых ещё остались записи, и запчасти, на которые ссылаются
+// комплекты и незавершённые документы, пропускаются до следующего запуска.
+// Возвращает количество удалённых записей и изображения удалённых запчастей.
+func PurgeTrash(db *sql.DB, retention time.Duration) (int64, []PartImage, error) {
+	cutoff := time.Now().Add(-retention)
+	log.Printf("[INFO] PurgeTrash: удаление записей корзины старше %s", cutoff.Format(time.DateTime))
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+
+	var purged int64
+	var count int64
+	args := append([]interface{}{cutoff, cutoff}, openDocumentArgs()...)
+	if err := tx.QueryRow("SELECT COUNT(*) FROM ("+purgeableParts+") t", args...).Scan(&count); err != nil {
+		return 0, nil, err
+	}
+	images, err := purgeParts(tx, purgeableParts, args...)
+	if err != nil {
+		return 0, nil, err
+	}
+	purged += count
+
+	queries := []string{
+		"DELETE FROM subcategory_attributes WHERE subcategory_id IN (SELECT subcategory_id FROM subcategories s " +
+			"WHERE s.deleted_at < ? AND NOT EXISTS (SELECT 1 FROM parts p WHERE p.subcategory_id = s.subcategory_id))",
+		"DELETE s FROM subcategories s LEFT JOIN parts p ON p.subcategory_id = s.subcategory_id " +
+			"WHERE s.deleted_at < ? AND p.part_id IS NULL",
+		"DELETE c FROM `categories` c LEFT JOIN subcategories s ON s.category_id = c.category_id " +
+			"WHERE c.deleted_at < ? AND s.subcategory_id IS NULL",
+		"DELETE g FROM `groups_main` g LEFT JOIN `categories` c ON c.group_id = g.group_id " +
+			"WHERE g.deleted_at < ? AND c.category_id IS NULL",
+	}
+	for i, query := range queries {
+		n, err := rowsAffected(tx.Exec(query, cutoff))
+		if err != nil {
+			return 0, nil, err
+		}
+		if i > 0 {
+			purged += n
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, nil, err
+	}
+	if purged > 0 {
+		InvalidateCatalogTree()
+	}
+	log.Printf("[INFO] PurgeTrash: удалено записей: %d", purged)
+	return purged, images, nil
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"AutoM/config"
+	"AutoM/models"
+
+	"github.com/gorilla/mux"
+)
+
+// GetTrash – содержимое корзины (API). Параметр type ограничивает выборку:
+// group, category, subcategory или part.
+func GetTrash(w http.ResponseWriter, r *http.Request) {
+	items, err := models.GetTrash(config.DB, r.URL.Query().Get("type"))
+	if errors.Is(err, models.ErrTrashType) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Ошибка загрузки корзины", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetTrash", items)
+}
+
+// RestoreFromTrash – восстановление записи из корзины.
+// Параметр cascade=1 восстанавливает вместе с группой, категорией или подкатегорией
+// её содержимое, удалённое одновременно с ней.
+func RestoreFromTrash(w http.ResponseWriter, r *http.Request) {
+	typ := mux.Vars(r)["type"]
+	if !models.ValidTrashType(typ) {
+		http.Error(w, models.ErrTrashType.Error(), http.StatusBadRequest)
+		return
+	}
+	id, ok := pathID(w, r, "RestoreFromTrash")
+	if !ok {
+		return
+	}
+	cascade := r.URL.Query().Get("cascade")
+	err := models.RestoreFromTrash(config.DB, typ, id, cascade != "" && cascade != "0" && cascade != "false")
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "Запись в корзине не найдена", http.StatusNotFound)
+	case errors.Is(err, models.ErrRestoreParent):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case err != nil:
+		log.Printf("[ERROR] RestoreFromTrash: ошибка восстановления %s %d: %v", typ, id, err)
+		http.Error(w, "Ошибка восстановления записи", http.StatusInternalServerError)
+	default:
+		log.Printf("[INFO] RestoreFromTrash: %s %d восстановлена", typ, id)
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -6,11 +6,13 @@ import (
 
 // User описывает пользователя системы.
 type User struct {
-	ID        int       `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	Password  string    `json:"password"`
-	IsAdmin   bool      `json:"is_admin"` // true, если пользователь администратор, false для обычных
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	IsAdmin  bool   `json:"is_admin"` // true, если пользователь администратор, false для обычных
+	// PriceGroupID – группа цен покупателя; nil – цены продажи.
+	PriceGroupID *int      `json:"price_group_id"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
 }
@@ -0,0 +1,450 @@
+package models
+
+import (
+	"database/sql"
+	"log"
+	"time"
+)
+
+// Справочник автомобилей: марка → модель → поколение → двигатель (модификация).
+// Применимость запчастей хранится в таблице part_fitments: запчасть привязывается
+// к поколению целиком либо к конкретному двигателю внутри поколения.
+
+// VehicleMake описывает марку автомобиля (vehicle_makes).
+type VehicleMake struct {
+	ID       int       `json:"id"`        // make_id – первичный ключ
+	Name     string    `json:"name"`      // название марки, например "Toyota"
+	CreateAt time.Time `json:"create_at"` // время создания
+	UpdateAt time.Time `json:"update_at"` // время последнего обновления
+}
+
+// VehicleModel описывает модель автомобиля (vehicle_models).
+type VehicleModel struct {
+	ID       int       `json:"id"`        // model_id – первичный ключ
+	MakeID   int       `json:"make_id"`   // марка, к которой относится модель
+	Name     string    `json:"name"`      // название модели, например "Camry"
+	CreateAt time.Time `json:"create_at"` // время создания
+	UpdateAt time.Time `json:"update_at"` // время последнего обновления
+}
+
+// VehicleGeneration описывает поколение модели (vehicle_generations).
+type VehicleGeneration struct {
+	ID       int       `json:"id"`        // generation_id – первичный ключ
+	ModelID  int       `json:"model_id"`  // модель, к которой относится поколение
+	Name     string    `json:"name"`      // обозначение поколения, например "XV70"
+	YearFrom *int      `json:"year_from"` // первый год выпуска
+	YearTo   *int      `json:"year_to"`   // последний год выпуска (nil – выпускается до сих пор)
+	CreateAt time.Time `json:"create_at"` // время создания
+	UpdateAt time.Time `json:"update_at"` // время последнего обновления
+}
+
+// VehicleEngine описывает двигатель (модификацию) поколения (vehicle_engines).
+type VehicleEngine struct {
+	ID           int       `json:"id"`            // engine_id – первичный ключ
+	GenerationID int       `json:"generation_id"` // поколение, к которому относится модификация
+	Name         string    `json:"name"`          // обозначение, например "2.5 A25A-FKS"
+	FuelType     string    `json:"fuel_type"`     // тип топлива: petrol, diesel, hybrid и т.п.
+	VolumeCC     *int      `json:"volume_cc"`     // рабочий объём, см³
+	PowerHP      *int      `json:"power_hp"`      // мощность, л.с.
+	CreateAt     time.Time `json:"create_at"`     // время создания
+	UpdateAt     time.Time `json:"update_at"`     // время последнего обновления
+}
+
+// PartFitment описывает применимость запчасти к автомобилю (part_fitments).
+// Если EngineID = nil, запчасть подходит ко всем двигателям поколения.
+type PartFitment struct {
+	ID           int       `json:"id"`            // fitment_id – первичный ключ
+	PartID       int       `json:"part_id"`       // запчасть
+	GenerationID int       `json:"generation_id"` // поколение автомобиля
+	EngineID     *int      `json:"engine_id"`     // конкретный двигатель (необязательно)
+	Note         string    `json:"note"`          // уточнение, например "только для седана"
+	CreateAt     time.Time `json:"create_at"`     // время создания
+}
+
+// VehicleSelection – выбранный покупателем автомобиль для фильтра "запчасти для моей машины".
+// Учитывается самый точный из заданных уровней. Year (например, из расшифровки VIN)
+// сужает выбор марки или модели до поколений, выпускавшихся в этот год.
+type VehicleSelection struct {
+	MakeID       int
+	ModelID      int
+	GenerationID int
+	EngineID     int
+	Year         int
+}
+
+// IsEmpty сообщает, что автомобиль не выбран.
+func (v VehicleSelection) IsEmpty() bool {
+	return v.MakeID == 0 && v.ModelID == 0 && v.GenerationID == 0 && v.EngineID == 0
+}
+
+// yearCondition возвращает условие на годы выпуска поколения (alias g), если задан год.
+func (v VehicleSelection) yearCondition() (string, []interface{}) {
+	if v.Year == 0 {
+		return "", nil
+	}
+	return " AND (g.year_from IS NULL OR g.year_from <= ?) AND (g.year_to IS NULL OR g.year_to >= ?)",
+		[]interface{}{v.Year, v.Year}
+}
+
+// condition возвращает SQL-условие на столбец part_id запчасти (alias p)
+// и его аргументы для выбранного автомобиля.
+func (v VehicleSelection) condition() (string, []interface{}) {
+	const prefix = "p.part_id IN (SELECT f.part_id FROM part_fitments f "
+	switch {
+	case v.EngineID > 0:
+		return prefix + "WHERE f.engine_id = ? OR (f.engine_id IS NULL AND f.generation_id = " +
+			"(SELECT generation_id FROM vehicle_engines WHERE engine_id = ?)))", []interface{}{v.EngineID, v.EngineID}
+	case v.GenerationID > 0:
+		return prefix + "WHERE f.generation_id = ?)", []interface{}{v.GenerationID}
+	case v.ModelID > 0:
+		yearCond, yearArgs := v.yearCondition()
+		return prefix + "JOIN vehicle_generations g ON g.generation_id = f.generation_id WHERE g.model_id = ?" + yearCond + ")",
+			append([]interface{}{v.ModelID}, yearArgs...)
+	case v.MakeID > 0:
+		yearCond, yearArgs := v.yearCondition()
+		return prefix + "JOIN vehicle_generations g ON g.generation_id = f.generation_id " +
+				"JOIN vehicle_models m ON m.model_id = g.model_id WHERE m.make_id = ?" + yearCond + ")",
+			append([]interface{}{v.MakeID}, yearArgs...)
+	}
+	return "", nil
+}
+
+// migrateVehicles создаёт таблицы справочника автомобилей и применимости запчастей.
+func migrateVehicles(db *sql.DB) error {
+	return execAll(db,
+		"CREATE TABLE IF NOT EXISTS vehicle_makes ("+
+			"make_id INT AUTO_INCREMENT PRIMARY KEY, "+
+			"name VARCHAR(100) NOT NULL UNIQUE, "+
+			"create_at DATETIME NOT NULL, update_at DATETIME NOT NULL"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"CREATE TABLE IF NOT EXISTS vehicle_models ("+
+			"model_id INT AUTO_INCREMENT PRIMARY KEY, "+
+			"make_id INT NOT NULL, "+
+			"name VARCHAR(100) NOT NULL, "+
+			"create_at DATETIME NOT NULL, update_at DATETIME NOT NULL, "+
+			"UNIQUE KEY uq_make_model (make_id, name), "+
+			"FOREIGN KEY (make_id) REFERENCES vehicle_makes (make_id) ON DELETE CASCADE"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"CREATE TABLE IF NOT EXISTS vehicle_generations ("+
+			"generation_id INT AUTO_INCREMENT PRIMARY KEY, "+
+			"model_id INT NOT NULL, "+
+			"name VARCHAR(100) NOT NULL, "+
+			"year_from SMALLINT NULL, year_to SMALLINT NULL, "+
+			"create_at DATETIME NOT NULL, update_at DATETIME NOT NULL, "+
+			"FOREIGN KEY (model_id) REFERENCES vehicle_models (model_id) ON DELETE CASCADE"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"CREATE TABLE IF NOT EXISTS vehicle_engines ("+
+			"engine_id INT AUTO_INCREMENT PRIMARY KEY, "+
+			"generation_id INT NOT NULL, "+
+			"name VARCHAR(100) NOT NULL, "+
+			"fuel_type VARCHAR(20) NOT NULL DEFAULT '', "+
+			"volume_cc INT NULL, power_hp INT NULL, "+
+			"create_at DATETIME NOT NULL, update_at DATETIME NOT NULL, "+
+			"FOREIGN KEY (generation_id) REFERENCES vehicle_generations (generation_id) ON DELETE CASCADE"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"CREATE TABLE IF NOT EXISTS part_fitments ("+
+			"fitment_id INT AUTO_INCREMENT PRIMARY KEY, "+
+			"part_id INT NOT NULL, "+
+			"generation_id INT NOT NULL, "+
+			"engine_id INT NULL, "+
+			"note VARCHAR(255) NOT NULL DEFAULT '', "+
+			"create_at DATETIME NOT NULL, "+
+			"KEY idx_fitment_part (part_id), "+
+			"KEY idx_fitment_generation (generation_id), "+
+			"KEY idx_fitment_engine (engine_id), "+
+			"FOREIGN KEY (generation_id) REFERENCES vehicle_generations (generation_id) ON DELETE CASCADE, "+
+			"FOREIGN KEY (engine_id) REFERENCES vehicle_engines (engine_id) ON DELETE CASCADE"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+	)
+}
+
+// rowsAffected возвращает количество затронутых строк результата запроса.
+func rowsAffected(result sql.Result, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// -----------------------------
+// Марки
+// -----------------------------
+
+// GetAllVehicleMakes возвращает все марки, отсортированные по названию.
+func GetAllVehicleMakes(db *sql.DB) ([]VehicleMake, error) {
+	rows, err := db.Query("SELECT make_id, name, create_at, update_at FROM vehicle_makes ORDER BY name")
+	if err != nil {
+		log.Printf("[ERROR] GetAllVehicleMakes: ошибка выполнения запроса: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var makes []VehicleMake
+	for rows.Next() {
+		var m VehicleMake
+		if err := rows.Scan(&m.ID, &m.Name, &m.CreateAt, &m.UpdateAt); err != nil {
+			return nil, err
+		}
+		makes = append(makes, m)
+	}
+	return makes, rows.Err()
+}
+
+// GetVehicleMakeByID возвращает марку по её ID.
+func GetVehicleMakeByID(db *sql.DB, id int) (VehicleMake, error) {
+	var m VehicleMake
+	query := "SELECT make_id, name, create_at, update_at FROM vehicle_makes WHERE make_id = ?"
+	if err := db.QueryRow(query, id).Scan(&m.ID, &m.Name, &m.CreateAt, &m.UpdateAt); err != nil {
+		return VehicleMake{}, err
+	}
+	return m, nil
+}
+
+// GetVehicleMakeByName возвращает марку по названию без учёта регистра.
+// Используется для сопоставления марки из расшифровки VIN со справочником магазина.
+func GetVehicleMakeByName(db *sql.DB, name string) (VehicleMake, error) {
+	var m VehicleMake
+	query := "SELECT make_id, name, create_at, update_at FROM vehicle_makes WHERE LOWER(name) = LOWER(?)"
+	if err := db.QueryRow(query, name).Scan(&m.ID, &m.Name, &m.CreateAt, &m.UpdateAt); err != nil {
+		return VehicleMake{}, err
+	}
+	return m, nil
+}
+
+// AddVehicleMake добавляет новую марку.
+func AddVehicleMake(db *sql.DB, name string) error {
+	_, err := db.Exec("INSERT INTO vehicle_makes (name, create_at, update_at) VALUES (?, NOW(), NOW())", name)
+	return err
+}
+
+// UpdateVehicleMake переименовывает марку.
+func UpdateVehicleMake(db *sql.DB, id int, name string) (int64, error) {
+	return rowsAffected(db.Exec("UPDATE vehicle_makes SET name = ?, update_at = NOW() WHERE make_id = ?", name, id))
+}
+
+// DeleteVehicleMake удаляет марку вместе с её моделями, поколениями и применимостью.
+func DeleteVehicleMake(db *sql.DB, id int) (int64, error) {
+	return rowsAffected(db.Exec("DELETE FROM vehicle_makes WHERE make_id = ?", id))
+}
+
+// -----------------------------
+// Модели
+// -----------------------------
+
+// GetVehicleModels возвращает модели марки makeID; при makeID = 0 – все модели.
+func GetVehicleModels(db *sql.DB, makeID int) ([]VehicleModel, error) {
+	query := "SELECT model_id, make_id, name, create_at, update_at FROM vehicle_models"
+	var args []interface{}
+	if makeID > 0 {
+		query += " WHERE make_id = ?"
+		args = append(args, makeID)
+	}
+	rows, err := db.Query(query+" ORDER BY name", args...)
+	if err != nil {
+		log.Printf("[ERROR] GetVehicleModels: ошибка выполнения запроса: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []VehicleModel
+	for rows.Next() {
+		var m VehicleModel
+		if err := rows.Scan(&m.ID, &m.MakeID, &m.Name, &m.CreateAt, &m.UpdateAt); err != nil {
+			return nil, err
+		}
+		list = append(list, m)
+	}
+	return list, rows.Err()
+}
+
+// GetVehicleModelByID возвращает модель по её ID.
+func GetVehicleModelByID(db *sql.DB, id int) (VehicleModel, error) {
+	var m VehicleModel
+	query := "SELECT model_id, make_id, name, create_at, update_at FROM vehicle_models WHERE model_id = ?"
+	if err := db.QueryRow(query, id).Scan(&m.ID, &m.MakeID, &m.Name, &m.CreateAt, &m.UpdateAt); err != nil {
+		return VehicleModel{}, err
+	}
+	return m, nil
+}
+
+// AddVehicleModel добавляет модель к марке.
+func AddVehicleModel(db *sql.DB, makeID int, name string) error {
+	_, err := db.Exec("INSERT INTO vehicle_models (make_id, name, create_at, update_at) VALUES (?, ?, NOW(), NOW())", makeID, name)
+	return err
+}
+
+// UpdateVehicleModel обновляет модель.
+func UpdateVehicleModel(db *sql.DB, id, makeID int, name string) (int64, error) {
+	return rowsAffected(db.Exec("UPDATE vehicle_models SET make_id = ?, name = ?, update_at = NOW() WHERE model_id = ?", makeID, name, id))
+}
+
+// DeleteVehicleModel удаляет модель вместе с её поколениями и применимостью.
+func DeleteVehicleModel(db *sql.DB, id int) (int64, error) {
+	return rowsAffected(db.Exec("DELETE FROM vehicle_models WHERE model_id = ?", id))
+}
+
+// -----------------------------
+// Поколения
+// -----------------------------
+
+// GetVehicleGenerations возвращает поколения модели modelID; при modelID = 0 – все поколения.
+func GetVehicleGenerations(db *sql.DB, modelID int) ([]VehicleGeneration, error) {
+	query := "SELECT generation_id, model_id, name, year_from, year_to, create_at, update_at FROM vehicle_generations"
+	var args []interface{}
+	if modelID > 0 {
+		query += " WHERE model_id = ?"
+		args = append(args, modelID)
+	}
+	rows, err := db.Query(query+" ORDER BY year_from, name", args...)
+	if err != nil {
+		log.Printf("[ERROR] GetVehicleGenerations: ошибка выполнения запроса: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []VehicleGeneration
+	for rows.Next() {
+		var g VehicleGeneration
+		if err := rows.Scan(&g.ID, &g.ModelID, &g.Name, &g.YearFrom, &g.YearTo, &g.CreateAt, &g.UpdateAt); err != nil {
+			return nil, err
+		}
+		list = append(list, g)
+	}
+	return list, rows.Err()
+}
+
+// GetVehicleGenerationByID возвращает поколение по его ID.
+func GetVehicleGenerationByID(db *sql.DB, id int) (VehicleGeneration, error) {
+	var g VehicleGeneration
+	query := "SELECT generation_id, model_id, name, year_from, year_to, create_at, update_at FROM vehicle_generations WHERE generation_id = ?"
+	if err := db.QueryRow(query, id).Scan(&g.ID, &g.ModelID, &g.Name, &g.YearFrom, &g.YearTo, &g.CreateAt, &g.UpdateAt); err != nil {
+		return VehicleGeneration{}, err
+	}
+	return g, nil
+}
+
+// AddVehicleGeneration добавляет поколение к модели.
+func AddVehicleGeneration(db *sql.DB, g VehicleGeneration) error {
+	query := "INSERT INTO vehicle_generations (model_id, name, year_from, year_to, create_at, update_at) VALUES (?, ?, ?, ?, NOW(), NOW())"
+	_, err := db.Exec(query, g.ModelID, g.Name, g.YearFrom, g.YearTo)
+	return err
+}
+
+// UpdateVehicleGeneration обновляет поколение.
+func UpdateVehicleGeneration(db *sql.DB, id int, g VehicleGeneration) (int64, error) {
+	query := "UPDATE vehicle_generations SET model_id = ?, name = ?, year_from = ?, year_to = ?, update_at = NOW() WHERE generation_id = ?"
+	return rowsAffected(db.Exec(query, g.ModelID, g.Name, g.YearFrom, g.YearTo, id))
+}
+
+// DeleteVehicleGeneration удаляет поколение вместе с двигателями и применимостью.
+func DeleteVehicleGeneration(db *sql.DB, id int) (int64, error) {
+	return rowsAffected(db.Exec("DELETE FROM vehicle_generations WHERE generation_id = ?", id))
+}
+
+// -----------------------------
+// Двигатели (модификации)
+// -----------------------------
+
+// GetVehicleEngines возвращает двигатели поколения generationID; при generationID = 0 – все двигатели.
+func GetVehicleEngines(db *sql.DB, generationID int) ([]VehicleEngine, error) {
+	query := "SELECT engine_id, generation_id, name, fuel_type, volume_cc, power_hp, create_at, update_at FROM vehicle_engines"
+	var args []interface{}
+	if generationID > 0 {
+		query += " WHERE generation_id = ?"
+		args = append(args, generationID)
+	}
+	rows, err := db.Query(query+" ORDER BY name", args...)
+	if err != nil {
+		log.Printf("[ERROR] GetVehicleEngines: ошибка выполнения запроса: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []VehicleEngine
+	for rows.Next() {
+		var e VehicleEngine
+		if err := rows.Scan(&e.ID, &e.GenerationID, &e.Name, &e.FuelType, &e.VolumeCC, &e.PowerHP, &e.CreateAt, &e.UpdateAt); err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+// GetVehicleEngineByID возвращает двигатель по его ID.
+func GetVehicleEngineByID(db *sql.DB, id int) (VehicleEngine, error) {
+	var e VehicleEngine
+	query := "SELECT engine_id, generation_id, name, fuel_type, volume_cc, power_hp, create_at, update_at FROM vehicle_engines WHERE engine_id = ?"
+	if err := db.QueryRow(query, id).Scan(&e.ID, &e.GenerationID, &e.Name, &e.FuelType, &e.VolumeCC, &e.PowerHP, &e.CreateAt, &e.UpdateAt); err != nil {
+		return VehicleEngine{}, err
+	}
+	return e, nil
+}
+
+// AddVehicleEngine добавляет двигатель к поколению.
+func AddVehicleEngine(db *sql.DB, e VehicleEngine) error {
+	query := "INSERT INTO vehicle_engines (generation_id, name, fuel_type, volume_cc, power_hp, create_at, update_at) VALUES (?, ?, ?, ?, ?, NOW(), NOW())"
+	_, err := db.Exec(query, e.GenerationID, e.Name, e.FuelType, e.VolumeCC, e.PowerHP)
+	return err
+}
+
+// UpdateVehicleEngine обновляет двигатель.
+func UpdateVehicleEngine(db *sql.DB, id int, e VehicleEngine) (int64, error) {
+	query := "UPDATE vehicle_engines SET generation_id = ?, name = ?, fuel_type = ?, volume_cc = ?, power_hp = ?, update_at = NOW() WHERE engine_id = ?"
+	return rowsAffected(db.Exec(query, e.GenerationID, e.Name, e.FuelType, e.VolumeCC, e.PowerHP, id))
+}
+
+// DeleteVehicleEngine удаляет двигатель вместе с применимостью к нему.
+func DeleteVehicleEngine(db *sql.DB, id int) (int64, error) {
+	return rowsAffected(db.Exec("DELETE FROM vehicle_engines WHERE engine_id = ?", id))
+}
+
+// -----------------------------
+// Применимость запчастей
+// -----------------------------
+
+// GetPartFitments возвращает записи применимости запчасти partID; при partID = 0 – все записи.
+func GetPartFitments(db *sql.DB, partID int) ([]PartFitment, error) {
+	query := "SELECT fitment_id, part_id, generation_id, engine_id, note, create_at FROM part_fitments"
+	var args []interface{}
+	if partID > 0 {
+		query += " WHERE part_id = ?"
+		args = append(args, partID)
+	}
+	rows, err := db.Query(query+" ORDER BY fitment_id", args...)
+	if err != nil {
+		log.Printf("[ERROR] GetPartFitments: ошибка выполнения запроса: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []PartFitment
+	for rows.Next() {
+		var f PartFitment
+		if err := rows.Scan(&f.ID, &f.PartID, &f.GenerationID, &f.EngineID, &f.Note, &f.CreateAt); err != nil {
+			return nil, err
+		}
+		list = append(list, f)
+	}
+	return list, rows.Err()
+}
+
+// AddPartFitment привязывает запчасть к поколению или двигателю.
+// Если указан двигатель, поколение берётся из справочника двигателей.
+func AddPartFitment(db *sql.DB, f PartFitment) error {
+	if f.EngineID != nil {
+		engine, err := GetVehicleEngineByID(db, *f.EngineID)
+		if err != nil {
+			return err
+		}
+		f.GenerationID = engine.GenerationID
+	}
+	query := "INSERT INTO part_fitments (part_id, generation_id, engine_id, note, create_at) VALUES (?, ?, ?, ?, NOW())"
+	_, err := db.Exec(query, f.PartID, f.GenerationID, f.EngineID, f.Note)
+	return err
+}
+
+// DeletePartFitment удаляет запись применимости.
+func DeletePartFitment(db *sql.DB, id int) (int64, error) {
+	return rowsAffected(db.Exec("DELETE FROM part_fitments WHERE fitment_id = ?", id))
+}
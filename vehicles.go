@@ -0,0 +1,367 @@
+package controllers
+
+import (
+	"AutoM/config"
+	"AutoM/models"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// -----------------------------
+// Марки
+// -----------------------------
+
+// GetVehicleMakes – список всех марок автомобилей.
+func GetVehicleMakes(w http.ResponseWriter, r *http.Request) {
+	makes, err := models.GetAllVehicleMakes(config.DB)
+	if err != nil {
+		http.Error(w, "Ошибка загрузки марок", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetVehicleMakes", makes)
+}
+
+// GetVehicleMakeByID – марка по ID.
+func GetVehicleMakeByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "GetVehicleMakeByID")
+	if !ok {
+		return
+	}
+	mk, err := models.GetVehicleMakeByID(config.DB, id)
+	if err != nil {
+		log.Printf("[ERROR] GetVehicleMakeByID: %v", err)
+		http.Error(w, "Марка не найдена", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, "GetVehicleMakeByID", mk)
+}
+
+// AddVehicleMake – добавление марки. Ожидается JSON с полем name.
+func AddVehicleMake(w http.ResponseWriter, r *http.Request) {
+	var mk models.VehicleMake
+	if !decodeJSON(w, r, "AddVehicleMake", &mk) {
+		return
+	}
+	if strings.TrimSpace(mk.Name) == "" {
+		http.Error(w, "Название марки обязательно", http.StatusBadRequest)
+		return
+	}
+	if err := models.AddVehicleMake(config.DB, strings.TrimSpace(mk.Name)); err != nil {
+		log.Printf("[ERROR] AddVehicleMake: %v", err)
+		http.Error(w, "Ошибка добавления марки", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// UpdateVehicleMake – переименование марки.
+func UpdateVehicleMake(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "UpdateVehicleMake")
+	if !ok {
+		return
+	}
+	var mk models.VehicleMake
+	if !decodeJSON(w, r, "UpdateVehicleMake", &mk) {
+		return
+	}
+	rows, err := models.UpdateVehicleMake(config.DB, id, strings.TrimSpace(mk.Name))
+	respondAffected(w, "UpdateVehicleMake", "Марка не найдена", rows, err)
+}
+
+// DeleteVehicleMake – удаление марки вместе с моделями и применимостью.
+func DeleteVehicleMake(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "DeleteVehicleMake")
+	if !ok {
+		return
+	}
+	rows, err := models.DeleteVehicleMake(config.DB, id)
+	respondAffected(w, "DeleteVehicleMake", "Марка не найдена", rows, err)
+}
+
+// -----------------------------
+// Модели
+// -----------------------------
+
+// GetVehicleModels – список моделей; параметр make_id ограничивает выборку одной маркой.
+func GetVehicleModels(w http.ResponseWriter, r *http.Request) {
+	makeID, err := queryInt(r, "make_id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	list, err := models.GetVehicleModels(config.DB, makeID)
+	if err != nil {
+		http.Error(w, "Ошибка загрузки моделей", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetVehicleModels", list)
+}
+
+// GetVehicleModelByID – модель по ID.
+func GetVehicleModelByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "GetVehicleModelByID")
+	if !ok {
+		return
+	}
+	m, err := models.GetVehicleModelByID(config.DB, id)
+	if err != nil {
+		log.Printf("[ERROR] GetVehicleModelByID: %v", err)
+		http.Error(w, "Модель не найдена", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, "GetVehicleModelByID", m)
+}
+
+// AddVehicleModel – добавление модели. Ожидается JSON с полями make_id и name.
+func AddVehicleModel(w http.ResponseWriter, r *http.Request) {
+	var m models.VehicleModel
+	if !decodeJSON(w, r, "AddVehicleModel", &m) {
+		return
+	}
+	if m.MakeID == 0 || strings.TrimSpace(m.Name) == "" {
+		http.Error(w, "Поля make_id и name обязательны", http.StatusBadRequest)
+		return
+	}
+	if err := models.AddVehicleModel(config.DB, m.MakeID, strings.TrimSpace(m.Name)); err != nil {
+		log.Printf("[ERROR] AddVehicleModel: %v", err)
+		http.Error(w, "Ошибка добавления модели", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// UpdateVehicleModel – обновление модели.
+func UpdateVehicleModel(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "UpdateVehicleModel")
+	if !ok {
+		return
+	}
+	var m models.VehicleModel
+	if !decodeJSON(w, r, "UpdateVehicleModel", &m) {
+		return
+	}
+	rows, err := models.UpdateVehicleModel(config.DB, id, m.MakeID, strings.TrimSpace(m.Name))
+	respondAffected(w, "UpdateVehicleModel", "Модель не найдена", rows, err)
+}
+
+// DeleteVehicleModel – удаление модели вместе с поколениями и применимостью.
+func DeleteVehicleModel(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "DeleteVehicleModel")
+	if !ok {
+		return
+	}
+	rows, err := models.DeleteVehicleModel(config.DB, id)
+	respondAffected(w, "DeleteVehicleModel", "Модель не найдена", rows, err)
+}
+
+// -----------------------------
+// Поколения
+// -----------------------------
+
+// GetVehicleGenerations – список поколений; параметр model_id ограничивает выборку одной моделью.
+func GetVehicleGenerations(w http.ResponseWriter, r *http.Request) {
+	modelID, err := queryInt(r, "model_id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	list, err := models.GetVehicleGenerations(config.DB, modelID)
+	if err != nil {
+		http.Error(w, "Ошибка загрузки поколений", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetVehicleGenerations", list)
+}
+
+// GetVehicleGenerationByID – поколение по ID.
+func GetVehicleGenerationByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "GetVehicleGenerationByID")
+	if !ok {
+		return
+	}
+	g, err := models.GetVehicleGenerationByID(config.DB, id)
+	if err != nil {
+		log.Printf("[ERROR] GetVehicleGenerationByID: %v", err)
+		http.Error(w, "Поколение не найдено", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, "GetVehicleGenerationByID", g)
+}
+
+// validGeneration проверяет обязательные поля и годы выпуска поколения.
+func validGeneration(w http.ResponseWriter, g models.VehicleGeneration) bool {
+	if g.ModelID == 0 || strings.TrimSpace(g.Name) == "" {
+		http.Error(w, "Поля model_id и name обязательны", http.StatusBadRequest)
+		return false
+	}
+	if g.YearFrom != nil && g.YearTo != nil && *g.YearFrom > *g.YearTo {
+		http.Error(w, "Год начала выпуска больше года окончания", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// AddVehicleGeneration – добавление поколения.
+// Ожидается JSON с полями model_id, name, year_from и year_to.
+func AddVehicleGeneration(w http.ResponseWriter, r *http.Request) {
+	var g models.VehicleGeneration
+	if !decodeJSON(w, r, "AddVehicleGeneration", &g) || !validGeneration(w, g) {
+		return
+	}
+	if err := models.AddVehicleGeneration(config.DB, g); err != nil {
+		log.Printf("[ERROR] AddVehicleGeneration: %v", err)
+		http.Error(w, "Ошибка добавления поколения", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// UpdateVehicleGeneration – обновление поколения.
+func UpdateVehicleGeneration(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "UpdateVehicleGeneration")
+	if !ok {
+		return
+	}
+	var g models.VehicleGeneration
+	if !decodeJSON(w, r, "UpdateVehicleGeneration", &g) || !validGeneration(w, g) {
+		return
+	}
+	rows, err := models.UpdateVehicleGeneration(config.DB, id, g)
+	respondAffected(w, "UpdateVehicleGeneration", "Запись поколения не найдена", rows, err)
+}
+
+// DeleteVehicleGeneration – удаление поколения вместе с двигателями и применимостью.
+func DeleteVehicleGeneration(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "DeleteVehicleGeneration")
+	if !ok {
+		return
+	}
+	rows, err := models.DeleteVehicleGeneration(config.DB, id)
+	respondAffected(w, "DeleteVehicleGeneration", "Запись поколения не найдена", rows, err)
+}
+
+// -----------------------------
+// Двигатели (модификации)
+// -----------------------------
+
+// GetVehicleEngines – список двигателей; параметр generation_id ограничивает выборку одним поколением.
+func GetVehicleEngines(w http.ResponseWriter, r *http.Request) {
+	generationID, err := queryInt(r, "generation_id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	list, err := models.GetVehicleEngines(config.DB, generationID)
+	if err != nil {
+		http.Error(w, "Ошибка загрузки двигателей", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetVehicleEngines", list)
+}
+
+// GetVehicleEngineByID – двигатель по ID.
+func GetVehicleEngineByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "GetVehicleEngineByID")
+	if !ok {
+		return
+	}
+	e, err := models.GetVehicleEngineByID(config.DB, id)
+	if err != nil {
+		log.Printf("[ERROR] GetVehicleEngineByID: %v", err)
+		http.Error(w, "Двигатель не найден", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, "GetVehicleEngineByID", e)
+}
+
+// AddVehicleEngine – добавление двигателя.
+// Ожидается JSON с полями generation_id, name, fuel_type, volume_cc и power_hp.
+func AddVehicleEngine(w http.ResponseWriter, r *http.Request) {
+	var e models.VehicleEngine
+	if !decodeJSON(w, r, "AddVehicleEngine", &e) {
+		return
+	}
+	if e.GenerationID == 0 || strings.TrimSpace(e.Name) == "" {
+		http.Error(w, "Поля generation_id и name обязательны", http.StatusBadRequest)
+		return
+	}
+	if err := models.AddVehicleEngine(config.DB, e); err != nil {
+		log.Printf("[ERROR] AddVehicleEngine: %v", err)
+		http.Error(w, "Ошибка добавления двигателя", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// UpdateVehicleEngine – обновление двигателя.
+func UpdateVehicleEngine(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "UpdateVehicleEngine")
+	if !ok {
+		return
+	}
+	var e models.VehicleEngine
+	if !decodeJSON(w, r, "UpdateVehicleEngine", &e) {
+		return
+	}
+	rows, err := models.UpdateVehicleEngine(config.DB, id, e)
+	respondAffected(w, "UpdateVehicleEngine", "Запись двигателя не найдена", rows, err)
+}
+
+// DeleteVehicleEngine – удаление двигателя вместе с применимостью к нему.
+func DeleteVehicleEngine(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "DeleteVehicleEngine")
+	if !ok {
+		return
+	}
+	rows, err := models.DeleteVehicleEngine(config.DB, id)
+	respondAffected(w, "DeleteVehicleEngine", "Запись двигателя не найдена", rows, err)
+}
+
+// -----------------------------
+// Применимость запчастей
+// -----------------------------
+
+// GetPartFitments – список записей применимости; параметр part_id ограничивает выборку одной запчастью.
+func GetPartFitments(w http.ResponseWriter, r *http.Request) {
+	partID, err := queryInt(r, "part_id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	list, err := models.GetPartFitments(config.DB, partID)
+	if err != nil {
+		http.Error(w, "Ошибка загрузки применимости", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetPartFitments", list)
+}
+
+// AddPartFitment – привязка запчасти к автомобилю.
+// Ожидается JSON с полями part_id и generation_id либо engine_id, а также необязательным note.
+func AddPartFitment(w http.ResponseWriter, r *http.Request) {
+	var f models.PartFitment
+	if !decodeJSON(w, r, "AddPartFitment", &f) {
+		return
+	}
+	if f.PartID == 0 || (f.GenerationID == 0 && f.EngineID == nil) {
+		http.Error(w, "Необходимо указать part_id и generation_id или engine_id", http.StatusBadRequest)
+		return
+	}
+	if err := models.AddPartFitment(config.DB, f); err != nil {
+		log.Printf("[ERROR] AddPartFitment: %v", err)
+		http.Error(w, "Ошибка добавления применимости", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// DeletePartFitment – удаление записи применимости.
+func DeletePartFitment(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "DeletePartFitment")
+	if !ok {
+		return
+	}
+	rows, err := models.DeletePartFitment(config.DB, id)
+	respondAffected(w, "DeletePartFitment", "Запись применимости не найдена", rows, err)
+}
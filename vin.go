@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"AutoM/config"
+	"AutoM/models"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// decodeVINForCatalog расшифровывает VIN и сопоставляет марку со справочником автомобилей магазина.
+// Возвращает расшифровку и выбор автомобиля (марка и модельный год) для фильтра запчастей.
+// Если марки нет в справочнике, выбор автомобиля остаётся пустым.
+func decodeVINForCatalog(vin string) (models.VINInfo, models.VehicleSelection, error) {
+	info, err := models.DecodeVIN(vin)
+	if err != nil {
+		return models.VINInfo{}, models.VehicleSelection{}, err
+	}
+	var vehicle models.VehicleSelection
+	if info.Make == "" {
+		return info, vehicle, nil
+	}
+	mk, err := models.GetVehicleMakeByName(config.DB, info.Make)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		log.Printf("[WARN] Марка %s из VIN %s отсутствует в справочнике автомобилей", info.Make, info.VIN)
+	case err != nil:
+		return models.VINInfo{}, models.VehicleSelection{}, err
+	default:
+		info.MakeID = mk.ID
+		vehicle = models.VehicleSelection{MakeID: mk.ID, Year: info.ModelYear}
+	}
+	return info, vehicle, nil
+}
+
+// isVINValidationError сообщает, вызвана ли ошибка неверным VIN, а не сбоем базы данных.
+func isVINValidationError(err error) bool {
+	return errors.Is(err, models.ErrVINLength) || errors.Is(err, models.ErrVINCharacters) || errors.Is(err, models.ErrVINCheckDigit)
+}
+
+// DecodeVIN – расшифровка VIN по локальным справочникам (API).
+// Возвращает изготовителя, марку, модельный год, регион и завод; make_id заполняется,
+// если марка есть в справочнике автомобилей магазина.
+func DecodeVIN(w http.ResponseWriter, r *http.Request) {
+	vin := mux.Vars(r)["vin"]
+	log.Printf("[INFO] DecodeVIN: расшифровка VIN %s", vin)
+	info, _, err := decodeVINForCatalog(vin)
+	if err != nil {
+		if isVINValidationError(err) {
+			log.Printf("[WARN] DecodeVIN: неверный VIN %s: %v", vin, err)
+			sendJSON(w, http.StatusBadRequest, JSONResponse{Error: err.Error()})
+			return
+		}
+		log.Printf("[ERROR] DecodeVIN: ошибка сопоставления марки: %v", err)
+		sendJSON(w, http.StatusInternalServerError, JSONResponse{Error: "Ошибка расшифровки VIN"})
+		return
+	}
+	writeJSON(w, "DecodeVIN", info)
+}
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// VINInfo – результат расшифровки VIN по локальным справочникам.
+type VINInfo struct {
+	VIN             string `json:"vin"`               // нормализованный VIN (верхний регистр)
+	WMI             string `json:"wmi"`               // позиции 1–3: мировой индекс изготовителя
+	VDS             string `json:"vds"`               // позиции 4–9: описательная часть
+	VIS             string `json:"vis"`               // позиции 10–17: указательная часть
+	Region          string `json:"region"`            // регион по первому символу
+	Country         string `json:"country"`           // страна изготовителя, если известна
+	Manufacturer    string `json:"manufacturer"`      // изготовитель по WMI, если известен
+	Make            string `json:"make"`              // марка по WMI, если известна
+	ModelYear       int    `json:"model_year"`        // модельный год (0 – не удалось определить)
+	PlantCode       string `json:"plant_code"`        // позиция 11: код сборочного завода
+	Plant           string `json:"plant"`             // завод, если код есть в справочнике
+	SerialNumber    string `json:"serial_number"`     // позиции 12–17: серийный номер
+	CheckDigit      string `json:"check_digit"`       // позиция 9: контрольный символ
+	CheckDigitValid bool   `json:"check_digit_valid"` // совпадает ли контрольный символ с расчётным
+	MakeID          int    `json:"make_id,omitempty"` // марка в справочнике автомобилей магазина
+}
+
+// Ошибки проверки VIN.
+var (
+	ErrVINLength     = errors.New("VIN должен содержать 17 символов")
+	ErrVINCharacters = errors.New("VIN содержит недопустимые символы (разрешены цифры и латинские буквы, кроме I, O, Q)")
+	ErrVINCheckDigit = errors.New("неверный контрольный символ VIN")
+)
+
+// vinWeights – веса позиций VIN для расчёта контрольного символа (ISO 3779, FMVSS 115).
+var vinWeights = [17]int{8, 7, 6, 5, 4, 3, 2, 10, 0, 9, 8, 7, 6, 5, 4, 3, 2}
+
+// vinYearCodes – символы модельного года (позиция 10) в порядке следования, начиная с 1980 года.
+// Цикл повторяется каждые 30 лет.
+const vinYearCodes = "ABCDEFGHJKLMNPRSTVWXY123456789"
+
+// vinValue возвращает числовое значение символа VIN для расчёта контрольного символа.
+// Для недопустимых символов возвращается -1.
+func vinValue(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c == 'I' || c == 'O' || c == 'Q':
+		return -1
+	case c >= 'A' && c <= 'H':
+		return int(c-'A') + 1
+	case c >= 'J' && c <= 'R':
+		return int(c-'J') + 1
+	case c >= 'S' && c <= 'Z':
+		return int(c-'S') + 2
+	}
+	return -1
+}
+
+// NormalizeVIN удаляет пробелы и дефисы и переводит VIN в верхний регистр.
+func NormalizeVIN(vin string) string {
+	vin = strings.ToUpper(strings.TrimSpace(vin))
+	return strings.NewReplacer(" ", "", "-", "").Replace(vin)
+}
+
+// VINCheckDigit вычисляет контрольный символ VIN (позиция 9).
+func VINCheckDigit(vin string) (byte, error) {
+	if len(vin) != 17 {
+		return 0, ErrVINLength
+	}
+	sum := 0
+	for i := 0; i < 17; i++ {
+		v := vinValue(vin[i])
+		if v < 0 {
+			return 0, ErrVINCharacters
+		}
+		sum += v * vinWeights[i]
+	}
+	if rem := sum % 11; rem != 10 {
+		return byte('0' + rem), nil
+	}
+	return 'X', nil
+}
+
+// isNorthAmericanVIN сообщает, выпущен ли автомобиль для рынка Северной Америки,
+// где контрольный символ и правило позиции 7 для модельного года обязательны.
+func isNorthAmericanVIN(vin string) bool {
+	return vin[0] >= '1' && vin[0] <= '5'
+}
+
+// vinModelYear определяет модельный год по позиции 10.
+// Для Северной Америки цикл выбирается по позиции 7 (буква – с 2010 года),
+// для остальных рынков берётся последний год цикла, не превышающий следующий календарный.
+func vinModelYear(vin string, now time.Time) int {
+	idx := strings.IndexByte(vinYearCodes, vin[9])
+	if idx < 0 {
+		return 0
+	}
+	year := 1980 + idx
+	if isNorthAmericanVIN(vin) {
+		if vin[6] < '0' || vin[6] > '9' {
+			year += 30
+		}
+		return year
+	}
+	for year+30 <= now.Year()+1 {
+		year += 30
+	}
+	return year
+}
+
+// DecodeVIN проверяет и расшифровывает VIN по локальным справочникам.
+// Неверный контрольный символ считается ошибкой только для VIN Северной Америки:
+// европейские и азиатские изготовители часто не используют позицию 9 как контрольную,
+// поэтому для них результат проверки только отражается в поле CheckDigitValid.
+func DecodeVIN(raw string) (VINInfo, error) {
+	vin := NormalizeVIN(raw)
+	if len(vin) != 17 {
+		return VINInfo{}, ErrVINLength
+	}
+	check, err := VINCheckDigit(vin)
+	if err != nil {
+		return VINInfo{}, err
+	}
+
+	info := VINInfo{
+		VIN:             vin,
+		WMI:             vin[0:3],
+		VDS:             vin[3:9],
+		VIS:             vin[9:17],
+		Region:          vinRegion(vin[0]),
+		ModelYear:       vinModelYear(vin, time.Now()),
+		PlantCode:       vin[10:11],
+		SerialNumber:    vin[11:17],
+		CheckDigit:      vin[8:9],
+		CheckDigitValid: vin[8] == check,
+	}
+	if !info.CheckDigitValid && isNorthAmericanVIN(vin) {
+		return VINInfo{}, ErrVINCheckDigit
+	}
+
+	if m, ok := vinManufacturers[info.WMI]; ok {
+		info.Manufacturer, info.Make, info.Country = m.manufacturer, m.make, m.country
+	}
+	if info.Country == "" {
+		info.Country = vinCountry(vin[0:2])
+	}
+	if plants, ok := vinPlants[info.WMI]; ok {
+		info.Plant = plants[vin[10]]
+	}
+	return info, nil
+}
+
+// vinRegion возвращает регион изготовителя по первому символу VIN.
+func vinRegion(c byte) string {
+	switch {
+	case c >= 'A' && c <= 'H':
+		return "Африка"
+	case c >= 'J' && c <= 'R':
+		return "Азия"
+	case c >= 'S' && c <= 'Z':
+		return "Европа"
+	case c >= '1' && c <= '5':
+		return "Северная Америка"
+	case c == '6' || c == '7':
+		return "Океания"
+	case c == '8' || c == '9':
+		return "Южная Америка"
+	}
+	return ""
+}
+
+// vinCountry возвращает страну по первым двум символам VIN, если диапазон есть в справочнике.
+func vinCountry(prefix string) string {
+	for _, r := range vinCountryRanges {
+		if prefix[0] == r.first && prefix[1] >= r.from && prefix[1] <= r.to {
+			return r.country
+		}
+	}
+	return ""
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVINCheckDigit(t *testing.T) {
+	tests := []struct {
+		vin  string
+		want byte
+		err  error
+	}{
+		{"1M8GDM9AXKP042788", 'X', nil}, // пример из стандарта 49 CFR 565
+		{"11111111111111111", '1', nil},
+		{"1HGCM82633A004352", '3', nil},
+		{"1M8GDM9AXKP04278", 0, ErrVINLength},
+		{"1M8GDM9AXKP0427881", 0, ErrVINLength},
+		{"1M8GDM9AXKP04278I", 0, ErrVINCharacters},
+		{"1M8GDM9AXKP04278O", 0, ErrVINCharacters},
+	}
+	for _, tt := range tests {
+		got, err := VINCheckDigit(tt.vin)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("VINCheckDigit(%q): ошибка %v, ожидалась %v", tt.vin, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("VINCheckDigit(%q) = %q, ожидалось %q", tt.vin, got, tt.want)
+		}
+	}
+}
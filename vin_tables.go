@@ -0,0 +1,166 @@
+package models
+
+// Локальные справочники для расшифровки VIN. Таблицы поставляются вместе с приложением
+// и не требуют обращения к внешним сервисам; при появлении новых изготовителей
+// записи добавляются сюда.
+
+// vinManufacturer – запись справочника WMI.
+type vinManufacturer struct {
+	manufacturer string // изготовитель
+	make         string // марка, как она заводится в справочнике автомобилей магазина
+	country      string // страна сборки
+}
+
+// vinManufacturers – справочник WMI (позиции 1–3 VIN).
+var vinManufacturers = map[string]vinManufacturer{
+	// Россия
+	"XTA": {"АвтоВАЗ", "Lada", "Россия"},
+	"XTT": {"УАЗ", "UAZ", "Россия"},
+	"XTH": {"ГАЗ", "GAZ", "Россия"},
+	"X96": {"ГАЗ", "GAZ", "Россия"},
+	"X7L": {"Рено Россия", "Renault", "Россия"},
+	"XW8": {"Фольксваген Груп Рус", "Volkswagen", "Россия"},
+	"X4X": {"Автотор", "BMW", "Россия"},
+	"Z94": {"Хендэ Мотор Мануфактуринг Рус", "Hyundai", "Россия"},
+	"Z8N": {"Ниссан Мануфэкчуринг Рус", "Nissan", "Россия"},
+
+	// Германия
+	"WVW": {"Volkswagen AG", "Volkswagen", "Германия"},
+	"WVG": {"Volkswagen AG (SUV)", "Volkswagen", "Германия"},
+	"WV1": {"Volkswagen Коммерческие автомобили", "Volkswagen", "Германия"},
+	"WV2": {"Volkswagen Коммерческие автомобили", "Volkswagen", "Германия"},
+	"WAU": {"Audi AG", "Audi", "Германия"},
+	"WBA": {"BMW AG", "BMW", "Германия"},
+	"WBS": {"BMW M GmbH", "BMW", "Германия"},
+	"WDB": {"Mercedes-Benz", "Mercedes-Benz", "Германия"},
+	"WDD": {"Mercedes-Benz", "Mercedes-Benz", "Германия"},
+	"WDC": {"Mercedes-Benz (SUV)", "Mercedes-Benz", "Германия"},
+	"WMW": {"MINI", "MINI", "Германия"},
+	"WP0": {"Porsche AG", "Porsche", "Германия"},
+	"WP1": {"Porsche AG (SUV)", "Porsche", "Германия"},
+	"W0L": {"Opel", "Opel", "Германия"},
+	"WF0": {"Ford Германия", "Ford", "Германия"},
+	"WME": {"smart", "smart", "Германия"},
+
+	// Другие страны Европы
+	"VF1": {"Renault", "Renault", "Франция"},
+	"VF3": {"Peugeot", "Peugeot", "Франция"},
+	"VF7": {"Citroën", "Citroen", "Франция"},
+	"VNK": {"Toyota Франция", "Toyota", "Франция"},
+	"VSS": {"SEAT", "SEAT", "Испания"},
+	"TMB": {"Škoda Auto", "Skoda", "Чехия"},
+	"TMA": {"Hyundai Чехия", "Hyundai", "Чехия"},
+	"U5Y": {"Kia Словакия", "Kia", "Словакия"},
+	"YV1": {"Volvo Cars", "Volvo", "Швеция"},
+	"YS3": {"Saab", "Saab", "Швеция"},
+	"ZFA": {"Fiat", "Fiat", "Италия"},
+	"ZAR": {"Alfa Romeo", "Alfa Romeo", "Италия"},
+	"ZFF": {"Ferrari", "Ferrari", "Италия"},
+	"SAL": {"Land Rover", "Land Rover", "Великобритания"},
+	"SAJ": {"Jaguar", "Jaguar", "Великобритания"},
+
+	// Азия
+	"JTD": {"Toyota", "Toyota", "Япония"},
+	"JTE": {"Toyota", "Toyota", "Япония"},
+	"JTM": {"Toyota (SUV)", "Toyota", "Япония"},
+	"JTN": {"Toyota", "Toyota", "Япония"},
+	"JT2": {"Toyota", "Toyota", "Япония"},
+	"JT3": {"Toyota (SUV)", "Toyota", "Япония"},
+	"JTH": {"Lexus", "Lexus", "Япония"},
+	"JHM": {"Honda", "Honda", "Япония"},
+	"JHL": {"Honda (SUV)", "Honda", "Япония"},
+	"JN1": {"Nissan", "Nissan", "Япония"},
+	"JN8": {"Nissan (SUV)", "Nissan", "Япония"},
+	"JMZ": {"Mazda", "Mazda", "Япония"},
+	"JM1": {"Mazda", "Mazda", "Япония"},
+	"JF1": {"Subaru", "Subaru", "Япония"},
+	"JF2": {"Subaru (SUV)", "Subaru", "Япония"},
+	"JA3": {"Mitsubishi", "Mitsubishi", "Япония"},
+	"JMB": {"Mitsubishi", "Mitsubishi", "Япония"},
+	"JS2": {"Suzuki", "Suzuki", "Япония"},
+	"JSA": {"Suzuki", "Suzuki", "Япония"},
+	"KMH": {"Hyundai", "Hyundai", "Южная Корея"},
+	"KNA": {"Kia", "Kia", "Южная Корея"},
+	"KND": {"Kia (SUV)", "Kia", "Южная Корея"},
+	"KL1": {"GM Korea", "Chevrolet", "Южная Корея"},
+
+	// Северная Америка
+	"1FA": {"Ford", "Ford", "США"},
+	"1FM": {"Ford (SUV)", "Ford", "США"},
+	"1FT": {"Ford (грузовые)", "Ford", "США"},
+	"1G1": {"Chevrolet", "Chevrolet", "США"},
+	"1GC": {"Chevrolet (грузовые)", "Chevrolet", "США"},
+	"1C4": {"Chrysler / Jeep", "Jeep", "США"},
+	"1J4": {"Jeep", "Jeep", "США"},
+	"1HG": {"Honda США", "Honda", "США"},
+	"1N4": {"Nissan США", "Nissan", "США"},
+	"4T1": {"Toyota США", "Toyota", "США"},
+	"4S3": {"Subaru США", "Subaru", "США"},
+	"5YJ": {"Tesla", "Tesla", "США"},
+	"5NP": {"Hyundai США", "Hyundai", "США"},
+	"5XY": {"Kia США", "Kia", "США"},
+	"2T1": {"Toyota Канада", "Toyota", "Канада"},
+	"2HG": {"Honda Канада", "Honda", "Канада"},
+	"3VW": {"Volkswagen Мексика", "Volkswagen", "Мексика"},
+}
+
+// vinCountryRange – диапазон первых двух символов VIN, закреплённый за страной.
+// Сравнение вторых символов выполняется по кодам ASCII.
+type vinCountryRange struct {
+	first    byte
+	from, to byte
+	country  string
+}
+
+// vinCountryRanges – страны по первым двум символам VIN (ISO 3780), используются,
+// если WMI нет в справочнике изготовителей.
+var vinCountryRanges = []vinCountryRange{
+	{'J', '0', 'Z', "Япония"},
+	{'K', 'L', 'R', "Южная Корея"},
+	{'L', '0', 'Z', "Китай"},
+	{'S', 'A', 'M', "Великобритания"},
+	{'T', 'J', 'P', "Чехия"},
+	{'T', 'R', 'V', "Венгрия"},
+	{'V', 'A', 'E', "Австрия"},
+	{'V', 'F', 'R', "Франция"},
+	{'V', 'S', 'W', "Испания"},
+	{'W', '0', 'Z', "Германия"},
+	{'X', '3', '9', "Россия"},
+	{'X', '0', '0', "Россия"},
+	{'Y', 'S', 'W', "Швеция"},
+	{'Z', 'A', 'R', "Италия"},
+	{'1', '0', 'Z', "США"},
+	{'4', '0', 'Z', "США"},
+	{'5', '0', 'Z', "США"},
+	{'2', '0', 'Z', "Канада"},
+	{'3', 'A', 'W', "Мексика"},
+	{'9', 'A', 'E', "Бразилия"},
+	{'9', '3', '9', "Бразилия"},
+}
+
+// volkswagenPlants – коды заводов концерна Volkswagen в Германии и Европе.
+var volkswagenPlants = map[byte]string{
+	'W': "Вольфсбург",
+	'H': "Ганновер",
+	'E': "Эмден",
+	'K': "Оснабрюк",
+	'P': "Цвиккау",
+	'D': "Братислава",
+	'X': "Познань",
+	'Y': "Памплона",
+	'M': "Пуэбла",
+	'U': "Уитенхаге",
+}
+
+// vinPlants – сборочные заводы по коду позиции 11. Коды заводов назначает сам изготовитель,
+// поэтому таблицы привязаны к WMI, а не к марке.
+var vinPlants = map[string]map[byte]string{
+	"WVW": volkswagenPlants,
+	"WVG": volkswagenPlants,
+	"WV1": volkswagenPlants,
+	"WV2": volkswagenPlants,
+	"WAU": {
+		'A': "Ингольштадт",
+		'N': "Неккарзульм",
+	},
+}
@@ -0,0 +1,600 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+// DefaultWarehouseName – название склада, который создаётся при первом запуске
+// и получает остатки, заведённые до появления складов.
+const DefaultWarehouseName = "Основной склад"
+
+// Ошибки складов и остатков.
+var (
+	ErrWarehouseName    = errors.New("название склада не может быть пустым")
+	ErrWarehouseExists  = errors.New("склад с таким названием уже существует")
+	ErrWarehouseDefault = errors.New("склад по умолчанию нельзя удалить; сначала назначьте другой склад по умолчанию")
+	ErrWarehouseInUse   = errors.New("на складе есть остатки; переместите их перед удалением")
+	ErrWarehouseHistory = errors.New("по складу есть движения в журнале; склад с историей нельзя удалить")
+	ErrWarehouseOrders  = errors.New("на склад ожидается поставка по незавершённым заказам поставщикам; получите или отмените их")
+	ErrWarehouseCount   = errors.New("на складе идёт инвентаризация; утвердите или отмените её")
+	ErrStockQuantity    = errors.New("остаток не может быть отрицательным")
+	ErrStockTotal       = errors.New("общий остаток меньше, чем лежит на остальных складах; измените остатки по складам")
+	ErrStockKit         = errors.New("остаток комплекта рассчитывается по компонентам")
+)
+
+// Warehouse – склад или точка выдачи (торговый зал, дальний склад, филиал).
+type Warehouse struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Address   string    `json:"address"`
+	IsDefault bool      `json:"is_default"` // сюда попадают остатки, заданные без указания склада
+	SortOrder int       `json:"sort_order"` // порядок вывода и списания при продаже
+	CreateAt  time.Time `json:"create_at"`
+}
+
+// PartStock – остаток запчасти на складе.
+type PartStock struct {
+	WarehouseID int    `json:"warehouse_id"`
+	Warehouse   string `json:"warehouse"`
+	Quantity    int    `json:"quantity"`
+}
+
+// migrateWarehouses создаёт склады и остатки по складам и заводит склад по умолчанию.
+// Остатки запчастей переносятся на него один раз – при создании таблицы остатков: после этого
+// остатки меняются только движениями по журналу.
+func migrateWarehouses(db *sql.DB) error {
+	seeded, err := tableExists(db, "part_stock")
+	if err != nil {
+		return err
+	}
+	err = execAll(db,
+		"CREATE TABLE IF NOT EXISTS warehouses ("+
+			"warehouse_id INT AUTO_INCREMENT PRIMARY KEY, "+
+			"name VARCHAR(100) NOT NULL, "+
+			"address VARCHAR(255) NOT NULL DEFAULT '', "+
+			"is_default TINYINT(1) NOT NULL DEFAULT 0, "+
+			"sort_order INT NOT NULL DEFAULT 0, "+
+			"create_at DATETIME NOT NULL, "+
+			"UNIQUE KEY uq_warehouse_name (name)"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"CREATE TABLE IF NOT EXISTS part_stock ("+
+			"part_id INT NOT NULL, "+
+			"warehouse_id INT NOT NULL, "+
+			"quantity INT NOT NULL DEFAULT 0, "+
+			"PRIMARY KEY (part_id, warehouse_id), "+
+			"KEY idx_part_stock_warehouse (warehouse_id)"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		"INSERT INTO warehouses (name, is_default, create_at) SELECT '"+DefaultWarehouseName+"', 1, NOW() FROM DUAL "+
+			"WHERE NOT EXISTS (SELECT 1 FROM warehouses)",
+	)
+	if err != nil || seeded {
+		return err
+	}
+	log.Println("[INFO] Migrate: перенос остатков запчастей на склад по умолчанию")
+	_, err = db.Exec("INSERT INTO part_stock (part_id, warehouse_id, quantity) " +
+		"SELECT p.part_id, w.warehouse_id, p.quantity FROM parts p JOIN warehouses w ON w.is_default = 1 " +
+		"WHERE p.quantity > 0 AND NOT EXISTS (SELECT 1 FROM part_kits k WHERE k.part_id = p.part_id)")
+	return err
+}
+
+// Validate проверяет название склада.
+func (wh *Warehouse) Validate() error {
+	wh.Name = strings.TrimSpace(wh.Name)
+	wh.Address = strings.TrimSpace(wh.Address)
+	if wh.Name == "" {
+		return ErrWarehouseName
+	}
+	return nil
+}
+
+// GetWarehouses возвращает склады в порядке вывода; склад по умолчанию первый.
+func GetWarehouses(db *sql.DB) ([]Warehouse, error) {
+	rows, err := db.Query("SELECT warehouse_id, name, address, is_default, sort_order, create_at FROM warehouses " +
+		"ORDER BY is_default DESC, sort_order, warehouse_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	warehouses := []Warehouse{}
+	for rows.Next() {
+		var wh Warehouse
+		if err := rows.Scan(&wh.ID, &wh.Name, &wh.Address, &wh.IsDefault, &wh.SortOrder, &wh.CreateAt); err != nil {
+			return nil, err
+		}
+		warehouses = append(warehouses, wh)
+	}
+	return warehouses, rows.Err()
+}
+
+// GetWarehouseByName возвращает ID склада по названию.
+func GetWarehouseByName(db *sql.DB, name string) (int, error) {
+	var id int
+	err := db.QueryRow("SELECT warehouse_id FROM warehouses WHERE name = ?", strings.TrimSpace(name)).Scan(&id)
+	return id, err
+}
+
+// defaultWarehouseID возвращает ID склада по умолчанию.
+func defaultWarehouseID(db dbExecutor) (int, error) {
+	var id int
+	err := db.QueryRow("SELECT warehouse_id FROM warehouses WHERE is_default = 1 ORDER BY warehouse_id LIMIT 1").Scan(&id)
+	return id, err
+}
+
+// saveWarehouse добавляет (wh.ID = 0) или изменяет склад. Если склад отмечен как склад
+// по умолчанию, отметка снимается с остальных.
+func saveWarehouse(db *sql.DB, wh Warehouse) (int64, error) {
+	if err := wh.Validate(); err != nil {
+		return 0, err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	id := int64(wh.ID)
+	if wh.ID == 0 {
+		res, err := tx.Exec("INSERT INTO warehouses (name, address, is_default, sort_order, create_at) VALUES (?, ?, ?, ?, NOW())",
+			wh.Name, wh.Address, wh.IsDefault, wh.SortOrder)
+		if err != nil {
+			return 0, err
+		}
+		if id, err = res.LastInsertId(); err != nil {
+			return 0, err
+		}
+	} else {
+		var exists int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM warehouses WHERE warehouse_id = ?", wh.ID).Scan(&exists); err != nil {
+			return 0, err
+		}
+		if exists == 0 {
+			return 0, sql.ErrNoRows
+		}
+		// Снять отметку со склада по умолчанию можно, только назначив другой склад.
+		query := "UPDATE warehouses SET name = ?, address = ?, sort_order = ?, is_default = is_default OR ? WHERE warehouse_id = ?"
+		if _, err := tx.Exec(query, wh.Name, wh.Address, wh.SortOrder, wh.IsDefault, wh.ID); err != nil {
+			return 0, err
+		}
+	}
+	if wh.IsDefault {
+		if _, err := tx.Exec("UPDATE warehouses SET is_default = 0 WHERE warehouse_id <> ?", id); err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit()
+}
+
+// AddWarehouse создаёт склад и возвращает его ID.
+func AddWarehouse(db *sql.DB, wh Warehouse) (int64, error) {
+	wh.ID = 0
+	id, err := saveWarehouse(db, wh)
+	if isDuplicateKey(err) {
+		return 0, ErrWarehouseExists
+	}
+	return id, err
+}
+
+// UpdateWarehouse изменяет склад. Возвращает sql.ErrNoRows, если склада нет.
+func UpdateWarehouse(db *sql.DB, wh Warehouse) error {
+	_, err := saveWarehouse(db, wh)
+	if isDuplicateKey(err) {
+		return ErrWarehouseExists
+	}
+	return err
+}
+
+// DeleteWarehouse удаляет склад без остатков, движений в журнале, незавершённых заказов поставщикам
+// и инвентаризаций. Склад по умолчанию удалить нельзя.
+// Проверки выполняются под блокировкой строки склада: движение по складу, начатое одновременно
+// с удалением, дождётся его окончания (см. stockTarget).
+func DeleteWarehouse(db *sql.DB, id int) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	var isDefault bool
+	err = tx.QueryRow("SELECT is_default FROM warehouses WHERE warehouse_id = ? FOR UPDATE", id).Scan(&isDefault)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if isDefault {
+		return 0, ErrWarehouseDefault
+	}
+	var stock int
+	if err := tx.QueryRow("SELECT COALESCE(SUM(quantity), 0) FROM part_stock WHERE warehouse_id = ? FOR UPDATE", id).Scan(&stock); err != nil {
+		return 0, err
+	}
+	if stock != 0 {
+		return 0, ErrWarehouseInUse
+	}
+	// Журнал движений ссылается на склад: удаление оставило бы в нём записи без склада.
+	var moved bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM stock_movements WHERE warehouse_id = ?)", id).Scan(&moved); err != nil {
+		return 0, err
+	}
+	if moved {
+		return 0, ErrWarehouseHistory
+	}
+	// Незавершённый заказ или инвентаризацию без склада нельзя было бы ни принять, ни утвердить.
+	var ordered, counting bool
+	query := "SELECT EXISTS(SELECT 1 FROM purchase_orders WHERE warehouse_id = ? AND status IN (?, ?, ?)), " +
+		"EXISTS(SELECT 1 FROM stocktakes WHERE warehouse_id = ? AND status = ?)"
+	err = tx.QueryRow(query, id, PurchaseDraft, PurchaseSent, PurchasePartial, id, StocktakeOpen).Scan(&ordered, &counting)
+	if err != nil {
+		return 0, err
+	}
+	if ordered {
+		return 0, ErrWarehouseOrders
+	}
+	if counting {
+		return 0, ErrWarehouseCount
+	}
+	if _, err := tx.Exec("DELETE FROM part_stock WHERE warehouse_id = ?", id); err != nil {
+		return 0, err
+	}
+	n, err := rowsAffected(tx.Exec("DELETE FROM warehouses WHERE warehouse_id = ?", id))
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// syncPartQuantity записывает в parts.quantity сумму остатков по складам.
+// Комплекты пропускаются: их остаток рассчитывает refreshKits.
+func syncPartQuantity(db dbExecutor, partIDs ...int) error {
+	if len(partIDs) == 0 {
+		return nil
+	}
+	ids := make([]interface{}, len(partIDs))
+	for i, id := range partIDs {
+		ids[i] = id
+	}
+	query := "UPDATE parts p SET p.quantity = COALESCE((SELECT SUM(s.quantity) FROM part_stock s WHERE s.part_id = p.part_id), 0) " +
+		"WHERE p.part_id IN (" + placeholders(len(ids)) + ") AND NOT EXISTS (SELECT 1 FROM part_kits k WHERE k.part_id = p.part_id)"
+	if _, err := db.Exec(query, ids...); err != nil {
+		return err
+	}
+	if err := checkStockAlerts(db, "p.part_id IN ("+placeholders(len(ids))+")", ids...); err != nil {
+		return err
+	}
+	return RefreshKitsFor(db, partIDs...)
+}
+
+// isKit сообщает, является ли запчасть комплектом.
+func isKit(db dbExecutor, partID int) (bool, error) {
+	var kit bool
+	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM part_kits WHERE part_id = ?)", partID).Scan(&kit)
+	return kit, err
+}
+
+// SetPartStock задаёт остаток запчасти на складе (warehouseID = 0 – склад по умолчанию)
+// и пересчитывает общий остаток. Разница с текущим остатком записывается в журнал движений
+// как корректировка. Возвращает sql.ErrNoRows, если нет запчасти или склада.
+func SetPartStock(db *sql.DB, partID, warehouseID, quantity int, src MovementSource) error {
+	if quantity < 0 {
+		return ErrStockQuantity
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := setPartStock(tx, partID, warehouseID, quantity, src); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	InvalidateCatalogTree()
+	return nil
+}
+
+// stockTarget проверяет, что запчасть есть, не в корзине и не является комплектом,
+// а склад существует; возвращает ID склада (для 0 – склада по умолчанию).
+func stockTarget(tx dbExecutor, partID, warehouseID int) (int, error) {
+	var exists int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM parts WHERE part_id = ? AND deleted_at IS NULL FOR UPDATE", partID).Scan(&exists); err != nil {
+		return 0, err
+	}
+	if exists == 0 {
+		return 0, sql.ErrNoRows
+	}
+	if kit, err := isKit(tx, partID); err != nil || kit {
+		if err == nil {
+			err = ErrStockKit
+		}
+		return 0, err
+	}
+	if warehouseID == 0 {
+		return defaultWarehouseID(tx)
+	}
+	// Разделяемая блокировка склада не даёт удалить его до окончания движения (см. DeleteWarehouse).
+	if err := tx.QueryRow("SELECT COUNT(*) FROM warehouses WHERE warehouse_id = ? LOCK IN SHARE MODE", warehouseID).Scan(&exists); err != nil {
+		return 0, err
+	}
+	if exists == 0 {
+		return 0, sql.ErrNoRows
+	}
+	return warehouseID, nil
+}
+
+// setPartStock – SetPartStock внутри транзакции.
+func setPartStock(tx dbExecutor, partID, warehouseID, quantity int, src MovementSource) error {
+	warehouseID, err := stockTarget(tx, partID, warehouseID)
+	if err != nil {
+		return err
+	}
+	current, err := lockStock(tx, partID, warehouseID)
+	if err != nil {
+		return err
+	}
+	if quantity == current {
+		return nil
+	}
+	if _, err := recordMovement(tx, partID, warehouseID, MovementAdjustment, quantity-current, src); err != nil {
+		return err
+	}
+	return syncPartQuantity(tx, partID)
+}
+
+// SetPartTotalStock приводит общий остаток запчасти к total, меняя остаток склада
+// по умолчанию на разницу. Используется, когда остаток задан одним числом (Part.Quantity).
+// Для комплекта ничего не делает. Возвращает ErrStockTotal, если остаток склада
+// по умолчанию стал бы отрицательным.
+func SetPartTotalStock(db *sql.DB, partID, total int, src MovementSource) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := setPartTotalStock(tx, partID, total, src); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	InvalidateCatalogTree()
+	return nil
+}
+
+// setPartTotalStock – SetPartTotalStock внутри транзакции.
+func setPartTotalStock(tx dbExecutor, partID, total int, src MovementSource) error {
+	if total < 0 {
+		return ErrStockQuantity
+	}
+	if kit, err := isKit(tx, partID); err != nil || kit {
+		return err
+	}
+	warehouseID, err := defaultWarehouseID(tx)
+	if err != nil {
+		return err
+	}
+	var others int
+	query := "SELECT COALESCE(SUM(quantity), 0) FROM part_stock WHERE part_id = ? AND warehouse_id <> ? FOR UPDATE"
+	if err := tx.QueryRow(query, partID, warehouseID).Scan(&others); err != nil {
+		return err
+	}
+	if total < others {
+		return ErrStockTotal
+	}
+	return setPartStock(tx, partID, warehouseID, total-others, src)
+}
+
+// PartFields – изменяемые поля карточки запчасти.
+type PartFields struct {
+	SubcategoryID int
+	Name          string
+	Description   string
+	Price         float64
+	BrandID       *int // nil – бренд не меняется, 0 – снять бренд
+}
+
+// UpdatePartFields изменяет поля запчасти и, если total задан, приводит её общий остаток к *total
+// в одной транзакции: если остаток нельзя изменить, поля тоже не меняются. При total == nil
+// остатки по складам не трогаются. Цену запчасти с базовой ценой в валюте изменить нельзя
+// (ErrPriceBase): её пересчитывает курс. Возвращает sql.ErrNoRows, если запчасти нет или она в корзине.
+func UpdatePartFields(db *sql.DB, partID int, f PartFields, total *int, src MovementSource) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var price float64
+	var hasBase bool
+	err = tx.QueryRow("SELECT price, base_price IS NOT NULL FROM parts WHERE part_id = ? AND deleted_at IS NULL FOR UPDATE", partID).
+		Scan(&price, &hasBase)
+	if err != nil {
+		return err
+	}
+	if hasBase && price != f.Price {
+		return ErrPriceBase
+	}
+	if total != nil {
+		if err := setPartTotalStock(tx, partID, *total, src); err != nil {
+			return err
+		}
+	}
+	// Количество не обновляется: оно складывается из остатков по складам.
+	query := "UPDATE parts SET subcategory_id = ?, name = ?, price = ?, description = ?, update_at = NOW()"
+	args := []interface{}{f.SubcategoryID, f.Name, f.Price, f.Description}
+	if f.BrandID != nil {
+		var brand interface{}
+		if *f.BrandID > 0 {
+			brand = *f.BrandID
+		}
+		query += ", brand_id = ?"
+		args = append(args, brand)
+	}
+	if _, err := tx.Exec(query+" WHERE part_id = ?", append(args, partID)...); err != nil {
+		return err
+	}
+	// В другой подкатегории может быть другой минимальный остаток.
+	if err := checkStockAlerts(tx, "p.part_id = ?", partID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	InvalidateCatalogTree()
+	return nil
+}
+
+// CreatePart добавляет запчасть с брендом (brandID = 0 – без бренда) и номерами в одной
+// транзакции: остаток part.Quantity заводится на склад по умолчанию движением по журналу,
+// записываются начальная цена, слаг, поисковый индекс и внешнее изображение. Если любой
+// шаг не удался, запчасть не создаётся. Возвращает ID новой запчасти.
+func CreatePart(db *sql.DB, part Part, brandID int, numbers []PartNumber, src PriceSource, stockSrc MovementSource) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	id, err := insertBrandedPart(tx, part, brandID, stockSrc)
+	if err != nil {
+		return 0, err
+	}
+	if err := RecordPriceChange(tx, id, nil, part.Price, src); err != nil {
+		return 0, err
+	}
+	if err := IndexPart(tx, id, part.Name, part.Description); err != nil {
+		return 0, err
+	}
+	if err := partSlugs.refresh(tx, id); err != nil {
+		return 0, err
+	}
+	if part.ImageURL != nil {
+		if err := EnsureExternalPartImage(tx, id, strings.TrimSpace(*part.ImageURL)); err != nil {
+			return 0, err
+		}
+	}
+	for _, n := range numbers {
+		n.PartID = id
+		if err := AddPartNumber(tx, n); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	InvalidateCatalogTree()
+	return id, nil
+}
+
+// deductStock списывает quantity единиц запчасти со склада warehouseID либо, если он
+// не задан, по складам по порядку: сначала склад по умолчанию. Каждое списание
+// записывается в журнал как продажа. Возвращает списания по складам
+// и ErrInsufficientStock, если товара не хватает.
+func deductStock(tx dbExecutor, partID, quantity, warehouseID int, src MovementSource) ([]StockChange, error) {
+	query := "SELECT s.warehouse_id, s.quantity FROM part_stock s JOIN warehouses w ON w.warehouse_id = s.warehouse_id " +
+		"WHERE s.part_id = ? AND s.quantity > 0"
+	args := []interface{}{partID}
+	if warehouseID > 0 {
+		query += " AND s.warehouse_id = ?"
+		args = append(args, warehouseID)
+	}
+	rows, err := tx.Query(query+" ORDER BY w.is_default DESC, w.sort_order, w.warehouse_id FOR UPDATE", args...)
+	if err != nil {
+		return nil, err
+	}
+	var changes []StockChange
+	left := quantity
+	for rows.Next() && left > 0 {
+		var change StockChange
+		var available int
+		if err := rows.Scan(&change.WarehouseID, &available); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		change.PartID, change.Quantity = partID, left
+		if available < left {
+			change.Quantity = available
+		}
+		left -= change.Quantity
+		changes = append(changes, change)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if left > 0 {
+		return nil, ErrInsufficientStock
+	}
+	for i, change := range changes {
+		if changes[i].Remaining, err = recordMovement(tx, partID, change.WarehouseID, MovementSale, -change.Quantity, src); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+// GetPartStock возвращает остатки запчасти по всем складам, включая нулевые.
+// Для комплекта остаток на складе – сколько комплектов можно собрать из компонентов этого склада.
+func GetPartStock(db *sql.DB, partID int) ([]PartStock, error) {
+	stock, err := GetPartsStock(db, partID)
+	if err != nil {
+		return nil, err
+	}
+	return stock[partID], nil
+}
+
+// GetPartsStock возвращает остатки по складам для нескольких запчастей.
+func GetPartsStock(db *sql.DB, partIDs ...int) (map[int][]PartStock, error) {
+	result := make(map[int][]PartStock, len(partIDs))
+	if len(partIDs) == 0 {
+		return result, nil
+	}
+	ids := make([]interface{}, len(partIDs))
+	for i, id := range partIDs {
+		ids[i] = id
+	}
+	in := placeholders(len(ids))
+	query := "SELECT p.part_id, w.warehouse_id, w.name, " +
+		"IF(k.part_id IS NULL, COALESCE(s.quantity, 0), COALESCE(" +
+		"(SELECT MIN(FLOOR(IF(cp.deleted_at IS NULL, GREATEST(COALESCE(cs.quantity, 0), 0), 0) / c.quantity)) " +
+		"FROM part_kit_components c LEFT JOIN parts cp ON cp.part_id = c.part_id " +
+		"LEFT JOIN part_stock cs ON cs.part_id = c.part_id AND cs.warehouse_id = w.warehouse_id WHERE c.kit_id = p.part_id), 0)) " +
+		"FROM parts p CROSS JOIN warehouses w " +
+		"LEFT JOIN part_stock s ON s.part_id = p.part_id AND s.warehouse_id = w.warehouse_id " +
+		"LEFT JOIN part_kits k ON k.part_id = p.part_id " +
+		"WHERE p.part_id IN (" + in + ") ORDER BY p.part_id, w.is_default DESC, w.sort_order, w.warehouse_id"
+	rows, err := db.Query(query, ids...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var partID int
+		var s PartStock
+		if err := rows.Scan(&partID, &s.WarehouseID, &s.Warehouse, &s.Quantity); err != nil {
+			return nil, err
+		}
+		result[partID] = append(result[partID], s)
+	}
+	return result, rows.Err()
+}
+
+// LoadStock заполняет остатки по складам у списка запчастей.
+func LoadStock(db *sql.DB, items []PartListItem) error {
+	ids := make([]int, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	stock, err := GetPartsStock(db, ids...)
+	if err != nil {
+		log.Printf("[ERROR] LoadStock: ошибка получения остатков по складам: %v", err)
+		return err
+	}
+	for i := range items {
+		items[i].Stock = stock[items[i].ID]
+	}
+	return nil
+}
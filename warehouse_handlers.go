@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"AutoM/config"
+	"AutoM/models"
+)
+
+// respondWarehouseError отвечает статусом ошибки изменения склада или остатка;
+// notFound – сообщение для sql.ErrNoRows.
+func respondWarehouseError(w http.ResponseWriter, handler, notFound string, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, notFound, http.StatusNotFound)
+	case errors.Is(err, models.ErrWarehouseName), errors.Is(err, models.ErrStockQuantity), errors.Is(err, models.ErrStockKit),
+		errors.Is(err, models.ErrMovementSource):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, models.ErrWarehouseExists), errors.Is(err, models.ErrWarehouseDefault), errors.Is(err, models.ErrWarehouseInUse),
+		errors.Is(err, models.ErrWarehouseHistory), errors.Is(err, models.ErrWarehouseOrders), errors.Is(err, models.ErrWarehouseCount):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("[ERROR] %s: %v", handler, err)
+		http.Error(w, "Ошибка сохранения склада", http.StatusInternalServerError)
+	}
+}
+
+// GetWarehouses – список складов (API).
+func GetWarehouses(w http.ResponseWriter, r *http.Request) {
+	warehouses, err := models.GetWarehouses(config.DB)
+	if err != nil {
+		log.Printf("[ERROR] GetWarehouses: ошибка получения складов: %v", err)
+		http.Error(w, "Ошибка получения складов", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetWarehouses", warehouses)
+}
+
+// AddWarehouse – создание склада (API).
+// Ожидается JSON {"name": "Филиал на Ленина", "address": "...", "is_default": false, "sort_order": 2}.
+func AddWarehouse(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	var wh models.Warehouse
+	if !decodeJSON(w, r, "AddWarehouse", &wh) {
+		return
+	}
+	id, err := models.AddWarehouse(config.DB, wh)
+	if err != nil {
+		respondWarehouseError(w, "AddWarehouse", "Склад не найден", err)
+		return
+	}
+	log.Printf("[INFO] AddWarehouse: склад %d '%s' создан", id, wh.Name)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, "AddWarehouse", map[string]int64{"id": id})
+}
+
+// UpdateWarehouse – изменение склада (API). Склад по умолчанию меняется, только если
+// другой склад отмечен как склад по умолчанию.
+func UpdateWarehouse(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "UpdateWarehouse")
+	if !ok {
+		return
+	}
+	var wh models.Warehouse
+	if !decodeJSON(w, r, "UpdateWarehouse", &wh) {
+		return
+	}
+	wh.ID = id
+	if err := models.UpdateWarehouse(config.DB, wh); err != nil {
+		respondWarehouseError(w, "UpdateWarehouse", "Склад не найден", err)
+		return
+	}
+	models.InvalidateCatalogTree()
+	log.Printf("[INFO] UpdateWarehouse: склад %d изменён", id)
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeleteWarehouse – удаление пустого склада (API).
+func DeleteWarehouse(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "DeleteWarehouse")
+	if !ok {
+		return
+	}
+	rowsAffected, err := models.DeleteWarehouse(config.DB, id)
+	if err == nil && rowsAffected == 0 {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		respondWarehouseError(w, "DeleteWarehouse", "Склад не найден", err)
+		return
+	}
+	log.Printf("[INFO] DeleteWarehouse: склад %d удалён", id)
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetPartStock – остатки запчасти по складам (API).
+func GetPartStock(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "GetPartStock")
+	if !ok || !partExists(w, "GetPartStock", id) {
+		return
+	}
+	stock, err := models.GetPartStock(config.DB, id)
+	if err != nil {
+		log.Printf("[ERROR] GetPartStock: ошибка получения остатков запчасти %d: %v", id, err)
+		http.Error(w, "Ошибка получения остатков", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, "GetPartStock", stock)
+}
+
+// SetPartStock – остаток запчасти на складе {warehouseId} (API; 0 – склад по умолчанию).
+// Ожидается JSON {"quantity": 5, "reason": "Пересчёт"}; разница записывается в журнал
+// движений как корректировка, общий остаток запчасти и остатки комплектов с ней пересчитываются.
+func SetPartStock(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := pathID(w, r, "SetPartStock")
+	if !ok {
+		return
+	}
+	warehouseID, ok := pathInt(w, r, "warehouseId", "SetPartStock")
+	if !ok {
+		return
+	}
+	var req struct {
+		Quantity int    `json:"quantity"`
+		Reason   string `json:"reason"`
+	}
+	if !decodeJSON(w, r, "SetPartStock", &req) {
+		return
+	}
+	if req.Reason == "" {
+		req.Reason = "Изменение остатка на складе"
+	}
+	src := models.MovementSource{Reason: req.Reason, Username: sessionUsername(r)}
+	if err := models.SetPartStock(config.DB, id, warehouseID, req.Quantity, src); err != nil {
+		respondWarehouseError(w, "SetPartStock", "Запчасть или склад не найдены", err)
+		return
+	}
+	log.Printf("[INFO] SetPartStock: запчасть %d, склад %d: остаток %d", id, warehouseID, req.Quantity)
+	w.WriteHeader(http.StatusOK)
+}
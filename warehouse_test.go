@@ -0,0 +1,132 @@
+package models
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+)
+
+// testDB открывает тестовую базу из переменной окружения TEST_DSN (например,
+// "root:pass@tcp(127.0.0.1:3306)/autom_test?parseTime=true") и применяет миграции.
+// Без TEST_DSN тест пропускается: база должна содержать таблицы каталога приложения.
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DSN не задана; тест с базой данных пропущен")
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatalf("подключение к базе: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := Migrate(db); err != nil {
+		t.Fatalf("миграции: %v", err)
+	}
+	return db
+}
+
+// testPart создаёт группу, категорию, подкатегорию и запчасть с остатком quantity
+// на складе по умолчанию; всё созданное удаляется по окончании теста.
+func testPart(t *testing.T, db *sql.DB, price float64, quantity int) (partID, subcategoryID int) {
+	t.Helper()
+	insert := func(query string, args ...interface{}) int {
+		res, err := db.Exec(query, args...)
+		if err != nil {
+			t.Fatalf("подготовка данных: %v", err)
+		}
+		id, _ := res.LastInsertId()
+		return int(id)
+	}
+	groupID := insert("INSERT INTO `groups_main` (name, create_at, update_at) VALUES ('Тест', NOW(), NOW())")
+	categoryID := insert("INSERT INTO `categories` (group_id, name, create_at, update_at, description) VALUES (?, 'Тест', NOW(), NOW(), '')", groupID)
+	subcategoryID = insert("INSERT INTO subcategories (category_id, name, create_at, update_at) VALUES (?, 'Тест', NOW(), NOW())", categoryID)
+	partID = insert("INSERT INTO parts (name, description, price, subcategory_id, quantity, create_at, update_at) VALUES ('Тестовая запчасть', '', ?, ?, 0, NOW(), NOW())",
+		price, subcategoryID)
+	t.Cleanup(func() {
+		for _, table := range []string{"stock_movements", "part_stock", "stock_alerts", "part_price_history", "purchase_order_items"} {
+			db.Exec("DELETE FROM "+table+" WHERE part_id = ?", partID)
+		}
+		db.Exec("DELETE FROM parts WHERE part_id = ?", partID)
+		db.Exec("DELETE FROM subcategories WHERE subcategory_id = ?", subcategoryID)
+		db.Exec("DELETE FROM `categories` WHERE category_id = ?", categoryID)
+		db.Exec("DELETE FROM `groups_main` WHERE group_id = ?", groupID)
+	})
+	if quantity > 0 {
+		if err := SetPartStock(db, partID, 0, quantity, MovementSource{Reason: "Тест"}); err != nil {
+			t.Fatalf("начальный остаток: %v", err)
+		}
+	}
+	return partID, subcategoryID
+}
+
+// partQuantity возвращает общий остаток запчасти и число записей журнала по ней.
+func partQuantity(t *testing.T, db *sql.DB, partID int) (quantity, movements int) {
+	t.Helper()
+	if err := db.QueryRow("SELECT quantity FROM parts WHERE part_id = ?", partID).Scan(&quantity); err != nil {
+		t.Fatalf("остаток запчасти: %v", err)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM stock_movements WHERE part_id = ?", partID).Scan(&movements); err != nil {
+		t.Fatalf("журнал движений: %v", err)
+	}
+	return quantity, movements
+}
+
+func TestUpdatePartFieldsStock(t *testing.T) {
+	db := testDB(t)
+	partID, subcategoryID := testPart(t, db, 100, 7)
+	fields := PartFields{SubcategoryID: subcategoryID, Name: "Переименованная запчасть", Price: 100}
+	src := MovementSource{Reason: "Тест"}
+
+	// Без количества меняются только поля карточки, остаток и журнал не трогаются.
+	if err := UpdatePartFields(db, partID, fields, nil, src); err != nil {
+		t.Fatalf("UpdatePartFields без количества: %v", err)
+	}
+	if quantity, movements := partQuantity(t, db, partID); quantity != 7 || movements != 1 {
+		t.Errorf("без количества: остаток %d, движений %d; ожидалось 7 и 1", quantity, movements)
+	}
+
+	// Новое количество записывается корректировкой в журнал.
+	total := 3
+	if err := UpdatePartFields(db, partID, fields, &total, src); err != nil {
+		t.Fatalf("UpdatePartFields с количеством: %v", err)
+	}
+	if quantity, movements := partQuantity(t, db, partID); quantity != 3 || movements != 2 {
+		t.Errorf("с количеством: остаток %d, движений %d; ожидалось 3 и 2", quantity, movements)
+	}
+
+	// Отрицательный остаток отклоняется, и поля карточки остаются прежними.
+	negative := -1
+	fields.Name = "Не сохранится"
+	if err := UpdatePartFields(db, partID, fields, &negative, src); err != ErrStockQuantity {
+		t.Errorf("отрицательный остаток: ошибка %v, ожидалась ErrStockQuantity", err)
+	}
+	var name string
+	if err := db.QueryRow("SELECT name FROM parts WHERE part_id = ?", partID).Scan(&name); err != nil {
+		t.Fatalf("название запчасти: %v", err)
+	}
+	if name != "Переименованная запчасть" {
+		t.Errorf("название после отклонённого изменения: %q", name)
+	}
+
+	if err := UpdatePartFields(db, 0, fields, nil, src); err != sql.ErrNoRows {
+		t.Errorf("несуществующая запчасть: ошибка %v, ожидалась sql.ErrNoRows", err)
+	}
+}
+
+func TestUpdatePartFieldsBasePrice(t *testing.T) {
+	db := testDB(t)
+	partID, subcategoryID := testPart(t, db, 100, 0)
+	if _, err := db.Exec("UPDATE parts SET base_price = 100, base_currency = ? WHERE part_id = ?", BaseCurrency, partID); err != nil {
+		t.Fatalf("базовая цена: %v", err)
+	}
+	fields := PartFields{SubcategoryID: subcategoryID, Name: "Тестовая запчасть", Price: 150}
+	if err := UpdatePartFields(db, partID, fields, nil, MovementSource{}); err != ErrPriceBase {
+		t.Errorf("изменение цены при базовой цене: ошибка %v, ожидалась ErrPriceBase", err)
+	}
+	// Та же цена не считается изменением: остальные поля карточки сохраняются.
+	fields.Price = 100
+	if err := UpdatePartFields(db, partID, fields, nil, MovementSource{}); err != nil {
+		t.Errorf("изменение карточки без изменения цены: %v", err)
+	}
+}